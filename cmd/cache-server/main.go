@@ -47,7 +47,12 @@ func main() {
 
             On a high level, the server exposes two HTTP paths. The first one is 
             used by the shards for getting all resources. The second one is used 
-            by individual shards to push data they wish to be shared.
+            by individual shards to push data they wish to be shared. With
+            --enable-shard-purge, the data of a decommissioned shard can be removed
+            with "DELETE /shards/<name>".
+
+            The data is persisted in etcd, by default in an embedded etcd server in
+            --root-directory. Shards resume watching their data after a restart.
 
             There are no limits on the types of data this server hosts. The rule of 
             thumb is that they must be common for a larger group of shards. 
@@ -21,6 +21,7 @@ import (
 	goflags "flag"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
 	"time"
 
@@ -29,11 +30,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/wait"
 	genericapifilters "k8s.io/apiserver/pkg/endpoints/filters"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericfilters "k8s.io/apiserver/pkg/server/filters"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	utilflag "k8s.io/component-base/cli/flag"
@@ -146,10 +151,34 @@ routed based on paths.`,
 			kcpSharedInformerFactory.WaitForCacheSync(ctx.Done())
 
 			// start the server
-			handler, err := proxy.NewHandler(&options.Proxy, indexController)
+			mappingHandler, err := proxy.NewHandler(&options.Proxy, indexController)
 			if err != nil {
 				return err
 			}
+			if options.Proxy.MappingFile != "" {
+				go mappingHandler.WatchMappingFile(ctx, options.Proxy.MappingFile, options.Proxy.MappingReloadInterval)
+			}
+			if options.Proxy.MappingConfigMap != "" {
+				namespace, name, err := options.Proxy.MappingConfigMapNamespaceName()
+				if err != nil {
+					return err
+				}
+				rootKubeClient, err := kubernetes.NewForConfig(rootShardConfigInformerConfig)
+				if err != nil {
+					return fmt.Errorf("failed to create kube client for informers: %w", err)
+				}
+				kubeSharedInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(rootKubeClient, options.Proxy.MappingReloadInterval,
+					kubeinformers.WithNamespace(namespace),
+					kubeinformers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+						opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+					}),
+				)
+				mappingHandler.WatchMappingConfigMap(kubeSharedInformerFactory.Core().V1().ConfigMaps(), namespace, name)
+				kubeSharedInformerFactory.Start(ctx.Done())
+				kubeSharedInformerFactory.WaitForCacheSync(ctx.Done())
+			}
+
+			var handler http.Handler = mappingHandler
 			failedHandler := newUnauthorizedHandler()
 			handler = withOptionalClientCert(handler, failedHandler, authenticationInfo.Authenticator)
 
@@ -72,7 +72,7 @@ func startFrontProxy(
 	mappings := []mappingEntry{
 		{
 			Path: "/services/",
-			// TODO: support multiple virtual workspace backend servers
+			// requests for workspaces on other shards are routed to their virtual workspace servers
 			Backend:         fmt.Sprintf("https://localhost:%s", vwPort),
 			BackendServerCA: ".kcp/serving-ca.crt",
 			ProxyClientCert: ".kcp-front-proxy/requestheader.crt",
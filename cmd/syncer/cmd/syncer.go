@@ -111,6 +111,8 @@ func Run(options *synceroptions.Options, ctx context.Context) error {
 			SyncTargetWorkspace: logicalcluster.New(options.FromClusterName),
 			SyncTargetName:      options.SyncTargetName,
 			SyncTargetUID:       options.SyncTargetUID,
+
+			SyncedNamespaceLabels: options.SyncedNamespaceLabels,
 		},
 		numThreads,
 		options.APIImportPollInterval,
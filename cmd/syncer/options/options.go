@@ -44,6 +44,8 @@ type Options struct {
 	Logs                *logs.Options
 	SyncedResourceTypes []string
 
+	SyncedNamespaceLabels []string
+
 	APIImportPollInterval time.Duration
 }
 
@@ -73,6 +75,8 @@ func (options *Options) AddFlags(fs *pflag.FlagSet) {
 		fmt.Sprintf("ID of the -to cluster. Resources with this ID set in the '%s' label will be synced.", workloadv1alpha1.ClusterResourceStateLabelPrefix+"<ClusterID>"))
 	fs.StringVar(&options.SyncTargetUID, "sync-target-uid", options.SyncTargetUID, "The UID from the SyncTarget resource in KCP.")
 	fs.StringArrayVarP(&options.SyncedResourceTypes, "resources", "r", options.SyncedResourceTypes, "Resources to be synchronized in kcp.")
+	fs.StringArrayVar(&options.SyncedNamespaceLabels, "synced-namespace-labels", options.SyncedNamespaceLabels,
+		"Keys of the labels of kcp namespaces to set on the namespaces in the -to cluster. A key ending with * matches all keys with that prefix.")
 	fs.DurationVar(&options.APIImportPollInterval, "api-import-poll-interval", options.APIImportPollInterval, "Polling interval for API import.")
 	fs.Var(kcpfeatures.NewFlagValue(), "feature-gates", ""+
 		"A set of key=value pairs that describe feature gates for alpha/experimental features. "+
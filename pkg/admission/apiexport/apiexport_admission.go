@@ -0,0 +1,172 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/authorization/delegated"
+)
+
+const (
+	PluginName = "apis.kcp.dev/APIExport"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &apiExportAdmission{
+				Handler:          admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: delegated.NewDelegatedAuthorizer,
+			}, nil
+		})
+}
+
+type apiExportAdmission struct {
+	*admission.Handler
+	deepSARClient kubernetesclient.ClusterInterface
+
+	createAuthorizer delegated.DelegatedAuthorizerFactory
+}
+
+// Ensure that the required admission interfaces are implemented.
+var (
+	_ = admission.ValidationInterface(&apiExportAdmission{})
+	_ = admission.InitializationValidator(&apiExportAdmission{})
+	_ = kcpinitializers.WantsDeepSARClient(&apiExportAdmission{})
+)
+
+// Validate verifies that the user creating or updating an APIExport has access to the workspace holding
+// its maximal permission policy, if that is not the APIExport's own workspace. Otherwise, the RBAC of any
+// workspace could be referenced, and probed through the bound resources.
+func (o *apiExportAdmission) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	if a.GetResource().GroupResource() != apisv1alpha1.Resource("apiexports") || a.GetSubresource() != "" {
+		return nil
+	}
+
+	u, ok := a.GetObject().(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T", a.GetObject())
+	}
+	apiExport := &apisv1alpha1.APIExport{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, apiExport); err != nil {
+		return fmt.Errorf("failed to convert unstructured to APIExport: %w", err)
+	}
+
+	policyPath := workspacePolicyPath(apiExport)
+	if policyPath == "" {
+		return nil
+	}
+
+	if a.GetOperation() == admission.Update {
+		u, ok = a.GetOldObject().(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected type %T", a.GetOldObject())
+		}
+		old := &apisv1alpha1.APIExport{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, old); err != nil {
+			return fmt.Errorf("failed to convert unstructured to APIExport: %w", err)
+		}
+		if workspacePolicyPath(old) == policyPath {
+			return nil // access was checked when the policy was set
+		}
+	}
+
+	if err := o.checkPolicyWorkspaceAccess(ctx, a.GetUserInfo(), logicalcluster.New(policyPath)); err != nil {
+		return admission.NewForbidden(a, fmt.Errorf("spec.maximalPermissionPolicy.workspace.path: %w", err))
+	}
+
+	return nil
+}
+
+// workspacePolicyPath returns the path of the workspace holding the maximal permission policy of the
+// given APIExport, or an empty string if it has no policy in another workspace.
+func workspacePolicyPath(apiExport *apisv1alpha1.APIExport) string {
+	policy := apiExport.Spec.MaximalPermissionPolicy
+	if policy == nil || policy.Workspace == nil {
+		return ""
+	}
+	return policy.Workspace.Path
+}
+
+// checkPolicyWorkspaceAccess checks that the user has access to the given workspace, like the workspace
+// content authorizer does it, i.e. by the "access" verb on the workspaces/content subresource in the parent.
+func (o *apiExportAdmission) checkPolicyWorkspaceAccess(ctx context.Context, user user.Info, policyCluster logicalcluster.Name) error {
+	parent, hasParent := policyCluster.Parent()
+	if !hasParent {
+		return fmt.Errorf("workspace %q cannot hold a maximal permission policy", policyCluster)
+	}
+
+	logger := klog.FromContext(ctx)
+	authz, err := o.createAuthorizer(parent, o.deepSARClient)
+	if err != nil {
+		// Logging a more specific error for the operator
+		logger.Error(err, "error creating authorizer from delegating authorizer config")
+		// Returning a less specific error to the end user
+		return errors.New("unable to authorize request")
+	}
+
+	accessAttr := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            "access",
+		APIGroup:        tenancyv1beta1.SchemeGroupVersion.Group,
+		APIVersion:      tenancyv1beta1.SchemeGroupVersion.Version,
+		Resource:        "workspaces",
+		Subresource:     "content",
+		Name:            policyCluster.Base(),
+		ResourceRequest: true,
+	}
+
+	if decision, _, err := authz.Authorize(ctx, accessAttr); err != nil {
+		return fmt.Errorf("unable to determine access to workspace %q: %w", policyCluster, err)
+	} else if decision != authorizer.DecisionAllow {
+		return fmt.Errorf("missing verb='access' permission on workspace %q", policyCluster)
+	}
+
+	return nil
+}
+
+// ValidateInitialization ensures the required injected fields are set.
+func (o *apiExportAdmission) ValidateInitialization() error {
+	if o.deepSARClient == nil {
+		return fmt.Errorf(PluginName + " plugin needs a Kubernetes ClusterInterface")
+	}
+
+	return nil
+}
+
+// SetDeepSARClient is an admission plugin initializer function that injects a client capable of deep SAR requests into
+// this admission plugin.
+func (o *apiExportAdmission) SetDeepSARClient(client kubernetesclient.ClusterInterface) {
+	o.deepSARClient = client
+}
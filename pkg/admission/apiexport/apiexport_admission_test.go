@@ -0,0 +1,179 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apiexport
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kcp-dev/kcp/pkg/admission/helpers"
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func createAttr(apiExport *apisv1alpha1.APIExport) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(apiExport),
+		nil,
+		apisv1alpha1.Kind("APIExport").WithVersion("v1alpha1"),
+		"",
+		apiExport.Name,
+		apisv1alpha1.Resource("apiexports").WithVersion("v1alpha1"),
+		"",
+		admission.Create,
+		&metav1.CreateOptions{},
+		false,
+		&user.DefaultInfo{Name: "owner"},
+	)
+}
+
+func updateAttr(newAPIExport, oldAPIExport *apisv1alpha1.APIExport) admission.Attributes {
+	return admission.NewAttributesRecord(
+		helpers.ToUnstructuredOrDie(newAPIExport),
+		helpers.ToUnstructuredOrDie(oldAPIExport),
+		apisv1alpha1.Kind("APIExport").WithVersion("v1alpha1"),
+		"",
+		newAPIExport.Name,
+		apisv1alpha1.Resource("apiexports").WithVersion("v1alpha1"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		&user.DefaultInfo{Name: "owner"},
+	)
+}
+
+func newAPIExport(policy *apisv1alpha1.MaximalPermissionPolicy) *apisv1alpha1.APIExport {
+	return &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "export",
+			Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org:ws"},
+		},
+		Spec: apisv1alpha1.APIExportSpec{
+			MaximalPermissionPolicy: policy,
+		},
+	}
+}
+
+func workspacePolicy(path string) *apisv1alpha1.MaximalPermissionPolicy {
+	return &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: path}}
+}
+
+type recordingAuthorizer struct {
+	decision authorizer.Decision
+	err      error
+
+	cluster logicalcluster.Name
+	attr    authorizer.Attributes
+}
+
+func (a *recordingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	a.attr = attr
+	return a.decision, "reason", a.err
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		attr          admission.Attributes
+		authzDecision authorizer.Decision
+		authzError    error
+
+		wantAuthorizedCluster logicalcluster.Name
+		wantErr               string
+	}{
+		"without policy": {
+			attr: createAttr(newAPIExport(nil)),
+		},
+		"local policy": {
+			attr: createAttr(newAPIExport(&apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}})),
+		},
+		"workspace policy with access": {
+			attr:                  createAttr(newAPIExport(workspacePolicy("root:org:policies"))),
+			authzDecision:         authorizer.DecisionAllow,
+			wantAuthorizedCluster: logicalcluster.New("root:org"),
+		},
+		"workspace policy without access": {
+			attr:                  createAttr(newAPIExport(workspacePolicy("root:org:policies"))),
+			authzDecision:         authorizer.DecisionNoOpinion,
+			wantAuthorizedCluster: logicalcluster.New("root:org"),
+			wantErr:               `missing verb='access' permission on workspace "root:org:policies"`,
+		},
+		"workspace policy with authorization error": {
+			attr:                  createAttr(newAPIExport(workspacePolicy("root:org:policies"))),
+			authzError:            errors.New("boom"),
+			wantAuthorizedCluster: logicalcluster.New("root:org"),
+			wantErr:               `unable to determine access to workspace "root:org:policies"`,
+		},
+		"root workspace policy": {
+			attr:    createAttr(newAPIExport(workspacePolicy("root"))),
+			wantErr: `workspace "root" cannot hold a maximal permission policy`,
+		},
+		"update without policy change": {
+			attr: updateAttr(
+				newAPIExport(workspacePolicy("root:org:policies")),
+				newAPIExport(workspacePolicy("root:org:policies")),
+			),
+		},
+		"update changing policy without access": {
+			attr: updateAttr(
+				newAPIExport(workspacePolicy("root:other:policies")),
+				newAPIExport(workspacePolicy("root:org:policies")),
+			),
+			authzDecision:         authorizer.DecisionDeny,
+			wantAuthorizedCluster: logicalcluster.New("root:other"),
+			wantErr:               `missing verb='access' permission on workspace "root:other:policies"`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			authz := &recordingAuthorizer{decision: tt.authzDecision, err: tt.authzError}
+			o := &apiExportAdmission{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				createAuthorizer: func(clusterName logicalcluster.Name, client kubernetes.ClusterInterface) (authorizer.Authorizer, error) {
+					authz.cluster = clusterName
+					return authz, nil
+				},
+			}
+
+			err := o.Validate(context.Background(), tt.attr, nil)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, tt.wantAuthorizedCluster, authz.cluster)
+			if !tt.wantAuthorizedCluster.Empty() {
+				require.Equal(t, "owner", authz.attr.GetUser().GetName())
+				require.Equal(t, "access", authz.attr.GetVerb())
+				require.Equal(t, "workspaces", authz.attr.GetResource())
+				require.Equal(t, "content", authz.attr.GetSubresource())
+				require.Equal(t, "policies", authz.attr.GetName())
+			}
+		})
+	}
+}
@@ -24,21 +24,29 @@ import (
 	"io"
 
 	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/validation"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/admission/initializer"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	kubernetesinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/clusters"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion/deletion"
 )
 
 // Validate ClusterWorkspace creation and updates for
 // - immutability of fields like type
 // - valid phase transitions fulfilling pre-conditions
 // - status.location.current and status.baseURL cannot be unset.
+// - no workspace of the same name is created while a deleted workspace is restored.
 
 // Mutate ClusterWorkspace creation and updates for
 // - initializers are short enough to be put into a label
@@ -59,17 +67,22 @@ func Register(plugins *admission.Plugins) {
 
 type clusterWorkspace struct {
 	*admission.Handler
+
+	getRestoreConfigMap func(name string) (*corev1.ConfigMap, error)
 }
 
 // Ensure that the required admission interfaces are implemented.
 var _ admission.MutationInterface = &clusterWorkspace{}
 var _ admission.ValidationInterface = &clusterWorkspace{}
+var _ admission.InitializationValidator = &clusterWorkspace{}
+var _ initializer.WantsExternalKubeInformerFactory = &clusterWorkspace{}
 
 var phaseOrdinal = map[tenancyv1alpha1.ClusterWorkspacePhaseType]int{
 	tenancyv1alpha1.ClusterWorkspacePhaseType(""):     1,
 	tenancyv1alpha1.ClusterWorkspacePhaseScheduling:   2,
 	tenancyv1alpha1.ClusterWorkspacePhaseInitializing: 3,
 	tenancyv1alpha1.ClusterWorkspacePhaseReady:        4,
+	tenancyv1alpha1.ClusterWorkspacePhaseDeleted:      5,
 }
 
 // Admit ensures that
@@ -109,6 +122,7 @@ func (o *clusterWorkspace) Admit(ctx context.Context, a admission.Attributes, _
 // - has a valid type
 // - has valid initializers when transitioning to initializing
 // - the user is recorded in annotations on create
+// - the name is not reserved by the pending restore of a deleted workspace on create
 func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) (err error) {
 	if a.GetResource().GroupResource() != tenancyv1alpha1.Resource("clusterworkspaces") {
 		return nil
@@ -166,6 +180,10 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 				return admission.NewForbidden(a, fmt.Errorf("expected user annotation %s=%s", tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey, userInfo))
 			}
 		}
+
+		if err := o.validateRestore(ctx, a, cw); err != nil {
+			return err
+		}
 	}
 
 	if phaseOrdinal[cw.Status.Phase] > phaseOrdinal[tenancyv1alpha1.ClusterWorkspacePhaseInitializing] && len(cw.Status.Initializers) > 0 {
@@ -184,6 +202,53 @@ func (o *clusterWorkspace) Validate(ctx context.Context, a admission.Attributes,
 	return nil
 }
 
+// validateRestore rejects the creation of a workspace while a deleted workspace of the same name is restored,
+// other than the creation of the restored workspace by the system.
+func (o *clusterWorkspace) validateRestore(ctx context.Context, a admission.Attributes, cw *tenancyv1alpha1.ClusterWorkspace) error {
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	cm, err := o.getRestoreConfigMap(deletion.RestoreConfigMapName(clusterName, a.GetName()))
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	restored, err := deletion.RestoredClusterWorkspace(cm)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	isSystemMaster := sets.NewString(a.GetUserInfo().GetGroups()...).Has(kuser.SystemPrivilegedGroup)
+	restoredFrom := restored.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey]
+	if isSystemMaster && cw.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey] == restoredFrom {
+		return nil
+	}
+	return admission.NewForbidden(a, fmt.Errorf("workspace %s is being restored", a.GetName()))
+}
+
+func (o *clusterWorkspace) ValidateInitialization() error {
+	if o.getRestoreConfigMap == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ConfigMap lister")
+	}
+	return nil
+}
+
+// SetExternalKubeInformerFactory implements the WantsExternalKubeInformerFactory interface.
+func (o *clusterWorkspace) SetExternalKubeInformerFactory(f kubernetesinformers.SharedInformerFactory) {
+	o.SetReadyFunc(f.Core().V1().ConfigMaps().Informer().HasSynced)
+	configMapLister := f.Core().V1().ConfigMaps().Lister()
+	o.getRestoreConfigMap = func(name string) (*corev1.ConfigMap, error) {
+		return configMapLister.ConfigMaps(deletion.RestoreConfigMapNamespace).Get(clusters.ToClusterAwareKey(deletion.RestoreConfigMapCluster, name))
+	}
+}
+
 // updateUnstructured updates the given unstructured object to match the given cluster workspace.
 func updateUnstructured(u *unstructured.Unstructured, cw *tenancyv1alpha1.ClusterWorkspace) error {
 	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cw)
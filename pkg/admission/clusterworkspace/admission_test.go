@@ -24,6 +24,8 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -33,6 +35,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/admission/helpers"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion/deletion"
 )
 
 func createAttr(ws *tenancyv1alpha1.ClusterWorkspace) admission.Attributes {
@@ -532,6 +535,81 @@ func TestValidate(t *testing.T) {
 				}),
 			expectedErrors: []string{"cannot transition from \"Ready\" to \"Initializing\""},
 		},
+		{
+			name: "allows transition from ready to deleted",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+						Name: "foo",
+						Path: "root:org",
+					},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseDeleted,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+							Name: "foo",
+							Path: "root:org",
+						},
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+						BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+					},
+				}),
+		},
+		{
+			name: "rejects transition from deleted back to ready",
+			a: updateAttr(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+				},
+				Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+					Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+						Name: "foo",
+						Path: "root:org",
+					},
+				},
+				Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+					Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+					Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+					BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+				},
+			},
+				&tenancyv1alpha1.ClusterWorkspace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "test",
+						Annotations: map[string]string{"experimental.tenancy.kcp.dev/owner": "{}"},
+					},
+					Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+						Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{
+							Name: "foo",
+							Path: "root:org",
+						},
+					},
+					Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+						Phase:    tenancyv1alpha1.ClusterWorkspacePhaseDeleted,
+						Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "somewhere"},
+						BaseURL:  "https://kcp.bigcorp.com/clusters/org:test",
+					},
+				}),
+			expectedErrors: []string{"cannot transition from \"Deleted\" to \"Ready\""},
+		},
 		{
 			name: "ignores different resources",
 			a: admission.NewAttributesRecord(
@@ -629,7 +707,8 @@ func TestValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			o := &clusterWorkspace{
-				Handler: admission.NewHandler(admission.Create, admission.Update),
+				Handler:             admission.NewHandler(admission.Create, admission.Update),
+				getRestoreConfigMap: noRestoreConfigMap,
 			}
 			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
 			err := o.Validate(ctx, tt.a, nil)
@@ -647,6 +726,93 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func noRestoreConfigMap(name string) (*corev1.ConfigMap, error) {
+	return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), name)
+}
+
+func TestValidateRestore(t *testing.T) {
+	restored := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:                              "root:org",
+				tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey: "uid-1",
+			},
+		},
+	}
+	restoreConfigMap, err := deletion.NewRestoreConfigMap(logicalcluster.New("root:org"), restored)
+	require.NoError(t, err)
+
+	privileged := &user.DefaultInfo{Name: "system:kcp", Groups: []string{user.SystemPrivilegedGroup}}
+	withRestoredFrom := func(uid string) *tenancyv1alpha1.ClusterWorkspace {
+		ws := &tenancyv1alpha1.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "team"}}
+		if uid != "" {
+			ws.Annotations = map[string]string{tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey: uid}
+		}
+		return ws
+	}
+
+	tests := []struct {
+		name    string
+		a       admission.Attributes
+		wantErr bool
+	}{
+		{
+			name: "restored workspace created by the system",
+			a:    createAttrWithUser(withRestoredFrom("uid-1"), privileged),
+		},
+		{
+			name:    "other workspace of the same name",
+			a:       createAttrWithUser(withRestoredFrom(""), privileged),
+			wantErr: true,
+		},
+		{
+			name:    "restored workspace of another deleted workspace",
+			a:       createAttrWithUser(withRestoredFrom("uid-2"), privileged),
+			wantErr: true,
+		},
+		{
+			name: "restored workspace created by a user",
+			a: func() admission.Attributes {
+				someone := &user.DefaultInfo{Name: "someone"}
+				ws := withRestoredFrom("uid-1")
+				owner, err := ClusterWorkspaceOwnerAnnotationValue(someone)
+				require.NoError(t, err)
+				ws.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey] = owner
+				return createAttrWithUser(ws, someone)
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "other name",
+			a: createAttrWithUser(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			}, privileged),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &clusterWorkspace{
+				Handler: admission.NewHandler(admission.Create, admission.Update),
+				getRestoreConfigMap: func(name string) (*corev1.ConfigMap, error) {
+					if name == restoreConfigMap.Name {
+						return restoreConfigMap, nil
+					}
+					return noRestoreConfigMap(name)
+				},
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:org")})
+			err := o.Validate(ctx, tt.a, nil)
+			if tt.wantErr {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "workspace team is being restored")
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 type builder struct {
 	*tenancyv1alpha1.ClusterWorkspaceType
 }
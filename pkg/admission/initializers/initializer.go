@@ -162,6 +162,24 @@ func (i *shardExternalURLInitializer) Initialize(plugin admission.Interface) {
 	}
 }
 
+// NewShardNameInitializer returns an admission plugin initializer that injects
+// the name of the shard into the admission plugin.
+func NewShardNameInitializer(shardName string) *shardNameInitializer {
+	return &shardNameInitializer{
+		shardName: shardName,
+	}
+}
+
+type shardNameInitializer struct {
+	shardName string
+}
+
+func (i *shardNameInitializer) Initialize(plugin admission.Interface) {
+	if wants, ok := plugin.(WantsShardName); ok {
+		wants.SetShardName(i.shardName)
+	}
+}
+
 // NewKubeQuotaConfigurationInitializer returns an admission plugin initializer that injects quota.Configuration
 // into admission plugins.
 func NewKubeQuotaConfigurationInitializer(quotaConfiguration quota.Configuration) *kubeQuotaConfigurationInitializer {
@@ -66,6 +66,12 @@ type WantsShardExternalURL interface {
 	SetShardExternalURL(string)
 }
 
+// WantsShardName interface should be implemented by admission plugins
+// that want to have the name of the shard injected.
+type WantsShardName interface {
+	SetShardName(string)
+}
+
 // WantsServerShutdownChannel interface should be implemented by admission plugins that want to perform cleanup
 // activities when the main server context/channel is done.
 type WantsServerShutdownChannel interface {
@@ -41,6 +41,7 @@ import (
 
 	"github.com/kcp-dev/kcp/pkg/admission/apibinding"
 	"github.com/kcp-dev/kcp/pkg/admission/apibindingfinalizer"
+	"github.com/kcp-dev/kcp/pkg/admission/apiexport"
 	"github.com/kcp-dev/kcp/pkg/admission/apiresourceschema"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/admission/clusterworkspacefinalizer"
@@ -52,6 +53,7 @@ import (
 	kcpmutatingwebhook "github.com/kcp-dev/kcp/pkg/admission/mutatingwebhook"
 	workspacenamespacelifecycle "github.com/kcp-dev/kcp/pkg/admission/namespacelifecycle"
 	"github.com/kcp-dev/kcp/pkg/admission/permissionclaims"
+	"github.com/kcp-dev/kcp/pkg/admission/readonlyworkspace"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdannotations"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedcrdgroups"
 	"github.com/kcp-dev/kcp/pkg/admission/reservedmetadata"
@@ -61,6 +63,7 @@ import (
 // AllOrderedPlugins is the list of all the plugins in order.
 var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	workspacenamespacelifecycle.PluginName,
+	readonlyworkspace.PluginName,
 	apiresourceschema.PluginName,
 	clusterworkspace.PluginName,
 	clusterworkspacefinalizer.PluginName,
@@ -69,6 +72,7 @@ var AllOrderedPlugins = beforeWebhooks(kubeapiserveroptions.AllOrderedPlugins,
 	clusterworkspacetypeexists.PluginName,
 	apibinding.PluginName,
 	apibindingfinalizer.PluginName,
+	apiexport.PluginName,
 	kcpvalidatingwebhook.PluginName,
 	kcpmutatingwebhook.PluginName,
 	reservedcrdannotations.PluginName,
@@ -95,6 +99,7 @@ func beforeWebhooks(recommended []string, plugins ...string) []string {
 func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	kubeapiserveroptions.RegisterAllAdmissionPlugins(plugins)
 	clusterworkspace.Register(plugins)
+	readonlyworkspace.Register(plugins)
 	clusterworkspacefinalizer.Register(plugins)
 	clusterworkspaceshard.Register(plugins)
 	clusterworkspacetype.Register(plugins)
@@ -102,6 +107,7 @@ func RegisterAllKcpAdmissionPlugins(plugins *admission.Plugins) {
 	apiresourceschema.Register(plugins)
 	apibinding.Register(plugins)
 	apibindingfinalizer.Register(plugins)
+	apiexport.Register(plugins)
 	workspacenamespacelifecycle.Register(plugins)
 	kcpvalidatingwebhook.Register(plugins)
 	kcpmutatingwebhook.Register(plugins)
@@ -121,6 +127,7 @@ var defaultOnPluginsInKcp = sets.NewString(
 	certsubjectrestriction.PluginName,      // CertificateSubjectRestriction
 
 	// KCP
+	readonlyworkspace.PluginName,
 	clusterworkspace.PluginName,
 	clusterworkspacefinalizer.PluginName,
 	clusterworkspaceshard.PluginName,
@@ -129,6 +136,7 @@ var defaultOnPluginsInKcp = sets.NewString(
 	apiresourceschema.PluginName,
 	apibinding.PluginName,
 	apibindingfinalizer.PluginName,
+	apiexport.PluginName,
 	kcpvalidatingwebhook.PluginName,
 	kcpmutatingwebhook.PluginName,
 	reservedcrdannotations.PluginName,
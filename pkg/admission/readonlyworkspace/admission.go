@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readonlyworkspace
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/tools/clusters"
+
+	kcpinitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+const (
+	PluginName = "tenancy.kcp.dev/ReadOnlyWorkspace"
+)
+
+func Register(plugins *admission.Plugins) {
+	plugins.Register(PluginName,
+		func(_ io.Reader) (admission.Interface, error) {
+			return &readOnlyWorkspace{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+			}, nil
+		})
+}
+
+// readOnlyWorkspace rejects writes to the contents of a workspace with spec.readOnly set, on the shard
+// the workspace is currently served from:
+//
+//   - while the workspace is migrated to another shard, all writes are rejected, for privileged users and
+//     system controllers too. Writes on the current shard would not be copied to the target shard.
+//   - while a deleted workspace is retained, privileged users and system controllers may only delete
+//     objects and update finalizers, e.g. to finish the deletion of namespaces.
+//   - otherwise, i.e. if the workspace was made read-only by its owner, privileged users and system
+//     controllers may write.
+//
+// Writes on other shards are allowed: the contents of a migrating workspace are copied to the target
+// shard while it is read-only, and deleted from the previous shard after switching over.
+type readOnlyWorkspace struct {
+	*admission.Handler
+
+	getClusterWorkspace func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error)
+	shardName           string
+}
+
+// Ensure that the required admission interfaces are implemented.
+var (
+	_ = admission.ValidationInterface(&readOnlyWorkspace{})
+	_ = admission.InitializationValidator(&readOnlyWorkspace{})
+	_ = kcpinitializers.WantsKcpInformers(&readOnlyWorkspace{})
+	_ = kcpinitializers.WantsShardName(&readOnlyWorkspace{})
+)
+
+func (o *readOnlyWorkspace) Validate(ctx context.Context, a admission.Attributes, _ admission.ObjectInterfaces) error {
+	clusterName, err := genericapirequest.ClusterNameFrom(ctx)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+	parent, hasParent := clusterName.Parent()
+	if !hasParent || clusterName == logicalcluster.Wildcard {
+		return nil
+	}
+
+	if !o.WaitForReady() {
+		return admission.NewForbidden(a, fmt.Errorf("not yet ready to handle request"))
+	}
+
+	workspace, err := o.getClusterWorkspace(parent, clusterName.Base())
+	if apierrors.IsNotFound(err) {
+		// the workspace content authorizer rejects requests to non-existing workspaces.
+		return nil
+	} else if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	if !workspace.Spec.ReadOnly || workspace.Status.Location.Current != o.shardName {
+		return nil
+	}
+
+	privileged := sets.NewString(a.GetUserInfo().GetGroups()...).Has(user.SystemPrivilegedGroup)
+	switch {
+	case workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey] != "":
+		return admission.NewForbidden(a, fmt.Errorf("workspace %s is read-only while migrating", clusterName))
+	case workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey] != "":
+		if privileged && (a.GetOperation() == admission.Delete || isFinalizerUpdate(a)) {
+			return nil
+		}
+		return admission.NewForbidden(a, fmt.Errorf("workspace %s is read-only while deleted", clusterName))
+	case privileged:
+		return nil
+	}
+	return admission.NewForbidden(a, fmt.Errorf("workspace %s is read-only", clusterName))
+}
+
+// isFinalizerUpdate returns whether the given request is an update of the main resource changing
+// nothing but the finalizers.
+func isFinalizerUpdate(a admission.Attributes) bool {
+	if a.GetOperation() != admission.Update || a.GetSubresource() != "" || a.GetObject() == nil || a.GetOldObject() == nil {
+		return false
+	}
+	obj, oldObj := a.GetObject().DeepCopyObject(), a.GetOldObject().DeepCopyObject()
+	for _, o := range []runtime.Object{obj, oldObj} {
+		objMeta, err := meta.Accessor(o)
+		if err != nil {
+			return false
+		}
+		objMeta.SetFinalizers(nil)
+		objMeta.SetResourceVersion("")
+		objMeta.SetManagedFields(nil)
+	}
+	return equality.Semantic.DeepEqual(obj, oldObj)
+}
+
+func (o *readOnlyWorkspace) ValidateInitialization() error {
+	if o.getClusterWorkspace == nil {
+		return fmt.Errorf(PluginName + " plugin needs a ClusterWorkspace lister")
+	}
+	if o.shardName == "" {
+		return fmt.Errorf(PluginName + " plugin needs the shard name")
+	}
+	return nil
+}
+
+func (o *readOnlyWorkspace) SetKcpInformers(informers kcpinformers.SharedInformerFactory) {
+	o.SetReadyFunc(informers.Tenancy().V1alpha1().ClusterWorkspaces().Informer().HasSynced)
+	workspaceLister := informers.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
+	o.getClusterWorkspace = func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+		return workspaceLister.Get(clusters.ToClusterAwareKey(clusterName, name))
+	}
+}
+
+func (o *readOnlyWorkspace) SetShardName(shardName string) {
+	o.shardName = shardName
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readonlyworkspace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+var (
+	nonPrivileged = &user.DefaultInfo{Name: "user", Groups: []string{user.AllAuthenticated}}
+	privileged    = &user.DefaultInfo{Name: "admin", Groups: []string{user.SystemPrivilegedGroup}}
+)
+
+func newAttr(op admission.Operation) admission.Attributes {
+	return newAttrAs(op, nonPrivileged)
+}
+
+func newAttrAs(op admission.Operation, userInfo user.Info) admission.Attributes {
+	return admission.NewAttributesRecord(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		nil,
+		corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		"default",
+		"test",
+		corev1.SchemeGroupVersion.WithResource("configmaps"),
+		"",
+		op,
+		&metav1.CreateOptions{},
+		false,
+		userInfo,
+	)
+}
+
+// newUpdateAttr returns a privileged update of a ConfigMap with the given finalizers to the given finalizers and data.
+func newUpdateAttr(oldFinalizers, finalizers []string, data map[string]string) admission.Attributes {
+	return admission.NewAttributesRecord(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", ResourceVersion: "2", Finalizers: finalizers}, Data: data},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test", ResourceVersion: "1", Finalizers: oldFinalizers}},
+		corev1.SchemeGroupVersion.WithKind("ConfigMap"),
+		"default",
+		"test",
+		corev1.SchemeGroupVersion.WithResource("configmaps"),
+		"",
+		admission.Update,
+		&metav1.UpdateOptions{},
+		false,
+		privileged,
+	)
+}
+
+func newWorkspace(readOnly bool, current string) *tenancyv1alpha1.ClusterWorkspace {
+	return newWorkspaceWithAnnotation(readOnly, current, "")
+}
+
+// newWorkspaceWithAnnotation returns a workspace with the given annotation, e.g. marking it read-only for a migration.
+func newWorkspaceWithAnnotation(readOnly bool, current string, annotation string) *tenancyv1alpha1.ClusterWorkspace {
+	annotations := map[string]string{
+		logicalcluster.AnnotationKey: "root:org",
+	}
+	if annotation != "" {
+		annotations[annotation] = "true"
+	}
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "ws",
+			Annotations: annotations,
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			ReadOnly: readOnly,
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Location: tenancyv1alpha1.ClusterWorkspaceLocation{
+				Current: current,
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		clusterName logicalcluster.Name
+		workspace   *tenancyv1alpha1.ClusterWorkspace
+		attr        admission.Attributes
+		wantErr     bool
+	}{
+		{
+			name:        "writable workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(false, "root"),
+			attr:        newAttr(admission.Create),
+		},
+		{
+			name:        "create in read-only workspace on current shard",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttr(admission.Create),
+			wantErr:     true,
+		},
+		{
+			name:        "update in read-only workspace on current shard",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttr(admission.Update),
+			wantErr:     true,
+		},
+		{
+			name:        "delete in read-only workspace on current shard",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttr(admission.Delete),
+			wantErr:     true,
+		},
+		{
+			name:        "privileged create in workspace made read-only by the owner",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttrAs(admission.Create, privileged),
+		},
+		{
+			name:        "privileged create in workspace read-only while migrating",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey),
+			attr:        newAttrAs(admission.Create, privileged),
+			wantErr:     true,
+		},
+		{
+			name:        "privileged delete in workspace read-only while migrating",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey),
+			attr:        newAttrAs(admission.Delete, privileged),
+			wantErr:     true,
+		},
+		{
+			name:        "privileged create in deleted workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey),
+			attr:        newAttrAs(admission.Create, privileged),
+			wantErr:     true,
+		},
+		{
+			name:        "privileged delete in deleted workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey),
+			attr:        newAttrAs(admission.Delete, privileged),
+		},
+		{
+			name:        "non-privileged delete in deleted workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey),
+			attr:        newAttr(admission.Delete),
+			wantErr:     true,
+		},
+		{
+			name:        "privileged finalizer update in deleted workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey),
+			attr:        newUpdateAttr([]string{"kubernetes"}, nil, nil),
+		},
+		{
+			name:        "privileged data update in deleted workspace",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspaceWithAnnotation(true, "root", tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey),
+			attr:        newUpdateAttr([]string{"kubernetes"}, nil, map[string]string{"foo": "bar"}),
+			wantErr:     true,
+		},
+		{
+			name:        "create in read-only workspace on another shard",
+			clusterName: logicalcluster.New("root:org:ws"),
+			workspace:   newWorkspace(true, "foo"),
+			attr:        newAttr(admission.Create),
+		},
+		{
+			name:        "workspace not found",
+			clusterName: logicalcluster.New("root:org:other"),
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttr(admission.Create),
+		},
+		{
+			name:        "root workspace",
+			clusterName: tenancyv1alpha1.RootCluster,
+			workspace:   newWorkspace(true, "root"),
+			attr:        newAttr(admission.Create),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &readOnlyWorkspace{
+				Handler: admission.NewHandler(admission.Create, admission.Update, admission.Delete),
+				getClusterWorkspace: func(clusterName logicalcluster.Name, name string) (*tenancyv1alpha1.ClusterWorkspace, error) {
+					if clusterName == logicalcluster.From(tt.workspace) && name == tt.workspace.Name {
+						return tt.workspace, nil
+					}
+					return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaces"), name)
+				},
+				shardName: "root",
+			}
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: tt.clusterName})
+			if err := o.Validate(ctx, tt.attr, nil); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	webhookconfiguration "k8s.io/apiserver/pkg/admission/configuration"
+	"k8s.io/apiserver/pkg/admission/plugin/webhook"
+	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
+)
+
+// clusterIndexedSource indexes the webhooks of a generic.Source by logical cluster.
+//
+// The webhook configuration managers store a new list of webhooks whenever a webhook configuration
+// changes. The index is rebuilt lazily on the first lookup after such a change, such that a lookup
+// per admission request takes constant time, independently of the number of workspaces.
+type clusterIndexedSource struct {
+	source generic.Source
+
+	lock      sync.RWMutex
+	indexed   []webhook.WebhookAccessor
+	byCluster map[logicalcluster.Name][]webhook.WebhookAccessor
+}
+
+func newClusterIndexedSource(source generic.Source) *clusterIndexedSource {
+	return &clusterIndexedSource{source: source}
+}
+
+func (s *clusterIndexedSource) HasSynced() bool {
+	return s.source.HasSynced()
+}
+
+// Webhooks returns the webhooks registered in the given logical cluster.
+func (s *clusterIndexedSource) Webhooks(clusterName logicalcluster.Name) []webhook.WebhookAccessor {
+	hooks := s.source.Webhooks()
+
+	s.lock.RLock()
+	if sameWebhooks(hooks, s.indexed) {
+		defer s.lock.RUnlock()
+		return s.byCluster[clusterName]
+	}
+	s.lock.RUnlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !sameWebhooks(hooks, s.indexed) {
+		byCluster := make(map[logicalcluster.Name][]webhook.WebhookAccessor)
+		for _, hook := range hooks {
+			hookCluster := hook.(webhookconfiguration.WebhookClusterAccessor).GetLogicalCluster()
+			byCluster[hookCluster] = append(byCluster[hookCluster], hook)
+		}
+		s.indexed, s.byCluster = hooks, byCluster
+	}
+	return s.byCluster[clusterName]
+}
+
+// sameWebhooks returns whether a and b are the same list, not only lists with equal elements. The
+// sources never modify a list they have returned, but replace it as a whole.
+func sameWebhooks(a, b []webhook.WebhookAccessor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	return len(a) == 0 || &a[0] == &b[0]
+}
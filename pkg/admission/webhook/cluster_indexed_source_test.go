@@ -0,0 +1,146 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/admission"
+	webhookconfiguration "k8s.io/apiserver/pkg/admission/configuration"
+	"k8s.io/apiserver/pkg/admission/plugin/webhook"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+)
+
+// replacingHookSource replaces its list of webhooks on every change, like the webhook configuration managers.
+type replacingHookSource struct {
+	hooks []webhook.WebhookAccessor
+}
+
+func (s *replacingHookSource) Webhooks() []webhook.WebhookAccessor {
+	return s.hooks
+}
+
+func (s *replacingHookSource) HasSynced() bool {
+	return true
+}
+
+func (s *replacingHookSource) add(hooks ...webhook.WebhookAccessor) {
+	s.hooks = append(append([]webhook.WebhookAccessor{}, s.hooks...), hooks...)
+}
+
+func newHook(cluster, uid string) webhook.WebhookAccessor {
+	return webhookconfiguration.WithCluster(logicalcluster.New(cluster), webhook.NewValidatingWebhookAccessor(uid, uid, nil))
+}
+
+func uids(hooks []webhook.WebhookAccessor) []string {
+	var ret []string
+	for _, h := range hooks {
+		ret = append(ret, h.GetUID())
+	}
+	return ret
+}
+
+func TestClusterIndexedSource(t *testing.T) {
+	source := &replacingHookSource{}
+	s := newClusterIndexedSource(source)
+
+	require.Empty(t, s.Webhooks(logicalcluster.New("root:org:ws")))
+
+	source.add(newHook("root:org:ws", "1"), newHook("root:org:other", "2"), newHook("root:org:ws", "3"))
+	require.Equal(t, []string{"1", "3"}, uids(s.Webhooks(logicalcluster.New("root:org:ws"))))
+	require.Equal(t, []string{"2"}, uids(s.Webhooks(logicalcluster.New("root:org:other"))))
+	require.Empty(t, s.Webhooks(logicalcluster.New("root:org:unknown")))
+
+	source.add(newHook("root:org:other", "4"))
+	require.Equal(t, []string{"2", "4"}, uids(s.Webhooks(logicalcluster.New("root:org:other"))))
+
+	source.hooks = []webhook.WebhookAccessor{newHook("root:org:other", "5")}
+	require.Empty(t, s.Webhooks(logicalcluster.New("root:org:ws")))
+	require.Equal(t, []string{"5"}, uids(s.Webhooks(logicalcluster.New("root:org:other"))))
+}
+
+// BenchmarkClusterIndexedSource shows that looking up the webhooks of a workspace does not depend
+// on the number of workspaces with webhooks.
+func BenchmarkClusterIndexedSource(b *testing.B) {
+	for _, workspaces := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("workspaces=%d", workspaces), func(b *testing.B) {
+			source := &replacingHookSource{}
+			for i := 0; i < workspaces; i++ {
+				cluster := fmt.Sprintf("root:org:ws-%d", i)
+				source.hooks = append(source.hooks, newHook(cluster, cluster+"/1"), newHook(cluster, cluster+"/2"))
+			}
+			s := newClusterIndexedSource(source)
+			cluster := logicalcluster.New(fmt.Sprintf("root:org:ws-%d", workspaces/2))
+			s.Webhooks(cluster) // build the index
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if len(s.Webhooks(cluster)) != 2 {
+					b.Fatal("unexpected number of webhooks")
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetAPIBindingWorkspace shows that looking up the export workspace of a bound resource does
+// not depend on the number of APIBindings.
+func BenchmarkGetAPIBindingWorkspace(b *testing.B) {
+	for _, bindings := range []int{10, 1000, 100000} {
+		b.Run(fmt.Sprintf("bindings=%d", bindings), func(b *testing.B) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{
+				indexers.APIBindingByClusterAndBoundGroupResources: indexers.IndexAPIBindingByClusterAndBoundGroupResources,
+			})
+			for i := 0; i < bindings; i++ {
+				cluster := fmt.Sprintf("root:org:ws-%d", i%100)
+				err := indexer.Add(&v1alpha1.APIBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        fmt.Sprintf("binding-%d", i),
+						Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+					},
+					Status: v1alpha1.APIBindingStatus{
+						BoundResources: []v1alpha1.BoundAPIResource{{Group: "wildwest.dev", Resource: fmt.Sprintf("cowboys-%d", i)}},
+						BoundAPIExport: &v1alpha1.ExportReference{
+							Workspace: &v1alpha1.WorkspaceExportReference{Path: "root:org:source-cluster"},
+						},
+					},
+				})
+				require.NoError(b, err)
+			}
+			p := &WebhookDispatcher{apiBindingsIndexer: indexer}
+			i := bindings / 2
+			a := attr(schema.GroupVersionKind{Group: "wildwest.dev", Version: "v1", Kind: "Cowboy"}, "bound-resource", fmt.Sprintf("cowboys-%d", i), admission.Create)
+			cluster := logicalcluster.New(fmt.Sprintf("root:org:ws-%d", i%100))
+
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if _, found, err := p.getAPIBindingWorkspace(a, cluster); err != nil || !found {
+					b.Fatal("binding not found")
+				}
+			}
+		})
+	}
+}
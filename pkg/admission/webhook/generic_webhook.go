@@ -22,9 +22,7 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/admission"
-	webhookconfiguration "k8s.io/apiserver/pkg/admission/configuration"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/generic"
 	"k8s.io/apiserver/pkg/admission/plugin/webhook/rules"
@@ -35,15 +33,14 @@ import (
 	"github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
-const byWorkspaceIndex = "webhookDispatcher-byWorkspace"
-
 var _ initializers.WantsKcpInformers = &WebhookDispatcher{}
 
 type WebhookDispatcher struct {
 	dispatcher           generic.Dispatcher
-	hookSource           generic.Source
+	hookSource           *clusterIndexedSource
 	apiBindingsIndexer   cache.Indexer
 	apiBindingsHasSynced func() bool
 	*admission.Handler
@@ -71,17 +68,16 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 		return admission.NewForbidden(attr, fmt.Errorf("not yet ready to handle request"))
 	}
 
-	hooks := p.hookSource.Webhooks()
 	var whAccessor []webhook.WebhookAccessor
 
 	// Determine the type of request, is it api binding or not.
 	if workspace, isAPIBinding, err := p.getAPIBindingWorkspace(attr, lcluster); err != nil {
 		return err
 	} else if isAPIBinding {
-		whAccessor = p.restrictToLogicalCluster(hooks, workspace)
+		whAccessor = p.hookSource.Webhooks(workspace)
 		klog.V(7).Infof("restricting call to api registration hooks in cluster: %v", workspace)
 	} else {
-		whAccessor = p.restrictToLogicalCluster(hooks, lcluster)
+		whAccessor = p.hookSource.Webhooks(lcluster)
 		klog.V(7).Infof("restricting call to hooks in cluster: %v", lcluster)
 	}
 
@@ -89,50 +85,27 @@ func (p *WebhookDispatcher) Dispatch(ctx context.Context, attr admission.Attribu
 }
 
 func (p *WebhookDispatcher) getAPIBindingWorkspace(attr admission.Attributes, clusterName logicalcluster.Name) (logicalcluster.Name, bool, error) {
-	objs, err := p.apiBindingsIndexer.ByIndex(byWorkspaceIndex, clusterName.String())
+	objs, err := p.apiBindingsIndexer.ByIndex(indexers.APIBindingByClusterAndBoundGroupResources, indexers.ClusterAndGroupResourceValue(clusterName, attr.GetResource().GroupResource()))
 	if err != nil {
 		return logicalcluster.New(""), false, err
 	}
-	for _, obj := range objs {
-		apiBinding := obj.(*apisv1alpha1.APIBinding)
-		for _, br := range apiBinding.Status.BoundResources {
-			if apiBinding.Status.BoundAPIExport != nil && apiBinding.Status.BoundAPIExport.Workspace == nil {
-				// this will never happen today. But as soon as we add other reference types (like exports), this log output will remind out of necessary work here.
-				klog.Errorf("APIBinding %s has no referenced workspace", clusterName, apiBinding.Name)
-				continue
-			}
-			if br.Group == attr.GetResource().Group && br.Resource == attr.GetResource().Resource {
-				return logicalcluster.New(apiBinding.Status.BoundAPIExport.Workspace.Path), true, nil
-			}
-		}
-	}
-	return logicalcluster.New(""), false, nil
-}
-
-// In the future use a restricted list call
-func (p *WebhookDispatcher) restrictToLogicalCluster(hooks []webhook.WebhookAccessor, lc logicalcluster.Name) []webhook.WebhookAccessor {
-	// TODO(sttts): this might not scale if there are many webhooks. This is called per request, and traverses all
-	//              webhook registrations. The hope is that there are not many webhooks per shard.
-	wh := []webhook.WebhookAccessor{}
-	for _, hook := range hooks {
-		if hook.(webhookconfiguration.WebhookClusterAccessor).GetLogicalCluster() == lc {
-			wh = append(wh, hook)
-		}
+	if len(objs) == 0 {
+		return logicalcluster.New(""), false, nil
 	}
-	return wh
+	// a group resource can only be bound once per workspace.
+	apiBinding := objs[0].(*apisv1alpha1.APIBinding)
+	return logicalcluster.New(apiBinding.Status.BoundAPIExport.Workspace.Path), true, nil
 }
 
 func (p *WebhookDispatcher) SetHookSource(s generic.Source) {
-	p.hookSource = s
+	p.hookSource = newClusterIndexedSource(s)
 }
 
 // SetKcpInformers implements the WantsExternalKcpInformerFactory interface.
 func (p *WebhookDispatcher) SetKcpInformers(f kcpinformers.SharedInformerFactory) {
-	if _, found := f.Apis().V1alpha1().APIBindings().Informer().GetIndexer().GetIndexers()[byWorkspaceIndex]; !found {
+	if _, found := f.Apis().V1alpha1().APIBindings().Informer().GetIndexer().GetIndexers()[indexers.APIBindingByClusterAndBoundGroupResources]; !found {
 		if err := f.Apis().V1alpha1().APIBindings().Informer().AddIndexers(cache.Indexers{
-			byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-				return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-			},
+			indexers.APIBindingByClusterAndBoundGroupResources: indexers.IndexAPIBindingByClusterAndBoundGroupResources,
 		}); err != nil {
 			// nothing we can do here. But this should also never happen. We check for existence before.
 			klog.Errorf("failed to add indexer for APIBindings: %v", err)
@@ -38,6 +38,7 @@ import (
 	"github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	"github.com/kcp-dev/kcp/pkg/indexers"
 )
 
 func attr(gvk schema.GroupVersionKind, name, resource string, op admission.Operation) admission.Attributes {
@@ -262,9 +263,7 @@ func TestDispatch(t *testing.T) {
 			fakeClient := fake.NewSimpleClientset(toObjects(tc.apiBindings)...)
 			fakeInformerFactory := kcpinformers.NewSharedInformerFactory(fakeClient, time.Hour)
 			err := fakeInformerFactory.Apis().V1alpha1().APIBindings().Informer().AddIndexers(cache.Indexers{
-				byWorkspaceIndex: func(obj interface{}) ([]string, error) {
-					return []string{logicalcluster.From(obj.(metav1.Object)).String()}, nil
-				},
+				indexers.APIBindingByClusterAndBoundGroupResources: indexers.IndexAPIBindingByClusterAndBoundGroupResources,
 			})
 			if err != nil {
 				t.Errorf("unable to add indexer to fake informer-%v", err)
@@ -273,7 +272,7 @@ func TestDispatch(t *testing.T) {
 			o := &WebhookDispatcher{
 				Handler:              admission.NewHandler(admission.Connect, admission.Create, admission.Delete, admission.Update),
 				dispatcher:           &validatingDispatcher{hooks: tc.expectedHooks},
-				hookSource:           &fakeHookSource{hooks: tc.hooksInSource, hasSynced: !tc.hookSourceNotSynced},
+				hookSource:           newClusterIndexedSource(&fakeHookSource{hooks: tc.hooksInSource, hasSynced: !tc.hookSourceNotSynced}),
 				apiBindingsIndexer:   fakeInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer(),
 				apiBindingsHasSynced: tc.apiBindingsSynced,
 			}
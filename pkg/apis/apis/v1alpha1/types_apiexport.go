@@ -83,6 +83,11 @@ const (
 	// MaximalPermissionPolicyRBACUserGroupPrefix is the prefix for the user and group names
 	// when verifying the APIExport.spec.maximalPermissionPolicy.
 	MaximalPermissionPolicyRBACUserGroupPrefix = "apis.kcp.dev:binding:"
+
+	// AnnotationMaximalPermissionPolicyKey is set by kcp on ClusterRoleBindings with subjects
+	// prefixed with MaximalPermissionPolicyRBACUserGroupPrefix, and on the ClusterRoles they refer to.
+	// Only RBAC objects with this annotation are replicated into the cache server.
+	AnnotationMaximalPermissionPolicyKey = "internal.apis.kcp.dev/maximal-permission-policy"
 )
 
 // APIExportSpec defines the desired state of APIExport.
@@ -172,12 +177,29 @@ type MaximalPermissionPolicy struct {
 	// local is policy that is defined in same namespace as API Export.
 	// +optional
 	Local *LocalAPIExportPolicy `json:"local,omitempty"`
+
+	// workspace is policy that is defined in another workspace, referenced by its path.
+	// This allows many API exports to share the same policy.
+	// +optional
+	Workspace *WorkspaceAPIExportPolicy `json:"workspace,omitempty"`
 }
 
 // LocalAPIExportPolicy will tell the APIBinding authorizer to check policy in the local namespace
 // of the API Export
 type LocalAPIExportPolicy struct{}
 
+// WorkspaceAPIExportPolicy will tell the APIBinding authorizer to check policy in the given workspace.
+// The RBAC ClusterRoles and ClusterRoleBindings in that workspace bound to the prefixed users and groups
+// (see MaximalPermissionPolicyRBACUserGroupPrefix) bound the permissions of the consumers.
+type WorkspaceAPIExportPolicy struct {
+	// path is a logical cluster path of the workspace holding the policy, e.g. root:org:policies.
+	//
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
+	Path string `json:"path"`
+}
+
 const (
 	APIExportPermissionClaimLabelPrefix = "claimed.internal.apis.kcp.dev/"
 )
@@ -531,6 +531,11 @@ func (in *MaximalPermissionPolicy) DeepCopyInto(out *MaximalPermissionPolicy) {
 		*out = new(LocalAPIExportPolicy)
 		**out = **in
 	}
+	if in.Workspace != nil {
+		in, out := &in.Workspace, &out.Workspace
+		*out = new(WorkspaceAPIExportPolicy)
+		**out = **in
+	}
 	return
 }
 
@@ -577,6 +582,22 @@ func (in *VirtualWorkspace) DeepCopy() *VirtualWorkspace {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkspaceAPIExportPolicy) DeepCopyInto(out *WorkspaceAPIExportPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkspaceAPIExportPolicy.
+func (in *WorkspaceAPIExportPolicy) DeepCopy() *WorkspaceAPIExportPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkspaceAPIExportPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkspaceExportReference) DeepCopyInto(out *WorkspaceExportReference) {
 	*out = *in
@@ -82,6 +82,29 @@ type PlacementSpec struct {
 	// +optional
 	// +kubebuilder:validation:Pattern:="^root(:[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$"
 	LocationWorkspace string `json:"locationWorkspace,omitempty"`
+
+	// spread describes how many instances of the selected location the placement is scheduled to.
+	// If it is not set, the placement is scheduled to one instance.
+	// +optional
+	Spread *PlacementSpread `json:"spread,omitempty"`
+}
+
+// PlacementSpread describes how a placement is spread over the instances of the selected location.
+type PlacementSpread struct {
+	// instances is the number of location instances the placement is scheduled to. If cellKey is set,
+	// it is the number of location instances per distinct value of that cell key.
+	//
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Instances int32 `json:"instances,omitempty"`
+
+	// cellKey is a key of the cells of the location instances. If it is set, the placement is spread
+	// over the distinct values of that cell key, and location instances without that cell key are not
+	// selected.
+	//
+	// +optional
+	CellKey string `json:"cellKey,omitempty"`
 }
 
 type PlacementStatus struct {
@@ -274,6 +274,11 @@ func (in *PlacementSpec) DeepCopyInto(out *PlacementSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Spread != nil {
+		in, out := &in.Spread, &out.Spread
+		*out = new(PlacementSpread)
+		**out = **in
+	}
 	return
 }
 
@@ -287,6 +292,22 @@ func (in *PlacementSpec) DeepCopy() *PlacementSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementSpread) DeepCopyInto(out *PlacementSpread) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementSpread.
+func (in *PlacementSpread) DeepCopy() *PlacementSpread {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementSpread)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementStatus) DeepCopyInto(out *PlacementStatus) {
 	*out = *in
@@ -17,15 +17,25 @@ limitations under the License.
 package projection
 
 import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
 )
 
 func ProjectClusterWorkspaceToWorkspace(from *tenancyv1alpha1.ClusterWorkspace, to *tenancyv1beta1.Workspace) {
 	to.ObjectMeta = from.ObjectMeta
 	to.Spec.Type = from.Spec.Type
+	to.Spec.ReadOnly = from.Spec.ReadOnly
 	to.Status.URL = from.Status.BaseURL
 	to.Status.Phase = from.Status.Phase
+	if from.Status.Location != (tenancyv1alpha1.ClusterWorkspaceLocation{}) {
+		location := from.Status.Location
+		to.Status.Location = &location
+	}
 	to.Status.Initializers = from.Status.Initializers
 
 	to.Annotations = make(map[string]string, len(from.Annotations))
@@ -34,13 +44,27 @@ func ProjectClusterWorkspaceToWorkspace(from *tenancyv1alpha1.ClusterWorkspace,
 			// do not leak user information
 			continue
 		}
+		if k == tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey {
+			// surfaced as managed fields below
+			continue
+		}
 		to.Annotations[k] = v
 	}
 
+	// The managed fields of the ClusterWorkspace are about the v1alpha1 schema. The Workspace has
+	// its own, stored in an annotation by the workspaces virtual workspace.
+	to.ManagedFields = nil
+	if v, found := from.Annotations[tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey]; found {
+		var managedFields []metav1.ManagedFieldsEntry
+		if err := json.Unmarshal([]byte(v), &managedFields); err == nil {
+			to.ManagedFields = managedFields
+		}
+	}
+
 	for i := range from.Status.Conditions {
 		c := &from.Status.Conditions[i]
 		switch c.Type {
-		case tenancyv1alpha1.WorkspaceContentDeleted, tenancyv1alpha1.WorkspaceDeletionContentSuccess, tenancyv1alpha1.WorkspaceInitialized:
+		case conditionsv1alpha1.ReadyCondition, tenancyv1alpha1.WorkspaceContentDeleted, tenancyv1alpha1.WorkspaceDeletionContentSuccess, tenancyv1alpha1.WorkspaceInitialized:
 			to.Status.Conditions = append(to.Status.Conditions, *c)
 		}
 	}
@@ -221,6 +221,15 @@ type ClusterWorkspaceTypeSpec struct {
 	//
 	// +optional
 	LimitAllowedParents *ClusterWorkspaceTypeSelector `json:"limitAllowedParents,omitempty"`
+
+	// deletionRetentionPeriod is the time a deleted ClusterWorkspace of this type is kept
+	// before its contents are purged. During that period the workspace is in the Deleted
+	// phase, read-only, and can be restored with `kubectl ws restore`. When unset, the
+	// contents of a deleted workspace are purged immediately. Extending another
+	// ClusterWorkspaceType does not inherit its deletionRetentionPeriod.
+	//
+	// +optional
+	DeletionRetentionPeriod *metav1.Duration `json:"deletionRetentionPeriod,omitempty"`
 }
 
 // ClusterWorkspaceTypeSelector describes a set of types.
@@ -330,13 +339,45 @@ const (
 	ClusterWorkspacePhaseScheduling   ClusterWorkspacePhaseType = "Scheduling"
 	ClusterWorkspacePhaseInitializing ClusterWorkspacePhaseType = "Initializing"
 	ClusterWorkspacePhaseReady        ClusterWorkspacePhaseType = "Ready"
+	// ClusterWorkspacePhaseDeleted is the phase of a deleted workspace whose contents are retained
+	// for the deletion retention period of its type. It is read-only and can be restored.
+	ClusterWorkspacePhaseDeleted ClusterWorkspacePhaseType = "Deleted"
 )
 
 const ExperimentalClusterWorkspaceOwnerAnnotationKey string = "experimental.tenancy.kcp.dev/owner"
 
+// ClusterWorkspaceMigrationReadOnlyAnnotationKey marks a workspace that was made read-only for a
+// migration to another shard, and has to be made writable again once the migration has switched
+// over to the target shard.
+const ClusterWorkspaceMigrationReadOnlyAnnotationKey string = "internal.tenancy.kcp.dev/migration-read-only"
+
+// ClusterWorkspaceMigrationProgressAnnotationKey holds the position of the ongoing copy or deletion
+// of the workspace contents during a migration to another shard, in order to continue after a restart.
+const ClusterWorkspaceMigrationProgressAnnotationKey string = "internal.tenancy.kcp.dev/migration-progress"
+
+// InternalWorkspaceManagedFieldsAnnotationKey holds the managed fields of the Workspace projection
+// of a ClusterWorkspace, as maintained by the workspaces virtual workspace for server-side apply.
+const InternalWorkspaceManagedFieldsAnnotationKey string = "internal.tenancy.kcp.dev/workspace-managed-fields"
+
+// ClusterWorkspaceRestoreAnnotationKey requests the restoration of a deleted workspace in the
+// Deleted phase. It is set by `kubectl ws restore`.
+const ClusterWorkspaceRestoreAnnotationKey string = "tenancy.kcp.dev/restore"
+
+// ClusterWorkspaceDeletionReadOnlyAnnotationKey marks a deleted workspace that was made read-only
+// for its deletion retention period, and has to be made writable again when it is restored.
+const ClusterWorkspaceDeletionReadOnlyAnnotationKey string = "internal.tenancy.kcp.dev/deletion-read-only"
+
+// ClusterWorkspaceRestoredFromAnnotationKey is set on a restored workspace to the UID of the deleted
+// workspace it was recreated from. Only the restore of that workspace may create it while it is pending.
+const ClusterWorkspaceRestoredFromAnnotationKey string = "internal.tenancy.kcp.dev/restored-from"
+
+// ClusterWorkspaceOwnerNameLabel is set on the ClusterRoles and ClusterRoleBindings in the parent
+// workspace that grant the owner of a workspace access to it. Its value is the workspace name.
+const ClusterWorkspaceOwnerNameLabel string = "workspaces.kcp.dev/name"
+
 // ClusterWorkspaceStatus communicates the observed state of the ClusterWorkspace.
 type ClusterWorkspaceStatus struct {
-	// Phase of the workspace  (Scheduling / Initializing / Ready)
+	// Phase of the workspace  (Scheduling / Initializing / Ready / Deleted)
 	Phase ClusterWorkspacePhaseType `json:"phase,omitempty"`
 
 	// Current processing state of the ClusterWorkspace.
@@ -378,8 +419,8 @@ const (
 	// some unexpected reason.
 	WorkspaceReasonReasonUnknown = "Unknown"
 	// WorkspaceReasonUnreschedulable reason in WorkspaceScheduled WorkspaceCondition means that the scheduler
-	// can't reschedule the workspace right now, for example because it not in Scheduling phase anymore and
-	// movement is not possible.
+	// can't reschedule the workspace right now, for example because no other shard matches the shard
+	// constraints of the workspace.
 	WorkspaceReasonUnreschedulable = "Unreschedulable"
 
 	// WorkspaceShardValid represents status of the connection process for this cluster workspace.
@@ -393,6 +434,9 @@ const (
 
 	// WorkspaceContentDeleted represents the status that all resources in the workspace is deleted.
 	WorkspaceContentDeleted conditionsv1alpha1.ConditionType = "WorkspaceContentDeleted"
+	// WorkspaceContentDeletedReasonRetained reason in WorkspaceContentDeleted condition means that the
+	// contents of the deleted workspace are retained for the deletion retention period of its type.
+	WorkspaceContentDeletedReasonRetained = "Retained"
 
 	// WorkspaceInitialized represents the status that initialization has finished.
 	WorkspaceInitialized conditionsv1alpha1.ConditionType = "WorkspaceInitialized"
@@ -402,6 +446,28 @@ const (
 	// WorkspaceInitializedAPIBindingNotBound reason in WorkspaceInitialized condition means that at least
 	// one APIBinding is not yet bound to the workspace.
 	WorkspaceInitializedAPIBindingNotBound = "APIBindingNotBound"
+
+	// WorkspaceMigrated represents the status of moving the workspace contents from the current to the
+	// target shard. It is false while a migration is in progress or has failed, and true once finished.
+	WorkspaceMigrated conditionsv1alpha1.ConditionType = "WorkspaceMigrated"
+	// WorkspaceMigrationReasonTargetShardNotFound reason in WorkspaceMigrated condition means that the
+	// target ClusterWorkspaceShard does not exist.
+	WorkspaceMigrationReasonTargetShardNotFound = "TargetShardNotFound"
+	// WorkspaceMigrationReasonNotReady reason in WorkspaceMigrated condition means that the migration
+	// waits for the workspace to become ready.
+	WorkspaceMigrationReasonNotReady = "NotReady"
+	// WorkspaceMigrationReasonCopying reason in WorkspaceMigrated condition means that the workspace is
+	// read-only and its objects are being copied to the target shard.
+	WorkspaceMigrationReasonCopying = "Copying"
+	// WorkspaceMigrationReasonCopyFailed reason in WorkspaceMigrated condition means that copying the
+	// objects to the target shard failed. The copy is retried.
+	WorkspaceMigrationReasonCopyFailed = "CopyFailed"
+	// WorkspaceMigrationReasonDeletingSource reason in WorkspaceMigrated condition means that the workspace
+	// is served from the target shard, and its objects are being deleted from the previous shard.
+	WorkspaceMigrationReasonDeletingSource = "DeletingSource"
+	// WorkspaceMigrationReasonDeleteFailed reason in WorkspaceMigrated condition means that deleting the
+	// objects from the previous shard failed. The deletion is retried.
+	WorkspaceMigrationReasonDeleteFailed = "DeleteFailed"
 )
 
 // ClusterWorkspaceLocation specifies workspace placement information, including current, desired (target), and
@@ -412,11 +478,17 @@ type ClusterWorkspaceLocation struct {
 	// +optional
 	Current string `json:"current,omitempty"`
 
-	// Target workspace placement (shard).
+	// Target workspace placement (shard). When set, the workspace is migrated from the current
+	// to the target shard.
 	//
 	// +optional
-	// +kubebuilder:validation:Enum=""
 	Target string `json:"target,omitempty"`
+
+	// Previous workspace placement (shard) from which the workspace contents are being deleted
+	// after a migration to the current shard.
+	//
+	// +optional
+	Previous string `json:"previous,omitempty"`
 }
 
 // ClusterWorkspaceList is a list of ClusterWorkspace resources
@@ -509,6 +581,10 @@ type ClusterWorkspaceShardSpec struct {
 	VirtualWorkspaceURL string `json:"virtualWorkspaceURL,omitempty"`
 }
 
+// ClusterWorkspaceShardCapacityWorkspaces is the capacity resource of a ClusterWorkspaceShard
+// limiting the number of workspaces that are scheduled onto the shard.
+const ClusterWorkspaceShardCapacityWorkspaces corev1.ResourceName = "workspaces"
+
 // ClusterWorkspaceShardStatus communicates the observed state of the ClusterWorkspaceShard.
 type ClusterWorkspaceShardStatus struct {
 	// Set of integer resources that workspaces can be scheduled into
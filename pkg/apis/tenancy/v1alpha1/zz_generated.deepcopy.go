@@ -409,6 +409,11 @@ func (in *ClusterWorkspaceTypeSpec) DeepCopyInto(out *ClusterWorkspaceTypeSpec)
 		*out = new(ClusterWorkspaceTypeSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DeletionRetentionPeriod != nil {
+		in, out := &in.DeletionRetentionPeriod, &out.DeletionRetentionPeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -52,6 +52,14 @@ type Workspace struct {
 	Status WorkspaceStatus `json:"status,omitempty"`
 }
 
+func (in *Workspace) SetConditions(c conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = c
+}
+
+func (in *Workspace) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
+
 // WorkspaceSpec holds the desired state of the ClusterWorkspace.
 type WorkspaceSpec struct {
 	// type defines properties of the workspace both on creation (e.g. initial
@@ -66,6 +74,11 @@ type WorkspaceSpec struct {
 	//
 	// +optional
 	Type v1alpha1.ClusterWorkspaceTypeReference `json:"type,omitempty"`
+
+	// readOnly marks the workspace as read-only.
+	//
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
 }
 
 // WorkspaceStatus communicates the observed state of the Workspace.
@@ -81,6 +94,11 @@ type WorkspaceStatus struct {
 	// Phase of the workspace (Initializing / Active / Terminating). This field is ALPHA.
 	Phase v1alpha1.ClusterWorkspacePhaseType `json:"phase,omitempty"`
 
+	// location is the placement of the workspace on shards.
+	//
+	// +optional
+	Location *v1alpha1.ClusterWorkspaceLocation `json:"location,omitempty"`
+
 	// Current processing state of the ClusterWorkspace.
 	// +optional
 	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	"crypto/sha256"
 	"math/big"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 )
@@ -31,6 +32,15 @@ func ToSyncTargetKey(syncTargetWorkspace logicalcluster.Name, syncTargetName str
 	return base62hash
 }
 
+// ParseSyncTargetPlacementAnnotation returns the SyncTarget keys stored in the value of the
+// InternalSyncTargetPlacementAnnotationKey annotation of a placement.
+func ParseSyncTargetPlacementAnnotation(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
 func toBase62(hash [28]byte) string {
 	var i big.Int
 	i.SetBytes(hash[:])
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceSyncState is the state of the sync of an upstream resource to a sync target. The syncer
+// stores it as JSON in the sync-state.workload.kcp.dev/<sync-target-key> annotation of the
+// upstream resource.
+type ResourceSyncState struct {
+	// syncedGeneration is the generation of the upstream resource last synced to the sync target.
+	//
+	// +optional
+	SyncedGeneration int64 `json:"syncedGeneration,omitempty"`
+
+	// specError is the error of the last failed sync of the resource to the sync target. It is
+	// removed once the resource is synced successfully.
+	//
+	// +optional
+	SpecError *ResourceSyncError `json:"specError,omitempty"`
+
+	// statusError is the error of the last failed sync of the status of the resource from the
+	// sync target. It is removed once the status is synced successfully.
+	//
+	// +optional
+	StatusError *ResourceSyncError `json:"statusError,omitempty"`
+}
+
+// ResourceSyncError describes why the sync of a resource failed.
+type ResourceSyncError struct {
+	// message describes the error.
+	//
+	// +required
+	// +kubebuilder:Required
+	Message string `json:"message"`
+
+	// lastErrorTime is the first time the sync failed with this message.
+	//
+	// +required
+	// +kubebuilder:Required
+	LastErrorTime metav1.Time `json:"lastErrorTime"`
+}
+
+// SyncDirection is the direction of a sync between kcp and a sync target.
+type SyncDirection string
+
+const (
+	// SyncDirectionSpec is the sync of resources from kcp to the sync target.
+	SyncDirectionSpec SyncDirection = "Spec"
+	// SyncDirectionStatus is the sync of the status of resources from the sync target to kcp.
+	SyncDirectionStatus SyncDirection = "Status"
+)
+
+// MaxSyncErrors is the maximal number of sync errors in the status of a SyncTarget.
+const MaxSyncErrors = 10
+
+// SyncError describes a resource the syncer fails to sync.
+type SyncError struct {
+	// direction is the direction of the failing sync.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:Enum=Spec;Status
+	Direction SyncDirection `json:"direction"`
+
+	// resource is the group-version-resource of the resource, in the form <resource>.<version>.<group>.
+	//
+	// +required
+	// +kubebuilder:Required
+	Resource string `json:"resource"`
+
+	// workspace is the logical cluster of the resource.
+	//
+	// +required
+	// +kubebuilder:Required
+	Workspace string `json:"workspace"`
+
+	// namespace is the namespace of the resource. It is empty for cluster-scoped resources.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// name is the name of the resource.
+	//
+	// +required
+	// +kubebuilder:Required
+	Name string `json:"name"`
+
+	// message describes the error.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// lastErrorTime is the first time the sync failed with this message.
+	//
+	// +required
+	// +kubebuilder:Required
+	LastErrorTime metav1.Time `json:"lastErrorTime"`
+}
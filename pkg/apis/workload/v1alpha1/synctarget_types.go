@@ -109,6 +109,12 @@ type SyncTargetStatus struct {
 	// VirtualWorkspaces contains all syncer virtual workspace URLs.
 	// +optional
 	VirtualWorkspaces []VirtualWorkspace `json:"virtualWorkspaces,omitempty"`
+
+	// syncErrors are the resources the syncer currently fails to sync, most recent first, at most
+	// MaxSyncErrors. The sync state of every resource is found in its
+	// sync-state.workload.kcp.dev/<sync-target-key> annotation.
+	// +optional
+	SyncErrors []SyncError `json:"syncErrors,omitempty"`
 }
 
 type ResourceToSync struct {
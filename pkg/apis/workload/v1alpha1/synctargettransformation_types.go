@@ -0,0 +1,305 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+)
+
+// SyncTargetTransformation describes transformations the syncer of a SyncTarget applies to
+// resources before they are synced down to the physical cluster, e.g. to rewrite images to a
+// registry mirror, to add node selectors and tolerations, or to rename storage classes.
+//
+// The transformations are applied after the built-in transformations of the syncer.
+//
+// +crd
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,categories=kcp
+// +kubebuilder:printcolumn:name="SyncTarget",type="string",JSONPath=`.spec.syncTargetName`
+// +kubebuilder:printcolumn:name="Applied",type="string",JSONPath=`.status.conditions[?(@.type=="RulesApplied")].status`
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SyncTargetTransformation struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the desired state.
+	// +optional
+	Spec SyncTargetTransformationSpec `json:"spec,omitempty"`
+
+	// Status communicates the observed state.
+	// +optional
+	Status SyncTargetTransformationStatus `json:"status,omitempty"`
+}
+
+var _ conditions.Getter = &SyncTargetTransformation{}
+var _ conditions.Setter = &SyncTargetTransformation{}
+
+// SyncTargetTransformationSpec holds the desired state of the SyncTargetTransformation.
+type SyncTargetTransformationSpec struct {
+	// syncTargetName is the name of the SyncTarget in the same workspace whose syncer
+	// applies the rules.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:MinLength=1
+	SyncTargetName string `json:"syncTargetName"`
+
+	// rules are applied in order to every synced resource they select.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:MinItems=1
+	// +listType=map
+	// +listMapKey=name
+	Rules []TransformationRule `json:"rules"`
+}
+
+// TransformationRule transforms the resources selected by resource and labelSelector, first
+// by applying jsonPatch, then by applying fields.
+type TransformationRule struct {
+	// name identifies the rule in the status.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Name string `json:"name"`
+
+	// resource selects the resource type the rule applies to.
+	//
+	// +required
+	// +kubebuilder:Required
+	Resource TransformationResource `json:"resource"`
+
+	// labelSelector selects the objects the rule applies to by their labels in kcp.
+	// If it is not set, the rule applies to all objects of the resource type.
+	//
+	// +optional
+	LabelSelector *metav1.LabelSelector `json:"labelSelector,omitempty"`
+
+	// jsonPatch is a JSON patch (https://tools.ietf.org/html/rfc6902) applied to the object.
+	//
+	// +optional
+	JSONPatch []JSONPatchOperation `json:"jsonPatch,omitempty"`
+
+	// fields are transformations of single fields of the object.
+	//
+	// +optional
+	Fields []FieldTransformation `json:"fields,omitempty"`
+}
+
+// TransformationResource selects a resource type.
+type TransformationResource struct {
+	// group is the name of an API group. The empty string is the core group.
+	//
+	// +kubebuilder:validation:Pattern=`^(|[a-z0-9]([-a-z0-9]*[a-z0-9](\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*)?)$`
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// version is the version of the API. If it is not set, all versions are selected.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z][-a-z0-9]*[a-z0-9]$`
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// resource is the name of the resource, e.g. deployments.
+	//
+	// +kubebuilder:validation:Pattern=`^[a-z][-a-z0-9]*[a-z0-9]$`
+	// +required
+	// +kubebuilder:Required
+	Resource string `json:"resource"`
+}
+
+// JSONPatchOperation is a single operation of a JSON patch.
+type JSONPatchOperation struct {
+	// op is the operation to perform.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:Enum=add;remove;replace;move;copy;test
+	Op string `json:"op"`
+
+	// path is the JSON pointer to the target of the operation.
+	//
+	// +required
+	// +kubebuilder:Required
+	Path string `json:"path"`
+
+	// from is the JSON pointer to the source of a move or copy operation.
+	//
+	// +optional
+	From string `json:"from,omitempty"`
+
+	// value is the value of an add, replace or test operation.
+	//
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Value *runtime.RawExtension `json:"value,omitempty"`
+}
+
+// FieldTransformation sets or rewrites the fields found at a path.
+type FieldTransformation struct {
+	// path is a JSON pointer to the field, e.g. /spec/template/spec/nodeSelector. A "*" segment
+	// matches every element of a list and every value of a map, e.g.
+	// /spec/template/spec/containers/*/image.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:Pattern=`^/`
+	Path string `json:"path"`
+
+	// value replaces the fields at path. Missing maps on the path are created, unless the
+	// path contains a "*" segment.
+	//
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Value *runtime.RawExtension `json:"value,omitempty"`
+
+	// replacements rewrite the existing string fields at path. The first matching
+	// replacement is applied. Fields that do not exist are left alone.
+	//
+	// +optional
+	Replacements []StringReplacement `json:"replacements,omitempty"`
+}
+
+// StringReplacement replaces a string value.
+type StringReplacement struct {
+	// from is the value to replace.
+	//
+	// +required
+	// +kubebuilder:Required
+	// +kubebuilder:validation:MinLength=1
+	From string `json:"from"`
+
+	// to is the replacement.
+	//
+	// +optional
+	To string `json:"to"`
+
+	// match is how from is matched. With Exact the whole value is replaced, with Prefix only
+	// the matched prefix, e.g. to rewrite images to a registry mirror.
+	//
+	// +kubebuilder:validation:Enum=Exact;Prefix
+	// +kubebuilder:default=Exact
+	// +optional
+	Match StringMatchType `json:"match,omitempty"`
+}
+
+// StringMatchType is the way a StringReplacement matches a value.
+type StringMatchType string
+
+const (
+	// StringMatchExact matches the whole value.
+	StringMatchExact StringMatchType = "Exact"
+	// StringMatchPrefix matches a prefix of the value.
+	StringMatchPrefix StringMatchType = "Prefix"
+)
+
+// SyncTargetTransformationStatus communicates the observed state of the SyncTargetTransformation.
+type SyncTargetTransformationStatus struct {
+	// Current processing state of the SyncTargetTransformation.
+	// +optional
+	Conditions conditionsv1alpha1.Conditions `json:"conditions,omitempty"`
+
+	// failures are the objects the rules currently fail to apply to, at most
+	// MaxTransformationFailures. An object with a failing rule is not synced.
+	//
+	// +optional
+	Failures []TransformationFailure `json:"failures,omitempty"`
+}
+
+// MaxTransformationFailures is the maximal number of failures recorded in the status of a SyncTargetTransformation.
+const MaxTransformationFailures = 10
+
+// TransformationFailure describes a rule that failed to apply to an object.
+type TransformationFailure struct {
+	// rule is the name of the failing rule.
+	//
+	// +required
+	// +kubebuilder:Required
+	Rule string `json:"rule"`
+
+	// resource is the group-version-resource of the object, in the form <resource>.<version>.<group>.
+	//
+	// +required
+	// +kubebuilder:Required
+	Resource string `json:"resource"`
+
+	// workspace is the logical cluster of the object.
+	//
+	// +required
+	// +kubebuilder:Required
+	Workspace string `json:"workspace"`
+
+	// namespace is the namespace of the object. It is empty for cluster-scoped objects.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// name is the name of the object.
+	//
+	// +required
+	// +kubebuilder:Required
+	Name string `json:"name"`
+
+	// message describes why the rule failed to apply.
+	//
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// lastFailureTime is the last time the rule failed to apply to the object.
+	//
+	// +required
+	// +kubebuilder:Required
+	LastFailureTime metav1.Time `json:"lastFailureTime"`
+}
+
+// SyncTargetTransformationList is a list of SyncTargetTransformation resources
+//
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SyncTargetTransformationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SyncTargetTransformation `json:"items"`
+}
+
+// Conditions and ConditionReasons for the kcp SyncTargetTransformation object.
+const (
+	// RulesApplied means that the syncer applies all rules without failures.
+	RulesApplied conditionsv1alpha1.ConditionType = "RulesApplied"
+
+	// RuleFailedReason indicates that a rule failed to apply to at least one object.
+	RuleFailedReason = "RuleFailed"
+)
+
+func (in *SyncTargetTransformation) SetConditions(conditions conditionsv1alpha1.Conditions) {
+	in.Status.Conditions = conditions
+}
+
+func (in *SyncTargetTransformation) GetConditions() conditionsv1alpha1.Conditions {
+	return in.Status.Conditions
+}
@@ -43,6 +43,20 @@ const (
 	// TODO(sttts): use sync-target-uid instead of sync-target-name
 	InternalClusterDeletionTimestampAnnotationPrefix = "deletion.internal.workload.kcp.dev/"
 
+	// InternalClusterMigrationAnnotationPrefix is the prefix of the annotation
+	//
+	//   migration.internal.workload.kcp.dev/<sync-target-name>
+	//
+	// on namespaces storing the timestamp when the namespace started to move away from
+	// the sync target to the other sync targets it is scheduled to. While the annotation
+	// exists, the sync target stays in "Sync" state. The migration controller replaces it
+	// with deletion.internal.workload.kcp.dev/<sync-target-name> once the resources of
+	// the namespace are ready on the new sync targets, as reported in
+	// experimental.status.workload.kcp.dev/<sync-target-name>.
+	//
+	// The format is RFC3339.
+	InternalClusterMigrationAnnotationPrefix = "migration.internal.workload.kcp.dev/"
+
 	// ClusterFinalizerAnnotationPrefix is the prefix of the annotation
 	//
 	//   finalizers.workload.kcp.dev/<sync-target-name>
@@ -91,6 +105,17 @@ const (
 	// The format is JSON.
 	InternalClusterStatusAnnotationPrefix = "experimental.status.workload.kcp.dev/"
 
+	// InternalClusterStatusGenerationAnnotationPrefix is the prefix of the annotation
+	//
+	//   experimental.status-generation.workload.kcp.dev/<sync-target-name>
+	//
+	// on upstream resources storing the metadata.generation of the downstream resource whose status
+	// is stored in experimental.status.workload.kcp.dev/<sync-target-name>. The status is up to date
+	// if its observedGeneration is not older than that.
+	//
+	// Note that this is experimental and will disappear in the future without prior notice.
+	InternalClusterStatusGenerationAnnotationPrefix = "experimental.status-generation.workload.kcp.dev/"
+
 	// ClusterSpecDiffAnnotationPrefix is the prefix of the annotation
 	//
 	//   experimental.spec-diff.workload.kcp.dev/<sync-target-name>
@@ -105,6 +130,17 @@ const (
 	// The format for the value of this annotation is: JSON Patch (https://tools.ietf.org/html/rfc6902).
 	ClusterSpecDiffAnnotationPrefix = "experimental.spec-diff.workload.kcp.dev/"
 
+	// SyncStateAnnotationPrefix is the prefix of the annotation
+	//
+	//   sync-state.workload.kcp.dev/<sync-target-key>
+	//
+	// on upstream resources storing the state of the sync of the resource to the sync target: the
+	// last synced generation, and the last errors syncing the resource to the sync target and its
+	// status back. It is set by the syncer, and summarized in the syncErrors of the SyncTarget status.
+	//
+	// The format is JSON, see ResourceSyncState.
+	SyncStateAnnotationPrefix = "sync-state.workload.kcp.dev/"
+
 	// InternalDownstreamClusterLabel is a label with the upstream cluster name applied on the downstream cluster
 	// instead of state.workload.kcp.dev/<sync-target-name> which is used upstream.
 	InternalDownstreamClusterLabel = "internal.workload.kcp.dev/cluster"
@@ -113,10 +149,20 @@ const (
 	// has been created already. If the created default resource is deleted, it will not be recreated.
 	AnnotationSkipDefaultObjectCreation = "workload.kcp.dev/skip-default-object-creation"
 
-	// InternalSyncTargetPlacementAnnotationKey is a internal annotation key on placement API to mark the synctarget scheduled
-	// from this placement. The value is a hash of the SyncTarget workspace + SyncTarget name, generated with the ToSyncTargetKey(..) helper func.
+	// InternalSyncTargetPlacementAnnotationKey is a internal annotation key on placement API to mark the synctargets scheduled
+	// from this placement. The value is a comma separated list of hashes of the SyncTarget workspace + SyncTarget name, generated
+	// with the ToSyncTargetKey(..) helper func. Use ParseSyncTargetPlacementAnnotation(..) to read it.
 	InternalSyncTargetPlacementAnnotationKey = "internal.workload.kcp.dev/synctarget"
 
+	// InternalSyncTargetPlacementScoreAnnotationKey is an internal annotation key on placement API recording the scores of the
+	// synctargets scheduled from this placement, at the time they were scheduled. The value is a comma separated list, in the
+	// same order as the synctargets in the internal.workload.kcp.dev/synctarget annotation.
+	InternalSyncTargetPlacementScoreAnnotationKey = "internal.workload.kcp.dev/synctarget-score"
+
+	// InternalSyncTargetPlacementReasonAnnotationKey is an internal annotation key on placement API recording a human readable
+	// explanation of why the synctargets were scheduled from this placement.
+	InternalSyncTargetPlacementReasonAnnotationKey = "internal.workload.kcp.dev/synctarget-reason"
+
 	// InternalSyncTargetKeyLabel is an internal label set on a SyncTarget resource that contains the full hash of the SyncTargetKey, generated with the ToSyncTargetKey(..)
 	// helper func, this label is used for reverse lookups of a syncTargetKey to SyncTarget.
 	InternalSyncTargetKeyLabel = "internal.workload.kcp.dev/key"
@@ -28,6 +28,8 @@ import (
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
 	kubernetesinformers "k8s.io/client-go/informers"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/plugin/pkg/auth/authorizer/rbac"
 
@@ -50,7 +52,11 @@ const (
 // bound resource or not. If the resource is bound we will check the user has RBAC access in the
 // exported resources workspace. If it is not allowed we will return NoDecision, if allowed we
 // will call the delegate authorizer.
-func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory, delegate authorizer.Authorizer) (authorizer.Authorizer, error) {
+//
+// Maximal permission policies in other workspaces are read from the RBAC replicated into the cache
+// server, given by cacheKubeInformers, because the policy workspace may live on another shard. If
+// cacheKubeInformers is nil, they are read from the local RBAC, and a policy on another shard denies.
+func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory, cacheKubeInformers kubernetesinformers.SharedInformerFactory, delegate authorizer.Authorizer) (authorizer.Authorizer, error) {
 	if _, found := kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer().GetIndexers()[byWorkspaceIndex]; !found {
 		err := kcpInformers.Apis().V1alpha1().APIBindings().Informer().AddIndexers(
 			cache.Indexers{
@@ -84,8 +90,17 @@ func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInfor
 	kubeInformers.Rbac().V1().ClusterRoles().Lister()
 	kubeInformers.Rbac().V1().ClusterRoleBindings().Lister()
 
+	var cacheRbacInformers rbacinformers.Interface
+	if cacheKubeInformers != nil {
+		// only cluster roles and their bindings are replicated into the cache server
+		cacheRbacInformers = cacheKubeInformers.Rbac().V1()
+		cacheRbacInformers.ClusterRoles().Lister()
+		cacheRbacInformers.ClusterRoleBindings().Lister()
+	}
+
 	return &apiBindingAccessAuthorizer{
 		versionedInformers: kubeInformers,
+		cacheRbacInformers: cacheRbacInformers,
 		apiBindingIndexer:  kcpInformers.Apis().V1alpha1().APIBindings().Informer().GetIndexer(),
 		apiExportIndexer:   kcpInformers.Apis().V1alpha1().APIExports().Informer().GetIndexer(),
 		delegate:           delegate,
@@ -94,6 +109,7 @@ func NewAPIBindingAccessAuthorizer(kubeInformers kubernetesinformers.SharedInfor
 
 type apiBindingAccessAuthorizer struct {
 	versionedInformers kubernetesinformers.SharedInformerFactory
+	cacheRbacInformers rbacinformers.Interface // nil without cache server
 	apiBindingIndexer  cache.Indexer
 	apiExportIndexer   cache.Indexer
 	delegate           authorizer.Authorizer
@@ -168,23 +184,18 @@ func (a *apiBindingAccessAuthorizer) Authorize(ctx context.Context, attr authori
 		return a.delegate.Authorize(ctx, attr)
 	}
 
-	if apiExport.Spec.MaximalPermissionPolicy.Local == nil {
+	policyCluster, policySource := maximalPermissionPolicyCluster(apiExport)
+	if policyCluster.Empty() {
 		kaudit.AddAuditAnnotations(
 			ctx,
 			APIBindingContentAuditDecision, DecisionAllowed,
-			APIBindingContentAuditReason, fmt.Sprintf("no maximal local permission policy present in API export %q, path: %q, owning cluster: %q", apiExport.Name, path, logicalcluster.From(apiExport)),
+			APIBindingContentAuditReason, fmt.Sprintf("no maximal permission policy source set in API export %q, path: %q, owning cluster: %q", apiExport.Name, path, logicalcluster.From(apiExport)),
 		)
 		return a.delegate.Authorize(ctx, attr)
 	}
 
-	// If bound, create a rbac authorizer filtered to the cluster.
-	clusterKubeInformer := rbacwrapper.FilterInformers(logicalcluster.From(apiExport), a.versionedInformers.Rbac().V1())
-	clusterAuthorizer := rbac.New(
-		&rbac.RoleGetter{Lister: clusterKubeInformer.Roles().Lister()},
-		&rbac.RoleBindingLister{Lister: clusterKubeInformer.RoleBindings().Lister()},
-		&rbac.ClusterRoleGetter{Lister: clusterKubeInformer.ClusterRoles().Lister()},
-		&rbac.ClusterRoleBindingLister{Lister: clusterKubeInformer.ClusterRoleBindings().Lister()},
-	)
+	// If bound, create a rbac authorizer filtered to the cluster holding the policy.
+	clusterAuthorizer := a.policyAuthorizer(policyCluster, apiExport.Spec.MaximalPermissionPolicy.Local != nil)
 	prefixedAttr := deepCopyAttributes(attr)
 	userInfo := prefixedAttr.User.(*user.DefaultInfo)
 	userInfo.Name = apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + userInfo.Name
@@ -197,22 +208,70 @@ func (a *apiBindingAccessAuthorizer) Authorize(ctx context.Context, attr authori
 		kaudit.AddAuditAnnotations(
 			ctx,
 			APIBindingContentAuditDecision, DecisionNoOpinion,
-			APIBindingContentAuditReason, fmt.Sprintf("error authorizing RBAC in API export cluster %q: %v", logicalcluster.From(apiExport), err),
+			APIBindingContentAuditReason, fmt.Sprintf("error authorizing RBAC of %s of API export %q, path: %q: %v", policySource, apiExport.Name, path, err),
 		)
 		return authorizer.DecisionNoOpinion, reason, err
 	}
 
+	if dec == authorizer.DecisionAllow {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			APIBindingContentAuditDecision, DecisionAllowed,
+			APIBindingContentAuditReason, fmt.Sprintf("allowed by %s of API export %q, path: %q: %v", policySource, apiExport.Name, path, reason),
+		)
+		return a.delegate.Authorize(ctx, attr)
+	}
+
+	if reason == "" {
+		reason = fmt.Sprintf("no RBAC policy matched user %q with groups %q", userInfo.Name, userInfo.Groups)
+	}
 	kaudit.AddAuditAnnotations(
 		ctx,
 		APIBindingContentAuditDecision, decisionString(dec),
-		APIBindingContentAuditReason, fmt.Sprintf("API export cluster %q reason: %v", logicalcluster.From(apiExport), reason),
+		APIBindingContentAuditReason, fmt.Sprintf("denied by %s of API export %q, path: %q: %v", policySource, apiExport.Name, path, reason),
 	)
+	return authorizer.DecisionNoOpinion, reason, nil
+}
 
-	if dec == authorizer.DecisionAllow {
-		return a.delegate.Authorize(ctx, attr)
+// policyAuthorizer returns an RBAC authorizer for the maximal permission policy in the given cluster.
+// A local policy lives in the workspace of the API export, i.e. on this shard. A policy in another
+// workspace is read from the cache server if there is one. The cache server only holds cluster roles
+// and cluster role bindings of maximal permission policies, and is eventually consistent.
+func (a *apiBindingAccessAuthorizer) policyAuthorizer(policyCluster logicalcluster.Name, local bool) *rbac.RBACAuthorizer {
+	if local || a.cacheRbacInformers == nil {
+		clusterKubeInformer := rbacwrapper.FilterInformers(policyCluster, a.versionedInformers.Rbac().V1())
+		return rbac.New(
+			&rbac.RoleGetter{Lister: clusterKubeInformer.Roles().Lister()},
+			&rbac.RoleBindingLister{Lister: clusterKubeInformer.RoleBindings().Lister()},
+			&rbac.ClusterRoleGetter{Lister: clusterKubeInformer.ClusterRoles().Lister()},
+			&rbac.ClusterRoleBindingLister{Lister: clusterKubeInformer.ClusterRoleBindings().Lister()},
+		)
 	}
 
-	return authorizer.DecisionNoOpinion, reason, nil
+	emptyIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	return rbac.New(
+		&rbac.RoleGetter{Lister: rbaclisters.NewRoleLister(emptyIndexer)},
+		&rbac.RoleBindingLister{Lister: rbaclisters.NewRoleBindingLister(emptyIndexer)},
+		&rbac.ClusterRoleGetter{Lister: rbacwrapper.FilterClusterRoleInformer(policyCluster, a.cacheRbacInformers.ClusterRoles()).Lister()},
+		&rbac.ClusterRoleBindingLister{Lister: rbacwrapper.FilterClusterRoleBindingInformer(policyCluster, a.cacheRbacInformers.ClusterRoleBindings()).Lister()},
+	)
+}
+
+// maximalPermissionPolicyCluster returns the logical cluster holding the RBAC maximal permission
+// policy of the given API export, and a description of it for audit reasons. The returned cluster
+// is empty if no policy source is set.
+func maximalPermissionPolicyCluster(apiExport *apisv1alpha1.APIExport) (logicalcluster.Name, string) {
+	policy := apiExport.Spec.MaximalPermissionPolicy
+	switch {
+	case policy.Local != nil:
+		cluster := logicalcluster.From(apiExport)
+		return cluster, fmt.Sprintf("local maximal permission policy in workspace %q", cluster)
+	case policy.Workspace != nil && policy.Workspace.Path != "":
+		cluster := logicalcluster.New(policy.Workspace.Path)
+		return cluster, fmt.Sprintf("maximal permission policy in workspace %q", cluster)
+	default:
+		return logicalcluster.Name{}, ""
+	}
 }
 
 //TODO [shawn-hurley]: this should be a helper shared.
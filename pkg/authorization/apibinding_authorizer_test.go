@@ -0,0 +1,241 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/informers"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/kubernetes/pkg/controller"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+func newPolicyClusterRole(cluster, name string) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			Name:        name,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				Verbs:     []string{"get"},
+				Resources: []string{"cowboys"},
+				APIGroups: []string{"wildwest.dev"},
+			},
+		},
+	}
+}
+
+func newPolicyClusterRoleBinding(cluster, name string, subject rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{logicalcluster.AnnotationKey: cluster},
+			Name:        name,
+		},
+		Subjects: []rbacv1.Subject{subject},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     name,
+		},
+	}
+}
+
+func TestAPIBindingAccessAuthorizer(t *testing.T) {
+	for _, tt := range []struct {
+		testName       string
+		policy         *apisv1alpha1.MaximalPermissionPolicy
+		requestingUser *user.DefaultInfo
+		resource       string
+		// withCacheServer serves the policy workspace RBAC from the cache server, and only a stale
+		// binding of it from the local informers.
+		withCacheServer bool
+
+		wantDecision    authorizer.Decision
+		wantDelegated   bool
+		wantAuditReason string
+	}{
+		{
+			testName:        "unbound resource is delegated",
+			requestingUser:  newUser("somebody"),
+			resource:        "horses",
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: "no API binding bound",
+		},
+		{
+			testName:        "no policy is delegated",
+			requestingUser:  newUser("somebody"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: `no maximal permission policy present in API export "export", path: "root:provider", owning cluster: "root:provider"`,
+		},
+		{
+			testName:        "local policy allows",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser:  newUser("local-user"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: `allowed by local maximal permission policy in workspace "root:provider" of API export "export", path: "root:provider": RBAC: allowed by ClusterRoleBinding "local" of ClusterRole "local" to User "apis.kcp.dev:binding:local-user"`,
+		},
+		{
+			testName:        "local policy denies",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser:  newUser("somebody", "cowboy-team"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionNoOpinion,
+			wantAuditReason: `denied by local maximal permission policy in workspace "root:provider" of API export "export", path: "root:provider": no RBAC policy matched user "apis.kcp.dev:binding:somebody" with groups ["apis.kcp.dev:binding:cowboy-team"]`,
+		},
+		{
+			testName:        "workspace policy allows group",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: "root:policies"}},
+			requestingUser:  newUser("somebody", "cowboy-team"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: `allowed by maximal permission policy in workspace "root:policies" of API export "export", path: "root:provider": RBAC: allowed by ClusterRoleBinding "shared" of ClusterRole "shared" to Group "apis.kcp.dev:binding:cowboy-team"`,
+		},
+		{
+			testName:        "workspace policy ignores local policy",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: "root:policies"}},
+			requestingUser:  newUser("local-user"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionNoOpinion,
+			wantAuditReason: `denied by maximal permission policy in workspace "root:policies" of API export "export", path: "root:provider": no RBAC policy matched user "apis.kcp.dev:binding:local-user" with groups []`,
+		},
+		{
+			testName:        "workspace policy in unknown workspace denies",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: "root:unknown"}},
+			requestingUser:  newUser("somebody", "cowboy-team"),
+			resource:        "cowboys",
+			wantDecision:    authorizer.DecisionNoOpinion,
+			wantAuditReason: `denied by maximal permission policy in workspace "root:unknown" of API export "export", path: "root:provider": no RBAC policy matched user "apis.kcp.dev:binding:somebody" with groups ["apis.kcp.dev:binding:cowboy-team"]`,
+		},
+		{
+			testName:        "workspace policy from cache server allows group",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: "root:policies"}},
+			requestingUser:  newUser("somebody", "cowboy-team"),
+			resource:        "cowboys",
+			withCacheServer: true,
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: `allowed by maximal permission policy in workspace "root:policies" of API export "export", path: "root:provider": RBAC: allowed by ClusterRoleBinding "shared" of ClusterRole "shared" to Group "apis.kcp.dev:binding:cowboy-team"`,
+		},
+		{
+			testName:        "workspace policy from cache server ignores local RBAC",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Workspace: &apisv1alpha1.WorkspaceAPIExportPolicy{Path: "root:policies"}},
+			requestingUser:  newUser("stale-user"),
+			resource:        "cowboys",
+			withCacheServer: true,
+			wantDecision:    authorizer.DecisionNoOpinion,
+			wantAuditReason: `denied by maximal permission policy in workspace "root:policies" of API export "export", path: "root:provider": no RBAC policy matched user "apis.kcp.dev:binding:stale-user" with groups []`,
+		},
+		{
+			testName:        "local policy with cache server is read locally",
+			policy:          &apisv1alpha1.MaximalPermissionPolicy{Local: &apisv1alpha1.LocalAPIExportPolicy{}},
+			requestingUser:  newUser("local-user"),
+			resource:        "cowboys",
+			withCacheServer: true,
+			wantDecision:    authorizer.DecisionAllow,
+			wantDelegated:   true,
+			wantAuditReason: `allowed by local maximal permission policy in workspace "root:provider" of API export "export", path: "root:provider": RBAC: allowed by ClusterRoleBinding "local" of ClusterRole "local" to User "apis.kcp.dev:binding:local-user"`,
+		},
+	} {
+		t.Run(tt.testName, func(t *testing.T) {
+			kubeShareInformerFactory := informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			kcpShareInformerFactory := kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+
+			var cacheKubeShareInformerFactory informers.SharedInformerFactory
+			if tt.withCacheServer {
+				cacheKubeShareInformerFactory = informers.NewSharedInformerFactory(kubefake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+			}
+
+			recordingAuthorizer := &recordingAuthorizer{decision: authorizer.DecisionAllow}
+			a, err := NewAPIBindingAccessAuthorizer(kubeShareInformerFactory, kcpShareInformerFactory, cacheKubeShareInformerFactory, recordingAuthorizer)
+			require.NoError(t, err)
+
+			rbacInformers := kubeShareInformerFactory.Rbac().V1()
+			require.NoError(t, rbacInformers.ClusterRoles().Informer().GetIndexer().Add(newPolicyClusterRole("root:provider", "local")))
+			require.NoError(t, rbacInformers.ClusterRoleBindings().Informer().GetIndexer().Add(newPolicyClusterRoleBinding("root:provider", "local",
+				rbacv1.Subject{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "apis.kcp.dev:binding:local-user"})))
+			policyRbacInformers := rbacInformers
+			if tt.withCacheServer {
+				policyRbacInformers = cacheKubeShareInformerFactory.Rbac().V1()
+				require.NoError(t, rbacInformers.ClusterRoles().Informer().GetIndexer().Add(newPolicyClusterRole("root:policies", "stale")))
+				require.NoError(t, rbacInformers.ClusterRoleBindings().Informer().GetIndexer().Add(newPolicyClusterRoleBinding("root:policies", "stale",
+					rbacv1.Subject{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "apis.kcp.dev:binding:stale-user"})))
+			}
+			require.NoError(t, policyRbacInformers.ClusterRoles().Informer().GetIndexer().Add(newPolicyClusterRole("root:policies", "shared")))
+			require.NoError(t, policyRbacInformers.ClusterRoleBindings().Informer().GetIndexer().Add(newPolicyClusterRoleBinding("root:policies", "shared",
+				rbacv1.Subject{Kind: "Group", APIGroup: "rbac.authorization.k8s.io", Name: "apis.kcp.dev:binding:cowboy-team"})))
+
+			require.NoError(t, kcpShareInformerFactory.Apis().V1alpha1().APIExports().Informer().GetIndexer().Add(&apisv1alpha1.APIExport{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:provider"},
+					Name:        "export",
+				},
+				Spec: apisv1alpha1.APIExportSpec{MaximalPermissionPolicy: tt.policy},
+			}))
+			exportRef := &apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:provider", ExportName: "export"}}
+			require.NoError(t, kcpShareInformerFactory.Apis().V1alpha1().APIBindings().Informer().GetIndexer().Add(&apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{logicalcluster.AnnotationKey: "root:consumer"},
+					Name:        "binding",
+				},
+				Spec: apisv1alpha1.APIBindingSpec{Reference: *exportRef},
+				Status: apisv1alpha1.APIBindingStatus{
+					BoundAPIExport: exportRef,
+					BoundResources: []apisv1alpha1.BoundAPIResource{{Group: "wildwest.dev", Resource: "cowboys"}},
+				},
+			}))
+
+			ctx := request.WithCluster(context.Background(), request.Cluster{Name: logicalcluster.New("root:consumer")})
+			auditEvent := &auditinternal.Event{Level: auditinternal.LevelMetadata}
+			ctx = kaudit.WithAuditContext(ctx, &kaudit.AuditContext{Event: auditEvent})
+			attr := authorizer.AttributesRecord{
+				User:            tt.requestingUser,
+				Verb:            "get",
+				APIGroup:        "wildwest.dev",
+				Resource:        tt.resource,
+				ResourceRequest: true,
+			}
+
+			gotDecision, _, err := a.Authorize(ctx, attr)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantDecision, gotDecision)
+			require.Equal(t, tt.wantDelegated, recordingAuthorizer.recordedAttributes != nil)
+			require.Equal(t, tt.wantAuditReason, auditEvent.Annotations[APIBindingContentAuditReason])
+		})
+	}
+}
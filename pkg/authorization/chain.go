@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/authorization/union"
+	kubernetesinformers "k8s.io/client-go/informers"
+
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+)
+
+// chainInformers are the informers the kcp authorizers are constructed from.
+type chainInformers struct {
+	kube kubernetesinformers.SharedInformerFactory
+	kcp  kcpinformers.SharedInformerFactory
+	// cacheKube brings the kube objects replicated into the cache server. It is nil if there is no cache server.
+	cacheKube kubernetesinformers.SharedInformerFactory
+}
+
+// chainLink is one authorizer of the kcp authorizer chain. It is constructed with the authorizer
+// of the next link as delegate, which is nil for the last link.
+type chainLink struct {
+	auditPrefix string
+	new         func(informers chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error)
+}
+
+// authorizerChain are the kcp authorizers in the order they are consulted.
+var authorizerChain = []chainLink{
+	{
+		auditPrefix: TopLevelContentAuditPrefix,
+		new: func(informers chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			return NewTopLevelOrganizationAccessAuthorizer(informers.kube, informers.kcp.Tenancy().V1alpha1().ClusterWorkspaces().Lister(), delegate), nil, nil
+		},
+	},
+	{
+		auditPrefix: WorkspaceContentAuditPrefix,
+		new: func(informers chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			return NewWorkspaceContentAuthorizer(informers.kube, informers.kcp.Tenancy().V1alpha1().ClusterWorkspaces().Lister(), delegate), nil, nil
+		},
+	},
+	{
+		auditPrefix: SystemCRDAuditPrefix,
+		new: func(_ chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			return NewSystemCRDAuthorizer(delegate), nil, nil
+		},
+	},
+	{
+		auditPrefix: APIBindingContentAuditPrefix,
+		new: func(informers chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			auth, err := NewAPIBindingAccessAuthorizer(informers.kube, informers.kcp, informers.cacheKube, delegate)
+			return auth, nil, err
+		},
+	},
+	{
+		// the bootstrap policy is consulted first, and the local policy if it has no opinion
+		auditPrefix: BootstrapPolicyAuditPrefix,
+		new: func(informers chainInformers, delegate authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			auth, rules := NewBootstrapPolicyAuthorizer(informers.kube)
+			return union.New(auth, delegate), rules, nil
+		},
+	},
+	{
+		auditPrefix: LocalAuditPrefix,
+		new: func(informers chainInformers, _ authorizer.Authorizer) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+			auth, rules := NewLocalAuthorizer(informers.kube)
+			return auth, rules, nil
+		},
+	},
+}
+
+// NewKcpAuthorizer returns the chain of kcp authorizers, and the rule resolver of those
+// authorizers that resolve rules, in the order they are consulted. The cache server informers
+// are optional.
+func NewKcpAuthorizer(kubeInformers kubernetesinformers.SharedInformerFactory, kcpInformers kcpinformers.SharedInformerFactory, cacheKubeInformers kubernetesinformers.SharedInformerFactory) (authorizer.Authorizer, authorizer.RuleResolver, error) {
+	informers := chainInformers{kube: kubeInformers, kcp: kcpInformers, cacheKube: cacheKubeInformers}
+	var auth authorizer.Authorizer
+	resolvers := make([]authorizer.RuleResolver, 0, len(authorizerChain))
+	for i := len(authorizerChain) - 1; i >= 0; i-- {
+		a, rules, err := authorizerChain[i].new(informers, auth)
+		if err != nil {
+			return nil, nil, err
+		}
+		auth = a
+		if rules != nil {
+			resolvers = append([]authorizer.RuleResolver{rules}, resolvers...)
+		}
+	}
+	return auth, union.NewRuleResolvers(resolvers...), nil
+}
+
+// authorizerAuditPrefixes returns the audit annotation prefixes of the kcp authorizers, in the
+// order they are consulted.
+func authorizerAuditPrefixes() []string {
+	prefixes := make([]string, 0, len(authorizerChain))
+	for _, link := range authorizerChain {
+		prefixes = append(prefixes, link.auditPrefix)
+	}
+	return prefixes
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	genericapirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+)
+
+// AuthorizationExplainHeader makes a SubjectAccessReview or SelfSubjectAccessReview return the
+// decision and reason of every kcp authorizer that was consulted, as annotations of the review.
+const AuthorizationExplainHeader = "X-Kcp-Authorization-Explain"
+
+var (
+	errorScheme = runtime.NewScheme()
+	errorCodecs = serializer.NewCodecFactory(errorScheme)
+)
+
+func init() {
+	errorScheme.AddUnversionedTypes(metav1.Unversioned,
+		&metav1.Status{},
+	)
+}
+
+// AuthorizerDecision is the decision of one authorizer of the chain, as recorded in its audit annotations.
+type AuthorizerDecision struct {
+	// Authorizer is the audit annotation prefix of the authorizer, e.g. content.authorization.kcp.dev.
+	Authorizer string
+	// Decision is one of Allowed, Denied or NoOpinion.
+	Decision string
+	// Reason is the audit reason of the authorizer.
+	Reason string
+}
+
+// DecisionsFromAnnotations returns the decisions of the kcp authorizers found in the given audit
+// annotations, in the order of the authorizer chain.
+func DecisionsFromAnnotations(annotations map[string]string) []AuthorizerDecision {
+	var decisions []AuthorizerDecision
+	for _, prefix := range authorizerAuditPrefixes() {
+		decision, found := annotations[prefix+"decision"]
+		if !found {
+			continue
+		}
+		decisions = append(decisions, AuthorizerDecision{
+			Authorizer: prefix[:len(prefix)-1],
+			Decision:   decision,
+			Reason:     annotations[prefix+"reason"],
+		})
+	}
+	return decisions
+}
+
+// ExplainDecision authorizes the given attributes like the authorizer does and collects the audit
+// annotations of the kcp authorizers on the way. The annotations are recorded in a separate audit
+// event, such that they do not collide with the annotations of the request authorization.
+func ExplainDecision(ctx context.Context, authz authorizer.Authorizer, attr authorizer.Attributes) (authorizer.Decision, string, map[string]string, error) {
+	ev := &auditinternal.Event{Level: auditinternal.LevelMetadata}
+	dec, reason, err := authz.Authorize(kaudit.WithAuditContext(ctx, &kaudit.AuditContext{Event: ev}), attr)
+
+	annotations := map[string]string{}
+	for _, prefix := range authorizerAuditPrefixes() {
+		for _, key := range []string{prefix + "decision", prefix + "reason"} {
+			if v, found := ev.Annotations[key]; found {
+				annotations[key] = v
+			}
+		}
+	}
+
+	return dec, reason, annotations, err
+}
+
+// WithAuthorizationExplainConfig modifies and returns the input rest.Config
+// with an additional header making SARs to be explained.
+func WithAuthorizationExplainConfig(config *rest.Config) *rest.Config {
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &withHeaderRoundtripper{
+			RoundTripper: rt,
+			headers: map[string]string{
+				AuthorizationExplainHeader: "true",
+			},
+		}
+	})
+	return config
+}
+
+// WithAuthorizationExplanation serves SubjectAccessReviews and SelfSubjectAccessReviews with the
+// AuthorizationExplainHeader set, like the regular endpoints, but additionally annotates the returned
+// review with the decisions and reasons of the kcp authorizers.
+//
+// It must be called after the request has been authorized to create the review.
+func WithAuthorizationExplanation(handler http.Handler, authz authorizer.Authorizer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(AuthorizationExplainHeader) != "true" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		ri, ok := genericapirequest.RequestInfoFrom(r.Context())
+		if !ok {
+			responsewriters.InternalError(w, r, fmt.Errorf("cannot get request info"))
+			return
+		}
+		if !ri.IsResourceRequest || ri.APIGroup != authorizationv1.GroupName || ri.APIVersion != authorizationv1.SchemeGroupVersion.Version || ri.Verb != "create" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		var (
+			review      interface{}
+			spec        *authorizationv1.SubjectAccessReviewSpec
+			status      *authorizationv1.SubjectAccessReviewStatus
+			annotations *map[string]string
+		)
+		switch ri.Resource {
+		case "subjectaccessreviews":
+			sar := &authorizationv1.SubjectAccessReview{}
+			if err := json.NewDecoder(r.Body).Decode(sar); err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("failed to decode SubjectAccessReview: %v", err)), errorCodecs, schema.GroupVersion{}, w, r)
+				return
+			}
+			if sar.Spec.User == "" && len(sar.Spec.Groups) == 0 {
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest("at least one of user or group must be specified"), errorCodecs, schema.GroupVersion{}, w, r)
+				return
+			}
+			sar.TypeMeta = metav1.TypeMeta{APIVersion: authorizationv1.SchemeGroupVersion.String(), Kind: "SubjectAccessReview"}
+			review, spec, status, annotations = sar, &sar.Spec, &sar.Status, &sar.Annotations
+		case "selfsubjectaccessreviews":
+			ssar := &authorizationv1.SelfSubjectAccessReview{}
+			if err := json.NewDecoder(r.Body).Decode(ssar); err != nil {
+				responsewriters.ErrorNegotiated(apierrors.NewBadRequest(fmt.Sprintf("failed to decode SelfSubjectAccessReview: %v", err)), errorCodecs, schema.GroupVersion{}, w, r)
+				return
+			}
+			u, ok := genericapirequest.UserFrom(r.Context())
+			if !ok {
+				responsewriters.InternalError(w, r, fmt.Errorf("cannot get user"))
+				return
+			}
+			spec = &authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes:    ssar.Spec.ResourceAttributes,
+				NonResourceAttributes: ssar.Spec.NonResourceAttributes,
+				User:                  u.GetName(),
+				Groups:                u.GetGroups(),
+				UID:                   u.GetUID(),
+				Extra:                 map[string]authorizationv1.ExtraValue{},
+			}
+			for k, v := range u.GetExtra() {
+				spec.Extra[k] = v
+			}
+			ssar.TypeMeta = metav1.TypeMeta{APIVersion: authorizationv1.SchemeGroupVersion.String(), Kind: "SelfSubjectAccessReview"}
+			review, status, annotations = ssar, &ssar.Status, &ssar.Annotations
+		default:
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if (spec.ResourceAttributes == nil) == (spec.NonResourceAttributes == nil) {
+			responsewriters.ErrorNegotiated(apierrors.NewBadRequest("exactly one of resourceAttributes or nonResourceAttributes must be specified"), errorCodecs, schema.GroupVersion{}, w, r)
+			return
+		}
+
+		dec, reason, decisions, err := ExplainDecision(r.Context(), authz, attributesFromSpec(spec))
+		*status = authorizationv1.SubjectAccessReviewStatus{
+			Allowed: dec == authorizer.DecisionAllow,
+			Denied:  dec == authorizer.DecisionDeny,
+			Reason:  reason,
+		}
+		if err != nil {
+			status.EvaluationError = err.Error()
+		}
+		if *annotations == nil {
+			*annotations = map[string]string{}
+		}
+		for k, v := range decisions {
+			(*annotations)[k] = v
+		}
+
+		responsewriters.WriteRawJSON(http.StatusCreated, review, w)
+	})
+}
+
+func attributesFromSpec(spec *authorizationv1.SubjectAccessReviewSpec) authorizer.AttributesRecord {
+	userInfo := &user.DefaultInfo{
+		Name:   spec.User,
+		Groups: spec.Groups,
+		UID:    spec.UID,
+	}
+	if spec.Extra != nil {
+		userInfo.Extra = map[string][]string{}
+		for k, v := range spec.Extra {
+			userInfo.Extra[k] = v
+		}
+	}
+
+	if spec.NonResourceAttributes != nil {
+		return authorizer.AttributesRecord{
+			User: userInfo,
+			Verb: spec.NonResourceAttributes.Verb,
+			Path: spec.NonResourceAttributes.Path,
+		}
+	}
+	return authorizer.AttributesRecord{
+		User:            userInfo,
+		Verb:            spec.ResourceAttributes.Verb,
+		Namespace:       spec.ResourceAttributes.Namespace,
+		APIGroup:        spec.ResourceAttributes.Group,
+		APIVersion:      spec.ResourceAttributes.Version,
+		Resource:        spec.ResourceAttributes.Resource,
+		Subresource:     spec.ResourceAttributes.Subresource,
+		Name:            spec.ResourceAttributes.Name,
+		ResourceRequest: true,
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	kaudit "k8s.io/apiserver/pkg/audit"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// annotatingAuthorizer records the audit annotations of a chain of authorizers that all delegate.
+type annotatingAuthorizer struct {
+	annotations []string
+	decision    authorizer.Decision
+	reason      string
+
+	recordedAttributes authorizer.Attributes
+	recordedDeepSAR    bool
+}
+
+func (a *annotatingAuthorizer) Authorize(ctx context.Context, attr authorizer.Attributes) (authorizer.Decision, string, error) {
+	a.recordedAttributes = attr
+	a.recordedDeepSAR = IsDeepSubjectAccessReviewFrom(ctx, attr)
+	kaudit.AddAuditAnnotations(ctx, a.annotations...)
+	return a.decision, a.reason, nil
+}
+
+func TestDecisionsFromAnnotations(t *testing.T) {
+	got := DecisionsFromAnnotations(map[string]string{
+		LocalAuditDecision:             DecisionNoOpinion,
+		LocalAuditReason:               "local reason",
+		WorkspaceContentAuditDecision:  DecisionAllowed,
+		WorkspaceContentAuditReason:    "content reason",
+		APIBindingContentAuditDecision: DecisionAllowed,
+		"unrelated":                    "value",
+	})
+	require.Equal(t, []AuthorizerDecision{
+		{Authorizer: "content.authorization.kcp.dev", Decision: DecisionAllowed, Reason: "content reason"},
+		{Authorizer: "apibinding.authorization.kcp.dev", Decision: DecisionAllowed},
+		{Authorizer: "local.authorization.kcp.dev", Decision: DecisionNoOpinion, Reason: "local reason"},
+	}, got)
+}
+
+func TestWithAuthorizationExplanation(t *testing.T) {
+	tests := map[string]struct {
+		resource string
+		header   string
+		body     interface{}
+
+		wantDelegated bool
+		wantCode      int
+		wantUser      string
+		wantGroups    []string
+	}{
+		"without header": {
+			resource:      "selfsubjectaccessreviews",
+			body:          &authorizationv1.SelfSubjectAccessReview{},
+			wantDelegated: true,
+		},
+		"other resource": {
+			resource:      "localsubjectaccessreviews",
+			header:        "true",
+			body:          &authorizationv1.SubjectAccessReview{},
+			wantDelegated: true,
+		},
+		"self subject access review": {
+			resource: "selfsubjectaccessreviews",
+			header:   "true",
+			body: &authorizationv1.SelfSubjectAccessReview{Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "configmaps"},
+			}},
+			wantCode:   http.StatusCreated,
+			wantUser:   "somebody",
+			wantGroups: []string{"system:authenticated"},
+		},
+		"subject access review": {
+			resource: "subjectaccessreviews",
+			header:   "true",
+			body: &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "configmaps"},
+				User:               "other",
+				Groups:             []string{"team"},
+			}},
+			wantCode:   http.StatusCreated,
+			wantUser:   "other",
+			wantGroups: []string{"team"},
+		},
+		"subject access review without subject": {
+			resource: "subjectaccessreviews",
+			header:   "true",
+			body: &authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "configmaps"},
+			}},
+			wantCode: http.StatusBadRequest,
+		},
+		"review without attributes": {
+			resource: "selfsubjectaccessreviews",
+			header:   "true",
+			body:     &authorizationv1.SelfSubjectAccessReview{},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			authz := &annotatingAuthorizer{
+				annotations: []string{
+					WorkspaceContentAuditDecision, DecisionAllowed,
+					WorkspaceContentAuditReason, "allowed by content",
+					LocalAuditDecision, DecisionNoOpinion,
+					LocalAuditReason, "no RBAC policy matched",
+				},
+				decision: authorizer.DecisionNoOpinion,
+				reason:   "no RBAC policy matched",
+			}
+			delegated := false
+			handler := WithAuthorizationExplanation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				delegated = true
+			}), authz)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/apis/authorization.k8s.io/v1/"+tt.resource, bytes.NewReader(body))
+			if tt.header != "" {
+				req.Header.Set(AuthorizationExplainHeader, tt.header)
+			}
+			ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{
+				IsResourceRequest: true,
+				Verb:              "create",
+				APIGroup:          authorizationv1.GroupName,
+				APIVersion:        "v1",
+				Resource:          tt.resource,
+			})
+			ctx = request.WithUser(ctx, newUser("somebody", "system:authenticated"))
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req.WithContext(ctx))
+
+			require.Equal(t, tt.wantDelegated, delegated)
+			if tt.wantDelegated {
+				return
+			}
+			require.Equal(t, tt.wantCode, rw.Code, rw.Body.String())
+			if tt.wantCode != http.StatusCreated {
+				return
+			}
+
+			require.Equal(t, tt.wantUser, authz.recordedAttributes.GetUser().GetName())
+			require.Equal(t, tt.wantGroups, authz.recordedAttributes.GetUser().GetGroups())
+
+			var got struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+				Status authorizationv1.SubjectAccessReviewStatus `json:"status"`
+			}
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &got))
+			require.Equal(t, authorizationv1.SubjectAccessReviewStatus{Reason: "no RBAC policy matched"}, got.Status)
+			require.Equal(t, []AuthorizerDecision{
+				{Authorizer: "content.authorization.kcp.dev", Decision: DecisionAllowed, Reason: "allowed by content"},
+				{Authorizer: "local.authorization.kcp.dev", Decision: DecisionNoOpinion, Reason: "no RBAC policy matched"},
+			}, DecisionsFromAnnotations(got.Metadata.Annotations))
+		})
+	}
+}
+
+func TestExplainedDeepSubjectAccessReview(t *testing.T) {
+	authz := &annotatingAuthorizer{decision: authorizer.DecisionAllow}
+	handler := WithDeepSubjectAccessReview(WithAuthorizationExplanation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("unexpected delegation")
+	}), authz))
+
+	body, err := json.Marshal(&authorizationv1.SubjectAccessReview{Spec: authorizationv1.SubjectAccessReviewSpec{
+		ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: "get", Resource: "configmaps"},
+		User:               "other",
+	}})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/apis/authorization.k8s.io/v1/subjectaccessreviews", bytes.NewReader(body))
+	req.Header.Set(AuthorizationExplainHeader, "true")
+	req.Header.Set(deepSARHeader, "true")
+	ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              "create",
+		APIGroup:          authorizationv1.GroupName,
+		APIVersion:        "v1",
+		Resource:          "subjectaccessreviews",
+	})
+	ctx = request.WithUser(ctx, newUser("admin", "system:masters"))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req.WithContext(ctx))
+
+	require.Equal(t, http.StatusCreated, rw.Code, rw.Body.String())
+	require.True(t, authz.recordedDeepSAR, "expected the explained review to be authorized as deep SAR")
+}
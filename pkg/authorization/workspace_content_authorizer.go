@@ -47,6 +47,7 @@ import (
 
 const (
 	WorkspaceAccessNotPermittedReason = "workspace access not permitted"
+	WorkspaceReadOnlyReason           = "workspace is read-only"
 
 	WorkspaceContentAuditPrefix   = "content.authorization.kcp.dev/"
 	WorkspaceContentAuditDecision = WorkspaceContentAuditPrefix + "decision"
@@ -201,7 +202,8 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 		return authorizer.DecisionNoOpinion, "", err
 	}
 
-	if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+	// deleted workspaces keep their contents for the retention period of their type, and are read-only.
+	if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseInitializing && ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady && ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseDeleted {
 		kaudit.AddAuditAnnotations(
 			ctx,
 			WorkspaceContentAuditDecision, DecisionNoOpinion,
@@ -210,6 +212,16 @@ func (a *workspaceContentAuthorizer) Authorize(ctx context.Context, attr authori
 		return authorizer.DecisionNoOpinion, WorkspaceAccessNotPermittedReason, nil
 	}
 
+	// read-only workspaces, e.g. during a migration to another shard, only allow reading.
+	if ws.Spec.ReadOnly && !attr.IsReadOnly() {
+		kaudit.AddAuditAnnotations(
+			ctx,
+			WorkspaceContentAuditDecision, DecisionDenied,
+			WorkspaceContentAuditReason, fmt.Sprintf("verb %q not permitted, clusterworkspace is read-only", attr.GetVerb()),
+		)
+		return authorizer.DecisionDeny, WorkspaceReadOnlyReason, nil
+	}
+
 	switch {
 	case isServiceAccountFromCluster:
 		// A service account declared in the requested workspace is authorized inside that workspace.
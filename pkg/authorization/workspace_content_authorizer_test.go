@@ -84,6 +84,7 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 		testName              string
 		requestedWorkspace    string
 		requestingUser        *user.DefaultInfo
+		verb                  string
 		wantReason, wantError string
 		wantDecision          authorizer.Decision
 		wantUser              *user.DefaultInfo
@@ -203,6 +204,24 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			requestingUser:     newUser("user-admin"),
 			wantUser:           newUser("user-admin", "system:kcp:clusterworkspace:access", "system:kcp:clusterworkspace:admin"),
 		},
+		{
+			testName: "mutating request to read-only workspace is denied",
+
+			requestedWorkspace: "root:readonly",
+			requestingUser:     newUser("user-admin"),
+			verb:               "create",
+			wantDecision:       authorizer.DecisionDeny,
+			wantReason:         "workspace is read-only",
+		},
+		{
+			testName: "reading request to read-only workspace is not denied",
+
+			requestedWorkspace: "root:readonly",
+			requestingUser:     newUser("user-access"),
+			verb:               "get",
+			wantDecision:       authorizer.DecisionNoOpinion,
+			wantReason:         "workspace access not permitted",
+		},
 		{
 			testName: "any user passed for deep SAR",
 
@@ -408,6 +427,11 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "initializing")},
 				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseInitializing},
 			}))
+			require.NoError(t, indexer.Add(&tenancyv1alpha1.ClusterWorkspace{
+				ObjectMeta: metav1.ObjectMeta{Name: clusters.ToClusterAwareKey(logicalcluster.New("root"), "readonly")},
+				Spec:       tenancyv1alpha1.ClusterWorkspaceSpec{ReadOnly: true},
+				Status:     tenancyv1alpha1.ClusterWorkspaceStatus{Phase: tenancyv1alpha1.ClusterWorkspacePhaseReady},
+			}))
 			lister := v1alpha1.NewClusterWorkspaceLister(indexer)
 
 			recordingAuthorizer := &recordingAuthorizer{}
@@ -423,6 +447,7 @@ func TestWorkspaceContentAuthorizer(t *testing.T) {
 			ctx = request.WithCluster(ctx, requestedCluster)
 			attr := authorizer.AttributesRecord{
 				User: tt.requestingUser,
+				Verb: tt.verb,
 			}
 			if tt.deepSARHeader {
 				ctx = context.WithValue(ctx, deepSARKey, true)
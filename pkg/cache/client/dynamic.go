@@ -0,0 +1,37 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+// NewDynamicClusterClientForConfig returns a dynamic cluster client for the cache server.
+// Requests target the shard from the context, or defaultShard if the context has none.
+//
+// Use clientshard.Wildcard as default shard to read the objects replicated by all shards,
+// e.g. /shards/*/clusters/*/apis/apis.kcp.dev/v1alpha1/apiexports.
+func NewDynamicClusterClientForConfig(cfg *rest.Config, defaultShard clientshard.Name) (*dynamic.Cluster, error) {
+	cfg = rest.CopyConfig(cfg)
+	// the last wrapper is the outermost one, i.e. the default shard is set before the path is rewritten.
+	cfg = WithShardNameFromContextRoundTripper(cfg)
+	cfg = WithDefaultShardRoundTripper(cfg, defaultShard)
+	return dynamic.NewClusterForConfig(cfg)
+}
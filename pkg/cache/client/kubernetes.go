@@ -0,0 +1,40 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+// NewKubeClusterClientForConfig returns a typed kube cluster client for the cache server, e.g. to
+// read the replicated RBAC objects. Requests target the shard from the context, or defaultShard if
+// the context has none.
+//
+// The cache server serves built-in resources through CRDs, hence the client only talks JSON.
+func NewKubeClusterClientForConfig(cfg *rest.Config, defaultShard clientshard.Name) (*kubernetesclient.Cluster, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.ContentType = runtime.ContentTypeJSON
+	cfg.AcceptContentTypes = runtime.ContentTypeJSON
+	// the last wrapper is the outermost one, i.e. the default shard is set before the path is rewritten.
+	cfg = WithShardNameFromContextRoundTripper(cfg)
+	cfg = WithDefaultShardRoundTripper(cfg, defaultShard)
+	return kubernetesclient.NewClusterForConfig(cfg)
+}
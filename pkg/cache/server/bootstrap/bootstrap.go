@@ -19,12 +19,15 @@ package bootstrap
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 	"k8s.io/utils/pointer"
@@ -37,21 +40,94 @@ import (
 // We use the same name as the KCP for symmetry.
 var SystemCRDLogicalCluster = logicalcluster.New("system:system-crds")
 
-func Bootstrap(ctx context.Context, apiExtensionsClusterClient apiextensionsclient.ClusterInterface) error {
-	crds := []*apiextensionsv1.CustomResourceDefinition{}
-	for _, resource := range []string{"apiresourceschemas", "apiexports"} {
-		crd := &apiextensionsv1.CustomResourceDefinition{}
-		if err := configcrds.Unmarshal(fmt.Sprintf("apis.kcp.dev_%s.yaml", resource), crd); err != nil {
-			panic(fmt.Errorf("failed to unmarshal %v resource: %w", resource, err))
+// DefaultReplicatedResources are the resources that are replicated from the shards into the cache
+// server by default. Of the RBAC resources, only the objects of APIExport maximal permission policies
+// are replicated, i.e. those annotated with apisv1alpha1.AnnotationMaximalPermissionPolicyKey.
+var DefaultReplicatedResources = []schema.GroupResource{
+	{Group: "apis.kcp.dev", Resource: "apiresourceschemas"},
+	{Group: "apis.kcp.dev", Resource: "apiexports"},
+	{Group: "tenancy.kcp.dev", Resource: "clusterworkspacetypes"},
+	{Group: "scheduling.kcp.dev", Resource: "locations"},
+	{Group: "workload.kcp.dev", Resource: "synctargets"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"},
+}
+
+// nativeResources describes the built-in resources that can be replicated. The cache server
+// does not serve any built-in API, hence they are served through CRDs like the kcp resources.
+var nativeResources = map[schema.GroupResource]struct {
+	version    string
+	kind       string
+	namespaced bool
+}{
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}:        {version: "v1", kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Resource: "clusterrolebindings"}: {version: "v1", kind: "ClusterRoleBinding"},
+	{Group: "rbac.authorization.k8s.io", Resource: "roles"}:               {version: "v1", kind: "Role", namespaced: true},
+	{Group: "rbac.authorization.k8s.io", Resource: "rolebindings"}:        {version: "v1", kind: "RoleBinding", namespaced: true},
+}
+
+// CRD returns the CustomResourceDefinition serving the given resource in the cache server,
+// or an error if the resource cannot be replicated.
+//
+// The schema and the subresources of the CRD are wiped. The cache server stores objects as they
+// are sent by the shards, including their status.
+func CRD(gr schema.GroupResource) (*apiextensionsv1.CustomResourceDefinition, error) {
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if native, found := nativeResources[gr]; found {
+		scope := apiextensionsv1.ClusterScoped
+		if native.namespaced {
+			scope = apiextensionsv1.NamespaceScoped
 		}
-		for i := range crd.Spec.Versions {
-			v := &crd.Spec.Versions[i]
-			v.Schema = &apiextensionsv1.CustomResourceValidation{
-				OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
-					Type:                   "object",
-					XPreserveUnknownFields: pointer.BoolPtr(true),
+		crd = &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: gr.String(),
+				Annotations: map[string]string{
+					// required for CRDs in *.k8s.io groups
+					apiextensionsv1.KubeAPIApprovedAnnotation: "https://github.com/kcp-dev/kubernetes/pull/4",
+				},
+			},
+			Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+				Group: gr.Group,
+				Names: apiextensionsv1.CustomResourceDefinitionNames{
+					Plural:   gr.Resource,
+					Singular: strings.ToLower(native.kind),
+					Kind:     native.kind,
+					ListKind: native.kind + "List",
 				},
-			} // wipe the schema, we don't need validation
+				Scope: scope,
+				Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+					{Name: native.version, Served: true, Storage: true},
+				},
+			},
+		}
+	} else if strings.HasSuffix(gr.Group, ".kcp.dev") {
+		if err := configcrds.Unmarshal(fmt.Sprintf("%s_%s.yaml", gr.Group, gr.Resource), crd); err != nil {
+			return nil, fmt.Errorf("resource %s cannot be replicated: %w", gr, err)
+		}
+	} else {
+		return nil, fmt.Errorf("resource %s cannot be replicated", gr)
+	}
+
+	for i := range crd.Spec.Versions {
+		v := &crd.Spec.Versions[i]
+		v.Schema = &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+				Type:                   "object",
+				XPreserveUnknownFields: pointer.BoolPtr(true),
+			},
+		} // wipe the schema, we don't need validation
+		v.Subresources = nil
+	}
+	return crd, nil
+}
+
+// Bootstrap creates the CustomResourceDefinitions for the given replicated resources.
+func Bootstrap(ctx context.Context, apiExtensionsClusterClient apiextensionsclient.ClusterInterface, resources []schema.GroupResource) error {
+	crds := []*apiextensionsv1.CustomResourceDefinition{}
+	for _, gr := range resources {
+		crd, err := CRD(gr)
+		if err != nil {
+			return err
 		}
 		crds = append(crds, crd)
 	}
@@ -0,0 +1,54 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCRD(t *testing.T) {
+	for _, gr := range DefaultReplicatedResources {
+		t.Run(gr.String(), func(t *testing.T) {
+			crd, err := CRD(gr)
+			require.NoError(t, err)
+			require.Equal(t, gr.String(), crd.Name)
+			require.Equal(t, gr.Group, crd.Spec.Group)
+			require.Equal(t, gr.Resource, crd.Spec.Names.Plural)
+			require.NotEmpty(t, crd.Spec.Versions)
+			for _, v := range crd.Spec.Versions {
+				require.Nil(t, v.Subresources, "status must be stored as sent by the shards")
+				require.True(t, *v.Schema.OpenAPIV3Schema.XPreserveUnknownFields)
+			}
+		})
+	}
+
+	crd, err := CRD(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "rolebindings"})
+	require.NoError(t, err)
+	require.Equal(t, apiextensionsv1.NamespaceScoped, crd.Spec.Scope)
+	require.Equal(t, "RoleBinding", crd.Spec.Names.Kind)
+	require.NotEmpty(t, crd.Annotations[apiextensionsv1.KubeAPIApprovedAnnotation])
+
+	_, err = CRD(schema.GroupResource{Group: "apps", Resource: "deployments"})
+	require.Error(t, err)
+	_, err = CRD(schema.GroupResource{Group: "apis.kcp.dev", Resource: "unknowns"})
+	require.Error(t, err)
+}
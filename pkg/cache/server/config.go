@@ -37,8 +37,11 @@ import (
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
 	"k8s.io/apiserver/pkg/util/webhook"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/kubernetes/pkg/genericcontrolplane/clientutils"
 
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
 	cacheserveroptions "github.com/kcp-dev/kcp/pkg/cache/server/options"
 	"github.com/kcp-dev/kcp/pkg/embeddedetcd"
 	kcpserver "github.com/kcp-dev/kcp/pkg/server"
@@ -126,7 +129,12 @@ func NewConfig(opts *cacheserveroptions.CompletedOptions) (*Config, error) {
 		return nil, err
 	}
 
+	purger := &shardPurger{}
 	serverConfig.Config.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
+		if opts.EnableShardPurge {
+			// TODO: require authentication and authorization once the cache server enables the authN/Z stack.
+			apiHandler = WithShardPurge(apiHandler, purger.purge)
+		}
 		apiHandler = genericapiserver.DefaultBuildHandlerChainFromAuthz(apiHandler, genericConfig)
 		apiHandler = genericapiserver.DefaultBuildHandlerChainBeforeAuthz(apiHandler, genericConfig)
 		apiHandler = kcpserver.WithClusterAnnotation(apiHandler)
@@ -168,6 +176,12 @@ func NewConfig(opts *cacheserveroptions.CompletedOptions) (*Config, error) {
 		resyncPeriod,
 	)
 
+	purger.crdLister = c.ApiExtensionsSharedInformerFactory.Apiextensions().V1().CustomResourceDefinitions().Lister()
+	purger.dynamicClusterClient, err = dynamic.NewClusterForConfig(cacheclient.WithShardNameFromContextRoundTripper(rest.CopyConfig(serverConfig.LoopbackClientConfig)))
+	if err != nil {
+		return nil, err
+	}
+
 	c.ApiExtensions = &apiextensionsapiserver.Config{
 		GenericConfig: serverConfig,
 		ExtraConfig: apiextensionsapiserver.ExtraConfig{
@@ -32,6 +32,9 @@ import (
 	"k8s.io/apiserver/pkg/endpoints/request"
 )
 
+// defaultShardName is assigned to requests without a shard name.
+const defaultShardName request.Shard = "system:cache:server"
+
 var (
 	shardNameRegExp = regexp.MustCompile(`^[a-z0-9-:]{0,61}$`)
 
@@ -48,6 +51,7 @@ func init() {
 // WithShardScope reads a shard name from the URL path and puts it into the context.
 // It also trims "/shards/" prefix from the URL.
 // If the path doesn't contain the shard name then a default "system:cache:server" name is assigned.
+// The path of /shards/<name> itself becomes /.
 //
 // For example:
 //
@@ -66,13 +70,10 @@ func WithShardScope(handler http.Handler) http.Handler {
 
 			i := strings.Index(path, "/")
 			if i == -1 {
-				responsewriters.ErrorNegotiated(
-					apierrors.NewBadRequest(fmt.Sprintf("unable to parse shard: no `/` found in path %s", path)),
-					errorCodecs, schema.GroupVersion{},
-					w, req)
-				return
+				shardName, path = path, "/"
+			} else {
+				shardName, path = path[:i], path[i:]
 			}
-			shardName, path = path[:i], path[i:]
 			req.URL.Path = path
 			newURL, err := url.Parse(req.URL.String())
 			if err != nil {
@@ -95,7 +96,7 @@ func WithShardScope(handler http.Handler) http.Handler {
 			// as of today we don't instruct controllers used by the apiextention server
 			// how to assign/extract a shard name to/from an object.
 			// so we need to set a default name here, otherwise these controllers will fail.
-			shard = "system:cache:server"
+			shard = defaultShardName
 		default:
 			if !shardNameRegExp.MatchString(shardName) {
 				responsewriters.ErrorNegotiated(
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+func TestWithShardPurge(t *testing.T) {
+	tests := map[string]struct {
+		method string
+		path   string
+
+		wantStatus    int
+		wantPurged    clientshard.Name
+		wantDelegated string
+	}{
+		"purge shard": {
+			method:     http.MethodDelete,
+			path:       "/shards/amber",
+			wantStatus: http.StatusOK,
+			wantPurged: "amber",
+		},
+		"purge shard with trailing slash": {
+			method:     http.MethodDelete,
+			path:       "/shards/amber/",
+			wantStatus: http.StatusOK,
+			wantPurged: "amber",
+		},
+		"wildcard shard cannot be purged": {
+			method:     http.MethodDelete,
+			path:       "/shards/*",
+			wantStatus: http.StatusBadRequest,
+		},
+		"delete of an object is delegated": {
+			method:        http.MethodDelete,
+			path:          "/shards/amber/clusters/root/apis/apis.kcp.dev/v1alpha1/apiexports/foo",
+			wantStatus:    http.StatusOK,
+			wantDelegated: "amber /clusters/root/apis/apis.kcp.dev/v1alpha1/apiexports/foo",
+		},
+		"get of a shard is delegated": {
+			method:        http.MethodGet,
+			path:          "/shards/amber",
+			wantStatus:    http.StatusOK,
+			wantDelegated: "amber /",
+		},
+		"default shard cannot be purged": {
+			method:     http.MethodDelete,
+			path:       "/",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var delegated string
+			delegate := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				delegated = request.ShardFrom(req.Context()).String() + " " + req.URL.Path
+			})
+			var purged clientshard.Name
+			purge := func(ctx context.Context, shard clientshard.Name) (int, error) {
+				purged = shard
+				return 3, nil
+			}
+
+			rw := httptest.NewRecorder()
+			WithShardScope(WithShardPurge(delegate, purge)).ServeHTTP(rw, httptest.NewRequest(tt.method, tt.path, nil))
+
+			require.Equal(t, tt.wantStatus, rw.Code, rw.Body.String())
+			require.Equal(t, tt.wantPurged, purged)
+			require.Equal(t, tt.wantDelegated, delegated)
+		})
+	}
+}
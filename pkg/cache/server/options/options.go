@@ -17,12 +17,19 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
 	"k8s.io/apiserver/pkg/storage/storagebackend"
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
+	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 )
 
@@ -34,6 +41,14 @@ type Options struct {
 	Authorization    *genericoptions.DelegatingAuthorizationOptions
 	APIEnablement    *genericoptions.APIEnablementOptions
 	EmbeddedEtcd     etcdoptions.Options
+
+	// RootDirectory holds the certificates and, with embedded etcd, the data of the cache server.
+	RootDirectory string
+	// ReplicatedResources are the group resources the cache server stores for the shards.
+	ReplicatedResources []string
+	// EnableShardPurge serves DELETE /shards/<name> to remove the data of a decommissioned shard.
+	// Requests are not authenticated yet, hence it is disabled by default.
+	EnableShardPurge bool
 }
 
 type completedOptions struct {
@@ -44,6 +59,10 @@ type completedOptions struct {
 	Authorization    *genericoptions.DelegatingAuthorizationOptions
 	APIEnablement    *genericoptions.APIEnablementOptions
 	EmbeddedEtcd     etcdoptions.CompletedOptions
+
+	RootDirectory       string
+	ReplicatedResources []schema.GroupResource
+	EnableShardPurge    bool
 }
 
 type CompletedOptions struct {
@@ -59,6 +78,14 @@ func (o *CompletedOptions) Validate() []error {
 	errors = append(errors, o.Authorization.Validate()...)
 	errors = append(errors, o.APIEnablement.Validate()...)
 	errors = append(errors, o.EmbeddedEtcd.Validate()...)
+	if o.Etcd.EnableWatchCache {
+		errors = append(errors, fmt.Errorf("--watch-cache is not supported by the cache server yet"))
+	}
+	for _, gr := range o.ReplicatedResources {
+		if _, err := bootstrap.CRD(gr); err != nil {
+			errors = append(errors, fmt.Errorf("--replicated-resources: %w", err))
+		}
+	}
 	return errors
 }
 
@@ -72,6 +99,10 @@ func NewOptions(rootDir string) *Options {
 		Authorization:    genericoptions.NewDelegatingAuthorizationOptions(),
 		APIEnablement:    genericoptions.NewAPIEnablementOptions(),
 		EmbeddedEtcd:     *etcdoptions.NewOptions(rootDir),
+		RootDirectory:    rootDir,
+	}
+	for _, gr := range bootstrap.DefaultReplicatedResources {
+		o.ReplicatedResources = append(o.ReplicatedResources, gr.String())
 	}
 
 	o.ServerRunOptions.EnablePriorityAndFairness = false
@@ -91,6 +122,17 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		o.EmbeddedEtcd.Enabled = true
 	}
 
+	// the cached data must survive restarts, independently of the working directory.
+	var err error
+	for _, dir := range []*string{&o.RootDirectory, &o.EmbeddedEtcd.Directory, &o.SecureServing.ServerCert.CertDirectory} {
+		if *dir, err = filepath.Abs(*dir); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(o.RootDirectory, 0700); err != nil {
+		return nil, err
+	}
+
 	// TODO: enable authN/Z stack
 	o.Authentication = nil
 	o.Authorization = nil
@@ -99,6 +141,11 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		return nil, err
 	}
 
+	var replicatedResources []schema.GroupResource
+	for _, r := range o.ReplicatedResources {
+		replicatedResources = append(replicatedResources, schema.ParseGroupResource(strings.TrimSpace(r)))
+	}
+
 	return &CompletedOptions{&completedOptions{
 		ServerRunOptions: o.ServerRunOptions,
 		Etcd:             o.Etcd,
@@ -107,9 +154,21 @@ func (o *Options) Complete() (*CompletedOptions, error) {
 		Authorization:    o.Authorization,
 		APIEnablement:    o.APIEnablement,
 		EmbeddedEtcd:     o.EmbeddedEtcd.Complete(o.Etcd),
+
+		RootDirectory:       o.RootDirectory,
+		ReplicatedResources: replicatedResources,
+		EnableShardPurge:    o.EnableShardPurge,
 	}}, nil
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
-	// TODO: figure out what flags needs to be exposed
+	// TODO: figure out what other flags needs to be exposed
+	o.ServerRunOptions.AddUniversalFlags(fs)
+	o.Etcd.AddFlags(fs)
+	o.SecureServing.AddFlags(fs)
+	o.EmbeddedEtcd.AddFlags(fs)
+
+	fs.StringVar(&o.RootDirectory, "root-directory", o.RootDirectory, "Root directory.")
+	fs.StringSliceVar(&o.ReplicatedResources, "replicated-resources", o.ReplicatedResources, "The resources in <resource>.<group> format which the shards replicate into the cache server.")
+	fs.BoolVar(&o.EnableShardPurge, "enable-shard-purge", o.EnableShardPurge, "Serve DELETE /shards/<name> to remove all data of a decommissioned shard. Requests to the cache server are not authenticated, so only enable this on a trusted network.")
 }
@@ -0,0 +1,132 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apiextensionslisters "k8s.io/apiextensions-apiserver/pkg/client/listers/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
+)
+
+// WithShardPurge deletes all objects stored for a shard on DELETE /shards/<name>, e.g. when the shard
+// has been decommissioned:
+//
+//	kubectl --server https://<cache-server> delete --raw /shards/amber
+//
+// It expects the shard to be set in the context by WithShardScope. It is only mounted with
+// --enable-shard-purge, because the cache server does not authenticate requests yet.
+func WithShardPurge(handler http.Handler, purge func(ctx context.Context, shard clientshard.Name) (int, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		shard := request.ShardFrom(req.Context())
+		if req.Method != http.MethodDelete || req.URL.Path != "/" || shard.Empty() {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		if shard.Wildcard() || shard == defaultShardName {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewBadRequest(fmt.Sprintf("shard %q cannot be purged", shard)),
+				errorCodecs, schema.GroupVersion{},
+				w, req)
+			return
+		}
+
+		deleted, err := purge(req.Context(), clientshard.New(shard.String()))
+		if err != nil {
+			responsewriters.ErrorNegotiated(
+				apierrors.NewInternalError(fmt.Errorf("failed to purge shard %q after deleting %d objects: %w", shard, deleted, err)),
+				errorCodecs, schema.GroupVersion{},
+				w, req)
+			return
+		}
+
+		responsewriters.WriteRawJSON(http.StatusOK, &metav1.Status{
+			TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"},
+			Status:   metav1.StatusSuccess,
+			Message:  fmt.Sprintf("deleted %d objects of shard %q", deleted, shard),
+		}, w)
+	})
+}
+
+// shardPurger deletes the objects of all replicated resources of a shard through the API, such that
+// watchers of the shard observe the deletions.
+type shardPurger struct {
+	crdLister            apiextensionslisters.CustomResourceDefinitionLister
+	dynamicClusterClient dynamic.ClusterInterface
+}
+
+func (p *shardPurger) purge(ctx context.Context, shard clientshard.Name) (int, error) {
+	logger := klog.FromContext(ctx).WithValues("shard", shard)
+	ctx = cacheclient.WithShardInContext(ctx, shard)
+
+	crds, err := p.crdLister.List(labels.Everything())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, crd := range crds {
+		if logicalcluster.From(crd) != bootstrap.SystemCRDLogicalCluster {
+			continue
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Storage {
+				continue
+			}
+			gvr := schema.GroupVersionResource{Group: crd.Spec.Group, Version: version.Name, Resource: crd.Spec.Names.Plural}
+
+			opts := metav1.ListOptions{Limit: 500}
+			for {
+				list, err := p.dynamicClusterClient.Cluster(logicalcluster.Wildcard).Resource(gvr).List(ctx, opts)
+				if err != nil {
+					return deleted, err
+				}
+				for i := range list.Items {
+					obj := &list.Items[i]
+					err := p.dynamicClusterClient.Cluster(logicalcluster.From(obj)).Resource(gvr).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+					if err != nil && !apierrors.IsNotFound(err) {
+						return deleted, err
+					}
+					deleted++
+				}
+				if list.GetContinue() == "" {
+					break
+				}
+				opts.Continue = list.GetContinue()
+			}
+		}
+	}
+
+	logger.Info("purged shard", "deleted", deleted)
+	return deleted, nil
+}
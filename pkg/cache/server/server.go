@@ -45,7 +45,7 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 	if err := server.GenericAPIServer.AddPostStartHook("bootstrap-cache-server", func(hookContext genericapiserver.PostStartHookContext) error {
 		logger = logger.WithValues("postStartHook", "bootstrap-cache-server")
-		if err = bootstrap.Bootstrap(klog.NewContext(util.GoContext(hookContext), logger), s.ApiExtensionsClusterClient); err != nil {
+		if err = bootstrap.Bootstrap(klog.NewContext(util.GoContext(hookContext), logger), s.ApiExtensionsClusterClient, s.Options.ReplicatedResources); err != nil {
 			logger.Error(err, "failed creating the static CustomResourcesDefinitions")
 			// nolint:nilerr
 			return nil // don't klog.Fatal. This only happens when context is cancelled.
@@ -32,6 +32,7 @@ import (
 type WorkloadV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	SyncTargetsGetter
+	SyncTargetTransformationsGetter
 }
 
 // WorkloadV1alpha1Client is used to interact with features provided by the workload.kcp.dev group.
@@ -44,6 +45,10 @@ func (c *WorkloadV1alpha1Client) SyncTargets() SyncTargetInterface {
 	return newSyncTargets(c)
 }
 
+func (c *WorkloadV1alpha1Client) SyncTargetTransformations() SyncTargetTransformationInterface {
+	return newSyncTargetTransformations(c)
+}
+
 // NewForConfig creates a new WorkloadV1alpha1Client for the given config.
 // NewForConfig is equivalent to NewForConfigAndClient(c, httpClient),
 // where httpClient was generated with rest.HTTPClientFor(c).
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// SyncTargets returns a SyncTargetInformer.
 	SyncTargets() SyncTargetInformer
+	// SyncTargetTransformations returns a SyncTargetTransformationInformer.
+	SyncTargetTransformations() SyncTargetTransformationInformer
 }
 
 type version struct {
@@ -43,3 +45,8 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) SyncTargets() SyncTargetInformer {
 	return &syncTargetInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
+
+// SyncTargetTransformations returns a SyncTargetTransformationInformer.
+func (v *version) SyncTargetTransformations() SyncTargetTransformationInformer {
+	return &syncTargetTransformationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -21,3 +21,7 @@ package v1alpha1
 // SyncTargetListerExpansion allows custom methods to be added to
 // SyncTargetLister.
 type SyncTargetListerExpansion interface{}
+
+// SyncTargetTransformationListerExpansion allows custom methods to be added to
+// SyncTargetTransformationLister.
+type SyncTargetTransformationListerExpansion interface{}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kcp-dev/kcp/pkg/cliplugins/auth/plugin"
+)
+
+var (
+	canIExampleUses = `
+	# Check whether the current user can list configmaps in the current workspace and namespace.
+	%[1]s auth can-i list configmaps
+
+	# Explain which kcp authorizer allowed or denied updating the status of an APIBinding.
+	%[1]s auth can-i update apibindings.apis.kcp.dev/my-binding --subresource status --explain
+
+	# Check a non-resource URL for another user.
+	%[1]s auth can-i get /version --user adam --group a-team --explain
+`
+)
+
+// New returns a cobra.Command for authorization operations.
+func New(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:              "auth",
+		Short:            "Inspect authorization in the current workspace.",
+		SilenceUsage:     true,
+		TraverseChildren: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	canIOpts := plugin.NewCanIOptions(streams)
+	canICmd := &cobra.Command{
+		Use:          "can-i <verb> (<resource>[.<group>][/<name>] | <non-resource-url>) [<name>]",
+		Short:        "Check whether an action is allowed in the current workspace",
+		Example:      fmt.Sprintf(canIExampleUses, "kubectl kcp"),
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) < 2 || len(args) > 3 {
+				return c.Help()
+			}
+
+			if err := canIOpts.Complete(args); err != nil {
+				return err
+			}
+
+			if err := canIOpts.Validate(); err != nil {
+				return err
+			}
+
+			return canIOpts.Run(c.Context())
+		},
+	}
+
+	canIOpts.BindFlags(canICmd)
+	cmd.AddCommand(canICmd)
+
+	return cmd
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kcp-dev/kcp/pkg/authorization"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+)
+
+// CanIOptions contains the options for checking whether an action is allowed in the current workspace.
+type CanIOptions struct {
+	*base.Options
+
+	// Verb is the verb to check, e.g. get.
+	Verb string
+	// Resource is the resource to check in the form <resource>[.<group>], e.g. configmaps or apibindings.apis.kcp.dev.
+	Resource schema.GroupResource
+	// ResourceName is the optional name of the object to check.
+	ResourceName string
+	// NonResourceURL is the path to check, e.g. /version. It is exclusive with Resource.
+	NonResourceURL string
+	// Subresource is the optional subresource to check, e.g. status.
+	Subresource string
+	// User is the user to check for. If neither User nor Groups are set, the current user is checked.
+	User string
+	// Groups are the groups to check for.
+	Groups []string
+	// Explain prints the decision and reason of every kcp authorizer.
+	Explain bool
+
+	namespace string
+}
+
+// NewCanIOptions returns a new CanIOptions.
+func NewCanIOptions(streams genericclioptions.IOStreams) *CanIOptions {
+	return &CanIOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *CanIOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.Subresource, "subresource", o.Subresource, "Subresource to check, e.g. status.")
+	cmd.Flags().StringVar(&o.User, "user", o.User, "User to check for instead of the current user. Requires permission to create SubjectAccessReviews.")
+	cmd.Flags().StringSliceVar(&o.Groups, "group", o.Groups, "Group to check for instead of the groups of the current user. Can be repeated.")
+	cmd.Flags().BoolVar(&o.Explain, "explain", o.Explain, "Print the decision and reason of every kcp authorizer in the order they are called.")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *CanIOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Verb = args[0]
+	}
+	if len(args) > 1 {
+		if strings.HasPrefix(args[1], "/") {
+			o.NonResourceURL = args[1]
+		} else {
+			resource := args[1]
+			if i := strings.Index(resource, "/"); i >= 0 {
+				resource, o.ResourceName = resource[:i], resource[i+1:]
+			}
+			o.Resource = schema.ParseGroupResource(resource)
+		}
+	}
+	if len(args) > 2 {
+		o.ResourceName = args[2]
+	}
+
+	namespace, _, err := o.ClientConfig.Namespace()
+	if err != nil {
+		return err
+	}
+	o.namespace = namespace
+
+	return nil
+}
+
+// Validate validates the CanIOptions are complete and usable.
+func (o *CanIOptions) Validate() error {
+	if o.Verb == "" {
+		return errors.New("verb is required")
+	}
+	if o.NonResourceURL == "" && o.Resource.Resource == "" {
+		return errors.New("resource or non-resource URL is required")
+	}
+	if o.NonResourceURL != "" && (o.ResourceName != "" || o.Subresource != "") {
+		return errors.New("non-resource URLs cannot be combined with a resource name or subresource")
+	}
+
+	return nil
+}
+
+// Run checks whether the action is allowed in the current workspace, and prints the decision.
+func (o *CanIOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	if o.Explain {
+		config = authorization.WithAuthorizationExplainConfig(rest.CopyConfig(config))
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kube client: %w", err)
+	}
+
+	var resourceAttributes *authorizationv1.ResourceAttributes
+	var nonResourceAttributes *authorizationv1.NonResourceAttributes
+	if o.NonResourceURL != "" {
+		nonResourceAttributes = &authorizationv1.NonResourceAttributes{Verb: o.Verb, Path: o.NonResourceURL}
+	} else {
+		resourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   o.namespace,
+			Verb:        o.Verb,
+			Group:       o.Resource.Group,
+			Resource:    o.Resource.Resource,
+			Subresource: o.Subresource,
+			Name:        o.ResourceName,
+		}
+	}
+
+	var status authorizationv1.SubjectAccessReviewStatus
+	var annotations map[string]string
+	if o.User == "" && len(o.Groups) == 0 {
+		review, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes:    resourceAttributes,
+				NonResourceAttributes: nonResourceAttributes,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		status, annotations = review.Status, review.Annotations
+	} else {
+		review, err := kubeClient.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes:    resourceAttributes,
+				NonResourceAttributes: nonResourceAttributes,
+				User:                  o.User,
+				Groups:                o.Groups,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		status, annotations = review.Status, review.Annotations
+	}
+
+	return printDecision(o.Out, status, authorization.DecisionsFromAnnotations(annotations), o.Explain)
+}
+
+func printDecision(out io.Writer, status authorizationv1.SubjectAccessReviewStatus, decisions []authorization.AuthorizerDecision, explain bool) error {
+	if status.Allowed {
+		fmt.Fprintln(out, "yes")
+	} else {
+		fmt.Fprintln(out, "no")
+	}
+	if !explain {
+		return nil
+	}
+
+	if status.Reason != "" {
+		fmt.Fprintf(out, "reason: %s\n", status.Reason)
+	}
+	if status.EvaluationError != "" {
+		fmt.Fprintf(out, "evaluation error: %s\n", status.EvaluationError)
+	}
+	if len(decisions) == 0 {
+		fmt.Fprintln(out, "no kcp authorizer was consulted")
+		return nil
+	}
+
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintln(w, "AUTHORIZER\tDECISION\tREASON")
+	for _, d := range decisions {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Authorizer, d.Decision, d.Reason)
+	}
+	return w.Flush()
+}
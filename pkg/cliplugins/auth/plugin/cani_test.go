@@ -0,0 +1,70 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+
+	"github.com/kcp-dev/kcp/pkg/authorization"
+)
+
+func TestPrintDecision(t *testing.T) {
+	decisions := []authorization.AuthorizerDecision{
+		{Authorizer: "toplevel.authorization.kcp.dev", Decision: "Allowed", Reason: "allowed by root workspace RBAC"},
+		{Authorizer: "local.authorization.kcp.dev", Decision: "NoOpinion", Reason: "no RBAC policy matched"},
+	}
+
+	tests := map[string]struct {
+		status    authorizationv1.SubjectAccessReviewStatus
+		decisions []authorization.AuthorizerDecision
+		explain   bool
+		want      string
+	}{
+		"allowed": {
+			status:    authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+			decisions: decisions,
+			want:      "yes\n",
+		},
+		"denied with explanation": {
+			status:    authorizationv1.SubjectAccessReviewStatus{Reason: "no RBAC policy matched"},
+			decisions: decisions,
+			explain:   true,
+			want: "no\n" +
+				"reason: no RBAC policy matched\n" +
+				"AUTHORIZER                       DECISION    REASON\n" +
+				"toplevel.authorization.kcp.dev   Allowed     allowed by root workspace RBAC\n" +
+				"local.authorization.kcp.dev      NoOpinion   no RBAC policy matched\n",
+		},
+		"allowed without kcp authorizers": {
+			status:  authorizationv1.SubjectAccessReviewStatus{Allowed: true},
+			explain: true,
+			want:    "yes\nno kcp authorizer was consulted\n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			require.NoError(t, printDecision(out, tt.status, tt.decisions, tt.explain))
+			require.Equal(t, tt.want, out.String())
+		})
+	}
+}
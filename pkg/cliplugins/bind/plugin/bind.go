@@ -0,0 +1,331 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// BindOptions contains the options for creating an APIBinding to an APIExport.
+type BindOptions struct {
+	*base.Options
+
+	// APIExportRef is the reference to the APIExport in the form <workspace-path>:<apiexport-name>.
+	// Without a workspace path, the APIExport is looked up in the current workspace.
+	APIExportRef string
+	// BindingName is the name of the APIBinding. It defaults to the name of the APIExport.
+	BindingName string
+	// AcceptedPermissionClaims are the permission claims to accept, in the form <resource>.<group>.
+	AcceptedPermissionClaims []string
+	// RejectedPermissionClaims are the permission claims to reject, in the form <resource>.<group>.
+	RejectedPermissionClaims []string
+	// AcceptAllPermissionClaims accepts all permission claims not explicitly rejected.
+	AcceptAllPermissionClaims bool
+	// BindWaitTimeout is how long to wait for the APIBinding to be bound before returning control to the user.
+	BindWaitTimeout time.Duration
+
+	kcpClusterClient kcpclient.ClusterInterface
+}
+
+// NewBindOptions returns a new BindOptions.
+func NewBindOptions(streams genericclioptions.IOStreams) *BindOptions {
+	return &BindOptions{
+		Options: base.NewOptions(streams),
+
+		BindWaitTimeout: 30 * time.Second,
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *BindOptions) BindFlags(cmd *cobra.Command) {
+	o.Options.BindFlags(cmd)
+
+	cmd.Flags().StringVar(&o.BindingName, "name", o.BindingName, "Name of the APIBinding to create. Defaults to the name of the APIExport.")
+	cmd.Flags().StringSliceVar(&o.AcceptedPermissionClaims, "accept-permission-claim", o.AcceptedPermissionClaims, "Permission claim to accept, in the form <resource>.<group>, e.g. configmaps or widgets.example.io. Can be repeated.")
+	cmd.Flags().StringSliceVar(&o.RejectedPermissionClaims, "reject-permission-claim", o.RejectedPermissionClaims, "Permission claim to reject, in the form <resource>.<group>, e.g. secrets. Can be repeated.")
+	cmd.Flags().BoolVar(&o.AcceptAllPermissionClaims, "accept-all-permission-claims", o.AcceptAllPermissionClaims, "Accept all permission claims that are not explicitly rejected, without prompting.")
+	cmd.Flags().DurationVar(&o.BindWaitTimeout, "timeout", o.BindWaitTimeout, "Duration to wait for the APIBinding to be bound. Zero means not to wait.")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *BindOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.APIExportRef = args[0]
+	}
+
+	kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+	if err != nil {
+		return err
+	}
+	o.kcpClusterClient = kcpClusterClient
+
+	return nil
+}
+
+// Validate validates the BindOptions are complete and usable.
+func (o *BindOptions) Validate() error {
+	if o.APIExportRef == "" {
+		return errors.New("APIExport reference is required")
+	}
+	if o.BindWaitTimeout < 0 {
+		return errors.New("--timeout must not be negative")
+	}
+
+	if both := sets.NewString(o.AcceptedPermissionClaims...).Intersection(sets.NewString(o.RejectedPermissionClaims...)); both.Len() > 0 {
+		return fmt.Errorf("permission claims cannot be both accepted and rejected: %s", strings.Join(both.List(), ", "))
+	}
+
+	return nil
+}
+
+// Run creates an APIBinding to the APIExport in the current workspace, and waits for it to be bound.
+func (o *BindOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	exportClusterName, exportName := logicalcluster.New(o.APIExportRef).Split()
+	if exportClusterName.Empty() {
+		exportClusterName = currentClusterName
+	}
+
+	export, err := o.kcpClusterClient.Cluster(exportClusterName).ApisV1alpha1().APIExports().Get(ctx, exportName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get APIExport %s|%s: %w", exportClusterName, exportName, err)
+	}
+
+	if err := o.printAPIExport(exportClusterName, export); err != nil {
+		return err
+	}
+
+	claims, err := o.permissionClaims(export)
+	if err != nil {
+		return err
+	}
+
+	bindingName := o.BindingName
+	if bindingName == "" {
+		bindingName = exportName
+	}
+	reference := apisv1alpha1.ExportReference{
+		Workspace: &apisv1alpha1.WorkspaceExportReference{
+			Path:       exportClusterName.String(),
+			ExportName: exportName,
+		},
+	}
+
+	bindings := o.kcpClusterClient.Cluster(currentClusterName).ApisV1alpha1().APIBindings()
+	binding, err := bindings.Create(ctx, &apisv1alpha1.APIBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: bindingName,
+		},
+		Spec: apisv1alpha1.APIBindingSpec{
+			Reference:        reference,
+			PermissionClaims: claims,
+		},
+	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		binding, err = bindings.Get(ctx, bindingName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(binding.Spec.Reference, reference) {
+			return fmt.Errorf("APIBinding %q already exists for a different APIExport", bindingName)
+		}
+		binding.Spec.PermissionClaims = claims
+		binding, err = bindings.Update(ctx, binding, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update APIBinding %q: %w", bindingName, err)
+		}
+		if _, err := fmt.Fprintf(o.Out, "APIBinding %q updated.\n", bindingName); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to create APIBinding %q: %w", bindingName, err)
+	} else if _, err := fmt.Fprintf(o.Out, "APIBinding %q created.\n", bindingName); err != nil {
+		return err
+	}
+
+	if binding.Status.Phase != apisv1alpha1.APIBindingPhaseBound {
+		if o.BindWaitTimeout == 0 {
+			return nil
+		}
+
+		if _, err := fmt.Fprintf(o.Out, "Waiting for APIBinding %q to be bound...\n", bindingName); err != nil {
+			return err
+		}
+		if err := wait.PollImmediate(time.Millisecond*500, o.BindWaitTimeout, func() (bool, error) {
+			binding, err = bindings.Get(ctx, bindingName, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			return binding.Status.Phase == apisv1alpha1.APIBindingPhaseBound, nil
+		}); err != nil {
+			return fmt.Errorf("APIBinding %q did not become bound: %w", bindingName, err)
+		}
+	}
+
+	_, err = fmt.Fprintf(o.Out, "APIBinding %q is bound to APIExport %s:%s.\n", bindingName, exportClusterName, exportName)
+	return err
+}
+
+func (o *BindOptions) printAPIExport(clusterName logicalcluster.Name, export *apisv1alpha1.APIExport) error {
+	identityHash := export.Status.IdentityHash
+	if identityHash == "" {
+		identityHash = "<none>"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "APIExport %s:%s\n", clusterName, export.Name)
+	fmt.Fprintf(&b, "  Identity hash: %s\n", identityHash)
+	if len(export.Spec.PermissionClaims) == 0 {
+		fmt.Fprintf(&b, "  Permission claims: <none>\n")
+	} else {
+		fmt.Fprintf(&b, "  Permission claims:\n")
+		for _, claim := range export.Spec.PermissionClaims {
+			fmt.Fprintf(&b, "    %s\n", describeClaim(claim))
+		}
+	}
+
+	_, err := io.WriteString(o.Out, b.String())
+	return err
+}
+
+// permissionClaims decides for every permission claim of the export whether it is accepted
+// or rejected, from the flags or, for claims not covered by flags, by prompting the user.
+func (o *BindOptions) permissionClaims(export *apisv1alpha1.APIExport) ([]apisv1alpha1.AcceptablePermissionClaim, error) {
+	accepted := sets.NewString(o.AcceptedPermissionClaims...)
+	rejected := sets.NewString(o.RejectedPermissionClaims...)
+
+	var claims []apisv1alpha1.AcceptablePermissionClaim
+	var in *bufio.Reader
+	for _, claim := range export.Spec.PermissionClaims {
+		name := claimName(claim)
+
+		var state apisv1alpha1.AcceptablePermissionClaimState
+		switch {
+		case accepted.Has(name):
+			state = apisv1alpha1.ClaimAccepted
+			accepted.Delete(name)
+		case rejected.Has(name):
+			state = apisv1alpha1.ClaimRejected
+			rejected.Delete(name)
+		case o.AcceptAllPermissionClaims:
+			state = apisv1alpha1.ClaimAccepted
+		default:
+			if in == nil {
+				in = bufio.NewReader(o.In)
+			}
+			var err error
+			if state, err = o.promptClaim(in, claim); err != nil {
+				return nil, err
+			}
+		}
+
+		claims = append(claims, apisv1alpha1.AcceptablePermissionClaim{
+			PermissionClaim: claim,
+			State:           state,
+		})
+	}
+
+	if unknown := accepted.Union(rejected); unknown.Len() > 0 {
+		return nil, fmt.Errorf("APIExport %q does not claim %s", export.Name, strings.Join(unknown.List(), ", "))
+	}
+
+	return claims, nil
+}
+
+func (o *BindOptions) promptClaim(in *bufio.Reader, claim apisv1alpha1.PermissionClaim) (apisv1alpha1.AcceptablePermissionClaimState, error) {
+	for {
+		if _, err := fmt.Fprintf(o.Out, "Accept permission claim %s? [y/n]: ", describeClaim(claim)); err != nil {
+			return "", err
+		}
+		answer, err := in.ReadString('\n')
+		if err != nil && (err != io.EOF || answer == "") {
+			return "", fmt.Errorf("no answer for permission claim %s, use --accept-permission-claim or --reject-permission-claim", claimName(claim))
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return apisv1alpha1.ClaimAccepted, nil
+		case "n", "no":
+			return apisv1alpha1.ClaimRejected, nil
+		}
+	}
+}
+
+// claimName returns the name of the claimed resource in the form <resource>.<group>, or
+// <resource> for the core group.
+func claimName(claim apisv1alpha1.PermissionClaim) string {
+	return schema.GroupResource{Group: claim.Group, Resource: claim.Resource}.String()
+}
+
+func describeClaim(claim apisv1alpha1.PermissionClaim) string {
+	if claim.IdentityHash == "" {
+		return claimName(claim)
+	}
+	return fmt.Sprintf("%s (identity hash %s)", claimName(claim), claim.IdentityHash)
+}
+
+func newKCPClusterClient(clientConfig clientcmd.ClientConfig) (kcpclient.ClusterInterface, error) {
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	clusterConfig := rest.CopyConfig(config)
+	u, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = ""
+	clusterConfig.Host = u.String()
+	clusterConfig.UserAgent = rest.DefaultKubernetesUserAgent()
+	return kcpclient.NewClusterForConfig(clusterConfig)
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestBind(t *testing.T) {
+	consumer := logicalcluster.New("root:consumer")
+	provider := logicalcluster.New("root:provider")
+
+	configMapsClaim := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Resource: "configmaps"}}
+	widgetsClaim := apisv1alpha1.PermissionClaim{GroupResource: apisv1alpha1.GroupResource{Group: "example.io", Resource: "widgets"}, IdentityHash: "abc"}
+	export := &apisv1alpha1.APIExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+		Spec: apisv1alpha1.APIExportSpec{
+			PermissionClaims: []apisv1alpha1.PermissionClaim{configMapsClaim, widgetsClaim},
+		},
+		Status: apisv1alpha1.APIExportStatus{IdentityHash: "xyz"},
+	}
+	reference := apisv1alpha1.ExportReference{Workspace: &apisv1alpha1.WorkspaceExportReference{Path: provider.String(), ExportName: "my-export"}}
+
+	tests := []struct {
+		name             string
+		apiExportRef     string
+		bindingName      string
+		accepted         []string
+		rejected         []string
+		acceptAll        bool
+		input            string
+		existingBindings []runtime.Object
+		markBound        bool
+
+		wantBinding *apisv1alpha1.APIBinding
+		wantErr     bool
+		wantOutput  []string
+	}{
+		{
+			name:         "claims from flags",
+			apiExportRef: "root:provider:my-export",
+			accepted:     []string{"configmaps"},
+			rejected:     []string{"widgets.example.io"},
+			markBound:    true,
+			wantBinding: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: reference,
+					PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+						{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimAccepted},
+						{PermissionClaim: widgetsClaim, State: apisv1alpha1.ClaimRejected},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+			},
+			wantOutput: []string{"Identity hash: xyz", "widgets.example.io (identity hash abc)", `APIBinding "my-export" created.`, `APIBinding "my-export" is bound`},
+		},
+		{
+			name:         "claims from prompt",
+			apiExportRef: "root:provider:my-export",
+			bindingName:  "my-binding",
+			input:        "maybe\nn\nyes\n",
+			markBound:    true,
+			wantBinding: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-binding"},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: reference,
+					PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+						{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimRejected},
+						{PermissionClaim: widgetsClaim, State: apisv1alpha1.ClaimAccepted},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+			},
+			wantOutput: []string{"Accept permission claim configmaps? [y/n]: Accept permission claim configmaps? [y/n]: Accept permission claim widgets.example.io"},
+		},
+		{
+			name:         "accept all except rejected",
+			apiExportRef: "root:provider:my-export",
+			rejected:     []string{"configmaps"},
+			acceptAll:    true,
+			markBound:    true,
+			wantBinding: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: reference,
+					PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+						{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimRejected},
+						{PermissionClaim: widgetsClaim, State: apisv1alpha1.ClaimAccepted},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+			},
+		},
+		{
+			name:         "no answer to prompt",
+			apiExportRef: "root:provider:my-export",
+			accepted:     []string{"configmaps"},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown claim",
+			apiExportRef: "root:provider:my-export",
+			acceptAll:    true,
+			rejected:     []string{"secrets"},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown export",
+			apiExportRef: "root:provider:other-export",
+			acceptAll:    true,
+			wantErr:      true,
+		},
+		{
+			name:         "update existing binding",
+			apiExportRef: "root:provider:my-export",
+			acceptAll:    true,
+			existingBindings: []runtime.Object{&apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+				Spec:       apisv1alpha1.APIBindingSpec{Reference: reference},
+				Status:     apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+			}},
+			wantBinding: &apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+				Spec: apisv1alpha1.APIBindingSpec{
+					Reference: reference,
+					PermissionClaims: []apisv1alpha1.AcceptablePermissionClaim{
+						{PermissionClaim: configMapsClaim, State: apisv1alpha1.ClaimAccepted},
+						{PermissionClaim: widgetsClaim, State: apisv1alpha1.ClaimAccepted},
+					},
+				},
+				Status: apisv1alpha1.APIBindingStatus{Phase: apisv1alpha1.APIBindingPhaseBound},
+			},
+			wantOutput: []string{`APIBinding "my-export" updated.`},
+		},
+		{
+			name:         "existing binding for another export",
+			apiExportRef: "root:provider:my-export",
+			acceptAll:    true,
+			existingBindings: []runtime.Object{&apisv1alpha1.APIBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-export"},
+				Spec: apisv1alpha1.APIBindingSpec{Reference: apisv1alpha1.ExportReference{
+					Workspace: &apisv1alpha1.WorkspaceExportReference{Path: "root:other", ExportName: "my-export"},
+				}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:         "binding not bound in time",
+			apiExportRef: "root:provider:my-export",
+			acceptAll:    true,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerClient := kcpfake.NewSimpleClientset(export)
+			consumerClient := kcpfake.NewSimpleClientset(tt.existingBindings...)
+			if tt.markBound {
+				consumerClient.PrependReactor("create", "apibindings", func(action clientgotesting.Action) (handled bool, ret runtime.Object, err error) {
+					obj := action.(clientgotesting.CreateAction).GetObject().(*apisv1alpha1.APIBinding)
+					obj.Status.Phase = apisv1alpha1.APIBindingPhaseBound
+					return false, nil, nil
+				})
+			}
+
+			streams, in, out, _ := genericclioptions.NewTestIOStreams()
+			in.WriteString(tt.input)
+
+			opts := NewBindOptions(streams)
+			opts.APIExportRef = tt.apiExportRef
+			opts.BindingName = tt.bindingName
+			opts.AcceptedPermissionClaims = tt.accepted
+			opts.RejectedPermissionClaims = tt.rejected
+			opts.AcceptAllPermissionClaims = tt.acceptAll
+			opts.BindWaitTimeout = time.Second
+			opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+				Clusters:       map[string]*clientcmdapi.Cluster{"workspace": {Server: "https://test/clusters/" + consumer.String()}},
+				Contexts:       map[string]*clientcmdapi.Context{"workspace": {Cluster: "workspace", AuthInfo: "user"}},
+				AuthInfos:      map[string]*clientcmdapi.AuthInfo{"user": {Token: "token"}},
+				CurrentContext: "workspace",
+			}, nil)
+			opts.kcpClusterClient = fakeKcpClusterClient{
+				t: t,
+				clients: map[logicalcluster.Name]*kcpfake.Clientset{
+					consumer: consumerClient,
+					provider: providerClient,
+				},
+			}
+
+			require.NoError(t, opts.Validate())
+			err := opts.Run(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			binding, err := consumerClient.ApisV1alpha1().APIBindings().Get(context.Background(), tt.wantBinding.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBinding, binding)
+			for _, output := range tt.wantOutput {
+				require.Contains(t, out.String(), output)
+			}
+		})
+	}
+}
+
+func TestBindValidate(t *testing.T) {
+	opts := NewBindOptions(genericclioptions.IOStreams{In: &bytes.Buffer{}, Out: &strings.Builder{}})
+	opts.APIExportRef = "root:provider:my-export"
+	opts.AcceptedPermissionClaims = []string{"configmaps", "secrets"}
+	opts.RejectedPermissionClaims = []string{"secrets"}
+	require.EqualError(t, opts.Validate(), "permission claims cannot be both accepted and rejected: secrets")
+}
+
+type fakeKcpClusterClient struct {
+	t       *testing.T
+	clients map[logicalcluster.Name]*kcpfake.Clientset
+}
+
+func (f fakeKcpClusterClient) Cluster(cluster logicalcluster.Name) kcpclient.Interface {
+	client, ok := f.clients[cluster]
+	require.True(f.t, ok, "no client for cluster %s", cluster)
+	return client
+}
@@ -91,6 +91,9 @@ type SyncOptions struct {
 	SyncTargetName string
 	// FeatureGates is used to configure which feature gates are enabled.
 	FeatureGates string
+	// SyncedNamespaceLabels are the keys of the labels of kcp namespaces the syncer sets on the namespaces
+	// in the physical cluster.
+	SyncedNamespaceLabels []string
 }
 
 // NewSyncOptions returns a new SyncOptions.
@@ -110,6 +113,7 @@ func (o *SyncOptions) BindFlags(cmd *cobra.Command) {
 	o.Options.BindFlags(cmd)
 
 	cmd.Flags().StringSliceVar(&o.ResourcesToSync, "resources", o.ResourcesToSync, "Resources to synchronize with kcp.")
+	cmd.Flags().StringSliceVar(&o.SyncedNamespaceLabels, "synced-namespace-labels", o.SyncedNamespaceLabels, "Keys of the labels of kcp namespaces to set on the namespaces in the physical cluster. A key ending with * matches all keys with that prefix.")
 	cmd.Flags().StringVar(&o.SyncerImage, "syncer-image", o.SyncerImage, "The syncer image to use in the syncer's deployment YAML. Images are published at https://github.com/kcp-dev/kcp/pkgs/container/kcp%2Fsyncer.")
 	cmd.Flags().IntVar(&o.Replicas, "replicas", o.Replicas, "Number of replicas of the syncer deployment.")
 	cmd.Flags().StringVar(&o.KCPNamespace, "kcp-namespace", o.KCPNamespace, "The name of the kcp namespace to create a service account in.")
@@ -231,6 +235,8 @@ func (o *SyncOptions) Run(ctx context.Context) error {
 		QPS:                o.QPS,
 		Burst:              o.Burst,
 		FeatureGatesString: o.FeatureGates,
+
+		SyncedNamespaceLabels: o.SyncedNamespaceLabels,
 	}
 
 	resources, err := renderSyncerResources(input, syncerID)
@@ -373,6 +379,16 @@ func (o *SyncOptions) enableSyncerForWorkspace(ctx context.Context, config *rest
 			ResourceNames: []string{syncTargetName},
 			Resources:     []string{"synctargets/status"},
 		},
+		{
+			Verbs:     []string{"get", "list", "watch"},
+			APIGroups: []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"synctargettransformations"},
+		},
+		{
+			Verbs:     []string{"update", "patch"},
+			APIGroups: []string{workloadv1alpha1.SchemeGroupVersion.Group},
+			Resources: []string{"synctargettransformations/status"},
+		},
 		{
 			Verbs:     []string{"get", "create", "update", "delete", "list", "watch"},
 			APIGroups: []string{apiresourcev1alpha1.SchemeGroupVersion.Group},
@@ -559,6 +575,9 @@ type templateInput struct {
 	Burst int
 	// FeatureGatesString is the set of features gates.
 	FeatureGatesString string
+	// SyncedNamespaceLabels are the keys of the labels of kcp namespaces the syncer sets on the
+	// namespaces in the pcluster.
+	SyncedNamespaceLabels []string
 }
 
 // templateArgs represents the full set of arguments required to render the resources
@@ -59,8 +59,10 @@ rules:
   - namespaces
   verbs:
   - "create"
+  - "get"
   - "list"
   - "watch"
+  - "update"
   - "delete"
 - apiGroups:
   - "apiextensions.k8s.io"
@@ -216,8 +218,10 @@ rules:
   - namespaces
   verbs:
   - "create"
+  - "get"
   - "list"
   - "watch"
+  - "update"
   - "delete"
 - apiGroups:
   - "apiextensions.k8s.io"
@@ -60,6 +60,15 @@ var (
 
 	# create a context with the current workspace, named context-name
 	%[1]s workspace create-context context-name
+
+	# show the workspace hierarchy below the current workspace
+	%[1]s workspace tree
+
+	# show two levels of the workspace hierarchy below root:default as JSON
+	%[1]s workspace tree root:default --depth 2 -o json
+
+	# restore a deleted workspace before its retention period expires
+	%[1]s workspace restore my-workspace
 `
 )
 
@@ -82,7 +91,7 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	cmd := &cobra.Command{
 		Aliases:          []string{"ws", "workspaces"},
-		Use:              "workspace [create|create-context|use|current|<workspace>|..|.|-|~|<root:absolute:workspace>]",
+		Use:              "workspace [create|create-context|use|current|tree|restore|<workspace>|..|.|-|~|<root:absolute:workspace>]",
 		Short:            "Manages KCP workspaces",
 		Example:          fmt.Sprintf(workspaceExample, "kubectl kcp"),
 		SilenceUsage:     true,
@@ -167,9 +176,50 @@ func New(streams genericclioptions.IOStreams) (*cobra.Command, error) {
 	}
 	createContextOpts.BindFlags(createContextCmd)
 
+	treeOpts := plugin.NewTreeOptions(streams)
+	treeCmd := &cobra.Command{
+		Use:          "tree [<workspace>|<root:absolute:workspace>] [--depth <n>] [-o json|yaml]",
+		Short:        "Print the workspace hierarchy below the given or current workspace",
+		Example:      "kcp workspace tree root:default --depth 2",
+		SilenceUsage: true,
+		RunE: func(c *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return c.Help()
+			}
+			if err := treeOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := treeOpts.Validate(); err != nil {
+				return err
+			}
+			return treeOpts.Run(c.Context())
+		},
+	}
+	treeOpts.BindFlags(treeCmd)
+
+	restoreOpts := plugin.NewRestoreWorkspaceOptions(streams)
+	restoreCmd := &cobra.Command{
+		Use:          "restore <workspace>",
+		Short:        "Restore a deleted workspace in its retention period",
+		Example:      "kcp workspace restore my-workspace",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			if err := restoreOpts.Complete(args); err != nil {
+				return err
+			}
+			if err := restoreOpts.Validate(); err != nil {
+				return err
+			}
+			return restoreOpts.Run(c.Context())
+		},
+	}
+
 	cmd.AddCommand(useCmd)
 	cmd.AddCommand(currentCmd)
 	cmd.AddCommand(createCmd)
 	cmd.AddCommand(createContextCmd)
+	cmd.AddCommand(treeCmd)
+	cmd.AddCommand(restoreCmd)
 	return cmd, nil
 }
@@ -0,0 +1,124 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// RestoreWorkspaceOptions contains options for restoring a deleted workspace.
+type RestoreWorkspaceOptions struct {
+	*base.Options
+
+	// Name is the name of the deleted workspace in the current workspace.
+	Name string
+
+	kcpClusterClient kcpclient.ClusterInterface
+}
+
+// NewRestoreWorkspaceOptions returns a new RestoreWorkspaceOptions.
+func NewRestoreWorkspaceOptions(streams genericclioptions.IOStreams) *RestoreWorkspaceOptions {
+	return &RestoreWorkspaceOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *RestoreWorkspaceOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+	if err != nil {
+		return err
+	}
+	o.kcpClusterClient = kcpClusterClient
+
+	return nil
+}
+
+// Validate validates the RestoreWorkspaceOptions are complete and usable.
+func (o *RestoreWorkspaceOptions) Validate() error {
+	if o.Name == "" {
+		return errors.New("a workspace name is required")
+	}
+	return o.Options.Validate()
+}
+
+// Run requests the restoration of a deleted workspace in its retention period.
+func (o *RestoreWorkspaceOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	workspaces := o.kcpClusterClient.Cluster(currentClusterName).TenancyV1beta1().Workspaces()
+	ws, err := workspaces.Get(ctx, o.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("workspace %q not found, it might have been purged already", o.Name)
+	} else if err != nil {
+		return err
+	}
+
+	if ws.DeletionTimestamp == nil {
+		return fmt.Errorf("workspace %q is not deleted", o.Name)
+	}
+	if ws.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseDeleted {
+		return fmt.Errorf("workspace %q cannot be restored, its contents are being purged", o.Name)
+	}
+	if _, found := ws.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey]; !found {
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"annotations": map[string]string{
+					tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey: "true",
+				},
+				"resourceVersion": ws.ResourceVersion,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := workspaces.Patch(ctx, o.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(o.Out, "Workspace %q is being restored.\n", o.Name)
+	return err
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	tenancyfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestRestore(t *testing.T) {
+	newWorkspace := func(phase tenancyv1alpha1.ClusterWorkspacePhaseType, deleted bool) *tenancyv1beta1.Workspace {
+		ws := &tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo"},
+			Status:     tenancyv1beta1.WorkspaceStatus{Phase: phase},
+		}
+		if deleted {
+			ws.DeletionTimestamp = &metav1.Time{}
+		}
+		return ws
+	}
+
+	tests := []struct {
+		name       string
+		existing   []runtime.Object
+		wantErr    string
+		wantOutput string
+	}{
+		{
+			name:       "deleted workspace in its retention period",
+			existing:   []runtime.Object{newWorkspace(tenancyv1alpha1.ClusterWorkspacePhaseDeleted, true)},
+			wantOutput: "Workspace \"foo\" is being restored.\n",
+		},
+		{
+			name:    "workspace not found",
+			wantErr: `workspace "foo" not found, it might have been purged already`,
+		},
+		{
+			name:     "workspace not deleted",
+			existing: []runtime.Object{newWorkspace(tenancyv1alpha1.ClusterWorkspacePhaseReady, false)},
+			wantErr:  `workspace "foo" is not deleted`,
+		},
+		{
+			name:     "workspace being purged",
+			existing: []runtime.Object{newWorkspace(tenancyv1alpha1.ClusterWorkspacePhaseReady, true)},
+			wantErr:  `workspace "foo" cannot be restored, its contents are being purged`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := tenancyfake.NewSimpleClientset(tt.existing...)
+
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewRestoreWorkspaceOptions(streams)
+			opts.Name = "foo"
+			opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+				Clusters:       map[string]*clientcmdapi.Cluster{"workspace": {Server: "https://test/clusters/root:org"}},
+				Contexts:       map[string]*clientcmdapi.Context{"workspace": {Cluster: "workspace", AuthInfo: "user"}},
+				AuthInfos:      map[string]*clientcmdapi.AuthInfo{"user": {Token: "token"}},
+				CurrentContext: "workspace",
+			}, nil)
+			opts.kcpClusterClient = fakeTenancyClient{t: t, clients: map[logicalcluster.Name]*tenancyfake.Clientset{
+				logicalcluster.New("root:org"): client,
+			}}
+
+			require.NoError(t, opts.Validate())
+			err := opts.Run(context.Background())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantOutput, out.String())
+
+			ws, err := client.TenancyV1beta1().Workspaces().Get(context.Background(), "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "true", ws.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey])
+		})
+	}
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/spf13/cobra"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	"github.com/kcp-dev/kcp/pkg/cliplugins/base"
+	pluginhelpers "github.com/kcp-dev/kcp/pkg/cliplugins/helpers"
+)
+
+// TreeOptions contains options for displaying the workspace hierarchy.
+type TreeOptions struct {
+	*base.Options
+
+	// Name is the workspace to start from, either absolute or relative to the current workspace.
+	// It defaults to the current workspace.
+	Name string
+	// MaxDepth limits the number of levels below the starting workspace. Zero means no limit.
+	MaxDepth int
+	// Output is the output format, empty for a tree, or json or yaml.
+	Output string
+
+	kcpClusterClient kcpclient.ClusterInterface
+}
+
+// WorkspaceTreeNode is a workspace and its child workspaces.
+type WorkspaceTreeNode struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Type  string `json:"type,omitempty"`
+	Phase string `json:"phase,omitempty"`
+	Shard string `json:"shard,omitempty"`
+	Ready bool   `json:"ready"`
+	// NoAccess is set when the children of the workspace could not be listed for lack of permissions.
+	NoAccess bool                 `json:"noAccess,omitempty"`
+	Children []*WorkspaceTreeNode `json:"children,omitempty"`
+}
+
+// NewTreeOptions returns a new TreeOptions.
+func NewTreeOptions(streams genericclioptions.IOStreams) *TreeOptions {
+	return &TreeOptions{
+		Options: base.NewOptions(streams),
+	}
+}
+
+// BindFlags binds fields to cmd's flagset.
+func (o *TreeOptions) BindFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&o.MaxDepth, "depth", o.MaxDepth, "Maximal number of levels below the workspace to show. Zero means no limit.")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", o.Output, "Output format. One of: json|yaml. Defaults to a tree.")
+}
+
+// Complete ensures all dynamically populated fields are initialized.
+func (o *TreeOptions) Complete(args []string) error {
+	if err := o.Options.Complete(); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		o.Name = args[0]
+	}
+
+	kcpClusterClient, err := newKCPClusterClient(o.ClientConfig)
+	if err != nil {
+		return err
+	}
+	o.kcpClusterClient = kcpClusterClient
+
+	return nil
+}
+
+// Validate validates the TreeOptions are complete and usable.
+func (o *TreeOptions) Validate() error {
+	if o.MaxDepth < 0 {
+		return errors.New("--depth must not be negative")
+	}
+	switch o.Output {
+	case "", "json", "yaml":
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: json|yaml", o.Output)
+	}
+	return o.Options.Validate()
+}
+
+// Run walks the workspaces below the starting workspace through the workspaces virtual workspace and prints them.
+func (o *TreeOptions) Run(ctx context.Context) error {
+	config, err := o.ClientConfig.ClientConfig()
+	if err != nil {
+		return err
+	}
+	_, currentClusterName, err := pluginhelpers.ParseClusterURL(config.Host)
+	if err != nil {
+		return fmt.Errorf("current URL %q does not point to cluster workspace", config.Host)
+	}
+
+	clusterName := currentClusterName
+	switch {
+	case o.Name == "" || o.Name == ".":
+	case strings.Contains(o.Name, ":"):
+		clusterName = logicalcluster.New(o.Name)
+		if !clusterName.HasPrefix(logicalcluster.New("system")) && !clusterName.HasPrefix(tenancyv1alpha1.RootCluster) {
+			return fmt.Errorf("invalid workspace name format: %s", o.Name)
+		}
+	default:
+		clusterName = currentClusterName.Join(o.Name)
+	}
+
+	root := &WorkspaceTreeNode{
+		Name: clusterName.Base(),
+		Path: clusterName.String(),
+	}
+	if parent, hasParent := clusterName.Parent(); hasParent {
+		// a 403 in the parent is not a blocker to walk the workspace itself
+		ws, err := o.kcpClusterClient.Cluster(parent).TenancyV1beta1().Workspaces().Get(ctx, clusterName.Base(), metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			return fmt.Errorf("workspace %q not found", clusterName)
+		case err == nil:
+			root = newWorkspaceTreeNode(parent, ws)
+		case !apierrors.IsForbidden(err):
+			return err
+		}
+	}
+
+	if err := o.walk(ctx, root, 1); err != nil {
+		return err
+	}
+
+	switch o.Output {
+	case "json":
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(o.Out, string(data))
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(root)
+		if err != nil {
+			return err
+		}
+		_, err = o.Out.Write(data)
+		return err
+	}
+
+	return printWorkspaceTree(o.Out, root)
+}
+
+// walk adds the child workspaces of node, recursively up to the maximal depth. Workspaces
+// the user is not allowed to list children of are marked as such.
+func (o *TreeOptions) walk(ctx context.Context, node *WorkspaceTreeNode, depth int) error {
+	if o.MaxDepth > 0 && depth > o.MaxDepth {
+		return nil
+	}
+
+	clusterName := logicalcluster.New(node.Path)
+	list, err := o.kcpClusterClient.Cluster(clusterName).TenancyV1beta1().Workspaces().List(ctx, metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		node.NoAccess = true
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to list workspaces in %q: %w", clusterName, err)
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+	for i := range list.Items {
+		child := newWorkspaceTreeNode(clusterName, &list.Items[i])
+		node.Children = append(node.Children, child)
+		if err := o.walk(ctx, child, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newWorkspaceTreeNode(parent logicalcluster.Name, ws *tenancyv1beta1.Workspace) *WorkspaceTreeNode {
+	node := &WorkspaceTreeNode{
+		Name:  ws.Name,
+		Path:  parent.Join(ws.Name).String(),
+		Phase: string(ws.Status.Phase),
+		Ready: conditions.IsTrue(ws, conditionsv1alpha1.ReadyCondition),
+	}
+	if ws.Status.Location != nil {
+		node.Shard = ws.Status.Location.Current
+	}
+	if ws.Spec.Type.Name != "" {
+		node.Type = ws.Spec.Type.String()
+	}
+	return node
+}
+
+func printWorkspaceTree(out io.Writer, root *WorkspaceTreeNode) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s%s\n", root.Path, describeWorkspaceTreeNode(root), noAccessMarker(root))
+	printWorkspaceTreeChildren(&b, root, "")
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func printWorkspaceTreeChildren(b *strings.Builder, node *WorkspaceTreeNode, prefix string) {
+	for i, child := range node.Children {
+		branch, indent := "├── ", "│   "
+		if i == len(node.Children)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintf(b, "%s%s%s%s%s\n", prefix, branch, child.Name, describeWorkspaceTreeNode(child), noAccessMarker(child))
+		printWorkspaceTreeChildren(b, child, prefix+indent)
+	}
+}
+
+func noAccessMarker(node *WorkspaceTreeNode) string {
+	if node.NoAccess {
+		return " [no access]"
+	}
+	return ""
+}
+
+func describeWorkspaceTreeNode(node *WorkspaceTreeNode) string {
+	if node.Type == "" && node.Phase == "" && node.Shard == "" {
+		return ""
+	}
+
+	var details []string
+	if node.Type != "" {
+		details = append(details, "type "+node.Type)
+	}
+	if node.Phase != "" {
+		details = append(details, "phase "+node.Phase)
+	}
+	if node.Shard != "" {
+		details = append(details, "shard "+node.Shard)
+	}
+	if node.Ready {
+		details = append(details, "ready")
+	} else {
+		details = append(details, "not ready")
+	}
+	return " (" + strings.Join(details, ", ") + ")"
+}
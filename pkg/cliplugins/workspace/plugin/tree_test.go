@@ -0,0 +1,201 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientgotesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	tenancyfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+)
+
+func TestTree(t *testing.T) {
+	newWorkspace := func(name, typeName, shard string, ready bool) *tenancyv1beta1.Workspace {
+		ws := &tenancyv1beta1.Workspace{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: tenancyv1beta1.WorkspaceSpec{
+				Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: tenancyv1alpha1.ClusterWorkspaceTypeName(typeName), Path: "root"},
+			},
+			Status: tenancyv1beta1.WorkspaceStatus{
+				Phase:    tenancyv1alpha1.ClusterWorkspacePhaseInitializing,
+				Location: &tenancyv1alpha1.ClusterWorkspaceLocation{Current: shard},
+			},
+		}
+		if ready {
+			ws.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseReady
+			ws.Status.Conditions = conditionsv1alpha1.Conditions{{Type: conditionsv1alpha1.ReadyCondition, Status: "True"}}
+		}
+		return ws
+	}
+
+	workspaces := map[string][]runtime.Object{
+		"root": {
+			newWorkspace("default", "organization", "root", true),
+		},
+		"root:default": {
+			newWorkspace("team-b", "universal", "shard-1", false),
+			newWorkspace("team-a", "universal", "root", true),
+		},
+		"root:default:team-a": {
+			newWorkspace("app", "universal", "shard-1", true),
+		},
+		"root:default:team-b":     {},
+		"root:default:team-a:app": {},
+	}
+
+	tests := []struct {
+		name          string
+		current       string
+		workspaceName string
+		maxDepth      int
+		output        string
+		forbidden     []string
+
+		wantOutput string
+		wantErr    bool
+	}{
+		{
+			name:    "tree below the current workspace",
+			current: "root:default",
+			wantOutput: `root:default (type root:organization, phase Ready, shard root, ready)
+├── team-a (type root:universal, phase Ready, shard root, ready)
+│   └── app (type root:universal, phase Ready, shard shard-1, ready)
+└── team-b (type root:universal, phase Initializing, shard shard-1, not ready)
+`,
+		},
+		{
+			name:          "relative workspace with depth limit",
+			current:       "root",
+			workspaceName: "default",
+			maxDepth:      1,
+			wantOutput: `root:default (type root:organization, phase Ready, shard root, ready)
+├── team-a (type root:universal, phase Ready, shard root, ready)
+└── team-b (type root:universal, phase Initializing, shard shard-1, not ready)
+`,
+		},
+		{
+			name:          "absolute workspace as yaml",
+			current:       "root",
+			workspaceName: "root:default:team-a",
+			output:        "yaml",
+			wantOutput: `children:
+- name: app
+  path: root:default:team-a:app
+  phase: Ready
+  ready: true
+  shard: shard-1
+  type: root:universal
+name: team-a
+path: root:default:team-a
+phase: Ready
+ready: true
+shard: root
+type: root:universal
+`,
+		},
+		{
+			name:     "root workspace without details",
+			current:  "root",
+			output:   "json",
+			maxDepth: 1,
+			wantOutput: `{
+  "name": "root",
+  "path": "root",
+  "ready": false,
+  "children": [
+    {
+      "name": "default",
+      "path": "root:default",
+      "type": "root:organization",
+      "phase": "Ready",
+      "shard": "root",
+      "ready": true
+    }
+  ]
+}
+`,
+		},
+		{
+			name:      "forbidden workspaces are marked",
+			current:   "root:default",
+			forbidden: []string{"root:default:team-a"},
+			wantOutput: `root:default (type root:organization, phase Ready, shard root, ready)
+├── team-a (type root:universal, phase Ready, shard root, ready) [no access]
+└── team-b (type root:universal, phase Initializing, shard shard-1, not ready)
+`,
+		},
+		{
+			name:          "unknown workspace",
+			current:       "root:default",
+			workspaceName: "team-c",
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clients := map[logicalcluster.Name]*tenancyfake.Clientset{}
+			for cluster, objects := range workspaces {
+				client := tenancyfake.NewSimpleClientset(objects...)
+				for _, forbidden := range tt.forbidden {
+					if forbidden == cluster {
+						client.PrependReactor("list", "workspaces", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+							return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "tenancy.kcp.dev", Resource: "workspaces"}, "", nil)
+						})
+					}
+				}
+				clients[logicalcluster.New(cluster)] = client
+			}
+
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			opts := NewTreeOptions(streams)
+			opts.Name = tt.workspaceName
+			opts.MaxDepth = tt.maxDepth
+			opts.Output = tt.output
+			opts.ClientConfig = clientcmd.NewDefaultClientConfig(clientcmdapi.Config{
+				Clusters:       map[string]*clientcmdapi.Cluster{"workspace": {Server: "https://test/clusters/" + tt.current}},
+				Contexts:       map[string]*clientcmdapi.Context{"workspace": {Cluster: "workspace", AuthInfo: "user"}},
+				AuthInfos:      map[string]*clientcmdapi.AuthInfo{"user": {Token: "token"}},
+				CurrentContext: "workspace",
+			}, nil)
+			opts.kcpClusterClient = fakeTenancyClient{t: t, clients: clients}
+
+			require.NoError(t, opts.Validate())
+			err := opts.Run(context.Background())
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantOutput, out.String())
+		})
+	}
+}
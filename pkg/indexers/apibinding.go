@@ -52,3 +52,24 @@ func IndexAPIBindingByClusterAndAcceptedClaimedGroupResources(obj interface{}) (
 
 	return ret, nil
 }
+
+// IndexAPIBindingByClusterAndBoundGroupResources is an index function that indexes an APIBinding by the
+// group resources bound from an APIExport in a workspace. Use ClusterAndGroupResourceValue for the lookup.
+func IndexAPIBindingByClusterAndBoundGroupResources(obj interface{}) ([]string, error) {
+	apiBinding, ok := obj.(*apisv1alpha1.APIBinding)
+	if !ok {
+		return []string{}, fmt.Errorf("obj %T is not an APIBinding", obj)
+	}
+
+	if apiBinding.Status.BoundAPIExport == nil || apiBinding.Status.BoundAPIExport.Workspace == nil {
+		return []string{}, nil
+	}
+
+	ret := make([]string, 0, len(apiBinding.Status.BoundResources))
+	for _, r := range apiBinding.Status.BoundResources {
+		groupResource := schema.GroupResource{Group: r.Group, Resource: r.Resource}
+		ret = append(ret, ClusterAndGroupResourceValue(logicalcluster.From(apiBinding), groupResource))
+	}
+
+	return ret, nil
+}
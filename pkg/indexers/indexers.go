@@ -41,6 +41,9 @@ const (
 	// APIBindingByClusterAndAcceptedClaimedGroupResources is the name for the index that indexes an APIBinding by its
 	// cluster name and accepted claimed group resources.
 	APIBindingByClusterAndAcceptedClaimedGroupResources = "byClusterAndAcceptedClaimedGroupResources"
+	// APIBindingByClusterAndBoundGroupResources is the name for the index that indexes an APIBinding by its
+	// cluster name and bound group resources.
+	APIBindingByClusterAndBoundGroupResources = "byClusterAndBoundGroupResources"
 )
 
 // ClusterScoped returns cache.Indexers appropriate for cluster-scoped resources.
@@ -31,5 +31,15 @@ limitations under the License.
 //    backend_server_ca: certs/kcp-ca-cert.pem
 //    proxy_client_cert: certs/proxy-client-cert.pem
 //    proxy_client_key: certs/proxy-client-key.pem
+//
+// The mapping file and the certificates it references are watched, and the
+// routes are replaced atomically when they change. An invalid mapping is
+// rejected and the previous routes are kept. Alternatively, the same mapping
+// can be read from the "mapping.yaml" key of a ConfigMap in the root workspace.
+//
+// Requests to /clusters/<logical-cluster>/... are routed to the shard owning
+// the logical cluster. Requests to /services/<virtual-workspace>/<logical-cluster>/...
+// are routed to the virtual workspace server of that shard, falling back to
+// the configured backend for paths without a known logical cluster.
 
 package proxy
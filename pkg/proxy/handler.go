@@ -75,3 +75,40 @@ func shardHandler(index index.Index, proxy http.Handler) http.HandlerFunc {
 		proxy.ServeHTTP(w, req)
 	}
 }
+
+// virtualWorkspaceHandler routes requests of the form /services/<virtual-workspace>/<logical-cluster>/...
+// to the virtual workspace server of the shard owning the logical cluster. Requests without a known
+// logical cluster in that position are passed to the fallback handler.
+func virtualWorkspaceHandler(index index.Index, proxy http.Handler, fallback http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var cs = strings.SplitN(strings.TrimLeft(req.URL.Path, "/"), "/", 4)
+		if len(cs) < 3 || cs[0] != "services" {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+
+		clusterName := logicalcluster.New(cs[2])
+		if !tenancyhelper.IsValidCluster(clusterName) {
+			fallback.ServeHTTP(w, req)
+			return
+		}
+
+		virtualWorkspaceURLString, found := index.LookupVirtualWorkspace(clusterName)
+		if !found {
+			klog.V(4).Infof("Unknown cluster %q for virtual workspace request %q", clusterName, req.URL.Path)
+			fallback.ServeHTTP(w, req)
+			return
+		}
+		virtualWorkspaceURL, err := url.Parse(virtualWorkspaceURLString)
+		if err != nil {
+			responsewriters.InternalError(w, req, err)
+			return
+		}
+
+		klog.V(4).Infof("Redirecting %q to %s", req.URL.Path, virtualWorkspaceURL)
+
+		ctx := WithShardURL(req.Context(), virtualWorkspaceURL)
+		req = req.WithContext(ctx)
+		proxy.ServeHTTP(w, req)
+	}
+}
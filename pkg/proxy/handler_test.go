@@ -0,0 +1,98 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIndex struct {
+	virtualWorkspaceURLs map[logicalcluster.Name]string
+	shardBaseURLs        map[string]string
+	synced               bool
+}
+
+func (i fakeIndex) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
+	return "", false
+}
+
+func (i fakeIndex) LookupVirtualWorkspace(logicalCluster logicalcluster.Name) (string, bool) {
+	url, found := i.virtualWorkspaceURLs[logicalCluster]
+	return url, found
+}
+
+func (i fakeIndex) ShardBaseURLs() map[string]string {
+	return i.shardBaseURLs
+}
+
+func (i fakeIndex) HasSynced() bool {
+	return i.synced
+}
+
+func TestVirtualWorkspaceHandler(t *testing.T) {
+	index := fakeIndex{virtualWorkspaceURLs: map[logicalcluster.Name]string{
+		logicalcluster.New("root:org:ws"): "https://shard-1:6444",
+		logicalcluster.New("root"):        "https://root:6444",
+	}}
+
+	tests := map[string]struct {
+		path         string
+		wantShardURL string
+	}{
+		"syncer virtual workspace": {
+			path:         "/services/syncer/root:org:ws/cluster-1/ab12/clusters/*/api/v1/configmaps",
+			wantShardURL: "https://shard-1:6444",
+		},
+		"apiexport virtual workspace in root": {
+			path:         "/services/apiexport/root/my-export/clusters/*/api/v1/configmaps",
+			wantShardURL: "https://root:6444",
+		},
+		"unknown logical cluster": {
+			path: "/services/apiexport/root:org:other/my-export/clusters/*/api/v1/configmaps",
+		},
+		"no logical cluster": {
+			path: "/services/initializingworkspaces/universal/clusters/*/apis/tenancy.kcp.dev/v1alpha1/clusterworkspaces",
+		},
+		"too short": {
+			path: "/services/syncer",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotShardURL string
+			var proxied, fellBack bool
+			proxy := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				proxied = true
+				gotShardURL = ShardURLFrom(req.Context()).String()
+			})
+			fallback := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fellBack = true
+			})
+
+			virtualWorkspaceHandler(index, proxy, fallback).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			require.Equal(t, tc.wantShardURL != "", proxied, "unexpected proxying to shard")
+			require.Equal(t, tc.wantShardURL == "", fellBack, "unexpected fallback")
+			require.Equal(t, tc.wantShardURL, gotShardURL)
+		})
+	}
+}
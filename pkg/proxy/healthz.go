@@ -0,0 +1,164 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/server/healthz"
+	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/klog/v2"
+)
+
+// shardReachableTimeout is the maximal time to wait for a shard to answer a readiness check.
+const shardReachableTimeout = 5 * time.Second
+
+// readyzChecks returns the checks served under /readyz. The proxy is ready when a mapping
+// is loaded, the shard index is synced, at least one shard answers, and no certificate is expired.
+func (h *Handler) readyzChecks() []healthz.HealthChecker {
+	return []healthz.HealthChecker{
+		healthz.PingHealthz,
+		healthz.NamedCheck("mapping", h.checkMapping),
+		healthz.NamedCheck("shard-index", h.checkShardIndex),
+		healthz.NamedCheck("shards", h.checkShards),
+		healthz.NamedCheck("certificates", h.checkCertificates),
+	}
+}
+
+// livezChecks returns the checks served under /livez. Neither shards nor certificates affect
+// liveness, as restarting the proxy does not fix them.
+func (h *Handler) livezChecks() []healthz.HealthChecker {
+	return []healthz.HealthChecker{
+		healthz.PingHealthz,
+	}
+}
+
+func (h *Handler) checkMapping(_ *http.Request) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if len(h.mapping) == 0 {
+		return fmt.Errorf("no path mapping loaded yet")
+	}
+	return nil
+}
+
+func (h *Handler) checkShardIndex(_ *http.Request) error {
+	if !h.index.HasSynced() {
+		return fmt.Errorf("shard index not synced yet")
+	}
+	return nil
+}
+
+// checkShards checks that the shards known to the index answer HTTP requests. Any response
+// counts, such that the check does not depend on the health of the shard. The proxy is degraded,
+// but still serves the other shards, if some shards are unreachable, hence the check only fails
+// if all shards are.
+func (h *Handler) checkShards(req *http.Request) error {
+	h.lock.Lock()
+	transport := h.shardTransport
+	h.lock.Unlock()
+
+	if transport == nil {
+		// nothing is routed to shards
+		return nil
+	}
+
+	shardBaseURLs := h.index.ShardBaseURLs()
+	names := make([]string, 0, len(shardBaseURLs))
+	for name := range shardBaseURLs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(req.Context(), shardReachableTimeout)
+	defer cancel()
+	client := &http.Client{Transport: transport}
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			baseURL := shardBaseURLs[name]
+			shardReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/livez", nil)
+			if err != nil {
+				errs[i] = fmt.Errorf("shard %q at %s: %w", name, baseURL, err)
+				return
+			}
+			resp, err := client.Do(shardReq)
+			if err != nil {
+				errs[i] = fmt.Errorf("shard %q at %s is not reachable: %w", name, baseURL, err)
+				return
+			}
+			resp.Body.Close() // nolint: errcheck
+		}(i, name)
+	}
+	wg.Wait()
+
+	err := utilerrors.NewAggregate(errs)
+	if err == nil {
+		return nil
+	}
+	if len(err.Errors()) < len(names) {
+		klog.Warningf("Some shards are not reachable: %v", err)
+		return nil
+	}
+	return err
+}
+
+// checkCertificates checks that the backend CAs and proxy client certificates of the
+// current mapping are valid now.
+func (h *Handler) checkCertificates(_ *http.Request) error {
+	h.lock.Lock()
+	mapping := h.mapping
+	h.lock.Unlock()
+
+	files := sets.NewString()
+	for _, m := range mapping {
+		files.Insert(m.BackendServerCA, m.ProxyClientCert)
+	}
+
+	now := h.now()
+	var errs []error
+	for _, file := range files.List() {
+		certs, err := certutil.CertsFromFile(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, cert := range certs {
+			if now.After(cert.NotAfter) {
+				errs = append(errs, fmt.Errorf("certificate %q in %q expired at %s", cert.Subject.CommonName, file, cert.NotAfter.Format(time.RFC3339)))
+			} else if now.Before(cert.NotBefore) {
+				errs = append(errs, fmt.Errorf("certificate %q in %q is not valid before %s", cert.Subject.CommonName, file, cert.NotBefore.Format(time.RFC3339)))
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func TestHealthChecks(t *testing.T) {
+	dir := t.TempDir()
+	cert, key, err := certutil.GenerateSelfSignedCertKey("localhost", nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), cert, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client.pem"), cert, 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client-key.pem"), key, 0600))
+	mapping := []PathMapping{{
+		Path:            "/clusters/",
+		Backend:         "https://localhost:6443",
+		BackendServerCA: filepath.Join(dir, "ca.pem"),
+		ProxyClientCert: filepath.Join(dir, "client.pem"),
+		ProxyClientKey:  filepath.Join(dir, "client-key.pem"),
+	}}
+
+	shard := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer shard.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := map[string]struct {
+		mapping        []PathMapping
+		shardTransport http.RoundTripper
+		index          fakeIndex
+		now            time.Time
+
+		wantReady  bool
+		wantLive   bool
+		wantOutput []string
+	}{
+		"ready": {
+			mapping:        mapping,
+			shardTransport: shard.Client().Transport,
+			index:          fakeIndex{synced: true, shardBaseURLs: map[string]string{"root": shard.URL}},
+			wantReady:      true,
+			wantLive:       true,
+			wantOutput:     []string{"[+]ping ok", "[+]mapping ok", "[+]shard-index ok", "[+]shards ok", "[+]certificates ok", "readyz check passed"},
+		},
+		"no mapping loaded": {
+			index:      fakeIndex{synced: true},
+			wantLive:   true,
+			wantOutput: []string{"[-]mapping failed: reason withheld", "[+]shards ok"},
+		},
+		"index not synced": {
+			mapping:        mapping,
+			shardTransport: shard.Client().Transport,
+			index:          fakeIndex{shardBaseURLs: map[string]string{"root": shard.URL}},
+			wantLive:       true,
+			wantOutput:     []string{"[-]shard-index failed: reason withheld", "[+]shards ok"},
+		},
+		"some shards not reachable": {
+			mapping:        mapping,
+			shardTransport: shard.Client().Transport,
+			index:          fakeIndex{synced: true, shardBaseURLs: map[string]string{"root": shard.URL, "one": unreachable.URL}},
+			wantReady:      true,
+			wantLive:       true,
+			wantOutput:     []string{"[+]shard-index ok", "[+]shards ok"},
+		},
+		"no shard reachable": {
+			mapping:        mapping,
+			shardTransport: shard.Client().Transport,
+			index:          fakeIndex{synced: true, shardBaseURLs: map[string]string{"one": unreachable.URL}},
+			wantLive:       true,
+			wantOutput:     []string{"[+]shard-index ok", "[-]shards failed: reason withheld"},
+		},
+		"certificate expired": {
+			mapping:        mapping,
+			shardTransport: shard.Client().Transport,
+			index:          fakeIndex{synced: true},
+			now:            time.Now().AddDate(2, 0, 0),
+			wantLive:       true,
+			wantOutput:     []string{"[-]certificates failed: reason withheld"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			h := &Handler{
+				index:          tc.index,
+				now:            time.Now,
+				mapping:        tc.mapping,
+				shardTransport: tc.shardTransport,
+			}
+			if !tc.now.IsZero() {
+				h.now = func() time.Time { return tc.now }
+			}
+			mux := h.newServeMux()
+
+			readyz := httptest.NewRecorder()
+			mux.ServeHTTP(readyz, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+			require.Equal(t, tc.wantReady, readyz.Code == http.StatusOK, "unexpected readyz status: %s", readyz.Body.String())
+			for _, output := range tc.wantOutput {
+				require.Contains(t, readyz.Body.String(), output)
+			}
+
+			livez := httptest.NewRecorder()
+			mux.ServeHTTP(livez, httptest.NewRequest(http.MethodGet, "/livez", nil))
+			require.Equal(t, tc.wantLive, livez.Code == http.StatusOK, "unexpected livez status: %s", livez.Body.String())
+		})
+	}
+}
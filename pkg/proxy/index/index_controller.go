@@ -25,6 +25,7 @@ import (
 	"github.com/kcp-dev/logicalcluster/v2"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
@@ -45,7 +46,14 @@ const (
 
 // Index implements a mapping from logical cluster to (shard) URL.
 type Index interface {
+	// Lookup returns the base URL of the shard owning the logical cluster.
 	Lookup(logicalCluster logicalcluster.Name) (string, bool)
+	// LookupVirtualWorkspace returns the virtual workspace URL of the shard owning the logical cluster.
+	LookupVirtualWorkspace(logicalCluster logicalcluster.Name) (string, bool)
+	// ShardBaseURLs returns the base URLs of all known shards by shard name.
+	ShardBaseURLs() map[string]string
+	// HasSynced returns whether the shards and the workspaces on every shard have been synced.
+	HasSynced() bool
 }
 
 type ClusterWorkspaceClientGetter func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (kcpclient.Interface, error)
@@ -64,13 +72,15 @@ func NewController(
 		clientGetter: clientGetter,
 
 		clusterWorkspaceShardIndexer: clusterWorkspaceShardInformer.Informer().GetIndexer(),
+		clusterWorkspaceShardsSynced: clusterWorkspaceShardInformer.Informer().HasSynced,
 		clusterWorkspaceShardLister:  clusterWorkspaceShardInformer.Lister(),
 
 		shardClusterWorkspaceInformers: map[string]cache.SharedIndexInformer{},
 		shardClusterWorkspaceStopCh:    map[string]chan struct{}{},
 
-		workspaceShardNames: map[logicalcluster.Name]string{},
-		shardBaseURLs:       map[string]string{},
+		workspaceShardNames:       map[logicalcluster.Name]string{},
+		shardBaseURLs:             map[string]string{},
+		shardVirtualWorkspaceURLs: map[string]string{},
 	}
 
 	c.clusterWorkspaceHandler = cache.ResourceEventHandlerFuncs{
@@ -116,12 +126,14 @@ func NewController(
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
 			c.lock.RLock()
 			got := c.shardBaseURLs[shard.Name]
+			gotVirtualWorkspace := c.shardVirtualWorkspaceURLs[shard.Name]
 			c.lock.RUnlock()
 
-			if expected := shard.Spec.BaseURL; got != expected {
+			if expected, expectedVirtualWorkspace := shard.Spec.BaseURL, virtualWorkspaceURL(shard); got != expected || gotVirtualWorkspace != expectedVirtualWorkspace {
 				c.lock.Lock()
 				defer c.lock.Unlock()
 				c.shardBaseURLs[shard.Name] = expected
+				c.shardVirtualWorkspaceURLs[shard.Name] = expectedVirtualWorkspace
 			}
 
 			c.enqueueShard(shard)
@@ -130,12 +142,14 @@ func NewController(
 			shard := obj.(*tenancyv1alpha1.ClusterWorkspaceShard)
 			c.lock.RLock()
 			got := c.shardBaseURLs[shard.Name]
+			gotVirtualWorkspace := c.shardVirtualWorkspaceURLs[shard.Name]
 			c.lock.RUnlock()
 
-			if expected := shard.Spec.BaseURL; got != expected {
+			if expected, expectedVirtualWorkspace := shard.Spec.BaseURL, virtualWorkspaceURL(shard); got != expected || gotVirtualWorkspace != expectedVirtualWorkspace {
 				c.lock.Lock()
 				defer c.lock.Unlock()
 				c.shardBaseURLs[shard.Name] = expected
+				c.shardVirtualWorkspaceURLs[shard.Name] = expectedVirtualWorkspace
 			}
 
 			// don't updates. Not of interest.
@@ -149,6 +163,7 @@ func NewController(
 			c.lock.Lock()
 			defer c.lock.Unlock()
 			delete(c.shardBaseURLs, shard.Name)
+			delete(c.shardVirtualWorkspaceURLs, shard.Name)
 
 			c.enqueueShard(shard)
 		},
@@ -167,6 +182,7 @@ type Controller struct {
 	clientGetter ClusterWorkspaceClientGetter
 
 	clusterWorkspaceShardIndexer cache.Indexer
+	clusterWorkspaceShardsSynced cache.InformerSynced
 	clusterWorkspaceShardLister  tenancylisters.ClusterWorkspaceShardLister
 
 	clusterWorkspaceHandler cache.ResourceEventHandler
@@ -175,9 +191,10 @@ type Controller struct {
 	shardClusterWorkspaceInformers map[string]cache.SharedIndexInformer
 	shardClusterWorkspaceStopCh    map[string]chan struct{}
 
-	lock                sync.RWMutex
-	workspaceShardNames map[logicalcluster.Name]string
-	shardBaseURLs       map[string]string
+	lock                      sync.RWMutex
+	workspaceShardNames       map[logicalcluster.Name]string
+	shardBaseURLs             map[string]string
+	shardVirtualWorkspaceURLs map[string]string
 }
 
 // Start the controller. It does not really do anything, but to keep the shape of a normal
@@ -293,3 +310,60 @@ func (c *Controller) Lookup(logicalCluster logicalcluster.Name) (string, bool) {
 	url, found := c.shardBaseURLs[shardName]
 	return url, found
 }
+
+func (c *Controller) LookupVirtualWorkspace(logicalCluster logicalcluster.Name) (string, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	shardName := tenancyv1alpha1.RootShard
+	if logicalCluster != tenancyv1alpha1.RootCluster {
+		var found bool
+		shardName, found = c.workspaceShardNames[logicalCluster]
+		if !found {
+			return "", false
+		}
+	}
+	url, found := c.shardVirtualWorkspaceURLs[shardName]
+	return url, found
+}
+
+func (c *Controller) ShardBaseURLs() map[string]string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	urls := make(map[string]string, len(c.shardBaseURLs))
+	for name, url := range c.shardBaseURLs {
+		urls[name] = url
+	}
+	return urls
+}
+
+func (c *Controller) HasSynced() bool {
+	if !c.clusterWorkspaceShardsSynced() {
+		return false
+	}
+
+	shards, err := c.clusterWorkspaceShardLister.List(labels.Everything())
+	if err != nil {
+		return false
+	}
+
+	c.shardInformersLock.RLock()
+	defer c.shardInformersLock.RUnlock()
+
+	for _, shard := range shards {
+		informer, found := c.shardClusterWorkspaceInformers[shard.Name]
+		if !found || !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// virtualWorkspaceURL returns the virtual workspace URL of the shard, defaulting to its base URL.
+func virtualWorkspaceURL(shard *tenancyv1alpha1.ClusterWorkspaceShard) string {
+	if shard.Spec.VirtualWorkspaceURL != "" {
+		return shard.Spec.VirtualWorkspaceURL
+	}
+	return shard.Spec.BaseURL
+}
@@ -17,12 +17,23 @@ limitations under the License.
 package proxy
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 
@@ -43,51 +54,212 @@ type PathMapping struct {
 	GroupHeader     string `json:"group_header,omitempty"`
 }
 
-func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error) {
-	mappingData, err := ioutil.ReadFile(o.MappingFile)
+// Handler routes requests to backends according to a list of PathMappings. The mappings
+// can be replaced at runtime. A new mapping is validated and its transports are built
+// before it is swapped in atomically, so an invalid mapping keeps the current routes.
+type Handler struct {
+	index index.Index
+
+	now func() time.Time
+
+	// lock serializes updates. Requests are served lock-free from mux.
+	lock           sync.Mutex
+	fingerprint    [sha256.Size]byte
+	mapping        []PathMapping
+	shardTransport http.RoundTripper
+	mux            atomic.Value // *http.ServeMux
+}
+
+// NewHandler returns a handler serving the mapping file from the options. Without a mapping
+// file, only the health endpoints are served until the first mapping is applied.
+func NewHandler(o *proxyoptions.Options, index index.Index) (*Handler, error) {
+	h := &Handler{index: index, now: time.Now}
+	h.mux.Store(h.newServeMux())
+
+	if o.MappingFile == "" {
+		return h, nil
+	}
+
+	data, err := ioutil.ReadFile(o.MappingFile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read mapping file %q: %w", o.MappingFile, err)
 	}
+	if _, err := h.UpdateFromData(data); err != nil {
+		return nil, fmt.Errorf("failed to load mapping file %q: %w", o.MappingFile, err)
+	}
+
+	return h, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.Load().(*http.ServeMux).ServeHTTP(w, r)
+}
+
+// UpdateFromData parses and validates the given mapping, and replaces the current routes
+// with it. The routes are only rebuilt if the mapping or any of the certificates it
+// references changed. It returns whether the routes were replaced.
+func (h *Handler) UpdateFromData(data []byte) (bool, error) {
+	mapping, err := ParseMapping(data)
+	if err != nil {
+		return false, err
+	}
 
+	fingerprint, err := fingerprintMapping(data, mapping)
+	if err != nil {
+		return false, err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if fingerprint == h.fingerprint {
+		return false, nil
+	}
+
+	mux, shardTransport, err := h.newMappingServeMux(mapping)
+	if err != nil {
+		return false, err
+	}
+
+	h.mux.Store(mux)
+	h.fingerprint = fingerprint
+	h.mapping = mapping
+	h.shardTransport = shardTransport
+
+	return true, nil
+}
+
+// WatchMappingFile checks the mapping file and the certificates it references for changes
+// in the given interval, and replaces the routes when they changed. Failures are logged,
+// and the current routes are kept. It blocks until the context is done.
+func (h *Handler) WatchMappingFile(ctx context.Context, path string, interval time.Duration) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to read mapping file %q, keeping current routes: %w", path, err))
+			return
+		}
+		updated, err := h.UpdateFromData(data)
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to reload mapping file %q, keeping current routes: %w", path, err))
+			return
+		}
+		if updated {
+			klog.Infof("Reloaded mapping file %q", path)
+		}
+	}, interval)
+}
+
+// ParseMapping parses and validates a list of PathMappings in YAML or JSON.
+func ParseMapping(data []byte) ([]PathMapping, error) {
 	var mapping []PathMapping
-	if err = yaml.Unmarshal(mappingData, &mapping); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal mapping file %q: %w", o.MappingFile, err)
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mapping: %w", err)
+	}
+	if err := ValidateMapping(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// ValidateMapping checks that the mapping is not empty, that every path is absolute and
+// unique, and that every backend is an absolute URL with certificates to talk to it.
+func ValidateMapping(mapping []PathMapping) error {
+	if len(mapping) == 0 {
+		return fmt.Errorf("mapping must contain at least one path")
 	}
 
+	var errs []error
+	paths := sets.NewString()
+	for i, m := range mapping {
+		if !strings.HasPrefix(m.Path, "/") {
+			errs = append(errs, fmt.Errorf("mapping[%d]: path %q must start with \"/\"", i, m.Path))
+		} else if paths.Has(m.Path) {
+			errs = append(errs, fmt.Errorf("mapping[%d]: duplicate path %q", i, m.Path))
+		}
+		paths.Insert(m.Path)
+
+		if u, err := url.Parse(m.Backend); err != nil {
+			errs = append(errs, fmt.Errorf("mapping[%d]: failed to parse backend URL %q: %w", i, m.Backend, err))
+		} else if u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("mapping[%d]: backend URL %q must have a scheme and a host", i, m.Backend))
+		}
+
+		if m.BackendServerCA == "" {
+			errs = append(errs, fmt.Errorf("mapping[%d]: backend_server_ca is required", i))
+		}
+		if m.ProxyClientCert == "" {
+			errs = append(errs, fmt.Errorf("mapping[%d]: proxy_client_cert is required", i))
+		}
+		if m.ProxyClientKey == "" {
+			errs = append(errs, fmt.Errorf("mapping[%d]: proxy_client_key is required", i))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// fingerprintMapping hashes the raw mapping together with all the certificate files it
+// references, such that rotating a certificate is detected as a change.
+func fingerprintMapping(data []byte, mapping []PathMapping) ([sha256.Size]byte, error) {
+	hash := sha256.New()
+	hash.Write(data) // nolint: errcheck
+	for _, m := range mapping {
+		for _, file := range []string{m.BackendServerCA, m.ProxyClientCert, m.ProxyClientKey} {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return [sha256.Size]byte{}, fmt.Errorf("failed to read %q for path %q: %w", file, m.Path, err)
+			}
+			hash.Write(content) // nolint: errcheck
+		}
+	}
+
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], hash.Sum(nil))
+	return fingerprint, nil
+}
+
+func (h *Handler) newServeMux() *http.ServeMux {
 	mux := http.NewServeMux()
 
-	// TODO: implement proper readyz handler
-	mux.Handle("/readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("OK")) // nolint: errcheck
-		w.WriteHeader(http.StatusOK)
-	}))
+	healthz.InstallReadyzHandler(mux, h.readyzChecks()...)
+	healthz.InstallLivezHandler(mux, h.livezChecks()...)
 
-	// TODO: implement proper livez handler
-	mux.Handle("/livez", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("OK")) // nolint: errcheck
-		w.WriteHeader(http.StatusOK)
-	}))
+	return mux
+}
+
+// newMappingServeMux returns a mux serving the given mapping, and the transport to talk to shards.
+func (h *Handler) newMappingServeMux(mapping []PathMapping) (*http.ServeMux, http.RoundTripper, error) {
+	mux := h.newServeMux()
+	var shardTransport http.RoundTripper
 
 	for _, m := range mapping {
 		klog.V(2).Infof("Adding mapping %v", m)
 
 		u, err := url.Parse(m.Backend)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
+			return nil, nil, fmt.Errorf("failed to create path mapping for path %q: failed to parse URL %q: %w", m.Path, m.Backend, err)
 		}
 
 		transport, err := newTransport(m.ProxyClientCert, m.ProxyClientKey, m.BackendServerCA)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create path mapping for path %q: %w", m.Path, err)
+			return nil, nil, fmt.Errorf("failed to create path mapping for path %q: %w", m.Path, err)
 		}
 
 		var handler http.HandlerFunc
-		if m.Path == "/clusters/" {
+		switch {
+		case m.Path == "/clusters/":
 			clusterProxy := newShardReverseProxy()
 			clusterProxy.Transport = transport
-			handler = shardHandler(index, clusterProxy)
-		} else {
-			// TODO: handle virtual workspace apiservers per shard
+			handler = shardHandler(h.index, clusterProxy)
+			shardTransport = transport
+		case strings.HasPrefix(m.Path, "/services/"):
+			virtualWorkspaceProxy := newShardReverseProxy()
+			virtualWorkspaceProxy.Transport = transport
+			proxy := httputil.NewSingleHostReverseProxy(u)
+			proxy.Transport = transport
+			handler = virtualWorkspaceHandler(h.index, virtualWorkspaceProxy, proxy)
+		default:
 			proxy := httputil.NewSingleHostReverseProxy(u)
 			proxy.Transport = transport
 			handler = proxy.ServeHTTP
@@ -107,5 +279,5 @@ func NewHandler(o *proxyoptions.Options, index index.Index) (http.Handler, error
 		mux.Handle(m.Path, handler)
 	}
 
-	return mux, nil
+	return mux, shardTransport, nil
 }
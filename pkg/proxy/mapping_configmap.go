@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// MappingConfigMapKey is the key of a ConfigMap holding the list of PathMappings.
+const MappingConfigMapKey = "mapping.yaml"
+
+// WatchMappingConfigMap replaces the routes whenever the given ConfigMap changes. The
+// informer resync period determines how often referenced certificates are checked for
+// rotation. Invalid mappings are logged, and the current routes are kept. Deleting the
+// ConfigMap keeps the current routes as well.
+func (h *Handler) WatchMappingConfigMap(informer coreinformers.ConfigMapInformer, namespace, name string) {
+	update := func(obj interface{}) {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return
+		}
+
+		data, found := cm.Data[MappingConfigMapKey]
+		if !found {
+			utilruntime.HandleError(fmt.Errorf("ConfigMap %s/%s has no %q key, keeping current routes", namespace, name, MappingConfigMapKey))
+			return
+		}
+		updated, err := h.UpdateFromData([]byte(data))
+		if err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to load mapping from ConfigMap %s/%s, keeping current routes: %w", namespace, name, err))
+			return
+		}
+		if updated {
+			klog.Infof("Reloaded mapping from ConfigMap %s/%s", namespace, name)
+		}
+	}
+
+	informer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			cm, ok := obj.(*corev1.ConfigMap)
+			return ok && cm.Namespace == namespace && cm.Name == name
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    update,
+			UpdateFunc: func(_, obj interface{}) { update(obj) },
+			DeleteFunc: func(obj interface{}) {
+				klog.Warningf("ConfigMap %s/%s was deleted, keeping current routes", namespace, name)
+			},
+		},
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	certutil "k8s.io/client-go/util/cert"
+
+	proxyoptions "github.com/kcp-dev/kcp/pkg/proxy/options"
+)
+
+func TestValidateMapping(t *testing.T) {
+	valid := PathMapping{
+		Path:            "/services/",
+		Backend:         "https://localhost:6444",
+		BackendServerCA: "ca.pem",
+		ProxyClientCert: "client.pem",
+		ProxyClientKey:  "client-key.pem",
+	}
+	withChange := func(change func(m *PathMapping)) PathMapping {
+		m := valid
+		change(&m)
+		return m
+	}
+
+	tests := map[string]struct {
+		mapping []PathMapping
+		wantErr bool
+	}{
+		"valid": {
+			mapping: []PathMapping{valid, withChange(func(m *PathMapping) { m.Path = "/" })},
+		},
+		"empty": {
+			wantErr: true,
+		},
+		"relative path": {
+			mapping: []PathMapping{withChange(func(m *PathMapping) { m.Path = "services/" })},
+			wantErr: true,
+		},
+		"duplicate path": {
+			mapping: []PathMapping{valid, valid},
+			wantErr: true,
+		},
+		"backend without host": {
+			mapping: []PathMapping{withChange(func(m *PathMapping) { m.Backend = "localhost:6444" })},
+			wantErr: true,
+		},
+		"missing client certificate": {
+			mapping: []PathMapping{withChange(func(m *PathMapping) { m.ProxyClientCert = "" })},
+			wantErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateMapping(tc.mapping)
+			if tc.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHandlerReload(t *testing.T) {
+	dir := t.TempDir()
+	writeCerts := func(host string) {
+		cert, key, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), cert, 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client.pem"), cert, 0600))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "client-key.pem"), key, 0600))
+	}
+	mappingFor := func(backend string) []byte {
+		return []byte(fmt.Sprintf(`
+- path: /services/
+  backend: %s
+  backend_server_ca: %s
+  proxy_client_cert: %s
+  proxy_client_key: %s
+`, backend, filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")))
+	}
+	mappingFile := filepath.Join(dir, "mapping.yaml")
+
+	writeCerts("localhost")
+	require.NoError(t, ioutil.WriteFile(mappingFile, mappingFor("https://localhost:6444"), 0600))
+
+	h, err := NewHandler(&proxyoptions.Options{MappingFile: mappingFile}, nil)
+	require.NoError(t, err)
+	initialMux := h.mux.Load()
+
+	updated, err := h.UpdateFromData(mappingFor("https://localhost:6444"))
+	require.NoError(t, err)
+	require.False(t, updated, "expected unchanged mapping not to replace the routes")
+
+	updated, err = h.UpdateFromData(mappingFor("localhost"))
+	require.Error(t, err)
+	require.False(t, updated)
+	require.Same(t, initialMux, h.mux.Load(), "expected invalid mapping to keep the current routes")
+
+	updated, err = h.UpdateFromData([]byte("- path: [invalid"))
+	require.Error(t, err)
+	require.False(t, updated)
+	require.Same(t, initialMux, h.mux.Load(), "expected unparsable mapping to keep the current routes")
+
+	writeCerts("other")
+	updated, err = h.UpdateFromData(mappingFor("https://localhost:6444"))
+	require.NoError(t, err)
+	require.True(t, updated, "expected rotated certificates to replace the routes")
+	require.NotSame(t, initialMux, h.mux.Load())
+
+	updated, err = h.UpdateFromData(mappingFor("https://localhost:6445"))
+	require.NoError(t, err)
+	require.True(t, updated, "expected changed backend to replace the routes")
+}
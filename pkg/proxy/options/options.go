@@ -18,21 +18,29 @@ package options
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 )
 
 type Options struct {
-	MappingFile string
+	MappingFile           string
+	MappingReloadInterval time.Duration
+	MappingConfigMap      string
 }
 
 func NewOptions() *Options {
-	o := &Options{}
+	o := &Options{
+		MappingReloadInterval: 10 * time.Second,
+	}
 	return o
 }
 
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
-	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends")
+	fs.StringVar(&o.MappingFile, "mapping-file", o.MappingFile, "Config file mapping paths to backends. The file and the certificates it references are watched and reloaded on change.")
+	fs.DurationVar(&o.MappingReloadInterval, "mapping-reload-interval", o.MappingReloadInterval, "Interval to check the mapping file and the certificates it references for changes.")
+	fs.StringVar(&o.MappingConfigMap, "mapping-configmap", o.MappingConfigMap, "ConfigMap in the root workspace mapping paths to backends, in the form <namespace>/<name>. The mapping is read from the \"mapping.yaml\" key. Mutually exclusive with --mapping-file.")
 }
 
 func (o *Options) Complete() error {
@@ -41,10 +49,28 @@ func (o *Options) Complete() error {
 
 func (o *Options) Validate() []error {
 	var errs []error
-
-	if o.MappingFile == "" {
-		errs = append(errs, fmt.Errorf("--mapping-file is required"))
+	if o.MappingFile == "" && o.MappingConfigMap == "" {
+		errs = append(errs, fmt.Errorf("either --mapping-file or --mapping-configmap is required"))
+	}
+	if o.MappingFile != "" && o.MappingConfigMap != "" {
+		errs = append(errs, fmt.Errorf("--mapping-file and --mapping-configmap are mutually exclusive"))
+	}
+	if o.MappingConfigMap != "" {
+		if _, _, err := o.MappingConfigMapNamespaceName(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if o.MappingReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("--mapping-reload-interval must be positive"))
 	}
-
 	return errs
 }
+
+// MappingConfigMapNamespaceName returns the namespace and name of the mapping ConfigMap.
+func (o *Options) MappingConfigMapNamespaceName() (string, string, error) {
+	parts := strings.Split(o.MappingConfigMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("--mapping-configmap must be of the form <namespace>/<name>, got %q", o.MappingConfigMap)
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maximalpermissionpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	ControllerName = "kcp-apiexport-maximal-permission-policy"
+
+	byClusterRoleRef = "maximalPermissionPolicy-byClusterRoleRef"
+
+	clusterRolesKeyPrefix        = "clusterroles::"
+	clusterRoleBindingsKeyPrefix = "clusterrolebindings::"
+)
+
+// NewController returns a new controller which annotates the ClusterRoleBindings granting permissions
+// to the users and groups of APIExport maximal permission policies, and the ClusterRoles they refer to,
+// with apisv1alpha1.AnnotationMaximalPermissionPolicyKey. The annotation limits the RBAC objects
+// replicated into the cache server to those needed to evaluate maximal permission policies on other shards.
+func NewController(
+	kubeClusterClient kubernetesclient.Interface,
+	clusterRoleInformer rbacinformers.ClusterRoleInformer,
+	clusterRoleBindingInformer rbacinformers.ClusterRoleBindingInformer,
+) (*controller, error) {
+	c := &controller{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		getClusterRole: func(clusterName logicalcluster.Name, name string) (*rbacv1.ClusterRole, error) {
+			return clusterRoleInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		getClusterRoleBinding: func(clusterName logicalcluster.Name, name string) (*rbacv1.ClusterRoleBinding, error) {
+			return clusterRoleBindingInformer.Lister().Get(clusters.ToClusterAwareKey(clusterName, name))
+		},
+		listClusterRoleBindingsForRole: func(clusterName logicalcluster.Name, roleName string) ([]*rbacv1.ClusterRoleBinding, error) {
+			objs, err := clusterRoleBindingInformer.Informer().GetIndexer().ByIndex(byClusterRoleRef, clusters.ToClusterAwareKey(clusterName, roleName))
+			if err != nil {
+				return nil, err
+			}
+			bindings := make([]*rbacv1.ClusterRoleBinding, 0, len(objs))
+			for _, obj := range objs {
+				bindings = append(bindings, obj.(*rbacv1.ClusterRoleBinding))
+			}
+			return bindings, nil
+		},
+		patchClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+			_, err := kubeClusterClient.RbacV1().ClusterRoles().Patch(logicalcluster.WithCluster(ctx, clusterName), name, types.MergePatchType, patch, metav1.PatchOptions{})
+			return err
+		},
+		patchClusterRoleBinding: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+			_, err := kubeClusterClient.RbacV1().ClusterRoleBindings().Patch(logicalcluster.WithCluster(ctx, clusterName), name, types.MergePatchType, patch, metav1.PatchOptions{})
+			return err
+		},
+	}
+
+	indexers.AddIfNotPresentOrDie(
+		clusterRoleBindingInformer.Informer().GetIndexer(),
+		cache.Indexers{
+			byClusterRoleRef: indexByClusterRoleRef,
+		},
+	)
+
+	clusterRoleInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(clusterRolesKeyPrefix, obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(clusterRolesKeyPrefix, obj) },
+	})
+
+	clusterRoleBindingInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueue(clusterRoleBindingsKeyPrefix, obj)
+			c.enqueueClusterRoleRef(obj)
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			c.enqueue(clusterRoleBindingsKeyPrefix, obj)
+			c.enqueueClusterRoleRef(oldObj)
+			c.enqueueClusterRoleRef(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueClusterRoleRef(obj)
+		},
+	})
+
+	return c, nil
+}
+
+func indexByClusterRoleRef(obj interface{}) ([]string, error) {
+	binding, ok := obj.(*rbacv1.ClusterRoleBinding)
+	if !ok {
+		return nil, fmt.Errorf("obj is supposed to be a ClusterRoleBinding, but is %T", obj)
+	}
+	if binding.RoleRef.APIGroup != rbacv1.GroupName || binding.RoleRef.Kind != "ClusterRole" {
+		return nil, nil
+	}
+	return []string{clusters.ToClusterAwareKey(logicalcluster.From(binding), binding.RoleRef.Name)}, nil
+}
+
+// controller annotates maximal permission policy RBAC objects, such that they are replicated
+// into the cache server.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	getClusterRole                 func(clusterName logicalcluster.Name, name string) (*rbacv1.ClusterRole, error)
+	getClusterRoleBinding          func(clusterName logicalcluster.Name, name string) (*rbacv1.ClusterRoleBinding, error)
+	listClusterRoleBindingsForRole func(clusterName logicalcluster.Name, roleName string) ([]*rbacv1.ClusterRoleBinding, error)
+	patchClusterRole               func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error
+	patchClusterRoleBinding        func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error
+}
+
+func (c *controller) enqueue(prefix string, obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), prefix+key)
+	logger.V(4).Info("queueing object")
+	c.queue.Add(prefix + key)
+}
+
+// enqueueClusterRoleRef enqueues the ClusterRole the given ClusterRoleBinding refers to.
+func (c *controller) enqueueClusterRoleRef(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	keys, err := indexByClusterRoleRef(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, key := range keys {
+		logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), ControllerName), clusterRolesKeyPrefix+key)
+		logger.V(4).Info("queueing ClusterRole referenced by ClusterRoleBinding")
+		c.queue.Add(clusterRolesKeyPrefix + key)
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("starting controller")
+	defer logger.Info("shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		utilruntime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+
+	var prefix string
+	switch {
+	case strings.HasPrefix(key, clusterRolesKeyPrefix):
+		prefix = clusterRolesKeyPrefix
+	case strings.HasPrefix(key, clusterRoleBindingsKeyPrefix):
+		prefix = clusterRoleBindingsKeyPrefix
+	default:
+		logger.Error(errors.New("unexpected key format"), "skipping key")
+		return nil
+	}
+
+	_, clusterAwareName, err := cache.SplitMetaNamespaceKey(strings.TrimPrefix(key, prefix))
+	if err != nil {
+		logger.Error(err, "skipping key")
+		return nil
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	if prefix == clusterRolesKeyPrefix {
+		role, err := c.getClusterRole(clusterName, name)
+		if apierrors.IsNotFound(err) {
+			return nil // nothing to annotate
+		} else if err != nil {
+			return err
+		}
+		return c.reconcileClusterRole(klog.NewContext(ctx, logging.WithObject(logger, role)), role)
+	}
+
+	binding, err := c.getClusterRoleBinding(clusterName, name)
+	if apierrors.IsNotFound(err) {
+		return nil // nothing to annotate
+	} else if err != nil {
+		return err
+	}
+	return c.reconcileClusterRoleBinding(klog.NewContext(ctx, logging.WithObject(logger, binding)), binding)
+}
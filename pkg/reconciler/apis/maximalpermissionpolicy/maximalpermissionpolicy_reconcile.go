@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maximalpermissionpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func (c *controller) reconcileClusterRoleBinding(ctx context.Context, binding *rbacv1.ClusterRoleBinding) error {
+	return ensureAnnotation(ctx, binding, grantsMaximalPermissionPolicy(binding), c.patchClusterRoleBinding)
+}
+
+func (c *controller) reconcileClusterRole(ctx context.Context, role *rbacv1.ClusterRole) error {
+	clusterName := logicalcluster.From(role)
+	bindings, err := c.listClusterRoleBindingsForRole(clusterName, role.Name)
+	if err != nil {
+		return err
+	}
+
+	referenced := false
+	for _, binding := range bindings {
+		if grantsMaximalPermissionPolicy(binding) {
+			referenced = true
+			break
+		}
+	}
+
+	return ensureAnnotation(ctx, role, referenced, c.patchClusterRole)
+}
+
+// grantsMaximalPermissionPolicy returns whether the binding has a user or group subject of an
+// APIExport maximal permission policy.
+func grantsMaximalPermissionPolicy(binding *rbacv1.ClusterRoleBinding) bool {
+	for _, subject := range binding.Subjects {
+		if subject.Kind != rbacv1.UserKind && subject.Kind != rbacv1.GroupKind {
+			continue
+		}
+		if strings.HasPrefix(subject.Name, apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureAnnotation sets or removes the maximal permission policy annotation of the given object.
+func ensureAnnotation(ctx context.Context, obj metav1.Object, want bool, patchFn func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error) error {
+	_, found := obj.GetAnnotations()[apisv1alpha1.AnnotationMaximalPermissionPolicyKey]
+	if found == want {
+		return nil
+	}
+
+	var value interface{} // nil removes the annotation
+	if want {
+		value = "true"
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"resourceVersion": obj.GetResourceVersion(),
+			"annotations": map[string]interface{}{
+				apisv1alpha1.AnnotationMaximalPermissionPolicyKey: value,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.FromContext(ctx).V(2).Info("patching maximal permission policy annotation", "annotated", want)
+	return patchFn(ctx, logicalcluster.From(obj), obj.GetName(), patch)
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package maximalpermissionpolicy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+)
+
+func newClusterRoleBinding(name, roleName string, annotated bool, subjects ...rbacv1.Subject) *rbacv1.ClusterRoleBinding {
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: "1",
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: "root:org:policy"},
+		},
+		RoleRef:  rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: roleName},
+		Subjects: subjects,
+	}
+	if annotated {
+		binding.Annotations[apisv1alpha1.AnnotationMaximalPermissionPolicyKey] = "true"
+	}
+	return binding
+}
+
+func newClusterRole(name string, annotated bool) *rbacv1.ClusterRole {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: "1",
+			Annotations:     map[string]string{logicalcluster.AnnotationKey: "root:org:policy"},
+		},
+	}
+	if annotated {
+		role.Annotations[apisv1alpha1.AnnotationMaximalPermissionPolicyKey] = "true"
+	}
+	return role
+}
+
+var (
+	policyUser           = rbacv1.Subject{Kind: rbacv1.UserKind, Name: apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + "user-1"}
+	policyGroup          = rbacv1.Subject{Kind: rbacv1.GroupKind, Name: apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + "system:authenticated"}
+	regularUser          = rbacv1.Subject{Kind: rbacv1.UserKind, Name: "user-1"}
+	policyServiceAccount = rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: apisv1alpha1.MaximalPermissionPolicyRBACUserGroupPrefix + "sa", Namespace: "default"}
+)
+
+func TestReconcileClusterRoleBinding(t *testing.T) {
+	tests := map[string]struct {
+		binding   *rbacv1.ClusterRoleBinding
+		wantPatch string
+	}{
+		"policy user is annotated": {
+			binding:   newClusterRoleBinding("binding", "role", false, regularUser, policyUser),
+			wantPatch: `{"metadata":{"annotations":{"internal.apis.kcp.dev/maximal-permission-policy":"true"},"resourceVersion":"1"}}`,
+		},
+		"policy group is annotated": {
+			binding:   newClusterRoleBinding("binding", "role", false, policyGroup),
+			wantPatch: `{"metadata":{"annotations":{"internal.apis.kcp.dev/maximal-permission-policy":"true"},"resourceVersion":"1"}}`,
+		},
+		"already annotated": {
+			binding: newClusterRoleBinding("binding", "role", true, policyUser),
+		},
+		"regular user is not annotated": {
+			binding: newClusterRoleBinding("binding", "role", false, regularUser),
+		},
+		"prefixed service account is not annotated": {
+			binding: newClusterRoleBinding("binding", "role", false, policyServiceAccount),
+		},
+		"annotation is removed without policy subjects": {
+			binding:   newClusterRoleBinding("binding", "role", true, regularUser),
+			wantPatch: `{"metadata":{"annotations":{"internal.apis.kcp.dev/maximal-permission-policy":null},"resourceVersion":"1"}}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotPatch string
+			c := &controller{
+				patchClusterRoleBinding: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+					require.Equal(t, logicalcluster.New("root:org:policy"), clusterName)
+					require.Equal(t, "binding", name)
+					gotPatch = string(patch)
+					return nil
+				},
+			}
+			require.NoError(t, c.reconcileClusterRoleBinding(context.Background(), tt.binding))
+			require.Equal(t, tt.wantPatch, gotPatch)
+		})
+	}
+}
+
+func TestReconcileClusterRole(t *testing.T) {
+	tests := map[string]struct {
+		role      *rbacv1.ClusterRole
+		bindings  []*rbacv1.ClusterRoleBinding
+		wantPatch string
+	}{
+		"referenced by policy binding is annotated": {
+			role: newClusterRole("role", false),
+			bindings: []*rbacv1.ClusterRoleBinding{
+				newClusterRoleBinding("regular", "role", false, regularUser),
+				newClusterRoleBinding("policy", "role", true, policyUser),
+			},
+			wantPatch: `{"metadata":{"annotations":{"internal.apis.kcp.dev/maximal-permission-policy":"true"},"resourceVersion":"1"}}`,
+		},
+		"referenced by regular binding only is not annotated": {
+			role: newClusterRole("role", false),
+			bindings: []*rbacv1.ClusterRoleBinding{
+				newClusterRoleBinding("regular", "role", false, regularUser),
+			},
+		},
+		"no longer referenced is unannotated": {
+			role:      newClusterRole("role", true),
+			wantPatch: `{"metadata":{"annotations":{"internal.apis.kcp.dev/maximal-permission-policy":null},"resourceVersion":"1"}}`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotPatch string
+			c := &controller{
+				listClusterRoleBindingsForRole: func(clusterName logicalcluster.Name, roleName string) ([]*rbacv1.ClusterRoleBinding, error) {
+					require.Equal(t, logicalcluster.New("root:org:policy"), clusterName)
+					require.Equal(t, "role", roleName)
+					return tt.bindings, nil
+				},
+				patchClusterRole: func(ctx context.Context, clusterName logicalcluster.Name, name string, patch []byte) error {
+					require.Equal(t, "role", name)
+					gotPatch = string(patch)
+					return nil
+				},
+			}
+			require.NoError(t, c.reconcileClusterRole(context.Background(), tt.role))
+			require.Equal(t, tt.wantPatch, gotPatch)
+		})
+	}
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+	"github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	ControllerName = "kcp-cache-replication"
+
+	resyncPeriod = 10 * time.Hour
+)
+
+// NewController returns a new controller which replicates the given resources of all logical
+// clusters of this shard into the cache server, under /shards/<shardName>/clusters/<cluster>/...
+//
+// The cache client is expected to be created with cacheclient.NewDynamicClusterClientForConfig
+// with the shard name as default. The objects already replicated by this shard are watched through
+// it, such that after a restart of the shard or of the cache server only the differences are written,
+// and objects deleted in the meantime are removed from the cache server.
+//
+// RBAC objects are only replicated if they are annotated with apisv1alpha1.AnnotationMaximalPermissionPolicyKey.
+func NewController(
+	shardName string,
+	cacheClusterClient dynamic.ClusterInterface,
+	dynamicDiscoverySharedInformerFactory *informer.DynamicDiscoverySharedInformerFactory,
+	resources []schema.GroupResource,
+) (*controller, error) {
+	c := &controller{
+		queue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), ControllerName),
+		shardName:          clientshard.New(shardName),
+		cacheClusterClient: cacheClusterClient,
+		cacheInformers:     dynamicinformer.NewDynamicSharedInformerFactory(cacheClusterClient.Cluster(logicalcluster.Wildcard), resyncPeriod),
+		ddsif:              dynamicDiscoverySharedInformerFactory,
+		gvrs:               map[schema.GroupResource]schema.GroupVersionResource{},
+	}
+
+	logger := logging.WithReconciler(klog.Background(), ControllerName)
+
+	for _, gr := range resources {
+		gvr, err := cacheGVR(gr)
+		if err != nil {
+			return nil, err
+		}
+		c.gvrs[gr] = gvr
+
+		c.cacheInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(logger, gvr, obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(logger, gvr, obj) },
+			DeleteFunc: func(obj interface{}) { c.enqueue(logger, gvr, obj) },
+		})
+	}
+
+	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueue(logger, gvr, obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueue(logger, gvr, obj) },
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueue(logger, gvr, obj) },
+	})
+
+	return c, nil
+}
+
+// cacheGVR returns the version of the given resource which is stored in the cache server.
+func cacheGVR(gr schema.GroupResource) (schema.GroupVersionResource, error) {
+	crd, err := bootstrap.CRD(gr)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	for _, v := range crd.Spec.Versions {
+		if v.Storage {
+			return gr.WithVersion(v.Name), nil
+		}
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("no storage version found for %s", gr)
+}
+
+// controller replicates objects of the configured resources into the cache server, and deletes
+// them there when they are deleted on the shard.
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	shardName          clientshard.Name
+	cacheClusterClient dynamic.ClusterInterface
+	cacheInformers     dynamicinformer.DynamicSharedInformerFactory
+	ddsif              *informer.DynamicDiscoverySharedInformerFactory
+	gvrs               map[schema.GroupResource]schema.GroupVersionResource
+}
+
+func (c *controller) enqueue(logger logr.Logger, gvr schema.GroupVersionResource, obj interface{}) {
+	gvr, found := c.gvrs[gvr.GroupResource()]
+	if !found {
+		return
+	}
+
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	queueKey := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".") + "::" + key
+	logging.WithQueueKey(logger, queueKey).V(2).Info("queuing resource")
+	c.queue.Add(queueKey)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), ControllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("starting controller")
+	defer logger.Info("shutting down controller")
+
+	// the informers resume watching from their last resourceVersion when the connection to the cache server breaks.
+	c.cacheInformers.Start(ctx.Done())
+	for gvr, synced := range c.cacheInformers.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			logger.Error(nil, "failed to sync cache server informer", "gvr", gvr.String())
+			return
+		}
+	}
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		utilruntime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", ControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		logger.Error(errors.New("unexpected key format"), "skipping key")
+		return nil
+	}
+
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		logger.Error(errors.New("unable to parse gvr string"), "skipping key", "gvr", parts[0])
+		return nil
+	}
+	key = parts[1]
+
+	namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Error(err, "skipping key")
+		return nil
+	}
+	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	inf, err := c.ddsif.ForResource(*gvr)
+	if err != nil {
+		return fmt.Errorf("error getting dynamic informer for GVR %q: %w", gvr, err)
+	}
+	if !inf.Informer().HasSynced() {
+		// otherwise objects missing locally would be deleted from the cache server.
+		return fmt.Errorf("informer for GVR %q has not synced yet", gvr)
+	}
+	local, err := getUnstructured(inf.Informer().GetIndexer(), key)
+	if err != nil {
+		logger.Error(err, "unable to get from local indexer")
+		return nil // retrying won't help
+	}
+	cached, err := getUnstructured(c.cacheInformers.ForResource(*gvr).Informer().GetIndexer(), key)
+	if err != nil {
+		logger.Error(err, "unable to get from cache server indexer")
+		return nil // retrying won't help
+	}
+
+	logger = logger.WithValues("gvr", gvr.String(), "cluster", clusterName.String(), "namespace", namespace, "name", name)
+	ctx = klog.NewContext(cacheclient.WithShardInContext(ctx, c.shardName), logger)
+
+	return c.reconcile(ctx, *gvr, clusterName, namespace, name, local, cached)
+}
+
+func getUnstructured(indexer cache.Indexer, key string) (*unstructured.Unstructured, error) {
+	obj, exists, err := indexer.GetByKey(key)
+	if err != nil || !exists {
+		return nil, err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("got unexpected type %T", obj)
+	}
+	return u, nil
+}
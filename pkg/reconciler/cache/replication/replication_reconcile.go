@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+// reconcile makes the cached copy of an object match the local object. A nil local object
+// means the object was deleted on this shard, a nil cached object that it has not been replicated
+// yet. Local objects which are not to be replicated are removed from the cache server.
+func (c *controller) reconcile(ctx context.Context, gvr schema.GroupVersionResource, clusterName logicalcluster.Name, namespace, name string, local, cached *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+	client := c.cacheClusterClient.Cluster(clusterName).Resource(gvr).Namespace(namespace)
+
+	if local == nil || local.GetDeletionTimestamp() != nil || !isReplicated(gvr.GroupResource(), local) {
+		if cached == nil {
+			return nil
+		}
+		logger.V(2).Info("deleting object from cache server")
+		if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	replicated := replicatedObject(local, c.shardName)
+
+	if cached == nil {
+		logger.V(2).Info("creating object in cache server")
+		_, err := client.Create(ctx, replicated, metav1.CreateOptions{})
+		return err // on AlreadyExists, retry when the informer has caught up
+	}
+
+	if equality.Semantic.DeepEqual(replicatedObject(cached, c.shardName).Object, replicated.Object) {
+		return nil
+	}
+
+	logger.V(2).Info("updating object in cache server")
+	replicated.SetResourceVersion(cached.GetResourceVersion())
+	_, err := client.Update(ctx, replicated, metav1.UpdateOptions{})
+	return err
+}
+
+// isReplicated returns whether the given local object is replicated. RBAC objects are only replicated
+// when they are part of an APIExport maximal permission policy, all objects of other resources are.
+func isReplicated(gr schema.GroupResource, obj *unstructured.Unstructured) bool {
+	if gr.Group != rbacv1.GroupName {
+		return true
+	}
+	_, found := obj.GetAnnotations()[apisv1alpha1.AnnotationMaximalPermissionPolicyKey]
+	return found
+}
+
+// replicatedObject returns a copy of obj as it is stored in the cache server, without the
+// metadata owned by the server it is stored in, and annotated with the name of the shard.
+func replicatedObject(obj *unstructured.Unstructured, shardName clientshard.Name) *unstructured.Unstructured {
+	replicated := obj.DeepCopy()
+	replicated.SetUID("")
+	replicated.SetResourceVersion("")
+	replicated.SetSelfLink("")
+	replicated.SetGeneration(0)
+	replicated.SetCreationTimestamp(metav1.Time{})
+	replicated.SetManagedFields(nil)
+	replicated.SetFinalizers(nil)
+
+	annotations := replicated.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[clientshard.AnnotationKey] = shardName.String()
+	replicated.SetAnnotations(annotations)
+
+	return replicated
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package replication
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
+)
+
+var (
+	apiExportsGVR   = schema.GroupVersionResource{Group: "apis.kcp.dev", Version: "v1alpha1", Resource: "apiexports"}
+	clusterRolesGVR = schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}
+)
+
+type mockedDynamicCluster struct {
+	client *dynamicfake.FakeDynamicClient
+}
+
+func (mdc *mockedDynamicCluster) Cluster(name logicalcluster.Name) dynamic.Interface {
+	return mdc.client
+}
+
+func newAPIExport(name string, mutators ...func(*unstructured.Unstructured)) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apis.kcp.dev/v1alpha1")
+	obj.SetKind("APIExport")
+	obj.SetName(name)
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org"})
+	for _, m := range mutators {
+		m(obj)
+	}
+	return obj
+}
+
+func withIdentity(identity string) func(*unstructured.Unstructured) {
+	return func(obj *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(obj.Object, identity, "status", "identityHash")
+	}
+}
+
+func newClusterRole(name string, mutators ...func(*unstructured.Unstructured)) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("rbac.authorization.k8s.io/v1")
+	obj.SetKind("ClusterRole")
+	obj.SetName(name)
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org"})
+	for _, m := range mutators {
+		m(obj)
+	}
+	return obj
+}
+
+func withMaximalPermissionPolicy(obj *unstructured.Unstructured) {
+	annotations := obj.GetAnnotations()
+	annotations[apisv1alpha1.AnnotationMaximalPermissionPolicyKey] = "true"
+	obj.SetAnnotations(annotations)
+}
+
+func withServerMetadata(rv string) func(*unstructured.Unstructured) {
+	return func(obj *unstructured.Unstructured) {
+		obj.SetUID("uid")
+		obj.SetResourceVersion(rv)
+		obj.SetCreationTimestamp(metav1.Now())
+		obj.SetFinalizers([]string{"some-finalizer"})
+	}
+}
+
+func cached(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	annotations[clientshard.AnnotationKey] = "amber"
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestReconcile(t *testing.T) {
+	tests := map[string]struct {
+		gvr    schema.GroupVersionResource // defaults to APIExports
+		local  *unstructured.Unstructured
+		cached *unstructured.Unstructured
+
+		wantVerbs  []string
+		wantCached *unstructured.Unstructured
+	}{
+		"created in cache": {
+			local:      newAPIExport("export", withServerMetadata("1"), withIdentity("abc")),
+			wantVerbs:  []string{"create"},
+			wantCached: cached(newAPIExport("export", withIdentity("abc"))),
+		},
+		"up to date": {
+			local:      newAPIExport("export", withServerMetadata("1"), withIdentity("abc")),
+			cached:     cached(newAPIExport("export", withServerMetadata("7"), withIdentity("abc"))),
+			wantCached: cached(newAPIExport("export", withServerMetadata("7"), withIdentity("abc"))),
+		},
+		"status updated in cache": {
+			local:      newAPIExport("export", withServerMetadata("2"), withIdentity("def")),
+			cached:     cached(newAPIExport("export", withServerMetadata("7"), withIdentity("abc"))),
+			wantVerbs:  []string{"update"},
+			wantCached: cached(newAPIExport("export", withIdentity("def"), func(obj *unstructured.Unstructured) { obj.SetResourceVersion("7") })),
+		},
+		"deleted locally": {
+			cached:    cached(newAPIExport("export", withServerMetadata("7"))),
+			wantVerbs: []string{"delete"},
+		},
+		"being deleted locally": {
+			local: newAPIExport("export", withServerMetadata("1"), func(obj *unstructured.Unstructured) {
+				now := metav1.Now()
+				obj.SetDeletionTimestamp(&now)
+			}),
+			cached:    cached(newAPIExport("export", withServerMetadata("7"))),
+			wantVerbs: []string{"delete"},
+		},
+		"deleted locally and in cache": {},
+		"policy cluster role created in cache": {
+			gvr:        clusterRolesGVR,
+			local:      newClusterRole("export", withServerMetadata("1"), withMaximalPermissionPolicy),
+			wantVerbs:  []string{"create"},
+			wantCached: cached(newClusterRole("export", withMaximalPermissionPolicy)),
+		},
+		"other cluster role not created in cache": {
+			gvr:   clusterRolesGVR,
+			local: newClusterRole("export", withServerMetadata("1")),
+		},
+		"cluster role no longer in policy deleted from cache": {
+			gvr:       clusterRolesGVR,
+			local:     newClusterRole("export", withServerMetadata("2")),
+			cached:    cached(newClusterRole("export", withServerMetadata("7"), withMaximalPermissionPolicy)),
+			wantVerbs: []string{"delete"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.cached != nil {
+				objects = append(objects, tt.cached)
+			}
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+			c := &controller{
+				shardName:          clientshard.New("amber"),
+				cacheClusterClient: &mockedDynamicCluster{client: client},
+			}
+
+			gvr := tt.gvr
+			if gvr.Empty() {
+				gvr = apiExportsGVR
+			}
+			err := c.reconcile(context.Background(), gvr, logicalcluster.New("root:org"), "", "export", tt.local, tt.cached)
+			require.NoError(t, err)
+
+			var verbs []string
+			for _, action := range client.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			require.Equal(t, tt.wantVerbs, verbs)
+
+			got, err := client.Tracker().Get(gvr, "", "export")
+			if tt.wantCached == nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantCached, got)
+		})
+	}
+}
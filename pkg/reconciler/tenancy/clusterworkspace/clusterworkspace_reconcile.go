@@ -48,6 +48,16 @@ func (c *Controller) reconcile(ctx context.Context, ws *tenancyv1alpha1.ClusterW
 				return c.clusterWorkspaceShardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
 			},
 			listShards: c.clusterWorkspaceShardLister.List,
+			// This only counts the ClusterWorkspaces known to this shard's informer, i.e. those
+			// with a parent workspace on this shard. Until workspaces of all shards are visible
+			// here, the counts approximate the shard load and capacity checks are best-effort.
+			countWorkspaces: func(shardName string) (int, error) {
+				objs, err := c.workspaceIndexer.ByIndex(byCurrentShard, shardName)
+				if err != nil {
+					return 0, err
+				}
+				return len(objs), nil
+			},
 		},
 		&phaseReconciler{
 			getShardWithQuorum: func(ctx context.Context, name string, options metav1.GetOptions) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
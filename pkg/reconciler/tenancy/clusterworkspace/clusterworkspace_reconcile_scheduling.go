@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -28,7 +29,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
@@ -38,8 +38,9 @@ import (
 )
 
 type schedulingReconciler struct {
-	getShard   func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
-	listShards func(selector labels.Selector) ([]*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	getShard        func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	listShards      func(selector labels.Selector) ([]*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	countWorkspaces func(shardName string) (int, error)
 }
 
 func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (reconcileStatus, error) {
@@ -88,35 +89,16 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				}
 			}
 
+			// Unconstrained workspaces are scored across all valid shards. This replaces the former
+			// fallback that always scheduled them onto the root shard: the sharded e2e setup only runs
+			// the root shard today, so they still land there. Tests running against more shards must
+			// constrain workspaces via spec.shard to get a deterministic location.
 			if len(shards) == 0 {
 				var err error
 				shards, err = r.listShards(selector)
 				if err != nil {
 					return reconcileStatusStopAndRequeue, err
 				}
-
-				// if no specific shard was required,
-				// we are going to schedule the given ws onto the root shard
-				// this step is temporary until working with multi-shard env works
-				// until then we need to assign ws to the root shard otherwise all e2e test will break
-				//
-				// note if there are no shards just let it run, at the end, we set a proper condition.
-				if len(shards) > 0 && (workspace.Spec.Shard == nil || workspace.Spec.Shard.Selector == nil) {
-					// trim the list to contain only the "root" shard so that we always schedule onto it
-					for _, shard := range shards {
-						if shard.Name == "root" {
-							shards = []*tenancyv1alpha1.ClusterWorkspaceShard{shard}
-							break
-						}
-					}
-					if len(shards) == 0 {
-						names := make([]string, 0, len(shards))
-						for _, shard := range shards {
-							names = append(names, shard.Name)
-						}
-						return reconcileStatusStopAndRequeue, fmt.Errorf("since no specific shard was requested we default to schedule onto the root shard, but the root shard wasn't found, found shards: %v", names)
-					}
-				}
 			}
 
 			validShards := make([]*tenancyv1alpha1.ClusterWorkspaceShard, 0, len(shards))
@@ -136,8 +118,14 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				}
 			}
 
-			if len(validShards) > 0 {
-				targetShard := validShards[rand.Intn(len(validShards))]
+			candidates, fullShards, err := scoreShards(validShards, r.countWorkspaces)
+			if err != nil {
+				return reconcileStatusStopAndRequeue, err
+			}
+
+			if len(candidates) > 0 {
+				target := pickShard(candidates)
+				targetShard := target.shard
 
 				u, err := url.Parse(targetShard.Spec.ExternalURL)
 				if err != nil {
@@ -150,8 +138,12 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				workspace.Status.BaseURL = u.String()
 				workspace.Status.Location.Current = targetShard.Name
 
-				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
-				logging.WithObject(logger, targetShard).Info("scheduled workspace to shard")
+				condition := conditions.TrueCondition(tenancyv1alpha1.WorkspaceScheduled)
+				condition.Message = fmt.Sprintf("Scheduled onto ClusterWorkspaceShard %q out of %d candidate(s) matching selector %q with %s.", targetShard.Name, len(candidates), selector.String(), target)
+				conditions.Set(workspace, condition)
+				logging.WithObject(logger, targetShard).Info("scheduled workspace to shard", "score", target.total(), "candidates", len(candidates))
+			} else if len(fullShards) > 0 {
+				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace, shards without free capacity: %s.", strings.Join(fullShards, ", "))
 			} else {
 				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "No available shards to schedule the workspace.")
 				failures := make([]error, 0, len(invalidShards))
@@ -161,32 +153,10 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 				logger.Error(utilerrors.NewAggregate(failures), "no valid shards found for workspace, skipping")
 			}
 		}
-	case tenancyv1alpha1.ClusterWorkspacePhaseInitializing, tenancyv1alpha1.ClusterWorkspacePhaseReady:
-		// movement can only happen after scheduling
-		if workspace.Status.Location.Target == "" {
-			break
-		}
-
-		current, target := workspace.Status.Location.Current, workspace.Status.Location.Target
-		if current == target {
-			workspace.Status.Location.Target = ""
-			break
-		}
-
-		_, err := r.getShard(target)
-		if apierrors.IsNotFound(err) {
-			logger.Info("cannot move to nonexistent shard", "ClusterWorkspaceShard", target)
-		} else if err != nil {
-			return reconcileStatusStopAndRequeue, err
-		}
-
-		logger.Info("moving workspace to shard", "ClusterWorkspaceShard", workspace.Status.Location.Target)
-		workspace.Status.Location.Current = workspace.Status.Location.Target
-		workspace.Status.Location.Target = ""
 	}
 
-	// check scheduled shard. This has no influence on the workspace baseURL or shard assignment. This might be a trigger for
-	// a movement controller in the future (or a human intervention) to move workspaces off a shard.
+	// check scheduled shard. This has no influence on the workspace baseURL or shard assignment. If the shard does not
+	// match the constraints anymore, the workspace is migrated to another shard by the clusterworkspacemigration controller.
 	if workspace.Status.Location.Current != "" {
 		shard, err := r.getShard(workspace.Status.Location.Current)
 		if apierrors.IsNotFound(err) {
@@ -201,9 +171,10 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 
 		if workspace.Spec.Shard != nil && shard != nil {
 			needsRescheduling := false
+			selector := labels.Everything()
 			if workspace.Spec.Shard.Selector != nil {
 				var err error
-				selector, err := metav1.LabelSelectorAsSelector(workspace.Spec.Shard.Selector)
+				selector, err = metav1.LabelSelectorAsSelector(workspace.Spec.Shard.Selector)
 				if err != nil {
 					conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnschedulable, conditionsv1alpha1.ConditionSeverityError, "spec.location.shardSelector is invalid: %v", err)
 					return reconcileStatusContinue, nil // don't retry, cannot do anything useful
@@ -212,13 +183,12 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 			} else if shardName := workspace.Spec.Shard.Name; shardName != "" && shardName != workspace.Status.Location.Current {
 				needsRescheduling = true
 			}
-			if needsRescheduling {
-				conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but movement is not supported yet")
-			} else {
-				conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+			if needsRescheduling && workspace.Status.Location.Target == "" {
+				return r.reschedule(ctx, workspace, selector)
 			}
+			markScheduled(workspace)
 		} else {
-			conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+			markScheduled(workspace)
 		}
 	}
 
@@ -228,3 +198,59 @@ func (r *schedulingReconciler) reconcile(ctx context.Context, workspace *tenancy
 func isValidShard(shard *tenancyv1alpha1.ClusterWorkspaceShard) (valid bool, reason, message string) {
 	return true, "", ""
 }
+
+// reschedule sets status.location.target to a shard matching the shard constraints of the workspace,
+// which triggers a migration by the clusterworkspacemigration controller.
+func (r *schedulingReconciler) reschedule(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, selector labels.Selector) (reconcileStatus, error) {
+	logger := klog.FromContext(ctx)
+
+	var shards []*tenancyv1alpha1.ClusterWorkspaceShard
+	if shardName := workspace.Spec.Shard.Name; shardName != "" {
+		shard, err := r.getShard(shardName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return reconcileStatusStopAndRequeue, err
+		}
+		if apierrors.IsNotFound(err) {
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but shard %q specified in spec.location.name does not exist.", shardName)
+			return reconcileStatusContinue, nil // retry is automatic when new shards show up
+		}
+		shards = []*tenancyv1alpha1.ClusterWorkspaceShard{shard}
+	} else {
+		all, err := r.listShards(selector)
+		if err != nil {
+			return reconcileStatusStopAndRequeue, err
+		}
+		for _, shard := range all {
+			if shard.Name != workspace.Status.Location.Current {
+				shards = append(shards, shard)
+			}
+		}
+	}
+
+	candidates, _, err := scoreShards(shards, r.countWorkspaces)
+	if err != nil {
+		return reconcileStatusStopAndRequeue, err
+	}
+	if len(candidates) == 0 {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceScheduled, tenancyv1alpha1.WorkspaceReasonUnreschedulable, conditionsv1alpha1.ConditionSeverityError, "Needs rescheduling, but no other shard with free capacity matches selector %q.", selector.String())
+		return reconcileStatusContinue, nil
+	}
+
+	target := pickShard(candidates)
+	workspace.Status.Location.Target = target.shard.Name
+
+	condition := conditions.TrueCondition(tenancyv1alpha1.WorkspaceScheduled)
+	condition.Message = fmt.Sprintf("Rescheduled from ClusterWorkspaceShard %q onto %q out of %d candidate(s) matching selector %q with %s.", workspace.Status.Location.Current, target.shard.Name, len(candidates), selector.String(), target)
+	conditions.Set(workspace, condition)
+	logging.WithObject(logger, target.shard).Info("rescheduled workspace to shard", "score", target.total(), "candidates", len(candidates))
+
+	return reconcileStatusContinue, nil
+}
+
+// markScheduled sets the WorkspaceScheduled condition to true, keeping the message recording
+// the scheduling decision if the condition is already true.
+func markScheduled(workspace *tenancyv1alpha1.ClusterWorkspace) {
+	if !conditions.IsTrue(workspace, tenancyv1alpha1.WorkspaceScheduled) {
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceScheduled)
+	}
+}
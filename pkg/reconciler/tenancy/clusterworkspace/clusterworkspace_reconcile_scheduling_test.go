@@ -24,21 +24,25 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 )
 
 func TestSchedulingReconciler(t *testing.T) {
 	tests := []struct {
-		name       string
-		workspace  *tenancyv1alpha1.ClusterWorkspace
-		shards     []*tenancyv1alpha1.ClusterWorkspaceShard
-		want       *tenancyv1alpha1.ClusterWorkspace
-		wantStatus reconcileStatus
-		wantErr    bool
+		name            string
+		workspace       *tenancyv1alpha1.ClusterWorkspace
+		shards          []*tenancyv1alpha1.ClusterWorkspaceShard
+		workspaceCounts map[string]int
+		want            *tenancyv1alpha1.ClusterWorkspace
+		wantMessage     string
+		wantStatus      reconcileStatus
+		wantErr         bool
 	}{
 		// TODO(sttts): add test coverage for all old cases
 
@@ -236,6 +240,218 @@ func TestSchedulingReconciler(t *testing.T) {
 			),
 			wantStatus: reconcileStatusContinue,
 		},
+		{
+			name:      "least loaded shard is preferred",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				withURLs("https://foo", "https://front-proxy", shard("foo")),
+			},
+			workspaceCounts: map[string]int{"root": 5, "foo": 1},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace", workspace())),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantMessage: `Scheduled onto ClusterWorkspaceShard "foo" out of 2 candidate(s) matching selector "" with score 180 (capacity 100, load 80): 1 workspaces scheduled, unbounded capacity.`,
+			wantStatus:  reconcileStatusContinue,
+		},
+		{
+			name:      "shard with more free capacity is preferred",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withCapacity(10, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withCapacity(100, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			workspaceCounts: map[string]int{"root": 5, "foo": 5},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace", workspace())),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantMessage: `Scheduled onto ClusterWorkspaceShard "foo" out of 2 candidate(s) matching selector "" with score 95 (capacity 95, load 0): 5 workspaces scheduled, 95/100 workspaces free.`,
+			wantStatus:  reconcileStatusContinue,
+		},
+		{
+			name:      "full shard is skipped",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withCapacity(2, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withURLs("https://foo", "https://front-proxy", shard("foo")),
+			},
+			workspaceCounts: map[string]int{"root": 2, "foo": 10},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace", workspace())),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name:      "all shards full",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withCapacity(2, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withCapacity(0, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			workspaceCounts: map[string]int{"root": 3},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling, workspace()),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantMessage: "No available shards to schedule the workspace, shards without free capacity: root, foo.",
+			wantStatus:  reconcileStatusContinue,
+		},
+		{
+			name: "spec shard name of full shard",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				withCapacity(1, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			workspaceCounts: map[string]int{"foo": 1},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnschedulable,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "ready workspace on shard not matching spec shard name is rescheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withURLs("https://root", "https://front-proxy", shard("root")),
+				withURLs("https://foo", "https://front-proxy", shard("foo")),
+			},
+			want: withConditions(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Name: "foo"}, workspace())))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "ready workspace on shard not matching spec shard selector is rescheduled",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{"b": "2"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+			},
+			want: withConditions(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace())))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantMessage: `Rescheduled from ClusterWorkspaceShard "root" onto "foo" out of 1 candidate(s) matching selector "a=1" with score 200 (capacity 100, load 100): 0 workspaces scheduled, unbounded capacity.`,
+			wantStatus:  reconcileStatusContinue,
+		},
+		{
+			name: "ready workspace without matching shard is unreschedulable",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{"b": "2"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+			},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				scheduled("root", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+				conditionsapi.Condition{
+					Type:     tenancyv1alpha1.WorkspaceScheduled,
+					Severity: conditionsapi.ConditionSeverityError,
+					Status:   corev1.ConditionFalse,
+					Reason:   tenancyv1alpha1.WorkspaceReasonUnreschedulable,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantStatus: reconcileStatusContinue,
+		},
+		{
+			name: "spec shard selector picks least loaded matching shard",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"a": "1"}},
+				}, workspace())),
+			shards: []*tenancyv1alpha1.ClusterWorkspaceShard{
+				withLabels(map[string]string{"b": "2"}, withURLs("https://root", "https://front-proxy", shard("root"))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://foo", "https://front-proxy", shard("foo"))),
+				withLabels(map[string]string{"a": "1"}, withURLs("https://bar", "https://front-proxy", shard("bar"))),
+			},
+			workspaceCounts: map[string]int{"foo": 3, "bar": 4},
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseScheduling,
+				scheduled("foo", "https://front-proxy/clusters/workspace",
+					constrained(tenancyv1alpha1.ShardConstraints{Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"a": "1"}},
+					}, workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceScheduled,
+					Status: corev1.ConditionTrue,
+				},
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceShardValid,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantMessage: `Scheduled onto ClusterWorkspaceShard "foo" out of 2 candidate(s) matching selector "a=1" with score 125 (capacity 100, load 25): 3 workspaces scheduled, unbounded capacity.`,
+			wantStatus:  reconcileStatusContinue,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -257,6 +473,9 @@ func TestSchedulingReconciler(t *testing.T) {
 					}
 					return shards, nil
 				},
+				countWorkspaces: func(shardName string) (int, error) {
+					return tt.workspaceCounts[shardName], nil
+				},
 			}
 			ws := tt.workspace.DeepCopy()
 			status, err := r.reconcile(context.Background(), ws)
@@ -268,6 +487,12 @@ func TestSchedulingReconciler(t *testing.T) {
 				t.Errorf("unexpected status: got = %v, want %v", status, tt.want)
 			}
 
+			if tt.wantMessage != "" {
+				if got := conditions.GetMessage(ws, tenancyv1alpha1.WorkspaceScheduled); got != tt.wantMessage {
+					t.Errorf("unexpected %s message:\n got: %s\nwant: %s", tenancyv1alpha1.WorkspaceScheduled, got, tt.wantMessage)
+				}
+			}
+
 			// prune conditions for easier comparison
 			for i := range ws.Status.Conditions {
 				ws.Status.Conditions[i].LastTransitionTime = metav1.Time{}
@@ -300,6 +525,11 @@ func scheduled(shard string, baseURL string, ws *tenancyv1alpha1.ClusterWorkspac
 	return ws
 }
 
+func targeted(shard string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Target = shard
+	return ws
+}
+
 func constrained(constraints tenancyv1alpha1.ShardConstraints, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
 	ws.Spec.Shard = &constraints
 	return ws
@@ -328,3 +558,10 @@ func withLabels(labels map[string]string, shard *tenancyv1alpha1.ClusterWorkspac
 	shard.Labels = labels
 	return shard
 }
+
+func withCapacity(workspaces int64, shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	shard.Status.Capacity = corev1.ResourceList{
+		tenancyv1alpha1.ClusterWorkspaceShardCapacityWorkspaces: *resource.NewQuantity(workspaces, resource.DecimalSI),
+	}
+	return shard
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspace
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// maxShardScore is the highest score of each scoring criterion of a shard.
+const maxShardScore int64 = 100
+
+// shardScore is a ClusterWorkspaceShard scored as scheduling candidate for a workspace.
+type shardScore struct {
+	shard *tenancyv1alpha1.ClusterWorkspaceShard
+
+	// workspaces is the number of workspaces currently scheduled onto the shard.
+	workspaces int
+	// capacity is the workspace capacity of the shard, or -1 if the shard is unbounded.
+	capacity int64
+
+	capacityScore int64
+	loadScore     int64
+}
+
+func (s shardScore) total() int64 {
+	return s.capacityScore + s.loadScore
+}
+
+// String explains the score, and is used in the WorkspaceScheduled condition message.
+func (s shardScore) String() string {
+	capacity := "unbounded capacity"
+	if s.capacity >= 0 {
+		capacity = fmt.Sprintf("%d/%d workspaces free", s.capacity-int64(s.workspaces), s.capacity)
+	}
+	return fmt.Sprintf("score %d (capacity %d, load %d): %d workspaces scheduled, %s", s.total(), s.capacityScore, s.loadScore, s.workspaces, capacity)
+}
+
+// workspaceCapacity returns the workspace capacity of the shard, or -1 if none is set.
+func workspaceCapacity(shard *tenancyv1alpha1.ClusterWorkspaceShard) int64 {
+	quantity, found := shard.Status.Capacity[tenancyv1alpha1.ClusterWorkspaceShardCapacityWorkspaces]
+	if !found {
+		return -1
+	}
+	return quantity.Value()
+}
+
+// scoreShards scores the given shards by free workspace capacity and by the number of workspaces
+// already scheduled onto them, relative to the other shards. Shards without free capacity are not
+// returned as candidates, but their names are returned as full. countWorkspaces may only see part of
+// the workspaces of a shard, so the scores are an approximation of the real shard load.
+func scoreShards(shards []*tenancyv1alpha1.ClusterWorkspaceShard, countWorkspaces func(shardName string) (int, error)) (candidates []shardScore, full []string, err error) {
+	maxWorkspaces := 0
+	for _, shard := range shards {
+		count, err := countWorkspaces(shard.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		score := shardScore{
+			shard:         shard,
+			workspaces:    count,
+			capacity:      workspaceCapacity(shard),
+			capacityScore: maxShardScore,
+		}
+		if score.capacity >= 0 {
+			free := score.capacity - int64(count)
+			if free <= 0 {
+				full = append(full, shard.Name)
+				continue
+			}
+			score.capacityScore = maxShardScore * free / score.capacity
+		}
+
+		if count > maxWorkspaces {
+			maxWorkspaces = count
+		}
+		candidates = append(candidates, score)
+	}
+
+	for i := range candidates {
+		candidates[i].loadScore = maxShardScore
+		if maxWorkspaces > 0 {
+			candidates[i].loadScore = maxShardScore * int64(maxWorkspaces-candidates[i].workspaces) / int64(maxWorkspaces)
+		}
+	}
+
+	return candidates, full, nil
+}
+
+// pickShard returns the candidate with the highest total score. Ties are broken randomly
+// such that equally loaded shards are filled evenly.
+func pickShard(candidates []shardScore) shardScore {
+	var best []shardScore
+	for _, candidate := range candidates {
+		switch {
+		case len(best) == 0 || candidate.total() > best[0].total():
+			best = []shardScore{candidate}
+		case candidate.total() == best[0].total():
+			best = append(best, candidate)
+		}
+	}
+	return best[rand.Intn(len(best))]
+}
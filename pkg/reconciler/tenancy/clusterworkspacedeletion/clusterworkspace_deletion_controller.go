@@ -21,23 +21,32 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
 	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
@@ -49,24 +58,39 @@ const (
 	controllerName = "kcp-clusterworkspacedeletion"
 )
 
+var ownerRBACResources = []schema.GroupVersionResource{
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"},
+}
+
 func NewController(
 	kcpClusterClient kcpclient.Interface,
+	kubeClusterClient kubernetes.Interface,
 	metadataClusterClient metadata.Interface,
 	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	clusterWorkspaceTypeInformer tenancyinformers.ClusterWorkspaceTypeInformer,
+	configMapInformer coreinformers.ConfigMapInformer,
 	discoverResourcesFn func(clusterName logicalcluster.Name) ([]*metav1.APIResourceList, error),
 ) *Controller {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
 	c := &Controller{
-		queue:                 queue,
-		kcpClusterClient:      kcpClusterClient,
-		metadataClusterClient: metadataClusterClient,
-		workspaceLister:       workspaceInformer.Lister(),
-		deleter:               deletion.NewWorkspacedResourcesDeleter(metadataClusterClient, discoverResourcesFn),
+		queue:                      queue,
+		kcpClusterClient:           kcpClusterClient,
+		kubeClusterClient:          kubeClusterClient,
+		metadataClusterClient:      metadataClusterClient,
+		workspaceLister:            workspaceInformer.Lister(),
+		clusterWorkspaceTypeLister: clusterWorkspaceTypeInformer.Lister(),
+		configMapLister:            configMapInformer.Lister(),
+		deleter:                    deletion.NewWorkspacedResourcesDeleter(metadataClusterClient, discoverResourcesFn),
+		now:                        time.Now,
 	}
 
 	workspaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
 			switch obj := obj.(type) {
 			case *tenancyv1alpha1.ClusterWorkspace:
 				return !obj.DeletionTimestamp.IsZero()
@@ -77,6 +101,23 @@ func NewController(
 		Handler: cache.ResourceEventHandlerFuncs{
 			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
 			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+			// a finalized workspace might have to be restored.
+			DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+		},
+	})
+
+	configMapInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch obj := obj.(type) {
+			case *corev1.ConfigMap:
+				return logicalcluster.From(obj) == deletion.RestoreConfigMapCluster && obj.Labels[deletion.RestoreConfigMapLabel] == "true"
+			default:
+				return false
+			}
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueueRestore(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueueRestore(obj) },
 		},
 	})
 
@@ -87,14 +128,29 @@ type Controller struct {
 	queue workqueue.RateLimitingInterface
 
 	kcpClusterClient      kcpclient.Interface
+	kubeClusterClient     kubernetes.Interface
 	metadataClusterClient metadata.Interface
 
-	workspaceLister tenancylisters.ClusterWorkspaceLister
-	deleter         deletion.WorkspaceResourcesDeleterInterface
+	workspaceLister            tenancylisters.ClusterWorkspaceLister
+	clusterWorkspaceTypeLister tenancylisters.ClusterWorkspaceTypeLister
+	configMapLister            corelisters.ConfigMapLister
+	deleter                    deletion.WorkspaceResourcesDeleterInterface
+
+	now func() time.Time
+}
+
+// enqueueRestore enqueues the ClusterWorkspace of a pending restore ConfigMap.
+func (c *Controller) enqueueRestore(obj interface{}) {
+	restored, err := deletion.RestoredClusterWorkspace(obj.(*corev1.ConfigMap))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.enqueue(restored)
 }
 
 func (c *Controller) enqueue(obj interface{}) {
-	key, err := cache.MetaNamespaceKeyFunc(obj)
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -170,8 +226,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	logger := klog.FromContext(ctx)
 	workspace, deleteErr := c.workspaceLister.Get(key)
 	if apierrors.IsNotFound(deleteErr) {
-		logger.V(2).Info("ClusterWorkspace has been deleted")
-		return nil
+		return c.createRestored(ctx, key)
 	}
 	if deleteErr != nil {
 		runtime.HandleError(fmt.Errorf("unable to retrieve workspace %v from store: %w", key, deleteErr))
@@ -182,7 +237,48 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	ctx = klog.NewContext(ctx, logger)
 
 	if workspace.DeletionTimestamp.IsZero() {
-		return nil
+		// either restored already, or a new workspace with the same name
+		return c.completeRestore(ctx, workspace)
+	}
+
+	restoring, err := c.isRestoring(workspace)
+	if err != nil {
+		return err
+	}
+	if restoring {
+		// the restore has been persisted, possibly just before the retention period expired.
+		logger.V(2).Info("finalizing restored ClusterWorkspace")
+		return c.finalizeWorkspace(ctx, workspace.DeepCopy())
+	}
+
+	retentionPeriod, err := c.deletionRetentionPeriod(ctx, workspace)
+	if err != nil {
+		return err
+	}
+	if retentionPeriod > 0 && workspace.Status.Location.Current != "" {
+		purgeTime := workspace.DeletionTimestamp.Add(retentionPeriod)
+		if remaining := purgeTime.Sub(c.now()); remaining > 0 {
+			if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey]; found {
+				return c.restore(ctx, workspace)
+			}
+			if err := c.retain(ctx, workspace, purgeTime); err != nil {
+				return err
+			}
+			c.queue.AddAfter(key, remaining)
+			return nil
+		}
+	}
+
+	if workspace.Spec.ReadOnly {
+		// writes to read-only workspaces are rejected by admission, including the purge of the contents.
+		logger.V(2).Info("making deleted ClusterWorkspace writable for purging its contents")
+		workspaceCopy := workspace.DeepCopy()
+		workspaceCopy.Spec.ReadOnly = false
+		delete(workspaceCopy.Annotations, tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey)
+		delete(workspaceCopy.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey)
+		_, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Update(
+			logicalcluster.WithCluster(ctx, logicalcluster.From(workspace)), workspaceCopy, metav1.UpdateOptions{})
+		return err
 	}
 
 	workspaceCopy := workspace.DeepCopy()
@@ -192,24 +288,229 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	deleteErr = c.deleter.Delete(ctx, workspaceCopy)
 	if deleteErr == nil {
 		logger.V(2).Info("finished deleting ClusterWorkspace content", "duration", time.Since(startTime))
+		if err := c.deleteOwnerRBAC(ctx, workspaceCopy); err != nil {
+			return err
+		}
 		return c.finalizeWorkspace(ctx, workspaceCopy)
 	}
 
-	if err := c.patchCondition(ctx, workspace, workspaceCopy); err != nil {
+	if err := c.patchStatus(ctx, workspace, workspaceCopy); err != nil {
 		return err
 	}
 
 	return deleteErr
 }
 
-func (c *Controller) patchCondition(ctx context.Context, old, new *tenancyv1alpha1.ClusterWorkspace) error {
+// deletionRetentionPeriod returns the deletion retention period of the type of the given
+// workspace, or zero if its contents are to be purged right away.
+func (c *Controller) deletionRetentionPeriod(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	ref := workspace.Spec.Type
+	if ref.Path == "" {
+		return 0, nil
+	}
+	cwt, err := c.clusterWorkspaceTypeLister.Get(clusters.ToClusterAwareKey(logicalcluster.New(ref.Path), tenancyv1alpha1.ObjectName(ref.Name)))
+	if apierrors.IsNotFound(err) {
+		klog.FromContext(ctx).V(2).Info("ClusterWorkspaceType of deleted ClusterWorkspace not found, not retaining contents", "type", ref.String())
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if cwt.Spec.DeletionRetentionPeriod == nil {
+		return 0, nil
+	}
+	return cwt.Spec.DeletionRetentionPeriod.Duration, nil
+}
+
+// retain keeps the contents of the deleted workspace until purgeTime. The workspace is made read-only
+// and moved to the Deleted phase.
+func (c *Controller) retain(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, purgeTime time.Time) error {
+	logger := klog.FromContext(ctx)
+
+	if !workspace.Spec.ReadOnly {
+		// Note: this is a spec change, status is updated in the next iteration.
+		logger.V(2).Info("making deleted ClusterWorkspace read-only", "purgeTime", purgeTime)
+		workspaceCopy := workspace.DeepCopy()
+		workspaceCopy.Spec.ReadOnly = true
+		if workspaceCopy.Annotations == nil {
+			workspaceCopy.Annotations = map[string]string{}
+		}
+		workspaceCopy.Annotations[tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey] = "true"
+		_, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Update(
+			logicalcluster.WithCluster(ctx, logicalcluster.From(workspace)), workspaceCopy, metav1.UpdateOptions{})
+		return err
+	}
+
+	workspaceCopy := workspace.DeepCopy()
+	workspaceCopy.Status.Phase = tenancyv1alpha1.ClusterWorkspacePhaseDeleted
+	conditions.MarkFalse(workspaceCopy, tenancyv1alpha1.WorkspaceContentDeleted, tenancyv1alpha1.WorkspaceContentDeletedReasonRetained, conditionsv1alpha1.ConditionSeverityInfo,
+		"Contents are retained until %s, and can be restored with \"kubectl ws restore %s\" until then.", purgeTime.UTC().Format(time.RFC3339), workspace.Name)
+	return c.patchStatus(ctx, workspace, workspaceCopy)
+}
+
+// restore persists the pending restore of the deleted workspace. The workspace is finalized without purging its
+// contents once the pending restore is known to the informers, i.e. to the admission of ClusterWorkspaces too, and
+// is created again from it. The restored workspace is scheduled onto the shard holding the contents.
+func (c *Controller) restore(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := klog.FromContext(ctx)
+	logger.Info("restoring deleted ClusterWorkspace")
+
+	parent := logicalcluster.From(workspace)
+	cm, err := deletion.NewRestoreConfigMap(parent, restoredClusterWorkspace(parent, workspace))
+	if err != nil {
+		return err
+	}
+	_, err = c.kubeClusterClient.CoreV1().ConfigMaps(cm.Namespace).Create(logicalcluster.WithCluster(ctx, deletion.RestoreConfigMapCluster), cm, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// isRestoring returns whether the restore of the given deleted workspace is pending.
+func (c *Controller) isRestoring(workspace *tenancyv1alpha1.ClusterWorkspace) (bool, error) {
+	cm, err := c.getRestoreConfigMap(logicalcluster.From(workspace), workspace.Name)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	restored, err := deletion.RestoredClusterWorkspace(cm)
+	if err != nil {
+		return false, err
+	}
+	if restoredFrom := restored.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey]; restoredFrom != string(workspace.UID) {
+		return false, fmt.Errorf("pending restore of ClusterWorkspace %s is for UID %q, not %q", workspace.Name, restoredFrom, workspace.UID)
+	}
+	return true, nil
+}
+
+// createRestored creates the workspace of the given key again if its restore is pending.
+func (c *Controller) createRestored(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+	clusterName, name := clusters.SplitClusterAwareKey(key)
+	cm, err := c.getRestoreConfigMap(clusterName, name)
+	if apierrors.IsNotFound(err) {
+		logger.V(2).Info("ClusterWorkspace has been deleted")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	restored, err := deletion.RestoredClusterWorkspace(cm)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Create(
+		logicalcluster.WithCluster(ctx, clusterName), restored, metav1.CreateOptions{})
+	if err != nil {
+		// an AlreadyExists error means that the deleted workspace is not gone yet, or that the informer
+		// has not seen the restored workspace yet. Try again.
+		return err
+	}
+	logger.Info("restored ClusterWorkspace", "shard", restored.Spec.Shard.Name)
+	return c.deleteRestoreConfigMap(ctx, cm)
+}
+
+// completeRestore removes the pending restore of the given workspace after it has been created again.
+func (c *Controller) completeRestore(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	cm, err := c.getRestoreConfigMap(logicalcluster.From(workspace), workspace.Name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	restored, err := deletion.RestoredClusterWorkspace(cm)
+	if err != nil {
+		return err
+	}
+	if restoredFrom := restored.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey]; workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey] != restoredFrom {
+		// admission rejects other workspaces of the same name while the restore is pending. Don't block
+		// the name forever though.
+		klog.FromContext(ctx).Error(nil, "dropping pending restore of a ClusterWorkspace that has been created otherwise", "restoredFrom", restoredFrom)
+	}
+	return c.deleteRestoreConfigMap(ctx, cm)
+}
+
+func (c *Controller) getRestoreConfigMap(clusterName logicalcluster.Name, name string) (*corev1.ConfigMap, error) {
+	return c.configMapLister.ConfigMaps(deletion.RestoreConfigMapNamespace).Get(clusters.ToClusterAwareKey(deletion.RestoreConfigMapCluster, deletion.RestoreConfigMapName(clusterName, name)))
+}
+
+func (c *Controller) deleteRestoreConfigMap(ctx context.Context, cm *corev1.ConfigMap) error {
+	err := c.kubeClusterClient.CoreV1().ConfigMaps(cm.Namespace).Delete(logicalcluster.WithCluster(ctx, deletion.RestoreConfigMapCluster), cm.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &cm.UID},
+	})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// restoredClusterWorkspace returns a new ClusterWorkspace in the given parent logical cluster for the given
+// deleted one. Labels and status maintained by the system are dropped, it is pinned to the shard holding
+// the contents, and it records the UID of the deleted workspace.
+func restoredClusterWorkspace(parent logicalcluster.Name, workspace *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	restored := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: workspace.Name,
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey: parent.String(),
+			},
+		},
+		Spec: *workspace.Spec.DeepCopy(),
+	}
+
+	for k, v := range workspace.Labels {
+		if k == tenancyv1alpha1.ClusterWorkspacePhaseLabel || strings.HasPrefix(k, tenancyv1alpha1.ClusterWorkspaceInitializerLabelPrefix) {
+			continue
+		}
+		if restored.Labels == nil {
+			restored.Labels = map[string]string{}
+		}
+		restored.Labels[k] = v
+	}
+	for k, v := range workspace.Annotations {
+		if k == logicalcluster.AnnotationKey || k == tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey || k == tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey {
+			continue
+		}
+		restored.Annotations[k] = v
+	}
+
+	restored.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey] = string(workspace.UID)
+
+	if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey]; found {
+		restored.Spec.ReadOnly = false
+	}
+	if restored.Spec.Shard == nil {
+		restored.Spec.Shard = &tenancyv1alpha1.ShardConstraints{}
+	}
+	restored.Spec.Shard.Name = workspace.Status.Location.Current
+
+	return restored
+}
+
+// deleteOwnerRBAC deletes the ClusterRoles and ClusterRoleBindings in the parent workspace which
+// give the owner access to the workspace. They are kept during the retention period of a deleted
+// workspace in order to allow the owner to restore it.
+func (c *Controller) deleteOwnerRBAC(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) error {
+	selector := fmt.Sprintf("%s=%s", tenancyv1alpha1.ClusterWorkspaceOwnerNameLabel, workspace.Name)
+	for _, gvr := range ownerRBACResources {
+		if err := c.metadataClusterClient.Resource(gvr).DeleteCollection(
+			logicalcluster.WithCluster(ctx, logicalcluster.From(workspace)), metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: selector}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Controller) patchStatus(ctx context.Context, old, new *tenancyv1alpha1.ClusterWorkspace) error {
 	logger := klog.FromContext(ctx)
-	if equality.Semantic.DeepEqual(old.Status.Conditions, new.Status.Conditions) {
+	if old.Status.Phase == new.Status.Phase && equality.Semantic.DeepEqual(old.Status.Conditions, new.Status.Conditions) {
 		return nil
 	}
 
 	oldData, err := json.Marshal(tenancyv1alpha1.ClusterWorkspace{
 		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:      old.Status.Phase,
 			Conditions: old.Status.Conditions,
 		},
 	})
@@ -223,6 +524,7 @@ func (c *Controller) patchCondition(ctx context.Context, old, new *tenancyv1alph
 			ResourceVersion: old.ResourceVersion,
 		}, // to ensure they appear in the patch as preconditions
 		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:      new.Status.Phase,
 			Conditions: new.Status.Conditions,
 		},
 	})
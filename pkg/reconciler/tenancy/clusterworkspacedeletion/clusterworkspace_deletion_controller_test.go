@@ -0,0 +1,411 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacedeletion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	kubefakeclient "k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpfakeclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion/deletion"
+)
+
+var scheme *runtime.Scheme
+
+func init() {
+	scheme = runtime.NewScheme()
+	utilruntime.Must(metav1.AddMetaToScheme(scheme))
+}
+
+type fakeDeleter struct {
+	called bool
+}
+
+func (d *fakeDeleter) Delete(ctx context.Context, ws *tenancyv1alpha1.ClusterWorkspace) error {
+	d.called = true
+	return nil
+}
+
+func TestProcessRetention(t *testing.T) {
+	now := time.Date(2022, 10, 18, 12, 0, 0, 0, time.UTC)
+	deletedAt := metav1.NewTime(now.Add(-time.Hour))
+
+	newWorkspace := func(readOnly bool, annotations map[string]string) *tenancyv1alpha1.ClusterWorkspace {
+		ws := &tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "team",
+				UID:               "uid-1",
+				DeletionTimestamp: &deletedAt,
+				Finalizers:        []string{deletion.WorkspaceFinalizer},
+				Labels: map[string]string{
+					tenancyv1alpha1.ClusterWorkspacePhaseLabel: string(tenancyv1alpha1.ClusterWorkspacePhaseReady),
+					"app": "team",
+				},
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey: "root:org",
+				},
+			},
+			Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+				ReadOnly: readOnly,
+				Type:     tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "team", Path: "root:org"},
+			},
+			Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+				Phase:    tenancyv1alpha1.ClusterWorkspacePhaseReady,
+				BaseURL:  "https://shard-1/clusters/root:org:team",
+				Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-1"},
+			},
+		}
+		for k, v := range annotations {
+			ws.Annotations[k] = v
+		}
+		return ws
+	}
+	newType := func(retention *metav1.Duration) *tenancyv1alpha1.ClusterWorkspaceType {
+		return &tenancyv1alpha1.ClusterWorkspaceType{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "team",
+				Annotations: map[string]string{logicalcluster.AnnotationKey: "root:org"},
+			},
+			Spec: tenancyv1alpha1.ClusterWorkspaceTypeSpec{
+				DeletionRetentionPeriod: retention,
+			},
+		}
+	}
+
+	restoreRequested := newWorkspace(true, map[string]string{tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey: "true", tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey: "true"})
+
+	tests := []struct {
+		name             string
+		workspace        *tenancyv1alpha1.ClusterWorkspace
+		cwt              *tenancyv1alpha1.ClusterWorkspaceType
+		restoreConfigMap *corev1.ConfigMap
+
+		wantPurged      bool
+		wantActions     []string
+		wantKubeActions []string
+		validateActions func(t *testing.T, actions, kubeActions []clienttesting.Action)
+	}{
+		{
+			name:        "no retention period, purged",
+			workspace:   newWorkspace(false, nil),
+			cwt:         newType(nil),
+			wantPurged:  true,
+			wantActions: []string{"update"},
+		},
+		{
+			name:        "unknown type, purged",
+			workspace:   newWorkspace(false, nil),
+			wantPurged:  true,
+			wantActions: []string{"update"},
+		},
+		{
+			name:        "retention period expired, purged",
+			workspace:   newWorkspace(false, map[string]string{tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey: "true"}),
+			cwt:         newType(&metav1.Duration{Duration: 30 * time.Minute}),
+			wantPurged:  true,
+			wantActions: []string{"update"},
+		},
+		{
+			name:        "retention period expired, made writable before purging",
+			workspace:   newWorkspace(true, map[string]string{tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey: "true"}),
+			cwt:         newType(&metav1.Duration{Duration: 30 * time.Minute}),
+			wantActions: []string{"update"},
+			validateActions: func(t *testing.T, actions, _ []clienttesting.Action) {
+				ws := actions[0].(clienttesting.UpdateAction).GetObject().(*tenancyv1alpha1.ClusterWorkspace)
+				require.False(t, ws.Spec.ReadOnly)
+				require.NotContains(t, ws.Annotations, tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey)
+				require.Equal(t, []string{deletion.WorkspaceFinalizer}, ws.Finalizers)
+			},
+		},
+		{
+			name: "never scheduled, purged",
+			workspace: func() *tenancyv1alpha1.ClusterWorkspace {
+				ws := newWorkspace(false, nil)
+				ws.Status.Location.Current = ""
+				return ws
+			}(),
+			cwt:         newType(&metav1.Duration{Duration: 24 * time.Hour}),
+			wantPurged:  true,
+			wantActions: []string{"update"},
+		},
+		{
+			name:        "retained, made read-only",
+			workspace:   newWorkspace(false, nil),
+			cwt:         newType(&metav1.Duration{Duration: 24 * time.Hour}),
+			wantActions: []string{"update"},
+			validateActions: func(t *testing.T, actions, _ []clienttesting.Action) {
+				ws := actions[0].(clienttesting.UpdateAction).GetObject().(*tenancyv1alpha1.ClusterWorkspace)
+				require.True(t, ws.Spec.ReadOnly)
+				require.Equal(t, "true", ws.Annotations[tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey])
+				require.Equal(t, []string{deletion.WorkspaceFinalizer}, ws.Finalizers)
+			},
+		},
+		{
+			name:        "retained read-only workspace, moved to deleted phase",
+			workspace:   newWorkspace(true, map[string]string{tenancyv1alpha1.ClusterWorkspaceDeletionReadOnlyAnnotationKey: "true"}),
+			cwt:         newType(&metav1.Duration{Duration: 24 * time.Hour}),
+			wantActions: []string{"patch"},
+			validateActions: func(t *testing.T, actions, _ []clienttesting.Action) {
+				patch := actions[0].(clienttesting.PatchAction)
+				require.Equal(t, "status", patch.GetSubresource())
+				require.Contains(t, string(patch.GetPatch()), `"phase":"Deleted"`)
+				require.Contains(t, string(patch.GetPatch()), `"reason":"Retained"`)
+				require.Contains(t, string(patch.GetPatch()), "2022-10-19T11:00:00Z")
+			},
+		},
+		{
+			name:            "restore requested, persisted",
+			workspace:       restoreRequested,
+			cwt:             newType(&metav1.Duration{Duration: 24 * time.Hour}),
+			wantKubeActions: []string{"create"},
+			validateActions: func(t *testing.T, _, kubeActions []clienttesting.Action) {
+				cm := kubeActions[0].(clienttesting.CreateAction).GetObject().(*corev1.ConfigMap)
+				require.Equal(t, deletion.RestoreConfigMapName(logicalcluster.New("root:org"), "team"), cm.Name)
+				restored, err := deletion.RestoredClusterWorkspace(cm)
+				require.NoError(t, err)
+
+				require.Nil(t, restored.DeletionTimestamp)
+				require.Empty(t, restored.Finalizers)
+				require.False(t, restored.Spec.ReadOnly)
+				require.Equal(t, &tenancyv1alpha1.ShardConstraints{Name: "shard-1"}, restored.Spec.Shard)
+				require.Equal(t, map[string]string{"app": "team"}, restored.Labels)
+				require.Equal(t, map[string]string{
+					logicalcluster.AnnotationKey:                              "root:org",
+					tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey: "uid-1",
+				}, restored.Annotations)
+				require.Empty(t, restored.Status)
+			},
+		},
+		{
+			name:             "restore persisted, finalized",
+			workspace:        restoreRequested,
+			cwt:              newType(&metav1.Duration{Duration: 24 * time.Hour}),
+			restoreConfigMap: newRestoreConfigMap(t, restoredClusterWorkspace(logicalcluster.From(restoreRequested), restoreRequested)),
+			wantActions:      []string{"update"},
+			validateActions: func(t *testing.T, actions, _ []clienttesting.Action) {
+				finalized := actions[0].(clienttesting.UpdateAction).GetObject().(*tenancyv1alpha1.ClusterWorkspace)
+				require.Empty(t, finalized.Finalizers)
+			},
+		},
+		{
+			name:             "restore persisted, retention period expired since, finalized",
+			workspace:        restoreRequested,
+			cwt:              newType(&metav1.Duration{Duration: 30 * time.Minute}),
+			restoreConfigMap: newRestoreConfigMap(t, restoredClusterWorkspace(logicalcluster.From(restoreRequested), restoreRequested)),
+			wantActions:      []string{"update"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			require.NoError(t, workspaceIndexer.Add(tt.workspace))
+			typeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tt.cwt != nil {
+				require.NoError(t, typeIndexer.Add(tt.cwt))
+			}
+
+			configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if tt.restoreConfigMap != nil {
+				require.NoError(t, configMapIndexer.Add(tt.restoreConfigMap))
+			}
+
+			kcpClient := kcpfakeclient.NewSimpleClientset(tt.workspace.DeepCopy())
+			kcpClient.PrependReactor("create", "clusterworkspaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				// the fake client does not delete the finalized workspace
+				return true, action.(clienttesting.CreateAction).GetObject(), nil
+			})
+			kubeClient := kubefakeclient.NewSimpleClientset()
+			metadataClient := metadatafake.NewSimpleMetadataClient(scheme)
+			deleter := &fakeDeleter{}
+
+			c := &Controller{
+				queue:                      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+				kcpClusterClient:           kcpClient,
+				kubeClusterClient:          kubeClient,
+				metadataClusterClient:      metadataClient,
+				workspaceLister:            tenancylisters.NewClusterWorkspaceLister(workspaceIndexer),
+				clusterWorkspaceTypeLister: tenancylisters.NewClusterWorkspaceTypeLister(typeIndexer),
+				configMapLister:            corelisters.NewConfigMapLister(configMapIndexer),
+				deleter:                    deleter,
+				now:                        func() time.Time { return now },
+			}
+			defer c.queue.ShutDown()
+
+			key, err := cache.MetaNamespaceKeyFunc(tt.workspace)
+			require.NoError(t, err)
+			require.NoError(t, c.process(context.Background(), key))
+
+			require.Equal(t, tt.wantPurged, deleter.called, "unexpected purge")
+			var verbs []string
+			for _, action := range kcpClient.Actions() {
+				verbs = append(verbs, action.GetVerb())
+			}
+			require.Equal(t, tt.wantActions, verbs)
+			var kubeVerbs []string
+			for _, action := range kubeClient.Actions() {
+				kubeVerbs = append(kubeVerbs, action.GetVerb())
+			}
+			require.Equal(t, tt.wantKubeActions, kubeVerbs)
+			if tt.validateActions != nil {
+				tt.validateActions(t, kcpClient.Actions(), kubeClient.Actions())
+			}
+
+			if tt.wantPurged {
+				// owner RBAC in the parent is removed together with the contents
+				require.Len(t, metadataClient.Actions(), 2)
+				for _, action := range metadataClient.Actions() {
+					require.Equal(t, "delete-collection", action.GetVerb())
+					require.Equal(t, tenancyv1alpha1.ClusterWorkspaceOwnerNameLabel+"=team", action.(clienttesting.DeleteCollectionAction).GetListRestrictions().Labels.String())
+				}
+			} else {
+				require.Empty(t, metadataClient.Actions())
+			}
+		})
+	}
+}
+
+// newRestoreConfigMap returns the pending restore ConfigMap of the given restored workspace as seen by the informer.
+func newRestoreConfigMap(t *testing.T, restored *tenancyv1alpha1.ClusterWorkspace) *corev1.ConfigMap {
+	cm, err := deletion.NewRestoreConfigMap(logicalcluster.From(restored), restored)
+	require.NoError(t, err)
+	cm.UID = "cm-uid"
+	cm.Annotations = map[string]string{logicalcluster.AnnotationKey: deletion.RestoreConfigMapCluster.String()}
+	return cm
+}
+
+func TestCreateRestored(t *testing.T) {
+	restored := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:                              "root:org",
+				tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey: "uid-1",
+			},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			Shard: &tenancyv1alpha1.ShardConstraints{Name: "shard-1"},
+		},
+	}
+	key, err := cache.MetaNamespaceKeyFunc(restored)
+	require.NoError(t, err)
+	cm := newRestoreConfigMap(t, restored)
+
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, configMapIndexer.Add(cm))
+	kcpClient := kcpfakeclient.NewSimpleClientset()
+	kubeClient := kubefakeclient.NewSimpleClientset(cm)
+	c := &Controller{
+		queue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		kcpClusterClient:  kcpClient,
+		kubeClusterClient: kubeClient,
+		workspaceLister:   tenancylisters.NewClusterWorkspaceLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})),
+		configMapLister:   corelisters.NewConfigMapLister(configMapIndexer),
+		now:               time.Now,
+	}
+	defer c.queue.ShutDown()
+
+	// the deleted workspace is gone, and created again from the pending restore
+	require.NoError(t, c.process(context.Background(), key))
+	require.Len(t, kcpClient.Actions(), 1)
+	require.Equal(t, "create", kcpClient.Actions()[0].GetVerb())
+	require.Equal(t, restored, kcpClient.Actions()[0].(clienttesting.CreateAction).GetObject())
+	require.Len(t, kubeClient.Actions(), 1)
+	require.Equal(t, "delete", kubeClient.Actions()[0].GetVerb())
+
+	// nothing left to do
+	require.NoError(t, configMapIndexer.Delete(cm))
+	require.NoError(t, c.process(context.Background(), key))
+	require.Len(t, kcpClient.Actions(), 1)
+	require.Len(t, kubeClient.Actions(), 1)
+}
+
+func TestCompleteRestore(t *testing.T) {
+	restored := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "team",
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:                              "root:org",
+				tenancyv1alpha1.ClusterWorkspaceRestoredFromAnnotationKey: "uid-1",
+			},
+		},
+	}
+	key, err := cache.MetaNamespaceKeyFunc(restored)
+	require.NoError(t, err)
+	cm := newRestoreConfigMap(t, restored)
+
+	// the restored workspace has been created, but the pending restore was not removed
+	workspaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, workspaceIndexer.Add(restored))
+	configMapIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, configMapIndexer.Add(cm))
+	kcpClient := kcpfakeclient.NewSimpleClientset()
+	kubeClient := kubefakeclient.NewSimpleClientset(cm)
+	c := &Controller{
+		queue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
+		kcpClusterClient:  kcpClient,
+		kubeClusterClient: kubeClient,
+		workspaceLister:   tenancylisters.NewClusterWorkspaceLister(workspaceIndexer),
+		configMapLister:   corelisters.NewConfigMapLister(configMapIndexer),
+		now:               time.Now,
+	}
+	defer c.queue.ShutDown()
+
+	require.NoError(t, c.process(context.Background(), key))
+	require.Empty(t, kcpClient.Actions())
+	require.Len(t, kubeClient.Actions(), 1)
+	require.Equal(t, "delete", kubeClient.Actions()[0].GetVerb())
+}
+
+func TestRestoredClusterWorkspaceKeepsShardSelector(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"region": "eu"}}
+	ws := &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team"},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			ReadOnly: true,
+			Shard:    &tenancyv1alpha1.ShardConstraints{Selector: selector},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Location: tenancyv1alpha1.ClusterWorkspaceLocation{Current: "shard-eu"},
+		},
+	}
+	conditions.MarkTrue(ws, tenancyv1alpha1.WorkspaceScheduled)
+
+	restored := restoredClusterWorkspace(logicalcluster.New("root:org"), ws)
+	require.Equal(t, logicalcluster.New("root:org"), logicalcluster.From(restored))
+	require.True(t, restored.Spec.ReadOnly, "read-only before deletion")
+	require.Equal(t, &tenancyv1alpha1.ShardConstraints{Name: "shard-eu", Selector: selector}, restored.Spec.Shard)
+	require.Empty(t, ws.Spec.Shard.Name, "original must not be mutated")
+	require.Empty(t, restored.Status.Conditions)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deletion
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/martinlindhe/base36"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	configshard "github.com/kcp-dev/kcp/config/shard"
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+)
+
+// A deleted ClusterWorkspace cannot be undeleted, hence a restore removes its finalizer without purging
+// the contents and creates it again. In between, the pending restore is persisted in a ConfigMap in the
+// system:shard logical cluster of the shard of the parent workspace. It holds the ClusterWorkspace to be
+// created, and no other ClusterWorkspace of that name may be created while it exists.
+const (
+	// RestoreConfigMapNamespace is the namespace of the pending restore ConfigMaps.
+	RestoreConfigMapNamespace = "default"
+	// RestoreConfigMapLabel marks ConfigMaps holding a pending restore.
+	RestoreConfigMapLabel = "internal.tenancy.kcp.dev/restore"
+
+	restoreConfigMapKey        = "clusterworkspace"
+	restoreConfigMapClusterKey = "cluster"
+)
+
+// RestoreConfigMapCluster is the logical cluster of the pending restore ConfigMaps.
+var RestoreConfigMapCluster = configshard.SystemShardCluster
+
+// RestoreConfigMapName returns the name of the ConfigMap holding the pending restore of the
+// ClusterWorkspace with the given name in the given logical cluster.
+func RestoreConfigMapName(clusterName logicalcluster.Name, name string) string {
+	hash := sha256.Sum224([]byte(clusterName.Join(name).String()))
+	base36hash := strings.ToLower(base36.EncodeBytes(hash[:]))
+	return fmt.Sprintf("clusterworkspace-restore-%s", base36hash[:12])
+}
+
+// NewRestoreConfigMap returns the ConfigMap holding the pending restore of the given ClusterWorkspace
+// in the given parent logical cluster.
+func NewRestoreConfigMap(parent logicalcluster.Name, restored *tenancyv1alpha1.ClusterWorkspace) (*corev1.ConfigMap, error) {
+	data, err := json.Marshal(restored)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: RestoreConfigMapNamespace,
+			Name:      RestoreConfigMapName(parent, restored.Name),
+			Labels: map[string]string{
+				RestoreConfigMapLabel: "true",
+			},
+		},
+		Data: map[string]string{
+			restoreConfigMapKey:        string(data),
+			restoreConfigMapClusterKey: parent.String(),
+		},
+	}, nil
+}
+
+// RestoredClusterWorkspace returns the ClusterWorkspace to be created by the pending restore in the given ConfigMap.
+// It belongs to the parent logical cluster recorded in the ConfigMap.
+func RestoredClusterWorkspace(cm *corev1.ConfigMap) (*tenancyv1alpha1.ClusterWorkspace, error) {
+	data, found := cm.Data[restoreConfigMapKey]
+	if !found {
+		return nil, fmt.Errorf("ConfigMap %s|%s/%s does not hold a restored ClusterWorkspace", logicalcluster.From(cm), cm.Namespace, cm.Name)
+	}
+	parent := cm.Data[restoreConfigMapClusterKey]
+	if parent == "" {
+		return nil, fmt.Errorf("ConfigMap %s|%s/%s does not hold the logical cluster of the restored ClusterWorkspace", logicalcluster.From(cm), cm.Namespace, cm.Name)
+	}
+	restored := &tenancyv1alpha1.ClusterWorkspace{}
+	if err := json.Unmarshal([]byte(data), restored); err != nil {
+		return nil, fmt.Errorf("failed to decode the restored ClusterWorkspace of ConfigMap %s|%s/%s: %w", logicalcluster.From(cm), cm.Namespace, cm.Name, err)
+	}
+	if restored.Annotations == nil {
+		restored.Annotations = map[string]string{}
+	}
+	restored.Annotations[logicalcluster.AnnotationKey] = parent
+	return restored, nil
+}
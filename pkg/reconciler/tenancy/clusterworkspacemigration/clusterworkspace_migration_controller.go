@@ -0,0 +1,467 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	tenancyinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/tenancy/v1alpha1"
+	tenancylisters "github.com/kcp-dev/kcp/pkg/client/listers/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemigration/migration"
+)
+
+const (
+	controllerName = "kcp-clusterworkspacemigration"
+
+	byTargetShard = "byTargetShard"
+
+	// stepTimeout bounds the time spent on one copy or deletion step.
+	stepTimeout = 30 * time.Second
+	// deletionRetryDelay is the time to wait for objects with finalizers on the previous shard
+	// before starting another deletion pass.
+	deletionRetryDelay = 5 * time.Second
+)
+
+// NewController returns a controller which moves the contents of workspaces from
+// status.location.current to status.location.target:
+//
+//  1. the workspace is made read-only through spec.readOnly,
+//  2. all objects of the logical cluster are copied to the target shard,
+//  3. status.location.current is switched to the target shard, which switches the front-proxy over,
+//     and the source shard is remembered in status.location.previous,
+//  4. the workspace is made writable again,
+//  5. all objects of the logical cluster are deleted from the previous shard.
+//
+// Copying and deleting is done in bounded steps, one per reconciliation. The position is persisted
+// in the migration progress annotation of the ClusterWorkspace after every step. Progress and failures
+// are reported through the WorkspaceMigrated condition.
+func NewController(
+	kcpClusterClient kcpclient.Interface,
+	workspaceInformer tenancyinformers.ClusterWorkspaceInformer,
+	clusterWorkspaceShardInformer tenancyinformers.ClusterWorkspaceShardInformer,
+	logicalClusterMigrator *migration.LogicalClusterMigrator,
+) (*Controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	shardLister := clusterWorkspaceShardInformer.Lister()
+	c := &Controller{
+		queue:            queue,
+		kcpClusterClient: kcpClusterClient,
+		workspaceIndexer: workspaceInformer.Informer().GetIndexer(),
+		workspaceLister:  workspaceInformer.Lister(),
+		getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+			return shardLister.Get(clusters.ToClusterAwareKey(tenancyv1alpha1.RootCluster, name))
+		},
+		copyLogicalCluster:   logicalClusterMigrator.Copy,
+		deleteLogicalCluster: logicalClusterMigrator.Delete,
+		now:                  time.Now,
+	}
+
+	if err := c.workspaceIndexer.AddIndexers(map[string]cache.IndexFunc{
+		byTargetShard: indexByTargetShard,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add indexer for ClusterWorkspace: %w", err)
+	}
+
+	workspaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch obj := obj.(type) {
+			case *tenancyv1alpha1.ClusterWorkspace:
+				return isMigrating(obj)
+			default:
+				return false
+			}
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	})
+
+	clusterWorkspaceShardInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) { c.enqueueShard(obj) },
+	})
+
+	return c, nil
+}
+
+// Controller migrates ClusterWorkspaces with a target location to the target shard.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kcpClusterClient kcpclient.Interface
+	workspaceIndexer cache.Indexer
+	workspaceLister  tenancylisters.ClusterWorkspaceLister
+
+	getShard             func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error)
+	copyLogicalCluster   func(ctx context.Context, clusterName logicalcluster.Name, from, to *tenancyv1alpha1.ClusterWorkspaceShard, progress migration.Progress) (migration.Progress, bool, error)
+	deleteLogicalCluster func(ctx context.Context, clusterName logicalcluster.Name, shard *tenancyv1alpha1.ClusterWorkspaceShard, progress migration.Progress) (migration.Progress, bool, error)
+
+	now func() time.Time
+}
+
+func isMigrating(workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	if workspace.Status.Location.Target != "" || workspace.Status.Location.Previous != "" {
+		return true
+	}
+	if _, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey]; found {
+		return true
+	}
+	_, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey]
+	return found
+}
+
+func indexByTargetShard(obj interface{}) ([]string, error) {
+	workspace := obj.(*tenancyv1alpha1.ClusterWorkspace)
+	if workspace.Status.Location.Target == "" {
+		return []string{}, nil
+	}
+	return []string{workspace.Status.Location.Target}, nil
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterWorkspace")
+	c.queue.Add(key)
+}
+
+// enqueueShard enqueues the workspaces waiting for a new shard to be migrated to.
+func (c *Controller) enqueueShard(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	_, name := clusters.SplitClusterAwareKey(key)
+	workspaces, err := c.workspaceIndexer.ByIndex(byTargetShard, name)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithReconciler(klog.Background(), controllerName)
+	for _, workspace := range workspaces {
+		key, err := cache.MetaNamespaceKeyFunc(workspace)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		logging.WithQueueKey(logger, key).V(2).Info("queueing ClusterWorkspace because of target shard creation", "clusterWorkspaceShard", name)
+		c.queue.Add(key)
+	}
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(func() { c.startWorker(ctx) }, time.Second, ctx.Done())
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	requeueAfter, err := c.process(ctx, key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	if requeueAfter > 0 {
+		c.queue.AddAfter(key, requeueAfter)
+	}
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) (time.Duration, error) {
+	workspace, err := c.workspaceLister.Get(key)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if !workspace.DeletionTimestamp.IsZero() {
+		// the contents are handled by the clusterworkspacedeletion controller now.
+		return 0, nil
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), workspace)
+	ctx = klog.NewContext(ctx, logger)
+
+	obj := workspace.DeepCopy()
+	requeueAfter, reconcileErr := c.reconcile(ctx, obj)
+
+	// the progress of a successful step must be persisted, even if the status update fails.
+	if err := c.updateIfNeeded(ctx, workspace, obj); err != nil {
+		return 0, utilerrors.NewAggregate([]error{reconcileErr, err})
+	}
+	return requeueAfter, reconcileErr
+}
+
+// updateIfNeeded writes the status first, and then metadata and spec of the given workspace.
+func (c *Controller) updateIfNeeded(ctx context.Context, old, obj *tenancyv1alpha1.ClusterWorkspace) error {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(old)
+	specOrObjectMetaChanged := !equality.Semantic.DeepEqual(old.Spec, obj.Spec) || !equality.Semantic.DeepEqual(old.ObjectMeta, obj.ObjectMeta)
+	statusChanged := !equality.Semantic.DeepEqual(old.Status, obj.Status)
+
+	if statusChanged {
+		logger.V(2).Info("updating ClusterWorkspace status")
+		updated, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().UpdateStatus(logicalcluster.WithCluster(ctx, clusterName), obj, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to update status of ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+		}
+		obj = obj.DeepCopy()
+		obj.ResourceVersion = updated.ResourceVersion
+	}
+	if specOrObjectMetaChanged {
+		logger.V(2).Info("updating ClusterWorkspace")
+		if _, err := c.kcpClusterClient.TenancyV1alpha1().ClusterWorkspaces().Update(logicalcluster.WithCluster(ctx, clusterName), obj, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update ClusterWorkspace %s|%s: %w", clusterName, obj.Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcile advances the migration of the given workspace by one step. It returns the time after
+// which to look at the workspace again, if no update is expected before.
+func (c *Controller) reconcile(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	location := &workspace.Status.Location
+
+	switch {
+	case location.Target != "" && location.Target == location.Current:
+		location.Target = ""
+		delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey)
+		return 0, nil
+	case location.Target != "" && location.Previous == "":
+		return c.migrate(ctx, workspace)
+	case workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey] != "":
+		// the workspace is served from the target shard now.
+		workspace.Spec.ReadOnly = false
+		delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey)
+		return 0, nil
+	case location.Previous != "":
+		return c.deleteSource(ctx, workspace)
+	}
+
+	delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey)
+	return 0, nil
+}
+
+func (c *Controller) migrate(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	logger := klog.FromContext(ctx)
+	location := &workspace.Status.Location
+
+	if workspace.Status.Phase != tenancyv1alpha1.ClusterWorkspacePhaseReady {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonNotReady, conditionsv1alpha1.ConditionSeverityInfo, "Waiting for the workspace to become ready before migrating to ClusterWorkspaceShard %q.", location.Target)
+		return 0, nil
+	}
+
+	target, err := c.getShard(location.Target)
+	if apierrors.IsNotFound(err) {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonTargetShardNotFound, conditionsv1alpha1.ConditionSeverityError, "Target ClusterWorkspaceShard %q does not exist.", location.Target)
+		return 0, nil // retry is automatic when new shards show up
+	} else if err != nil {
+		return 0, err
+	}
+	current, err := c.getShard(location.Current)
+	if apierrors.IsNotFound(err) {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceShardValidReasonShardNotFound, conditionsv1alpha1.ConditionSeverityError, "Current ClusterWorkspaceShard %q does not exist, cannot copy from it.", location.Current)
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	if !workspace.Spec.ReadOnly {
+		// From here on, writes to the workspace on the current shard are rejected by admission.
+		logger.Info("making workspace read-only for migration", "from", current.Name, "to", target.Name)
+		workspace.Spec.ReadOnly = true
+		if workspace.Annotations == nil {
+			workspace.Annotations = map[string]string{}
+		}
+		workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey] = "true"
+		return 0, nil
+	}
+
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonCopying, conditionsv1alpha1.ConditionSeverityInfo, "Copying objects from ClusterWorkspaceShard %q to %q.", current.Name, target.Name)
+
+	clusterName := logicalcluster.From(workspace).Join(workspace.Name)
+	progress := getProgress(ctx, workspace, migration.StageCopy, migration.StageOwnerReferences)
+	stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+	defer cancel()
+	next, done, err := c.copyLogicalCluster(stepCtx, clusterName, current, target, progress)
+	if err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonCopyFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to copy objects from ClusterWorkspaceShard %q to %q: %v", current.Name, target.Name, err)
+		return 0, err
+	}
+	if !done {
+		return 0, setProgress(workspace, next)
+	}
+
+	u, err := url.Parse(target.Spec.ExternalURL)
+	if err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonCopyFailed, conditionsv1alpha1.ConditionSeverityError, "Invalid connection information on target ClusterWorkspaceShard: %v.", err)
+		return 0, err
+	}
+	u.Path = path.Join(u.Path, clusterName.Path())
+
+	// switch over. The front-proxy follows status.location.current.
+	logger.Info("switching workspace to shard", "from", current.Name, "to", target.Name, "copied", next.Count)
+	workspace.Status.BaseURL = u.String()
+	location.Previous = location.Current
+	location.Current = location.Target
+	location.Target = ""
+	delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey)
+
+	conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonDeletingSource, conditionsv1alpha1.ConditionSeverityInfo, "Copied %d objects to ClusterWorkspaceShard %q, deleting objects from %q.", next.Count, location.Current, location.Previous)
+	return 0, nil
+}
+
+func (c *Controller) deleteSource(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace) (time.Duration, error) {
+	logger := klog.FromContext(ctx)
+	location := &workspace.Status.Location
+
+	previous, err := c.getShard(location.Previous)
+	if apierrors.IsNotFound(err) {
+		logger.Info("previous shard of migrated workspace does not exist anymore", "ClusterWorkspaceShard", location.Previous)
+		location.Previous = ""
+		delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey)
+		conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceMigrated)
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	progress := getProgress(ctx, workspace, migration.StageDelete)
+	if progress.RetryAfter != nil {
+		if remaining := progress.RetryAfter.Sub(c.now()); remaining > 0 {
+			return remaining, nil
+		}
+	}
+
+	clusterName := logicalcluster.From(workspace).Join(workspace.Name)
+	stepCtx, cancel := context.WithTimeout(ctx, stepTimeout)
+	defer cancel()
+	next, done, err := c.deleteLogicalCluster(stepCtx, clusterName, previous, progress)
+	if err != nil {
+		conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonDeleteFailed, conditionsv1alpha1.ConditionSeverityWarning, "Failed to delete objects from ClusterWorkspaceShard %q: %v", previous.Name, err)
+		return 0, err
+	}
+	if !done {
+		if next.Resource == "" && next.Count > 0 {
+			// a whole pass is done, but objects are left, e.g. because of finalizers. The controller
+			// does not watch the previous shard, so look again after a delay.
+			conditions.MarkFalse(workspace, tenancyv1alpha1.WorkspaceMigrated, tenancyv1alpha1.WorkspaceMigrationReasonDeletingSource, conditionsv1alpha1.ConditionSeverityInfo, "Waiting for %d objects to be deleted from ClusterWorkspaceShard %q.", next.Count, previous.Name)
+			retryAfter := metav1.NewTime(c.now().Add(deletionRetryDelay))
+			next.RetryAfter = &retryAfter
+		}
+		return 0, setProgress(workspace, next)
+	}
+
+	logger.Info("finished migration of workspace", "from", previous.Name, "to", location.Current)
+	location.Previous = ""
+	delete(workspace.Annotations, tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey)
+	conditions.MarkTrue(workspace, tenancyv1alpha1.WorkspaceMigrated)
+	return 0, nil
+}
+
+// getProgress returns the persisted migration progress of the workspace if it belongs to one of the
+// given stages. Otherwise, e.g. for the progress of a previous stage, the stage starts from scratch.
+func getProgress(ctx context.Context, workspace *tenancyv1alpha1.ClusterWorkspace, stages ...migration.Stage) migration.Progress {
+	value, found := workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey]
+	if !found {
+		return migration.Progress{}
+	}
+	var progress migration.Progress
+	if err := json.Unmarshal([]byte(value), &progress); err != nil {
+		klog.FromContext(ctx).Error(err, "ignoring invalid migration progress annotation", "value", value)
+		return migration.Progress{}
+	}
+	for _, stage := range stages {
+		if progress.Stage == stage {
+			return progress
+		}
+	}
+	return migration.Progress{}
+}
+
+func setProgress(workspace *tenancyv1alpha1.ClusterWorkspace, progress migration.Progress) error {
+	bs, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	if workspace.Annotations == nil {
+		workspace.Annotations = map[string]string{}
+	}
+	workspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey] = string(bs)
+	return nil
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspacemigration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	conditionsapi "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemigration/migration"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2022, 9, 1, 12, 0, 0, 0, time.UTC)
+	later := metav1.NewTime(now.Add(deletionRetryDelay))
+	earlier := metav1.NewTime(now.Add(-time.Second))
+
+	shards := []*tenancyv1alpha1.ClusterWorkspaceShard{
+		withURLs("https://root", "https://front-proxy", shard("root")),
+		withURLs("https://foo", "https://front-proxy-foo", shard("foo")),
+	}
+
+	tests := []struct {
+		name      string
+		workspace *tenancyv1alpha1.ClusterWorkspace
+
+		stepProgress migration.Progress
+		stepDone     bool
+		stepErr      error
+
+		want             *tenancyv1alpha1.ClusterWorkspace
+		wantCopied       *migration.Progress
+		wantDeleted      *migration.Progress
+		wantRequeueAfter time.Duration
+		wantErr          bool
+	}{
+		{
+			name:      "no target",
+			workspace: phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())),
+			want:      phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())),
+		},
+		{
+			name:      "target is current",
+			workspace: targeted("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+			want:      phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())),
+		},
+		{
+			name:      "waits for ready",
+			workspace: targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseInitializing, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+			want: withConditions(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseInitializing, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonNotReady),
+			),
+		},
+		{
+			name:      "target shard not found",
+			workspace: targeted("bar", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+			want: withConditions(targeted("bar", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+				migratedCondition(conditionsapi.ConditionSeverityError, tenancyv1alpha1.WorkspaceMigrationReasonTargetShardNotFound),
+			),
+		},
+		{
+			name:      "workspace is made read-only first",
+			workspace: targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))),
+			want:      migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))))),
+		},
+		{
+			name:         "first copy step persists the progress",
+			workspace:    migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace()))))),
+			stepProgress: migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100},
+			want: withConditions(withProgress(migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonCopying),
+			),
+			wantCopied: &migration.Progress{},
+		},
+		{
+			name:         "copy continues from the persisted progress",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+			stepProgress: migration.Progress{Stage: migration.StageOwnerReferences, Count: 142},
+			want: withConditions(withProgress(migration.Progress{Stage: migration.StageOwnerReferences, Count: 142}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonCopying),
+			),
+			wantCopied: &migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100},
+		},
+		{
+			name:         "copy ignores the progress of another stage",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 3}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+			stepProgress: migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100},
+			want: withConditions(withProgress(migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonCopying),
+			),
+			wantCopied: &migration.Progress{},
+		},
+		{
+			name:         "copied workspace is switched over",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageOwnerReferences, Count: 142}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+			stepProgress: migration.Progress{Stage: migration.StageOwnerReferences, Count: 142},
+			stepDone:     true,
+			want: withConditions(previous("root", migrationReadOnly(readOnly(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonDeletingSource),
+			),
+			wantCopied: &migration.Progress{Stage: migration.StageOwnerReferences, Count: 142},
+		},
+		{
+			name:      "copy step fails",
+			workspace: withProgress(migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+			stepErr:   errors.New("boom"),
+			want: withConditions(withProgress(migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100}, migrationReadOnly(readOnly(targeted("foo", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("root", "https://front-proxy/clusters/root:org:workspace", workspace())))))),
+				migratedCondition(conditionsapi.ConditionSeverityWarning, tenancyv1alpha1.WorkspaceMigrationReasonCopyFailed),
+			),
+			wantCopied: &migration.Progress{Stage: migration.StageCopy, Resource: "/v1, Resource=secrets", Count: 100},
+			wantErr:    true,
+		},
+		{
+			name:      "workspace is made writable after switching over",
+			workspace: previous("root", migrationReadOnly(readOnly(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))))),
+			want:      previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+		},
+		{
+			name:         "deletion step persists the progress",
+			workspace:    previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+			stepProgress: migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 100},
+			want:         withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 100}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			wantDeleted:  &migration.Progress{},
+		},
+		{
+			name:         "objects remaining on previous shard after a deletion pass",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 100}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			stepProgress: migration.Progress{Stage: migration.StageDelete, Count: 3},
+			want: withConditions(withProgress(migration.Progress{Stage: migration.StageDelete, Count: 3, RetryAfter: &later}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+				migratedCondition(conditionsapi.ConditionSeverityInfo, tenancyv1alpha1.WorkspaceMigrationReasonDeletingSource),
+			),
+			wantDeleted: &migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 100},
+		},
+		{
+			name:             "next deletion pass waits",
+			workspace:        withProgress(migration.Progress{Stage: migration.StageDelete, Count: 3, RetryAfter: &later}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			want:             withProgress(migration.Progress{Stage: migration.StageDelete, Count: 3, RetryAfter: &later}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			wantRequeueAfter: deletionRetryDelay,
+		},
+		{
+			name:         "next deletion pass starts after waiting",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageDelete, Count: 3, RetryAfter: &earlier}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			stepProgress: migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 1},
+			want:         withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets", Count: 1}, previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			wantDeleted:  &migration.Progress{Stage: migration.StageDelete, Count: 3, RetryAfter: &earlier},
+		},
+		{
+			name:      "deletion from previous shard fails",
+			workspace: previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+			stepErr:   errors.New("boom"),
+			want: withConditions(previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+				migratedCondition(conditionsapi.ConditionSeverityWarning, tenancyv1alpha1.WorkspaceMigrationReasonDeleteFailed),
+			),
+			wantDeleted: &migration.Progress{},
+			wantErr:     true,
+		},
+		{
+			name:         "migration finished, user requested read-only is kept",
+			workspace:    withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets"}, readOnly(previous("root", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))))),
+			stepProgress: migration.Progress{Stage: migration.StageDelete},
+			stepDone:     true,
+			want: withConditions(readOnly(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceMigrated,
+					Status: corev1.ConditionTrue,
+				},
+			),
+			wantDeleted: &migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets"},
+		},
+		{
+			name:      "previous shard does not exist anymore",
+			workspace: withProgress(migration.Progress{Stage: migration.StageDelete, Resource: "/v1, Resource=secrets"}, previous("bar", phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())))),
+			want: withConditions(phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())),
+				conditionsapi.Condition{
+					Type:   tenancyv1alpha1.WorkspaceMigrated,
+					Status: corev1.ConditionTrue,
+				},
+			),
+		},
+		{
+			name:      "left-over progress is removed",
+			workspace: withProgress(migration.Progress{Stage: migration.StageCopy}, phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace()))),
+			want:      phase(tenancyv1alpha1.ClusterWorkspacePhaseReady, scheduled("foo", "https://front-proxy-foo/clusters/root:org:workspace", workspace())),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var copied, deleted *migration.Progress
+			c := &Controller{
+				getShard: func(name string) (*tenancyv1alpha1.ClusterWorkspaceShard, error) {
+					for _, shard := range shards {
+						if shard.Name == name {
+							return shard, nil
+						}
+					}
+					return nil, apierrors.NewNotFound(tenancyv1alpha1.Resource("clusterworkspaceshard"), name)
+				},
+				copyLogicalCluster: func(ctx context.Context, clusterName logicalcluster.Name, from, to *tenancyv1alpha1.ClusterWorkspaceShard, progress migration.Progress) (migration.Progress, bool, error) {
+					if clusterName != logicalcluster.New("root:org:workspace") {
+						t.Errorf("unexpected logical cluster %s", clusterName)
+					}
+					if _, found := ctx.Deadline(); !found {
+						t.Errorf("expected copy step to be bounded by a deadline")
+					}
+					copied = &progress
+					return tt.stepProgress, tt.stepDone, tt.stepErr
+				},
+				deleteLogicalCluster: func(ctx context.Context, clusterName logicalcluster.Name, shard *tenancyv1alpha1.ClusterWorkspaceShard, progress migration.Progress) (migration.Progress, bool, error) {
+					if shard.Name != "root" {
+						t.Errorf("unexpected deletion from shard %q", shard.Name)
+					}
+					deleted = &progress
+					return tt.stepProgress, tt.stepDone, tt.stepErr
+				},
+				now: func() time.Time { return now },
+			}
+
+			ws := tt.workspace.DeepCopy()
+			if ws.Annotations == nil {
+				ws.Annotations = map[string]string{}
+			}
+			ws.Annotations[logicalcluster.AnnotationKey] = "root:org"
+			requeueAfter, err := c.reconcile(context.Background(), ws)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if requeueAfter != tt.wantRequeueAfter {
+				t.Errorf("unexpected requeue: got = %v, want %v", requeueAfter, tt.wantRequeueAfter)
+			}
+			if diff := cmp.Diff(tt.wantCopied, copied); diff != "" {
+				t.Errorf("unexpected copy progress (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeleted, deleted); diff != "" {
+				t.Errorf("unexpected deletion progress (-want +got):\n%s", diff)
+			}
+
+			// prune conditions for easier comparison
+			delete(ws.Annotations, logicalcluster.AnnotationKey)
+			if len(ws.Annotations) == 0 {
+				ws.Annotations = nil
+			}
+			for i := range ws.Status.Conditions {
+				ws.Status.Conditions[i].LastTransitionTime = metav1.Time{}
+				ws.Status.Conditions[i].Message = ""
+			}
+
+			if diff := cmp.Diff(tt.want, ws); diff != "" {
+				t.Errorf("unexpected workspace (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func workspace() *tenancyv1alpha1.ClusterWorkspace {
+	return &tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "workspace",
+		},
+	}
+}
+
+func phase(phase tenancyv1alpha1.ClusterWorkspacePhaseType, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Phase = phase
+	return ws
+}
+
+func scheduled(shard string, baseURL string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Current = shard
+	ws.Status.BaseURL = baseURL
+	return ws
+}
+
+func targeted(shard string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Target = shard
+	return ws
+}
+
+func previous(shard string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Location.Previous = shard
+	return ws
+}
+
+func readOnly(ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Spec.ReadOnly = true
+	return ws
+}
+
+func migrationReadOnly(ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	return withAnnotation(tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey, "true", ws)
+}
+
+func withProgress(progress migration.Progress, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	bs, err := json.Marshal(progress)
+	if err != nil {
+		panic(err)
+	}
+	return withAnnotation(tenancyv1alpha1.ClusterWorkspaceMigrationProgressAnnotationKey, string(bs), ws)
+}
+
+func withAnnotation(key, value string, ws *tenancyv1alpha1.ClusterWorkspace) *tenancyv1alpha1.ClusterWorkspace {
+	if ws.Annotations == nil {
+		ws.Annotations = map[string]string{}
+	}
+	ws.Annotations[key] = value
+	return ws
+}
+
+func withConditions(ws *tenancyv1alpha1.ClusterWorkspace, conditions ...conditionsapi.Condition) *tenancyv1alpha1.ClusterWorkspace {
+	ws.Status.Conditions = append(ws.Status.Conditions, conditions...)
+	return ws
+}
+
+func migratedCondition(severity conditionsapi.ConditionSeverity, reason string) conditionsapi.Condition {
+	return conditionsapi.Condition{
+		Type:     tenancyv1alpha1.WorkspaceMigrated,
+		Severity: severity,
+		Status:   corev1.ConditionFalse,
+		Reason:   reason,
+	}
+}
+
+func shard(name string) *tenancyv1alpha1.ClusterWorkspaceShard {
+	return &tenancyv1alpha1.ClusterWorkspaceShard{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+func withURLs(baseURL, externalURL string, shard *tenancyv1alpha1.ClusterWorkspaceShard) *tenancyv1alpha1.ClusterWorkspaceShard {
+	shard.Spec.BaseURL = baseURL
+	shard.Spec.ExternalURL = externalURL
+	return shard
+}
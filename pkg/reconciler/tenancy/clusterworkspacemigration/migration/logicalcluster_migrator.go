@@ -0,0 +1,505 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/projection"
+)
+
+// ShardConfigFunc returns a rest config with admin credentials for the given shard.
+type ShardConfigFunc func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (*rest.Config, error)
+
+// Stage is a stage of the migration of a logical cluster between shards.
+type Stage string
+
+const (
+	// StageCopy creates the objects of the logical cluster on the target shard.
+	StageCopy Stage = "Copy"
+	// StageOwnerReferences rewrites the owner references of the copied objects to the new UIDs.
+	StageOwnerReferences Stage = "OwnerReferences"
+	// StageDelete deletes the objects of the logical cluster from the previous shard.
+	StageDelete Stage = "Delete"
+)
+
+// pageSize is the maximal number of objects processed in one step.
+const pageSize = 100
+
+// Progress is the position of a copy or deletion of a logical cluster. It is persisted between
+// steps, so that a migration continues where it stopped, e.g. after a restart.
+type Progress struct {
+	Stage Stage `json:"stage"`
+
+	// Resource is the resource processed next, empty to start a pass over all resources.
+	Resource string `json:"resource,omitempty"`
+	// Continue is the continue token of the next page of objects of Resource.
+	Continue string `json:"continue,omitempty"`
+	// Count is the number of objects created on the target shard, or the number of objects
+	// found on the previous shard during the current deletion pass.
+	Count int `json:"count,omitempty"`
+	// RetryAfter delays the next deletion pass when objects were left, e.g. because of finalizers.
+	RetryAfter *metav1.Time `json:"retryAfter,omitempty"`
+}
+
+// skippedResources are not copied to the target shard, either because they are projections of
+// other resources, or because they are transient.
+var skippedResources = sets.NewString(
+	"workspaces.tenancy.kcp.dev",
+	"events",
+	"events.events.k8s.io",
+)
+
+// LogicalClusterMigrator copies the objects of a logical cluster from one shard to another,
+// and deletes them from a shard afterwards. Both are done in steps of one page of objects of
+// one resource, which are continued from the Progress returned by the previous step.
+//
+// The logical cluster is expected to be read-only while being copied. Objects are created with
+// new UIDs on the target shard, and owner references are rewritten to the new UIDs once all
+// objects are copied. Owner references to objects that were not copied are dropped.
+type LogicalClusterMigrator struct {
+	shardConfig ShardConfigFunc
+}
+
+// NewLogicalClusterMigrator returns a LogicalClusterMigrator talking to shards with the given configs.
+func NewLogicalClusterMigrator(shardConfig ShardConfigFunc) *LogicalClusterMigrator {
+	return &LogicalClusterMigrator{
+		shardConfig: shardConfig,
+	}
+}
+
+type clients struct {
+	dynamic   dynamic.Interface
+	discovery discovery.DiscoveryInterface
+}
+
+func (m *LogicalClusterMigrator) clientsFor(shard *tenancyv1alpha1.ClusterWorkspaceShard, clusterName logicalcluster.Name) (*clients, error) {
+	config, err := m.shardConfig(shard)
+	if err != nil {
+		return nil, err
+	}
+	config = rest.CopyConfig(config)
+	config.Host += clusterName.Path()
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &clients{dynamic: dynamicClient, discovery: discoveryClient}, nil
+}
+
+// resources returns the copyable resources of the logical cluster on the shard of the given clients.
+func (c *clients) resources(clusterName logicalcluster.Name, shard *tenancyv1alpha1.ClusterWorkspaceShard) ([]resource, error) {
+	// a partial discovery result is not good enough, we would silently lose objects.
+	resourceLists, err := c.discovery.ServerPreferredResources()
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover resources of logical cluster %s on shard %q: %w", clusterName, shard.Name, err)
+	}
+	return copyableResources(resourceLists)
+}
+
+// Copy copies one page of objects of the logical cluster from one shard to another, or rewrites
+// the owner references of one page of copied objects. Objects already existing on the target shard
+// are kept. It returns the progress to continue with, and whether the copy is complete. Steps
+// are idempotent, and a failed step is expected to be retried with the same progress.
+func (m *LogicalClusterMigrator) Copy(ctx context.Context, clusterName logicalcluster.Name, from, to *tenancyv1alpha1.ClusterWorkspaceShard, progress Progress) (Progress, bool, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "copy", "from", from.Name, "to", to.Name)
+
+	source, err := m.clientsFor(from, clusterName)
+	if err != nil {
+		return progress, false, err
+	}
+	target, err := m.clientsFor(to, clusterName)
+	if err != nil {
+		return progress, false, err
+	}
+	resources, err := source.resources(clusterName, from)
+	if err != nil {
+		return progress, false, err
+	}
+
+	if progress.Stage != StageOwnerReferences {
+		progress.Stage = StageCopy
+	}
+	i, list, err := nextPage(ctx, source, resources, progress)
+	if err != nil {
+		return progress, false, err
+	}
+
+	var errs []error
+	created := 0
+	if list != nil {
+		r := resources[i]
+		for j := range list.Items {
+			item := &list.Items[j]
+			if item.GetDeletionTimestamp() != nil {
+				continue
+			}
+			client := target.dynamic.Resource(r.gvr).Namespace(item.GetNamespace())
+
+			if progress.Stage == StageOwnerReferences {
+				if len(item.GetOwnerReferences()) == 0 {
+					continue
+				}
+				if err := updateOwnerReferences(ctx, target, resources, client, item); err != nil {
+					errs = append(errs, fmt.Errorf("failed to update owner references of %s %s: %w", r.gvr, objectName(item), err))
+				}
+				continue
+			}
+
+			obj, err := client.Create(ctx, prepareForCopy(item), metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				continue
+			} else if err == nil && r.hasStatus {
+				_, err = copyStatus(ctx, client, item, obj)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to copy %s %s: %w", r.gvr, objectName(item), err))
+				continue
+			}
+			created++
+		}
+	}
+	if len(errs) > 0 {
+		return progress, false, utilerrors.NewAggregate(errs)
+	}
+
+	next, passDone := advance(progress, resources, i, list)
+	next.Count += created
+	logger.V(2).Info("copied page of logical cluster", "stage", progress.Stage, "resource", progress.Resource, "created", created)
+	if !passDone {
+		return next, false, nil
+	}
+	if progress.Stage == StageCopy {
+		return Progress{Stage: StageOwnerReferences, Count: next.Count}, false, nil
+	}
+	return next, true, nil
+}
+
+// Delete deletes one page of objects of the logical cluster from the given shard. It returns the
+// progress to continue with, and whether a whole pass over all resources found no objects left.
+// When objects are left at the end of a pass, e.g. because they have finalizers, the returned
+// progress starts a new pass and holds the number of objects found.
+func (m *LogicalClusterMigrator) Delete(ctx context.Context, clusterName logicalcluster.Name, shard *tenancyv1alpha1.ClusterWorkspaceShard, progress Progress) (Progress, bool, error) {
+	logger := klog.FromContext(ctx).WithValues("operation", "delete", "shard", shard.Name)
+
+	c, err := m.clientsFor(shard, clusterName)
+	if err != nil {
+		return progress, false, err
+	}
+	resources, err := c.resources(clusterName, shard)
+	if err != nil {
+		return progress, false, err
+	}
+
+	progress.Stage = StageDelete
+	progress.RetryAfter = nil
+	if progress.Resource == "" {
+		progress.Count = 0
+	}
+	i, list, err := nextPage(ctx, c, resources, progress)
+	if err != nil {
+		return progress, false, err
+	}
+
+	var errs []error
+	found := 0
+	if list != nil {
+		r := resources[i]
+		for j := range list.Items {
+			item := &list.Items[j]
+			found++
+			if item.GetDeletionTimestamp() != nil {
+				continue
+			}
+
+			client := c.dynamic.Resource(r.gvr).Namespace(item.GetNamespace())
+			if r.gvr.GroupResource() == tenancyv1alpha1.Resource("clusterworkspaces") && len(item.GetFinalizers()) > 0 {
+				// child workspaces were copied to the target shard as well. Their contents must survive,
+				// so skip the workspace deletion finalizer.
+				patch := []byte(fmt.Sprintf(`{"metadata":{"finalizers":null,"resourceVersion":%q}}`, item.GetResourceVersion()))
+				if _, err := client.Patch(ctx, item.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil && !apierrors.IsNotFound(err) {
+					errs = append(errs, fmt.Errorf("failed to remove finalizers of %s %s: %w", r.gvr, objectName(item), err))
+					continue
+				}
+			}
+
+			background := metav1.DeletePropagationBackground
+			if err := client.Delete(ctx, item.GetName(), metav1.DeleteOptions{PropagationPolicy: &background}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", r.gvr, objectName(item), err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return progress, false, utilerrors.NewAggregate(errs)
+	}
+
+	next, passDone := advance(progress, resources, i, list)
+	next.Count += found
+	logger.V(2).Info("deleted page of logical cluster", "resource", progress.Resource, "found", found)
+	if !passDone {
+		return next, false, nil
+	}
+	if next.Count > 0 {
+		return Progress{Stage: StageDelete, Count: next.Count}, false, nil
+	}
+	return next, true, nil
+}
+
+// nextPage lists the page of objects to be processed next according to the progress. It returns
+// the index of the listed resource, and a nil list if there are no resources at all.
+func nextPage(ctx context.Context, c *clients, resources []resource, progress Progress) (int, *unstructured.UnstructuredList, error) {
+	if len(resources) == 0 {
+		return 0, nil, nil
+	}
+
+	i, continueToken := 0, ""
+	for j := range resources {
+		if resources[j].gvr.String() == progress.Resource {
+			// a resource that is gone since the last step starts a new pass. Objects
+			// are copied and deleted idempotently.
+			i, continueToken = j, progress.Continue
+			break
+		}
+	}
+
+	client := c.dynamic.Resource(resources[i].gvr)
+	list, err := client.List(ctx, metav1.ListOptions{Limit: pageSize, Continue: continueToken})
+	if apierrors.IsResourceExpired(err) {
+		// the continue token has expired. Start over with this resource.
+		list, err = client.List(ctx, metav1.ListOptions{Limit: pageSize})
+	}
+	if err != nil {
+		return i, nil, fmt.Errorf("failed to list %s: %w", resources[i].gvr, err)
+	}
+	return i, list, nil
+}
+
+// advance returns the progress after the given page of the i-th resource has been processed, and
+// whether the pass over all resources is done.
+func advance(progress Progress, resources []resource, i int, list *unstructured.UnstructuredList) (Progress, bool) {
+	next := Progress{Stage: progress.Stage, Count: progress.Count}
+	switch {
+	case list == nil:
+		return next, true
+	case list.GetContinue() != "":
+		next.Resource, next.Continue = resources[i].gvr.String(), list.GetContinue()
+	case i+1 < len(resources):
+		next.Resource = resources[i+1].gvr.String()
+	default:
+		return next, true
+	}
+	return next, false
+}
+
+type resource struct {
+	gvr        schema.GroupVersionResource
+	kind       string
+	namespaced bool
+	hasStatus  bool
+}
+
+// copyableResources returns the resources that can be copied, in the order they have to be created:
+// first the resources defining APIs, then namespaces, then other cluster-scoped resources, and then
+// namespaced resources.
+func copyableResources(resourceLists []*metav1.APIResourceList) ([]resource, error) {
+	var resources []resource
+	status := sets.NewString()
+	for _, rl := range resourceLists {
+		gv, err := schema.ParseGroupVersion(rl.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rl.APIResources {
+			if strings.HasSuffix(r.Name, "/status") {
+				status.Insert(gv.WithResource(strings.TrimSuffix(r.Name, "/status")).String())
+				continue
+			}
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			if projection.Includes(gvr) || skippedResources.Has(gvr.GroupResource().String()) {
+				continue
+			}
+			if !sets.NewString(r.Verbs...).HasAll("list", "get", "create", "delete") {
+				continue
+			}
+			resources = append(resources, resource{gvr: gvr, kind: r.Kind, namespaced: r.Namespaced})
+		}
+	}
+
+	for i := range resources {
+		resources[i].hasStatus = status.Has(resources[i].gvr.String())
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		if pi, pj := creationPriority(resources[i]), creationPriority(resources[j]); pi != pj {
+			return pi < pj
+		}
+		return resources[i].gvr.String() < resources[j].gvr.String()
+	})
+
+	return resources, nil
+}
+
+func creationPriority(r resource) int {
+	switch r.gvr.GroupResource().String() {
+	case "customresourcedefinitions.apiextensions.k8s.io", "apibindings.apis.kcp.dev":
+		return 0
+	case "namespaces":
+		return 1
+	}
+	if !r.namespaced {
+		return 2
+	}
+	return 3
+}
+
+// prepareForCopy returns a copy of the object that can be created on another shard.
+func prepareForCopy(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetResourceVersion("")
+	obj.SetUID("")
+	obj.SetSelfLink("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetOwnerReferences(nil)
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, logicalcluster.AnnotationKey)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	obj.SetAnnotations(annotations)
+
+	return obj
+}
+
+func copyStatus(ctx context.Context, client dynamic.ResourceInterface, source, created *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	status, found, err := unstructured.NestedFieldCopy(source.Object, "status")
+	if err != nil || !found {
+		return created, err
+	}
+	created = created.DeepCopy()
+	if err := unstructured.SetNestedField(created.Object, status, "status"); err != nil {
+		return nil, err
+	}
+	return client.UpdateStatus(ctx, created, metav1.UpdateOptions{})
+}
+
+// updateOwnerReferences sets the owner references of the copy of the given source object, with the
+// UIDs of the copied owners.
+func updateOwnerReferences(ctx context.Context, target *clients, resources []resource, client dynamic.ResourceInterface, source *unstructured.Unstructured) error {
+	obj, err := client.Get(ctx, source.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// not copied, e.g. because it was being deleted.
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	refs, err := mapOwnerReferences(source.GetOwnerReferences(), func(ref metav1.OwnerReference) (types.UID, error) {
+		gk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind()
+		for _, r := range resources {
+			if r.gvr.Group != gk.Group || r.kind != gk.Kind {
+				continue
+			}
+			namespace := ""
+			if r.namespaced {
+				namespace = source.GetNamespace()
+			}
+			owner, err := target.dynamic.Resource(r.gvr).Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return "", nil
+			} else if err != nil {
+				return "", err
+			}
+			return owner.GetUID(), nil
+		}
+		return "", nil
+	})
+	if err != nil {
+		return err
+	}
+	if equalOwnerReferences(obj.GetOwnerReferences(), refs) {
+		return nil
+	}
+	obj.SetOwnerReferences(refs)
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+// mapOwnerReferences rewrites the UIDs of the owner references with the UIDs returned by uidOf.
+// References to owners that have not been copied, i.e. with an empty UID, are dropped, because the
+// garbage collector would delete the object otherwise.
+func mapOwnerReferences(refs []metav1.OwnerReference, uidOf func(ref metav1.OwnerReference) (types.UID, error)) ([]metav1.OwnerReference, error) {
+	var mapped []metav1.OwnerReference
+	for _, ref := range refs {
+		uid, err := uidOf(ref)
+		if err != nil {
+			return nil, err
+		}
+		if uid == "" {
+			continue
+		}
+		ref.UID = uid
+		mapped = append(mapped, ref)
+	}
+	return mapped, nil
+}
+
+func equalOwnerReferences(a, b []metav1.OwnerReference) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].UID != b[i].UID || a[i].Name != b[i].Name || a[i].Kind != b[i].Kind || a[i].APIVersion != b[i].APIVersion {
+			return false
+		}
+	}
+	return true
+}
+
+func objectName(obj metav1.Object) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns + "/" + obj.GetName()
+	}
+	return obj.GetName()
+}
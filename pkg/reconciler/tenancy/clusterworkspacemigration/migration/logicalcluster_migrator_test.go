@@ -0,0 +1,194 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCopyableResources(t *testing.T) {
+	allVerbs := metav1.Verbs{"create", "delete", "get", "list", "watch"}
+	resources, err := copyableResources([]*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Verbs: allVerbs},
+				{Name: "events", Namespaced: true, Verbs: allVerbs},
+				{Name: "namespaces", Verbs: allVerbs},
+				{Name: "namespaces/status", Verbs: metav1.Verbs{"get", "update"}},
+				{Name: "bindings", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+			},
+		},
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Namespaced: true, Verbs: allVerbs},
+				{Name: "deployments/status", Namespaced: true, Verbs: metav1.Verbs{"get", "update"}},
+			},
+		},
+		{
+			GroupVersion: "rbac.authorization.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "clusterroles", Verbs: allVerbs},
+			},
+		},
+		{
+			GroupVersion: "apiextensions.k8s.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "customresourcedefinitions", Verbs: allVerbs},
+			},
+		},
+		{
+			GroupVersion: "tenancy.kcp.dev/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "workspaces", Verbs: allVerbs},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var got []string
+	for _, r := range resources {
+		s := r.gvr.String()
+		if r.hasStatus {
+			s += " (status)"
+		}
+		got = append(got, s)
+	}
+	require.Equal(t, []string{
+		"apiextensions.k8s.io/v1, Resource=customresourcedefinitions",
+		"/v1, Resource=namespaces (status)",
+		"rbac.authorization.k8s.io/v1, Resource=clusterroles",
+		"/v1, Resource=configmaps",
+		"apps/v1, Resource=deployments (status)",
+	}, got)
+}
+
+func TestPrepareForCopy(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName("cm")
+	obj.SetUID("uid")
+	obj.SetResourceVersion("42")
+	obj.SetGeneration(3)
+	obj.SetCreationTimestamp(metav1.Now())
+	obj.SetLabels(map[string]string{"a": "b"})
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org:ws", "c": "d"})
+	obj.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "owner-uid"}})
+
+	got := prepareForCopy(obj)
+
+	want := &unstructured.Unstructured{}
+	want.SetAPIVersion("v1")
+	want.SetKind("ConfigMap")
+	want.SetNamespace("default")
+	want.SetName("cm")
+	want.SetLabels(map[string]string{"a": "b"})
+	want.SetAnnotations(map[string]string{"c": "d"})
+	require.Equal(t, want.Object["metadata"], got.Object["metadata"])
+
+	require.Equal(t, types.UID("uid"), obj.GetUID(), "original object must not be mutated")
+}
+
+func TestMapOwnerReferences(t *testing.T) {
+	refs := []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "copied", UID: "old-1"},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "not-copied", UID: "old-2"},
+	}
+	uids := map[string]types.UID{"copied": "new-1"}
+	got, err := mapOwnerReferences(refs, func(ref metav1.OwnerReference) (types.UID, error) {
+		return uids[ref.Name], nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "copied", UID: "new-1"},
+	}, got)
+	require.Equal(t, types.UID("old-1"), refs[0].UID, "original references must not be mutated")
+
+	got, err = mapOwnerReferences(refs, func(metav1.OwnerReference) (types.UID, error) { return "", nil })
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	_, err = mapOwnerReferences(refs, func(metav1.OwnerReference) (types.UID, error) { return "", errors.New("boom") })
+	require.Error(t, err)
+}
+
+func TestAdvance(t *testing.T) {
+	resources := []resource{
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}},
+		{gvr: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}},
+	}
+	page := func(continueToken string) *unstructured.UnstructuredList {
+		list := &unstructured.UnstructuredList{}
+		list.SetContinue(continueToken)
+		return list
+	}
+
+	tests := []struct {
+		name     string
+		progress Progress
+		i        int
+		list     *unstructured.UnstructuredList
+		want     Progress
+		wantDone bool
+	}{
+		{
+			name:     "more pages of the same resource",
+			progress: Progress{Stage: StageCopy, Count: 3},
+			list:     page("token"),
+			want:     Progress{Stage: StageCopy, Resource: "/v1, Resource=namespaces", Continue: "token", Count: 3},
+		},
+		{
+			name:     "last page of a resource continues with the next one",
+			progress: Progress{Stage: StageCopy, Resource: "/v1, Resource=namespaces", Continue: "token"},
+			list:     page(""),
+			want:     Progress{Stage: StageCopy, Resource: "/v1, Resource=configmaps"},
+		},
+		{
+			name:     "last page of the last resource ends the pass",
+			progress: Progress{Stage: StageDelete, Resource: "/v1, Resource=configmaps", Count: 2},
+			i:        1,
+			list:     page(""),
+			want:     Progress{Stage: StageDelete, Count: 2},
+			wantDone: true,
+		},
+		{
+			name:     "no resources",
+			progress: Progress{Stage: StageOwnerReferences},
+			want:     Progress{Stage: StageOwnerReferences},
+			wantDone: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, done := advance(tt.progress, resources, tt.i, tt.list)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.wantDone, done)
+		})
+	}
+}
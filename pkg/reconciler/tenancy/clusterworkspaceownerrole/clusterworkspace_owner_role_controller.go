@@ -0,0 +1,196 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceownerrole
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	rbacinformers "k8s.io/client-go/informers/rbac/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	rbaclisters "k8s.io/client-go/listers/rbac/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	controllerName = "kcp-clusterworkspaceownerrole"
+)
+
+// ownerVerbs are the verbs on the Workspace granted to its owners by the owner ClusterRole
+// created with the Workspace in the workspaces virtual workspace.
+var ownerVerbs = []string{"get", "update", "patch", "delete"}
+
+// NewController returns a controller which keeps the owner ClusterRoles of workspaces, i.e. the
+// ClusterRoles labelled with the workspace name in the parent workspace, up to date with the verbs
+// granted to owners. Owner ClusterRoles created by earlier versions only grant get and delete.
+func NewController(
+	kubeClusterClient kubernetesclient.ClusterInterface,
+	clusterRoleInformer rbacinformers.ClusterRoleInformer,
+) *Controller {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &Controller{
+		queue: queue,
+		updateClusterRole: func(ctx context.Context, clusterRole *rbacv1.ClusterRole) error {
+			_, err := kubeClusterClient.Cluster(logicalcluster.From(clusterRole)).RbacV1().ClusterRoles().Update(ctx, clusterRole, metav1.UpdateOptions{})
+			return err
+		},
+		clusterRoleLister: clusterRoleInformer.Lister(),
+	}
+
+	clusterRoleInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			switch obj := obj.(type) {
+			case *rbacv1.ClusterRole:
+				_, found := obj.Labels[tenancyv1alpha1.ClusterWorkspaceOwnerNameLabel]
+				return found
+			default:
+				return false
+			}
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+			UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		},
+	})
+
+	return c
+}
+
+// Controller reconciles the rules of the owner ClusterRoles of workspaces.
+type Controller struct {
+	queue workqueue.RateLimitingInterface
+
+	updateClusterRole func(ctx context.Context, clusterRole *rbacv1.ClusterRole) error
+	clusterRoleLister rbaclisters.ClusterRoleLister
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing ClusterRole")
+	c.queue.Add(key)
+}
+
+func (c *Controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *Controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *Controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *Controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+
+	clusterRole, err := c.clusterRoleLister.Get(key)
+	if apierrors.IsNotFound(err) {
+		return nil // object deleted before we handled it
+	} else if err != nil {
+		return err
+	}
+	logger = logging.WithObject(logger, clusterRole)
+
+	clusterRole = clusterRole.DeepCopy()
+	if !reconcileOwnerRules(clusterRole) {
+		return nil
+	}
+
+	logger.V(2).Info("adding missing owner verbs to the owner ClusterRole")
+	err = c.updateClusterRole(ctx, clusterRole)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// reconcileOwnerRules adds the missing owner verbs to the rules granting access to the Workspace.
+// It returns whether the rules were changed.
+func reconcileOwnerRules(clusterRole *rbacv1.ClusterRole) bool {
+	changed := false
+	for i := range clusterRole.Rules {
+		rule := &clusterRole.Rules[i]
+		if !sets.NewString(rule.APIGroups...).Has(tenancyv1beta1.SchemeGroupVersion.Group) || !sets.NewString(rule.Resources...).Has("workspaces") {
+			continue
+		}
+		verbs := sets.NewString(rule.Verbs...)
+		for _, verb := range ownerVerbs {
+			if !verbs.Has(verb) {
+				rule.Verbs = append(rule.Verbs, verb)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
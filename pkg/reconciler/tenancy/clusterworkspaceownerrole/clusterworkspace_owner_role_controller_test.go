@@ -0,0 +1,71 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterworkspaceownerrole
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+func TestReconcileOwnerRules(t *testing.T) {
+	tests := map[string]struct {
+		rules       []rbacv1.PolicyRule
+		wantRules   []rbacv1.PolicyRule
+		wantChanged bool
+	}{
+		"owner role created before update and patch were granted": {
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces"}, ResourceNames: []string{"ws"}, Verbs: []string{"get", "delete"}},
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"ws"}, Verbs: []string{"admin", "access"}},
+			},
+			wantRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces"}, ResourceNames: []string{"ws"}, Verbs: []string{"get", "delete", "update", "patch"}},
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"ws"}, Verbs: []string{"admin", "access"}},
+			},
+			wantChanged: true,
+		},
+		"up to date owner role": {
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces"}, ResourceNames: []string{"ws"}, Verbs: []string{"get", "update", "patch", "delete"}},
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"ws"}, Verbs: []string{"admin", "access"}},
+			},
+			wantRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces"}, ResourceNames: []string{"ws"}, Verbs: []string{"get", "update", "patch", "delete"}},
+				{APIGroups: []string{"tenancy.kcp.dev"}, Resources: []string{"workspaces/content"}, ResourceNames: []string{"ws"}, Verbs: []string{"admin", "access"}},
+			},
+		},
+		"rules of other groups are left alone": {
+			rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"example.com"}, Resources: []string{"workspaces"}, Verbs: []string{"get"}},
+			},
+			wantRules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"example.com"}, Resources: []string{"workspaces"}, Verbs: []string{"get"}},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			clusterRole := &rbacv1.ClusterRole{Rules: tt.rules}
+			changed := reconcileOwnerRules(clusterRole)
+			require.Equal(t, tt.wantChanged, changed)
+			require.Equal(t, tt.wantRules, clusterRole.Rules)
+		})
+	}
+}
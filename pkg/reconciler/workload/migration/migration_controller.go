@@ -0,0 +1,294 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	kubernetesclient "k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/indexers"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const (
+	controllerName = "kcp-workload-migration"
+
+	// notSyncedRecheckInterval is the interval after which a migrating namespace is checked again
+	// when some resource informers have not synced yet.
+	notSyncedRecheckInterval = 10 * time.Second
+)
+
+// NewController returns a new controller coordinating the migration of namespaces between sync targets.
+// A namespace moving away from a sync target keeps being synced to it until its resources are ready
+// on the sync targets it moves to. Only then the sync target is marked as removing.
+func NewController(
+	kubeClusterClient kubernetesclient.Interface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	syncTargetInformer workloadinformers.SyncTargetInformer,
+	namespaceInformer coreinformers.NamespaceInformer,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+
+		kubeClusterClient: kubeClusterClient,
+
+		namespaceLister:   namespaceInformer.Lister(),
+		syncTargetIndexer: syncTargetInformer.Informer().GetIndexer(),
+
+		ddsif: ddsif,
+	}
+
+	namespaceInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: func(obj interface{}) bool {
+			ns, ok := obj.(*corev1.Namespace)
+			return ok && isMigrating(ns)
+		},
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.enqueueNamespace,
+			UpdateFunc: func(_, obj interface{}) { c.enqueueNamespace(obj) },
+		},
+	})
+
+	// the readiness of the resources on the new sync targets is reported through their status annotations.
+	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResourceNamespace(obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueueResourceNamespace(obj) },
+		DeleteFunc: func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResourceNamespace(obj) },
+	})
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) { c.enqueueMigratingNamespaces() },
+	})
+
+	return c, nil
+}
+
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	kubeClusterClient kubernetesclient.Interface
+
+	namespaceLister   corelisters.NamespaceLister
+	syncTargetIndexer cache.Indexer
+
+	ddsif *informer.DynamicDiscoverySharedInformerFactory
+}
+
+// isMigrating returns whether the namespace is moving away from some sync target.
+func isMigrating(ns *corev1.Namespace) bool {
+	for k := range ns.Annotations {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterMigrationAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *controller) enqueueNamespace(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), key)
+	logger.V(2).Info("queueing Namespace")
+	c.queue.Add(key)
+}
+
+// enqueueResourceNamespace enqueues the namespace of the resource if it is migrating.
+func (c *controller) enqueueResourceNamespace(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	namespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	if namespace == "" {
+		return
+	}
+	clusterName, _ := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	nsKey := clusters.ToClusterAwareKey(clusterName, namespace)
+	ns, err := c.namespaceLister.Get(nsKey)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			runtime.HandleError(err)
+		}
+		return
+	}
+	if !isMigrating(ns) {
+		return
+	}
+
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), nsKey)
+	logger.V(4).Info("queueing Namespace because of resource")
+	c.queue.Add(nsKey)
+}
+
+// enqueueMigratingNamespaces enqueues all migrating namespaces, e.g. because a sync target
+// they move away from might have been deleted.
+func (c *controller) enqueueMigratingNamespaces() {
+	nss, err := c.namespaceLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, ns := range nss {
+		if isMigrating(ns) {
+			c.enqueueNamespace(ns)
+		}
+	}
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *controller) process(ctx context.Context, key string) error {
+	ns, err := c.namespaceLister.Get(key) // TODO: clients need a way to scope down the lister per-cluster
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil // object deleted before we handled it
+		}
+		return err
+	}
+
+	logger := logging.WithObject(klog.FromContext(ctx), ns)
+	ctx = klog.NewContext(ctx, logger)
+
+	r := &migrationReconciler{
+		listResources:    c.listResources,
+		syncTargetExists: c.syncTargetExists,
+		patchNamespace:   c.patchNamespace,
+		enqueueAfter: func(ns *corev1.Namespace, duration time.Duration) {
+			c.queue.AddAfter(clusters.ToClusterAwareKey(logicalcluster.From(ns), ns.Name), duration)
+		},
+		now: time.Now,
+	}
+	return r.reconcile(ctx, ns.DeepCopy())
+}
+
+// listResources returns the resources of all types in the namespace. The returned bool is false
+// if the informers of some types have not synced yet.
+func (c *controller) listResources(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, bool, error) {
+	listers, notSynced := c.ddsif.Listers()
+
+	var resources []*unstructured.Unstructured
+	for gvr, lister := range listers {
+		objs, err := lister.ByNamespace(namespace).List(labels.Everything())
+		if err != nil {
+			return nil, false, fmt.Errorf("error listing %q in %s|%s: %w", gvr, clusterName, namespace, err)
+		}
+		for _, obj := range objs {
+			u, ok := obj.(*unstructured.Unstructured)
+			// TODO(ncdc): remove this when we have namespaced listers that only return for the scoped cluster (https://github.com/kcp-dev/kcp/issues/685).
+			if !ok || logicalcluster.From(u) != clusterName {
+				continue
+			}
+			resources = append(resources, u)
+		}
+	}
+	return resources, len(notSynced) == 0, nil
+}
+
+func (c *controller) syncTargetExists(syncTargetKey string) (bool, error) {
+	objs, err := c.syncTargetIndexer.ByIndex(indexers.SyncTargetsBySyncTargetKey, syncTargetKey)
+	if err != nil {
+		return false, err
+	}
+	return len(objs) > 0, nil
+}
+
+func (c *controller) patchNamespace(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+	logger := klog.FromContext(ctx)
+	logger.WithValues("patch", string(data)).V(2).Info("patching Namespace")
+	return c.kubeClusterClient.CoreV1().Namespaces().Patch(logicalcluster.WithCluster(ctx, clusterName), name, pt, data, opts, subresources...)
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// migrationReconciler completes the migration of a namespace away from a sync target, i.e. it marks
+// the sync target as removing once the resources of the namespace are ready on the sync targets the
+// namespace moves to.
+type migrationReconciler struct {
+	listResources    func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, bool, error)
+	syncTargetExists func(syncTargetKey string) (bool, error)
+	patchNamespace   func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error)
+	enqueueAfter     func(*corev1.Namespace, time.Duration)
+
+	now func() time.Time
+}
+
+func (r *migrationReconciler) reconcile(ctx context.Context, ns *corev1.Namespace) error {
+	logger := klog.FromContext(ctx)
+	clusterName := logicalcluster.From(ns)
+
+	var migrating []string
+	for k := range ns.Annotations {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterMigrationAnnotationPrefix) {
+			migrating = append(migrating, strings.TrimPrefix(k, workloadv1alpha1.InternalClusterMigrationAnnotationPrefix))
+		}
+	}
+	if len(migrating) == 0 {
+		return nil
+	}
+	sort.Strings(migrating)
+
+	// the sync targets the namespace moves to
+	var targets []string
+	for k, v := range ns.Labels {
+		if !strings.HasPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix) || v != string(workloadv1alpha1.ResourceStateSync) {
+			continue
+		}
+		syncTarget := strings.TrimPrefix(k, workloadv1alpha1.ClusterResourceStateLabelPrefix)
+		if _, found := ns.Annotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTarget]; found {
+			continue
+		}
+		if _, found := ns.Annotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+syncTarget]; found {
+			continue
+		}
+		targets = append(targets, syncTarget)
+	}
+	sort.Strings(targets)
+
+	var resources []*unstructured.Unstructured
+	var synced bool
+	if len(targets) > 0 {
+		var err error
+		resources, synced, err = r.listResources(clusterName, ns.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	expectedAnnotations := map[string]interface{}{} // nil means to remove the key
+	for _, from := range migrating {
+		logger := logger.WithValues("syncTarget", from)
+
+		if ns.Labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+from] != string(workloadv1alpha1.ResourceStateSync) {
+			// not synced anymore, nothing to migrate.
+			logger.V(4).Info("removing migration annotation of Namespace since the SyncTarget is not synced anymore")
+			expectedAnnotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+from] = nil
+			continue
+		}
+
+		exists, err := r.syncTargetExists(from)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !exists:
+			logger.V(4).Info("completing migration of Namespace since the SyncTarget does not exist anymore")
+		case len(targets) == 0:
+			logger.V(4).Info("waiting for Namespace to be scheduled to another SyncTarget")
+			continue
+		case !synced:
+			logger.V(4).Info("waiting for resource informers to sync")
+			r.enqueueAfter(ns, notSyncedRecheckInterval)
+			continue
+		default:
+			if reason := resourcesReady(resources, from, targets); reason != "" {
+				logger.V(4).Info("waiting for resources to be ready on the new SyncTargets", "reason", reason)
+				continue
+			}
+			logger.V(2).Info("completing migration of Namespace since its resources are ready on the new SyncTargets", "syncTargets", targets)
+		}
+
+		expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+from] = r.now().UTC().Format(time.RFC3339)
+		expectedAnnotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+from] = nil
+	}
+
+	if len(expectedAnnotations) == 0 {
+		return nil
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": expectedAnnotations,
+		},
+	}
+	patchBytes, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	logger.WithValues("patch", string(patchBytes)).V(3).Info("patching Namespace to update migration state")
+	_, err = r.patchNamespace(ctx, clusterName, ns.Name, types.MergePatchType, patchBytes, metav1.PatchOptions{})
+	return err
+}
+
+// resourcesReady returns an empty string if all resources synced to the sync target from are ready on
+// all the sync targets in to, or the reason why not.
+func resourcesReady(resources []*unstructured.Unstructured, from string, to []string) string {
+	for _, obj := range resources {
+		for _, syncTarget := range to {
+			if reason := readyOnSyncTarget(obj, from, syncTarget); reason != "" {
+				return fmt.Sprintf("%s %s/%s: %s", strings.ToLower(obj.GetKind()), obj.GetNamespace(), obj.GetName(), reason)
+			}
+		}
+	}
+	return ""
+}
+
+// readyOnSyncTarget returns an empty string if the resource synced to the sync target from is ready on
+// the sync target to, or the reason why not. The readiness is read from the status the syncer of to
+// reports in experimental.status.workload.kcp.dev/<sync-target-name>: it must have observed the generation
+// reported in experimental.status-generation.workload.kcp.dev/<sync-target-name>, Ready and Available
+// conditions must be true, the ready replicas must match the replicas, and at least one of them must
+// confirm the readiness. Resources without any status, e.g. ConfigMaps, are ready once synced to to.
+func readyOnSyncTarget(obj *unstructured.Unstructured, from, to string) string {
+	if _, found := obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+from]; !found {
+		// not synced to from, nothing to wait for.
+		return ""
+	}
+
+	if obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+to] != string(workloadv1alpha1.ResourceStateSync) {
+		return fmt.Sprintf("not synced to SyncTarget %q yet", to)
+	}
+	statusJSON, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+to]
+	if !found {
+		_, fromReportsStatus := obj.GetAnnotations()[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+from]
+		if _, hasStatus := obj.Object["status"]; !fromReportsStatus && !hasStatus {
+			// a resource without status. Being synced is all there is.
+			return ""
+		}
+		return fmt.Sprintf("no status reported by SyncTarget %q yet", to)
+	}
+	var status map[string]interface{}
+	if err := json.Unmarshal([]byte(statusJSON), &status); err != nil {
+		return fmt.Sprintf("invalid status reported by SyncTarget %q: %v", to, err)
+	}
+	if len(status) == 0 {
+		return fmt.Sprintf("empty status reported by SyncTarget %q", to)
+	}
+
+	if generationString, found := obj.GetAnnotations()[workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix+to]; found {
+		generation, err := strconv.ParseInt(generationString, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid generation reported by SyncTarget %q: %v", to, err)
+		}
+		observedGeneration, _ := status["observedGeneration"].(float64)
+		if int64(observedGeneration) < generation {
+			return fmt.Sprintf("generation %d not observed on SyncTarget %q yet", generation, to)
+		}
+	}
+
+	confirmed := false
+	conditions, _, _ := unstructured.NestedSlice(status, "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := condition["type"].(string)
+		if conditionType != "Ready" && conditionType != "Available" {
+			continue
+		}
+		if conditionStatus, _ := condition["status"].(string); conditionStatus != string(metav1.ConditionTrue) {
+			return fmt.Sprintf("condition %s is not true on SyncTarget %q", conditionType, to)
+		}
+		confirmed = true
+	}
+
+	if replicas, found := status["replicas"].(float64); found {
+		readyReplicas, _ := status["readyReplicas"].(float64)
+		if readyReplicas < replicas {
+			return fmt.Sprintf("%d of %d replicas ready on SyncTarget %q", int64(readyReplicas), int64(replicas), to)
+		}
+		confirmed = true
+	}
+
+	if !confirmed {
+		return fmt.Sprintf("status reported by SyncTarget %q does not confirm readiness", to)
+	}
+	return ""
+}
@@ -0,0 +1,354 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestMigration(t *testing.T) {
+	now := time.Now()
+	now3339 := now.UTC().Format(time.RFC3339)
+
+	syncLabels := map[string]string{
+		workloadv1alpha1.ClusterResourceStateLabelPrefix + "old": string(workloadv1alpha1.ResourceStateSync),
+		workloadv1alpha1.ClusterResourceStateLabelPrefix + "new": string(workloadv1alpha1.ResourceStateSync),
+	}
+	migrating := map[string]string{
+		workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "old": now3339,
+	}
+
+	testCases := []struct {
+		name string
+
+		labels      map[string]string
+		annotations map[string]string
+		resources   []*unstructured.Unstructured
+		notSynced   bool
+		syncTargets []string
+
+		wantPatch           bool
+		wantRequeue         bool
+		expectedAnnotations map[string]interface{}
+	}{
+		{
+			name:        "not migrating",
+			labels:      syncLabels,
+			syncTargets: []string{"old", "new"},
+		},
+		{
+			name:        "resources not ready on the new synctarget",
+			labels:      syncLabels,
+			annotations: migrating,
+			resources: []*unstructured.Unstructured{
+				newDeployment("old", "new", map[string]string{
+					"old": `{"replicas":2,"readyReplicas":2}`,
+					"new": `{"replicas":2,"readyReplicas":1}`,
+				}),
+			},
+			syncTargets: []string{"old", "new"},
+		},
+		{
+			name:        "resources ready on the new synctarget",
+			labels:      syncLabels,
+			annotations: migrating,
+			resources: []*unstructured.Unstructured{
+				newDeployment("old", "new", map[string]string{
+					"old": `{"replicas":2,"readyReplicas":2}`,
+					"new": `{"replicas":2,"readyReplicas":2}`,
+				}),
+			},
+			syncTargets: []string{"old", "new"},
+			wantPatch:   true,
+			expectedAnnotations: map[string]interface{}{
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "old": now3339,
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "old":         nil,
+			},
+		},
+		{
+			name:        "resources without status are ready",
+			labels:      syncLabels,
+			annotations: migrating,
+			resources: []*unstructured.Unstructured{
+				newDeployment("old", "new", nil),
+			},
+			syncTargets: []string{"old", "new"},
+			wantPatch:   true,
+			expectedAnnotations: map[string]interface{}{
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "old": now3339,
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "old":         nil,
+			},
+		},
+		{
+			name:        "informers not synced",
+			labels:      syncLabels,
+			annotations: migrating,
+			notSynced:   true,
+			syncTargets: []string{"old", "new"},
+			wantRequeue: true,
+		},
+		{
+			name: "no synctarget to migrate to",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "old": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: migrating,
+			syncTargets: []string{"old"},
+		},
+		{
+			name:        "synctarget deleted",
+			labels:      syncLabels,
+			annotations: migrating,
+			resources: []*unstructured.Unstructured{
+				newDeployment("old", "new", map[string]string{
+					"old": `{"replicas":2,"readyReplicas":2}`,
+				}),
+			},
+			syncTargets: []string{"new"},
+			wantPatch:   true,
+			expectedAnnotations: map[string]interface{}{
+				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "old": now3339,
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "old":         nil,
+			},
+		},
+		{
+			name: "synctarget not synced anymore",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "new": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: migrating,
+			syncTargets: []string{"old", "new"},
+			wantPatch:   true,
+			expectedAnnotations: map[string]interface{}{
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "old": nil,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ns := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					Annotations: map[string]string{
+						logicalcluster.AnnotationKey: "root:default",
+					},
+					Labels: testCase.labels,
+				},
+			}
+			for k, v := range testCase.annotations {
+				ns.Annotations[k] = v
+			}
+
+			var patched bool
+			var requeued bool
+			r := &migrationReconciler{
+				listResources: func(clusterName logicalcluster.Name, namespace string) ([]*unstructured.Unstructured, bool, error) {
+					return testCase.resources, !testCase.notSynced, nil
+				},
+				syncTargetExists: func(syncTargetKey string) (bool, error) {
+					for _, syncTarget := range testCase.syncTargets {
+						if syncTarget == syncTargetKey {
+							return true, nil
+						}
+					}
+					return false, nil
+				},
+				patchNamespace: func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*corev1.Namespace, error) {
+					patched = true
+					var patch map[string]interface{}
+					require.NoError(t, json.Unmarshal(data, &patch))
+					annotations, _, err := unstructured.NestedFieldNoCopy(patch, "metadata", "annotations")
+					require.NoError(t, err)
+					require.Equal(t, testCase.expectedAnnotations, annotations)
+					return ns, nil
+				},
+				enqueueAfter: func(*corev1.Namespace, time.Duration) { requeued = true },
+				now:          func() time.Time { return now },
+			}
+
+			err := r.reconcile(context.TODO(), ns)
+			require.NoError(t, err)
+			require.Equal(t, testCase.wantPatch, patched)
+			require.Equal(t, testCase.wantRequeue, requeued)
+		})
+	}
+}
+
+func TestReadyOnSyncTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		wantReady bool
+	}{
+		{
+			name:      "not synced to the old synctarget",
+			obj:       newDeployment("", "new", nil),
+			wantReady: true,
+		},
+		{
+			name: "without status, not synced to the new synctarget",
+			obj:  newDeployment("old", "", nil),
+		},
+		{
+			name:      "without status, synced to the new synctarget",
+			obj:       newDeployment("old", "new", nil),
+			wantReady: true,
+		},
+		{
+			name: "no status on the old synctarget, status field upstream",
+			obj: func() *unstructured.Unstructured {
+				obj := newDeployment("old", "new", nil)
+				obj.Object["status"] = map[string]interface{}{}
+				return obj
+			}(),
+		},
+		{
+			name: "not synced to the new synctarget",
+			obj: newDeployment("old", "", map[string]string{
+				"old": `{}`,
+			}),
+		},
+		{
+			name: "no status on the new synctarget",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+			}),
+		},
+		{
+			name: "invalid status on the new synctarget",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{`,
+			}),
+		},
+		{
+			name: "available condition false",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"conditions":[{"type":"Progressing","status":"True"},{"type":"Available","status":"False"}]}`,
+			}),
+		},
+		{
+			name: "available condition true",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"conditions":[{"type":"Progressing","status":"False"},{"type":"Available","status":"True"}]}`,
+			}),
+			wantReady: true,
+		},
+		{
+			name: "ready replicas missing",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"replicas":1}`,
+			}),
+		},
+		{
+			name: "empty status",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{}`,
+			}),
+		},
+		{
+			name: "no readiness signal",
+			obj: newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"observedGeneration":1,"conditions":[{"type":"Progressing","status":"True"}]}`,
+			}),
+		},
+		{
+			name: "stale observedGeneration",
+			obj: withStatusGeneration(newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"observedGeneration":1,"replicas":1,"readyReplicas":1}`,
+			}), "new", "2"),
+		},
+		{
+			name: "missing observedGeneration",
+			obj: withStatusGeneration(newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"replicas":1,"readyReplicas":1}`,
+			}), "new", "1"),
+		},
+		{
+			name: "current observedGeneration",
+			obj: withStatusGeneration(newDeployment("old", "new", map[string]string{
+				"old": `{}`,
+				"new": `{"observedGeneration":2,"replicas":1,"readyReplicas":1}`,
+			}), "new", "2"),
+			wantReady: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := readyOnSyncTarget(tt.obj, "old", "new")
+			if tt.wantReady {
+				require.Empty(t, reason)
+			} else {
+				require.NotEmpty(t, reason)
+			}
+		})
+	}
+}
+
+// newDeployment returns a deployment synced to the given synctargets, with the given
+// status per synctarget.
+func newDeployment(from, to string, statuses map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("test")
+	obj.SetName("deployment")
+
+	labels := map[string]string{}
+	for _, syncTarget := range []string{from, to} {
+		if syncTarget != "" {
+			labels[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTarget] = string(workloadv1alpha1.ResourceStateSync)
+		}
+	}
+	obj.SetLabels(labels)
+
+	annotations := map[string]string{logicalcluster.AnnotationKey: "root:default"}
+	for syncTarget, status := range statuses {
+		annotations[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+syncTarget] = status
+	}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+// withStatusGeneration sets the downstream generation the status of the given synctarget belongs to.
+func withStatusGeneration(obj *unstructured.Unstructured, syncTarget, generation string) *unstructured.Unstructured {
+	annotations := obj.GetAnnotations()
+	annotations[workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix+syncTarget] = generation
+	obj.SetAnnotations(annotations)
+	return obj
+}
@@ -38,7 +38,7 @@ import (
 const removingGracePeriod = 5 * time.Second
 
 // placementSchedulingReconciler reconciles the state.workload.kcp.dev/<syncTarget> labels according the
-// selected synctarget stored in the internal.workload.kcp.dev/synctarget annotation
+// selected synctargets stored in the internal.workload.kcp.dev/synctarget annotation
 // on each placement.
 type placementSchedulingReconciler struct {
 	listPlacement func(clusterName logicalcluster.Name) ([]*schedulingv1alpha1.Placement, error)
@@ -73,21 +73,47 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, ns *corev
 		if !foundScheduled {
 			continue
 		}
-		scheduledSyncTargets.Insert(currentScheduled)
+		scheduledSyncTargets.Insert(workloadv1alpha1.ParseSyncTargetPlacementAnnotation(currentScheduled)...)
 	}
 
 	// 2. find the scheduled synctarget to the ns, including synced, removing
 	synced, removing := syncedRemovingCluster(ns)
 
-	// 3. if the synced synctarget is not in the scheduled synctargets, mark it as removing.
+	// 3. if the synced synctarget is not in the scheduled synctargets, migrate the namespace to the other
+	// scheduled synctargets. Only if there is none, mark it as removing right away.
 	expectedAnnotations := map[string]interface{}{} // nil means to remove the key
 	expectedLabels := map[string]interface{}{}      // nil means to remove the key
 
+	migrationTargets := sets.NewString()
+	for scheduledSyncTarget := range scheduledSyncTargets {
+		if _, ok := removing[scheduledSyncTarget]; !ok {
+			migrationTargets.Insert(scheduledSyncTarget)
+		}
+	}
 	for syncTarget := range synced {
-		if !scheduledSyncTargets.Has(syncTarget) {
-			// it is no longer a synced synctarget, mark it as removing.
+		_, migrating := ns.Annotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+syncTarget]
+		switch {
+		case scheduledSyncTargets.Has(syncTarget):
+			if migrating {
+				// it is scheduled again, cancel the migration.
+				expectedAnnotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+syncTarget] = nil
+				logger.WithValues("syncTarget", syncTarget).V(4).Info("cancelling migration of Namespace away from SyncTarget since it is scheduled again")
+			}
+		case migrationTargets.Len() > 0:
+			if !migrating {
+				// it is no longer a synced synctarget, keep it synced until the migration controller
+				// sees the workload ready on the other synctargets.
+				now := r.now().UTC().Format(time.RFC3339)
+				expectedAnnotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+syncTarget] = now
+				logger.WithValues("syncTarget", syncTarget).V(4).Info("migrating Namespace away from SyncTarget since it is not a valid syncTarget anymore")
+			}
+		default:
+			// it is no longer a synced synctarget and there is nothing to migrate to, mark it as removing.
 			now := r.now().UTC().Format(time.RFC3339)
 			expectedAnnotations[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTarget] = now
+			if migrating {
+				expectedAnnotations[workloadv1alpha1.InternalClusterMigrationAnnotationPrefix+syncTarget] = nil
+			}
 			logger.WithValues("syncTarget", syncTarget).V(4).Info("setting SyncTarget as removing for Namespace since it is not a valid syncTarget anymore")
 		}
 	}
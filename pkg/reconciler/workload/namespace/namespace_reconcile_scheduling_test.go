@@ -19,6 +19,7 @@ package namespace
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -126,13 +127,71 @@ func TestScheduling(t *testing.T) {
 			},
 			placement: newPlacement("test-placement", "test-location", "test-cluster-2"),
 			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                            "",
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "no update while migrating to a new synctarget",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                            "",
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster-2"),
+			wantPatch: false,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                            "",
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "cancel migration when the synctarget is scheduled again",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                            "",
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", "test-cluster"),
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "mark migrating synctarget as removing when no synctarget is left",
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey:                                                            "",
+				workloadv1alpha1.InternalClusterMigrationAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
+			},
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
+			},
+			placement: newPlacement("test-placement", "test-location", ""),
+			wantPatch: true,
 			expectedAnnotations: map[string]string{
 				schedulingv1alpha1.PlacementAnnotationKey: "",
 				workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": now3339,
 			},
 			expectedLabels: map[string]string{
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "34sZi3721YwBLDHUuNVIOLxuYp5nEZBpsTQyDq": string(workloadv1alpha1.ResourceStateSync),
-				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQA9mRmZ5RuT9vKRZokxZTm1Yk9SqKyfOMoTEr": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
 		{
@@ -239,6 +298,23 @@ func TestMultiplePlacements(t *testing.T) {
 				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
 			},
 		},
+		{
+			name: "placement spread to two synctargets",
+			placements: []*schedulingv1alpha1.Placement{
+				withSyncTargets(newPlacement("p1", "loc1", ""), "c1", "c2"),
+			},
+			annotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				schedulingv1alpha1.PlacementAnnotationKey: "",
+			},
+			expectedLabels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
 		{
 			name: "placement select the same location",
 			placements: []*schedulingv1alpha1.Placement{
@@ -362,3 +438,14 @@ func newPlacement(name, location, synctarget string) *schedulingv1alpha1.Placeme
 
 	return placement
 }
+
+func withSyncTargets(placement *schedulingv1alpha1.Placement, synctargets ...string) *schedulingv1alpha1.Placement {
+	keys := make([]string, 0, len(synctargets))
+	for _, synctarget := range synctargets {
+		keys = append(keys, workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), synctarget))
+	}
+	placement.Annotations = map[string]string{
+		workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: strings.Join(keys, ","),
+	}
+	return placement
+}
@@ -46,14 +46,22 @@ const (
 	controllerName      = "kcp-workload-placement"
 	byWorkspace         = controllerName + "-byWorkspace" // will go away with scoping
 	byLocationWorkspace = controllerName + "-byLocationWorkspace"
+	bySyncTargetKey     = controllerName + "-bySyncTargetKey"
+
+	// heartbeatFreshnessThreshold is the heartbeat age at which the heartbeat scorer gives the lowest score.
+	// It matches the default of --sync-target-heartbeat-threshold.
+	heartbeatFreshnessThreshold = time.Minute
 )
 
-// NewController returns a new controller starting the process of selecting synctarget for a placement
+// NewController returns a new controller starting the process of selecting synctarget for a placement.
+// SyncTargets are scored by the given scorers. If none are given, SyncTargets are scored by allocatable
+// resources, number of scheduled placements and heartbeat freshness.
 func NewController(
 	kcpClusterClient kcpclient.Interface,
 	locationInformer schedulinginformers.LocationInformer,
 	syncTargetInformer workloadinformers.SyncTargetInformer,
 	placementInformer schedulinginformers.PlacementInformer,
+	scorers ...SyncTargetScorer,
 ) (*controller, error) {
 	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
 
@@ -70,6 +78,16 @@ func NewController(
 
 		placmentLister:   placementInformer.Lister(),
 		placementIndexer: placementInformer.Informer().GetIndexer(),
+
+		scorers: scorers,
+	}
+
+	if len(c.scorers) == 0 {
+		c.scorers = []SyncTargetScorer{
+			NewAllocatableScorer(),
+			NewPlacementCountScorer(c.countPlacements),
+			NewHeartbeatScorer(time.Now, heartbeatFreshnessThreshold),
+		}
 	}
 
 	if err := locationInformer.Informer().AddIndexers(cache.Indexers{
@@ -87,6 +105,7 @@ func NewController(
 	if err := placementInformer.Informer().AddIndexers(cache.Indexers{
 		byWorkspace:         indexByWorkspace,
 		byLocationWorkspace: indexByLocationWorkspace,
+		bySyncTargetKey:     indexBySyncTargetKey,
 	}); err != nil {
 		return nil, err
 	}
@@ -158,6 +177,8 @@ type controller struct {
 
 	placmentLister   schedulinglisters.PlacementLister
 	placementIndexer cache.Indexer
+
+	scorers []SyncTargetScorer
 }
 
 // enqueueLocation finds placement ref to this location at first, and then namespaces bound to this placement.
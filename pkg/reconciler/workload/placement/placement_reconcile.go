@@ -48,6 +48,7 @@ func (c *controller) reconcile(ctx context.Context, placement *schedulingv1alpha
 			listSyncTarget: c.listSyncTarget,
 			getLocation:    c.getLocation,
 			patchPlacement: c.patchPlacement,
+			scorers:        c.scorers,
 		},
 	}
 
@@ -80,6 +81,14 @@ func (c *controller) listSyncTarget(clusterName logicalcluster.Name) ([]*workloa
 	return ret, nil
 }
 
+func (c *controller) countPlacements(syncTargetKey string) (int, error) {
+	items, err := c.placementIndexer.ByIndex(bySyncTargetKey, syncTargetKey)
+	if err != nil {
+		return 0, err
+	}
+	return len(items), nil
+}
+
 func (c *controller) getLocation(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error) {
 	key := clusters.ToClusterAwareKey(clusterName, name)
 	return c.locationLister.Get(key)
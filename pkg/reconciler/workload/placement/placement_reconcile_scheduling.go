@@ -19,7 +19,12 @@ package placement
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -35,12 +40,16 @@ import (
 )
 
 // placementSchedulingReconciler schedules placments according to the selected locations.
-// It considers only valid SyncTargets and updates the internal.workload.kcp.dev/synctarget
-// annotation with the selected one on the placement object.
+// It considers only valid SyncTargets, scores them with the configured scorers, and updates the
+// internal.workload.kcp.dev/synctarget annotation with the highest scored one on the placement object.
+// The score and the reason of the choice are recorded in the internal.workload.kcp.dev/synctarget-score
+// and internal.workload.kcp.dev/synctarget-reason annotations.
 type placementSchedulingReconciler struct {
 	listSyncTarget func(clusterName logicalcluster.Name) ([]*workloadv1alpha1.SyncTarget, error)
 	getLocation    func(clusterName logicalcluster.Name, name string) (*schedulingv1alpha1.Location, error)
 	patchPlacement func(ctx context.Context, clusterName logicalcluster.Name, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*schedulingv1alpha1.Placement, error)
+
+	scorers []SyncTargetScorer
 }
 
 func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement *schedulingv1alpha1.Placement) (reconcileStatus, *schedulingv1alpha1.Placement, error) {
@@ -48,7 +57,8 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 
 	// 1. get current scheduled
 	expectedAnnotations := map[string]interface{}{} // nil means to remove the key
-	currentScheduled, foundScheduled := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
+	currentScheduledValue, foundScheduled := placement.Annotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey]
+	currentScheduled := workloadv1alpha1.ParseSyncTargetPlacementAnnotation(currentScheduledValue)
 
 	// 2. pick all valid synctargets in this placements
 	syncTargetClusterName, syncTargets, err := r.getAllValidSyncTargetsForPlacement(clusterName, placement)
@@ -59,33 +69,192 @@ func (r *placementSchedulingReconciler) reconcile(ctx context.Context, placement
 	// no valid synctarget, clean the annotation.
 	if foundScheduled && len(syncTargets) == 0 {
 		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = nil
+		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey] = nil
+		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey] = nil
 		updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
 		return reconcileStatusContinue, updated, err
 	}
 
-	// 2. do nothing if scheduled cluster is in the valid clusters
-	if foundScheduled && len(syncTargets) > 0 {
-		for _, syncTarget := range syncTargets {
-			syncTargetKey := workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name)
-			if syncTargetKey != currentScheduled {
-				continue
-			}
-			return reconcileStatusContinue, placement, nil
-		}
+	if len(syncTargets) == 0 {
+		return reconcileStatusContinue, placement, nil
 	}
 
-	// 3. randomly select one as the scheduled cluster
+	// 3. keep the scheduled clusters that are still valid, and select the highest scored ones for the missing instances.
 	// TODO(qiujian16): we currently schedule each in each location independently. It cannot guarantee 1 cluster is scheduled per location
 	// when the same synctargets are in multiple locations, we need to rethink whether we need a better algorithm or we need location
 	// to be exclusive.
-	if len(syncTargets) > 0 {
-		scheduledSyncTarget := syncTargets[rand.Intn(len(syncTargets))]
-		expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, scheduledSyncTarget.Name)
-		updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
-		return reconcileStatusContinue, updated, err
+	scheduled, err := r.scheduleSyncTargets(placement, syncTargetClusterName, syncTargets, currentScheduled)
+	if err != nil {
+		return reconcileStatusStop, placement, err
+	}
+
+	// 4. do nothing if the scheduled clusters did not change
+	keys := make([]string, 0, len(scheduled))
+	scores := make([]string, 0, len(scheduled))
+	reasons := make([]string, 0, len(scheduled))
+	for _, s := range scheduled {
+		keys = append(keys, s.key)
+		scores = append(scores, strconv.FormatInt(s.score, 10))
+		reasons = append(reasons, fmt.Sprintf("%s: %s", s.syncTarget.Name, s.reason))
+	}
+	if foundScheduled && reflect.DeepEqual(keys, currentScheduled) {
+		return reconcileStatusContinue, placement, nil
 	}
 
-	return reconcileStatusContinue, placement, nil
+	expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey] = strings.Join(keys, ",")
+	expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey] = strings.Join(scores, ",")
+	expectedAnnotations[workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey] = strings.Join(reasons, "; ")
+	updated, err := r.patchPlacementAnnotation(ctx, clusterName, placement, expectedAnnotations)
+	return reconcileStatusContinue, updated, err
+}
+
+type scheduledSyncTarget struct {
+	syncTarget *workloadv1alpha1.SyncTarget
+	key        string
+	score      int64
+	reason     string
+}
+
+// scheduleSyncTargets spreads the placement over the given valid SyncTargets according to the spread of the
+// placement. Currently scheduled SyncTargets are kept as long as they are valid, so that workloads do not move
+// when the set of valid SyncTargets changes. The kept SyncTargets come first, in their current order, followed
+// by the newly selected ones.
+func (r *placementSchedulingReconciler) scheduleSyncTargets(placement *schedulingv1alpha1.Placement, syncTargetClusterName logicalcluster.Name, syncTargets []*workloadv1alpha1.SyncTarget, currentScheduled []string) ([]scheduledSyncTarget, error) {
+	instances := 1
+	var cellKey string
+	if spread := placement.Spec.Spread; spread != nil {
+		if spread.Instances > 0 {
+			instances = int(spread.Instances)
+		}
+		cellKey = spread.CellKey
+	}
+
+	currentIndex := make(map[string]int, len(currentScheduled))
+	for i, key := range currentScheduled {
+		currentIndex[key] = i
+	}
+
+	// group the sync targets by the value of the cell key. Without cell key, there is only one group.
+	groups := map[string][]*workloadv1alpha1.SyncTarget{}
+	for _, syncTarget := range syncTargets {
+		var cell string
+		if cellKey != "" {
+			value, found := syncTarget.Spec.Cells[cellKey]
+			if !found {
+				continue
+			}
+			cell = value
+		}
+		groups[cell] = append(groups[cell], syncTarget)
+	}
+	cells := make([]string, 0, len(groups))
+	for cell := range groups {
+		cells = append(cells, cell)
+	}
+	sort.Strings(cells)
+
+	var kept, added []scheduledSyncTarget
+	for _, cell := range cells {
+		var keptInCell []scheduledSyncTarget
+		var candidates []*workloadv1alpha1.SyncTarget
+		for _, syncTarget := range groups[cell] {
+			key := workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, syncTarget.Name)
+			if _, found := currentIndex[key]; !found {
+				candidates = append(candidates, syncTarget)
+				continue
+			}
+			score, _, err := r.scoreSyncTarget(placement, syncTarget)
+			if err != nil {
+				return nil, err
+			}
+			keptInCell = append(keptInCell, scheduledSyncTarget{syncTarget: syncTarget, key: key, score: score, reason: "already scheduled"})
+		}
+		sort.Slice(keptInCell, func(i, j int) bool {
+			return currentIndex[keptInCell[i].key] < currentIndex[keptInCell[j].key]
+		})
+		if len(keptInCell) > instances {
+			keptInCell = keptInCell[:instances]
+		}
+		kept = append(kept, keptInCell...)
+
+		for missing := instances - len(keptInCell); missing > 0 && len(candidates) > 0; missing-- {
+			selected, score, reason, err := r.selectSyncTarget(placement, candidates)
+			if err != nil {
+				return nil, err
+			}
+			if cellKey != "" {
+				reason += fmt.Sprintf(" in cell %s=%s", cellKey, cell)
+			}
+			added = append(added, scheduledSyncTarget{
+				syncTarget: selected,
+				key:        workloadv1alpha1.ToSyncTargetKey(syncTargetClusterName, selected.Name),
+				score:      score,
+				reason:     reason,
+			})
+			for i := range candidates {
+				if candidates[i] == selected {
+					candidates = append(candidates[:i], candidates[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(kept, func(i, j int) bool {
+		return currentIndex[kept[i].key] < currentIndex[kept[j].key]
+	})
+	return append(kept, added...), nil
+}
+
+// selectSyncTarget returns the SyncTarget with the highest total score, its score and a human readable
+// reason for the choice. Ties are broken randomly.
+func (r *placementSchedulingReconciler) selectSyncTarget(placement *schedulingv1alpha1.Placement, syncTargets []*workloadv1alpha1.SyncTarget) (*workloadv1alpha1.SyncTarget, int64, string, error) {
+	type candidate struct {
+		syncTarget *workloadv1alpha1.SyncTarget
+		details    []string
+	}
+
+	var best []candidate
+	var bestScore int64
+	for _, syncTarget := range syncTargets {
+		score, details, err := r.scoreSyncTarget(placement, syncTarget)
+		if err != nil {
+			return nil, 0, "", err
+		}
+
+		switch {
+		case len(best) == 0 || score > bestScore:
+			best = []candidate{{syncTarget: syncTarget, details: details}}
+			bestScore = score
+		case score == bestScore:
+			best = append(best, candidate{syncTarget: syncTarget, details: details})
+		}
+	}
+
+	selected := best[rand.Intn(len(best))]
+	reason := fmt.Sprintf("highest score among %d valid SyncTargets", len(syncTargets))
+	if len(selected.details) > 0 {
+		reason += fmt.Sprintf(" (%s)", strings.Join(selected.details, ", "))
+	}
+	if len(best) > 1 {
+		reason += fmt.Sprintf(", picked randomly among %d SyncTargets with the same score", len(best))
+	}
+	return selected.syncTarget, bestScore, reason, nil
+}
+
+// scoreSyncTarget returns the total score of the SyncTarget, and the score of each scorer.
+func (r *placementSchedulingReconciler) scoreSyncTarget(placement *schedulingv1alpha1.Placement, syncTarget *workloadv1alpha1.SyncTarget) (int64, []string, error) {
+	var score int64
+	details := make([]string, 0, len(r.scorers))
+	for _, scorer := range r.scorers {
+		s, err := scorer.Score(placement, syncTarget)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to score SyncTarget %s|%s with %s scorer: %w", logicalcluster.From(syncTarget), syncTarget.Name, scorer.Name(), err)
+		}
+		score += s
+		details = append(details, fmt.Sprintf("%s: %d", scorer.Name(), s))
+	}
+	return score, details, nil
 }
 
 func (r *placementSchedulingReconciler) getAllValidSyncTargetsForPlacement(clusterName logicalcluster.Name, placement *schedulingv1alpha1.Placement) (logicalcluster.Name, []*workloadv1alpha1.SyncTarget, error) {
@@ -19,13 +19,16 @@ package placement
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/require"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
@@ -43,6 +46,7 @@ func TestSchedulingReconcile(t *testing.T) {
 		placement   *schedulingv1alpha1.Placement
 		location    *schedulingv1alpha1.Location
 		syncTargets []*workloadv1alpha1.SyncTarget
+		scorers     []SyncTargetScorer
 
 		wantPatch           bool
 		expectedAnnotations map[string]string
@@ -63,7 +67,9 @@ func TestSchedulingReconcile(t *testing.T) {
 			syncTargets: []*workloadv1alpha1.SyncTarget{newSyncTarget("c1", true)},
 			wantPatch:   true,
 			expectedAnnotations: map[string]string{
-				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       "aQtdeEWVcqU7h7AKnYMm3KRQ96U4oU2W04yeOa",
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "0",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c1: highest score among 1 valid SyncTargets",
 			},
 		},
 		{
@@ -90,9 +96,164 @@ func TestSchedulingReconcile(t *testing.T) {
 			syncTargets: []*workloadv1alpha1.SyncTarget{newSyncTarget("c1", false), newSyncTarget("c2", true)},
 			wantPatch:   true,
 			expectedAnnotations: map[string]string{
-				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "0",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: highest score among 1 valid SyncTargets",
+			},
+		},
+		{
+			name:      "schedule highest scored synctarget",
+			placement: newPlacement("test", "test-location", ""),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "75",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: highest score among 2 valid SyncTargets (allocatable: 75)",
 			},
 		},
+		{
+			name:      "schedule synctarget with fewest placements",
+			placement: newPlacement("test", "test-location", ""),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "2", "4"),
+				newSyncTargetWithAllocatable("c2", "2", "4"),
+			},
+			scorers: []SyncTargetScorer{
+				NewAllocatableScorer(),
+				NewPlacementCountScorer(func(syncTargetKey string) (int, error) {
+					if syncTargetKey == workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), "c2") {
+						return 1, nil
+					}
+					return 3, nil
+				}),
+			},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       "aPkhvUbGK0xoZIjMnM2pA0AuV1g7i4tBwxu5m4",
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "100",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: highest score among 2 valid SyncTargets (allocatable: 50, placements: 50)",
+			},
+		},
+		{
+			name:      "spread to multiple synctargets",
+			placement: withSpread(newPlacement("test", "test-location", ""), 2, ""),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+				newSyncTargetWithAllocatable("c3", "2", "4"),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       syncTargetKeys("c2", "c3"),
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "75,50",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: highest score among 3 valid SyncTargets (allocatable: 75); c3: highest score among 2 valid SyncTargets (allocatable: 50)",
+			},
+		},
+		{
+			name:      "spread keeps scheduled synctargets",
+			placement: withSpread(newPlacement("test", "test-location", "c1"), 2, ""),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+				newSyncTargetWithAllocatable("c3", "2", "4"),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       syncTargetKeys("c1", "c2"),
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "25,75",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c1: already scheduled; c2: highest score among 2 valid SyncTargets (allocatable: 75)",
+			},
+		},
+		{
+			name: "spread satisfied",
+			placement: withSpread(withAnnotations(newPlacement("test", "test-location", ""), map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: syncTargetKeys("c3", "c1"),
+			}), 2, ""),
+			location: newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+				newSyncTargetWithAllocatable("c3", "2", "4"),
+			},
+			scorers: []SyncTargetScorer{NewAllocatableScorer()},
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: syncTargetKeys("c3", "c1"),
+			},
+		},
+		{
+			name: "spread replaces invalid synctarget",
+			placement: withSpread(withAnnotations(newPlacement("test", "test-location", ""), map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: syncTargetKeys("c3", "c1"),
+			}), 2, ""),
+			location: newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+				newSyncTarget("c3", false),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       syncTargetKeys("c1", "c2"),
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "25,75",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c1: already scheduled; c2: highest score among 1 valid SyncTargets (allocatable: 75)",
+			},
+		},
+		{
+			name: "spread scales down",
+			placement: withAnnotations(newPlacement("test", "test-location", ""), map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey: syncTargetKeys("c2", "c1"),
+			}),
+			location: newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				newSyncTargetWithAllocatable("c1", "1", "4"),
+				newSyncTargetWithAllocatable("c2", "3", "4"),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       syncTargetKeys("c2"),
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "75",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: already scheduled",
+			},
+		},
+		{
+			name:      "spread by cell",
+			placement: withSpread(newPlacement("test", "test-location", ""), 1, "zone"),
+			location:  newLocation("test-location"),
+			syncTargets: []*workloadv1alpha1.SyncTarget{
+				withCells(newSyncTargetWithAllocatable("c1", "1", "4"), map[string]string{"zone": "a"}),
+				withCells(newSyncTargetWithAllocatable("c2", "3", "4"), map[string]string{"zone": "a"}),
+				withCells(newSyncTargetWithAllocatable("c3", "1", "4"), map[string]string{"zone": "b"}),
+				newSyncTargetWithAllocatable("c4", "4", "4"),
+			},
+			scorers:   []SyncTargetScorer{NewAllocatableScorer()},
+			wantPatch: true,
+			expectedAnnotations: map[string]string{
+				workloadv1alpha1.InternalSyncTargetPlacementAnnotationKey:       syncTargetKeys("c2", "c3"),
+				workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey:  "75,25",
+				workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "c2: highest score among 2 valid SyncTargets (allocatable: 75) in cell zone=a; c3: highest score among 1 valid SyncTargets (allocatable: 25) in cell zone=b",
+			},
+		},
+		{
+			name:                "unschedule synctarget clears score and reason",
+			placement:           withAnnotations(newPlacement("test", "test-location", "c1"), map[string]string{workloadv1alpha1.InternalSyncTargetPlacementScoreAnnotationKey: "75", workloadv1alpha1.InternalSyncTargetPlacementReasonAnnotationKey: "reason"}),
+			location:            newLocation("test-location"),
+			syncTargets:         []*workloadv1alpha1.SyncTarget{newSyncTarget("c1", false)},
+			wantPatch:           true,
+			expectedAnnotations: map[string]string{},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -126,6 +287,7 @@ func TestSchedulingReconcile(t *testing.T) {
 				listSyncTarget: listSyncTarget,
 				getLocation:    getLocation,
 				patchPlacement: patchPlacement,
+				scorers:        testCase.scorers,
 			}
 
 			_, updated, err := reconciler.reconcile(context.TODO(), testCase.placement)
@@ -184,3 +346,41 @@ func newSyncTarget(name string, ready bool) *workloadv1alpha1.SyncTarget {
 
 	return syncTarget
 }
+
+func newSyncTargetWithAllocatable(name, allocatableCPU, capacityCPU string) *workloadv1alpha1.SyncTarget {
+	syncTarget := newSyncTarget(name, true)
+	syncTarget.Status.Allocatable = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(allocatableCPU)}
+	syncTarget.Status.Capacity = &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(capacityCPU)}
+	return syncTarget
+}
+
+func withAnnotations(placement *schedulingv1alpha1.Placement, annotations map[string]string) *schedulingv1alpha1.Placement {
+	if placement.Annotations == nil {
+		placement.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		placement.Annotations[k] = v
+	}
+	return placement
+}
+
+func withSpread(placement *schedulingv1alpha1.Placement, instances int32, cellKey string) *schedulingv1alpha1.Placement {
+	placement.Spec.Spread = &schedulingv1alpha1.PlacementSpread{
+		Instances: instances,
+		CellKey:   cellKey,
+	}
+	return placement
+}
+
+func withCells(syncTarget *workloadv1alpha1.SyncTarget, cells map[string]string) *workloadv1alpha1.SyncTarget {
+	syncTarget.Spec.Cells = cells
+	return syncTarget
+}
+
+func syncTargetKeys(names ...string) string {
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), name))
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"math"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	schedulingv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/scheduling/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// MaxSyncTargetScore is the highest score a SyncTargetScorer can give to a SyncTarget.
+const MaxSyncTargetScore int64 = 100
+
+// SyncTargetScorer scores a SyncTarget as a candidate for a Placement. The scores of all
+// scorers are summed up, and the SyncTarget with the highest total score is scheduled.
+type SyncTargetScorer interface {
+	// Name identifies the scorer when explaining the score of a SyncTarget.
+	Name() string
+	// Score returns a score between 0 and MaxSyncTargetScore. Higher is better.
+	Score(placement *schedulingv1alpha1.Placement, syncTarget *workloadv1alpha1.SyncTarget) (int64, error)
+}
+
+// NewAllocatableScorer returns a scorer preferring SyncTargets with a higher ratio of allocatable
+// resources to resource capacity. SyncTargets not reporting their capacity get the lowest score.
+func NewAllocatableScorer() SyncTargetScorer {
+	return &allocatableScorer{}
+}
+
+type allocatableScorer struct{}
+
+func (s *allocatableScorer) Name() string {
+	return "allocatable"
+}
+
+func (s *allocatableScorer) Score(_ *schedulingv1alpha1.Placement, syncTarget *workloadv1alpha1.SyncTarget) (int64, error) {
+	if syncTarget.Status.Capacity == nil || syncTarget.Status.Allocatable == nil {
+		return 0, nil
+	}
+
+	var sum float64
+	var count int
+	for name, capacity := range *syncTarget.Status.Capacity {
+		if capacity.IsZero() {
+			continue
+		}
+		count++
+		allocatable, found := (*syncTarget.Status.Allocatable)[name]
+		if !found {
+			continue
+		}
+		ratio := allocatable.AsApproximateFloat64() / capacity.AsApproximateFloat64()
+		sum += math.Max(0, math.Min(1, ratio))
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	return int64(math.Round(sum / float64(count) * float64(MaxSyncTargetScore))), nil
+}
+
+// NewPlacementCountScorer returns a scorer preferring SyncTargets with fewer Placements scheduled
+// to them, spreading Placements over the SyncTargets of a location.
+func NewPlacementCountScorer(countPlacements func(syncTargetKey string) (int, error)) SyncTargetScorer {
+	return &placementCountScorer{countPlacements: countPlacements}
+}
+
+type placementCountScorer struct {
+	countPlacements func(syncTargetKey string) (int, error)
+}
+
+func (s *placementCountScorer) Name() string {
+	return "placements"
+}
+
+func (s *placementCountScorer) Score(_ *schedulingv1alpha1.Placement, syncTarget *workloadv1alpha1.SyncTarget) (int64, error) {
+	count, err := s.countPlacements(workloadv1alpha1.ToSyncTargetKey(logicalcluster.From(syncTarget), syncTarget.Name))
+	if err != nil {
+		return 0, err
+	}
+	return MaxSyncTargetScore / int64(1+count), nil
+}
+
+// NewHeartbeatScorer returns a scorer preferring SyncTargets with a more recent syncer heartbeat.
+// The score decreases linearly from the maximum for a heartbeat at the current time to zero for a
+// heartbeat older than the given threshold.
+func NewHeartbeatScorer(now func() time.Time, threshold time.Duration) SyncTargetScorer {
+	return &heartbeatScorer{now: now, threshold: threshold}
+}
+
+type heartbeatScorer struct {
+	now       func() time.Time
+	threshold time.Duration
+}
+
+func (s *heartbeatScorer) Name() string {
+	return "heartbeat"
+}
+
+func (s *heartbeatScorer) Score(_ *schedulingv1alpha1.Placement, syncTarget *workloadv1alpha1.SyncTarget) (int64, error) {
+	if syncTarget.Status.LastSyncerHeartbeatTime == nil || s.threshold <= 0 {
+		return 0, nil
+	}
+
+	age := s.now().Sub(syncTarget.Status.LastSyncerHeartbeatTime.Time)
+	switch {
+	case age <= 0:
+		return MaxSyncTargetScore, nil
+	case age >= s.threshold:
+		return 0, nil
+	}
+
+	return int64(math.Round(float64(MaxSyncTargetScore) * float64(s.threshold-age) / float64(s.threshold))), nil
+}
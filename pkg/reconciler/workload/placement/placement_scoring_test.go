@@ -0,0 +1,140 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestAllocatableScorer(t *testing.T) {
+	testCases := map[string]struct {
+		allocatable *corev1.ResourceList
+		capacity    *corev1.ResourceList
+		wantScore   int64
+	}{
+		"no capacity reported": {
+			wantScore: 0,
+		},
+		"fully allocatable": {
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			capacity:    &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			wantScore:   100,
+		},
+		"average over resources": {
+			allocatable: &corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			capacity: &corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			wantScore: 38,
+		},
+		"resource without allocatable counts as exhausted": {
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+			capacity: &corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			wantScore: 50,
+		},
+		"zero capacity is ignored": {
+			allocatable: &corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			capacity: &corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("0"),
+			},
+			wantScore: 50,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			syncTarget := newSyncTarget("c1", true)
+			syncTarget.Status.Allocatable = tc.allocatable
+			syncTarget.Status.Capacity = tc.capacity
+
+			score, err := NewAllocatableScorer().Score(newPlacement("test", "test-location", ""), syncTarget)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantScore, score)
+		})
+	}
+}
+
+func TestPlacementCountScorer(t *testing.T) {
+	counts := map[string]int{
+		workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), "c1"): 0,
+		workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), "c2"): 1,
+		workloadv1alpha1.ToSyncTargetKey(logicalcluster.New(""), "c3"): 3,
+	}
+	scorer := NewPlacementCountScorer(func(syncTargetKey string) (int, error) {
+		return counts[syncTargetKey], nil
+	})
+
+	for name, wantScore := range map[string]int64{"c1": 100, "c2": 50, "c3": 25} {
+		score, err := scorer.Score(newPlacement("test", "test-location", ""), newSyncTarget(name, true))
+		require.NoError(t, err)
+		require.Equal(t, wantScore, score, "unexpected score for %s", name)
+	}
+}
+
+func TestHeartbeatScorer(t *testing.T) {
+	now := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		heartbeat *metav1.Time
+		wantScore int64
+	}{
+		"no heartbeat": {
+			wantScore: 0,
+		},
+		"fresh heartbeat": {
+			heartbeat: &metav1.Time{Time: now},
+			wantScore: 100,
+		},
+		"heartbeat half the threshold ago": {
+			heartbeat: &metav1.Time{Time: now.Add(-30 * time.Second)},
+			wantScore: 50,
+		},
+		"heartbeat older than the threshold": {
+			heartbeat: &metav1.Time{Time: now.Add(-2 * time.Minute)},
+			wantScore: 0,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			syncTarget := newSyncTarget("c1", true)
+			syncTarget.Status.LastSyncerHeartbeatTime = tc.heartbeat
+
+			score, err := NewHeartbeatScorer(func() time.Time { return now }, time.Minute).Score(newPlacement("test", "test-location", ""), syncTarget)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantScore, score)
+		})
+	}
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Aggregator computes the status of a resource synced to one or more sync targets from the statuses
+// reported by the syncers in experimental.status.workload.kcp.dev/<sync-target-key>. The statuses are
+// keyed by sync target key, and must not be mutated.
+type Aggregator interface {
+	Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error)
+}
+
+// AggregatorFunc is an Aggregator implemented by a function.
+type AggregatorFunc func(statuses map[string]map[string]interface{}) (map[string]interface{}, error)
+
+func (f AggregatorFunc) Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	return f(statuses)
+}
+
+// Registry holds the aggregators per resource. Resources without an aggregator are not aggregated.
+type Registry struct {
+	aggregators map[schema.GroupResource]Aggregator
+}
+
+// NewRegistry returns a registry with the built-in aggregators for Deployments, StatefulSets,
+// Services, Ingresses and Jobs.
+func NewRegistry() *Registry {
+	r := &Registry{aggregators: map[schema.GroupResource]Aggregator{}}
+	for gr, aggregator := range builtinAggregators {
+		r.Register(gr, aggregator)
+	}
+	return r
+}
+
+// Register sets the aggregator of the given resource, replacing an existing one. It must be
+// called before the controller is started.
+func (r *Registry) Register(gr schema.GroupResource, aggregator Aggregator) {
+	r.aggregators[gr] = aggregator
+}
+
+// Aggregator returns the aggregator of the given resource.
+func (r *Registry) Aggregator(gr schema.GroupResource) (Aggregator, bool) {
+	aggregator, found := r.aggregators[gr]
+	return aggregator, found
+}
+
+// sortedSyncTargets returns the sync target keys of the statuses in a stable order.
+func sortedSyncTargets(statuses map[string]map[string]interface{}) []string {
+	syncTargets := make([]string, 0, len(statuses))
+	for syncTarget := range statuses {
+		syncTargets = append(syncTargets, syncTarget)
+	}
+	sort.Strings(syncTargets)
+	return syncTargets
+}
+
+// firstStatus returns a copy of the status of the first sync target, used for the fields an
+// aggregator has no better strategy for.
+func firstStatus(statuses map[string]map[string]interface{}) map[string]interface{} {
+	syncTargets := sortedSyncTargets(statuses)
+	if len(syncTargets) == 0 {
+		return map[string]interface{}{}
+	}
+	return runtime.DeepCopyJSON(statuses[syncTargets[0]])
+}
+
+// sumField sets the field at path of the aggregated status to the sum of the field over all statuses.
+// The field is left unset if no status has it.
+func sumField(aggregated map[string]interface{}, statuses map[string]map[string]interface{}, path ...string) error {
+	var intSum int64
+	var floatSum float64
+	isFloat, found := false, false
+	for _, syncTarget := range sortedSyncTargets(statuses) {
+		value, ok := nestedValue(statuses[syncTarget], path...)
+		if !ok {
+			continue
+		}
+		found = true
+		switch v := value.(type) {
+		case int64:
+			intSum += v
+		case float64:
+			isFloat = true
+			floatSum += v
+		default:
+			return fmt.Errorf("cannot sum %T at .%s of the status of SyncTarget %q", value, joinPath(path), syncTarget)
+		}
+	}
+	if !found {
+		return nil
+	}
+	if isFloat {
+		return setNestedValue(aggregated, floatSum+float64(intSum), path...)
+	}
+	return setNestedValue(aggregated, intSum, path...)
+}
+
+// minField sets the field at path of the aggregated status to the minimum of the field over all statuses.
+func minField(aggregated map[string]interface{}, statuses map[string]map[string]interface{}, path ...string) error {
+	var minimum interface{}
+	for _, syncTarget := range sortedSyncTargets(statuses) {
+		value, ok := nestedValue(statuses[syncTarget], path...)
+		if !ok {
+			continue
+		}
+		if minimum == nil || less(value, minimum) {
+			minimum = value
+		}
+	}
+	if minimum == nil {
+		return nil
+	}
+	return setNestedValue(aggregated, minimum, path...)
+}
+
+// mergeField sets the field at path of the aggregated status to the merge of the field over all statuses:
+// lists are concatenated without duplicates, maps are merged with the values of the first sync targets
+// winning, and scalars are taken from the first sync target.
+func mergeField(aggregated map[string]interface{}, statuses map[string]map[string]interface{}, path ...string) error {
+	var merged interface{}
+	found := false
+	for _, syncTarget := range sortedSyncTargets(statuses) {
+		value, ok := nestedValue(statuses[syncTarget], path...)
+		if !ok {
+			continue
+		}
+		if !found {
+			merged = runtime.DeepCopyJSONValue(value)
+			found = true
+			continue
+		}
+		merged = mergeValues(merged, value)
+	}
+	if !found {
+		return nil
+	}
+	return setNestedValue(aggregated, merged, path...)
+}
+
+func mergeValues(merged, value interface{}) interface{} {
+	switch m := merged.(type) {
+	case []interface{}:
+		v, ok := value.([]interface{})
+		if !ok {
+			return merged
+		}
+	items:
+		for _, item := range v {
+			for _, existing := range m {
+				if reflect.DeepEqual(existing, item) {
+					continue items
+				}
+			}
+			m = append(m, runtime.DeepCopyJSONValue(item))
+		}
+		return m
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			return merged
+		}
+		for k, item := range v {
+			if existing, found := m[k]; found {
+				m[k] = mergeValues(existing, item)
+			} else {
+				m[k] = runtime.DeepCopyJSONValue(item)
+			}
+		}
+		return m
+	default:
+		return merged
+	}
+}
+
+// mergeConditions sets the conditions of the aggregated status from the conditions of all statuses.
+// A condition is true only if it is true on all sync targets, unless its type is one of the negative
+// polarity types, e.g. Failed, which are true if they are true on any sync target. Otherwise the first
+// condition that is not true is taken. Conditions not reported by all sync targets are dropped while
+// they are true where reported.
+func mergeConditions(aggregated map[string]interface{}, statuses map[string]map[string]interface{}, negativeTypes ...string) error {
+	syncTargets := sortedSyncTargets(statuses)
+
+	var types []string
+	byType := map[string][]map[string]interface{}{}
+	for _, syncTarget := range syncTargets {
+		conditions, ok := nestedValue(statuses[syncTarget], "conditions")
+		if !ok {
+			continue
+		}
+		list, ok := conditions.([]interface{})
+		if !ok {
+			return fmt.Errorf("invalid conditions in the status of SyncTarget %q", syncTarget)
+		}
+		for _, c := range list {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conditionType, _ := condition["type"].(string)
+			if _, found := byType[conditionType]; !found {
+				types = append(types, conditionType)
+			}
+			byType[conditionType] = append(byType[conditionType], condition)
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	negative := map[string]bool{}
+	for _, t := range negativeTypes {
+		negative[t] = true
+	}
+
+	merged := []interface{}{}
+	for _, conditionType := range types {
+		conditions := byType[conditionType]
+
+		var chosen map[string]interface{}
+		if negative[conditionType] {
+			chosen = conditions[0]
+			for _, condition := range conditions {
+				if condition["status"] == "True" {
+					chosen = condition
+					break
+				}
+			}
+		} else {
+			for _, condition := range conditions {
+				if condition["status"] != "True" {
+					chosen = condition
+					break
+				}
+			}
+			if chosen == nil {
+				if len(conditions) < len(syncTargets) {
+					continue
+				}
+				chosen = conditions[0]
+			}
+		}
+		merged = append(merged, runtime.DeepCopyJSON(chosen))
+	}
+	aggregated["conditions"] = merged
+	return nil
+}
+
+func nestedValue(obj map[string]interface{}, path ...string) (interface{}, bool) {
+	var value interface{} = obj
+	for _, field := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[field]; !ok {
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+func setNestedValue(obj map[string]interface{}, value interface{}, path ...string) error {
+	m := obj
+	for i, field := range path[:len(path)-1] {
+		next, found := m[field]
+		if !found || next == nil {
+			next = map[string]interface{}{}
+			m[field] = next
+		}
+		nextMap, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cannot set .%s: .%s is not an object", joinPath(path), joinPath(path[:i+1]))
+		}
+		m = nextMap
+	}
+	m[path[len(path)-1]] = value
+	return nil
+}
+
+// less compares numbers and strings, e.g. RFC3339 timestamps. Other values are never less.
+func less(a, b interface{}) bool {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return a < b
+		case float64:
+			return float64(a) < b
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return a < float64(b)
+		case float64:
+			return a < b
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return a < b
+		}
+	}
+	return false
+}
+
+func joinPath(path []string) string {
+	return strings.Join(path, ".")
+}
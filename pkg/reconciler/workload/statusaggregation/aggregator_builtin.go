@@ -0,0 +1,114 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var builtinAggregators = map[schema.GroupResource]Aggregator{
+	{Group: "apps", Resource: "deployments"}:            AggregatorFunc(aggregateDeploymentStatus),
+	{Group: "apps", Resource: "statefulsets"}:           AggregatorFunc(aggregateStatefulSetStatus),
+	{Group: "", Resource: "services"}:                   AggregatorFunc(aggregateLoadBalancerStatus),
+	{Group: "networking.k8s.io", Resource: "ingresses"}: AggregatorFunc(aggregateLoadBalancerStatus),
+	{Group: "batch", Resource: "jobs"}:                  AggregatorFunc(aggregateJobStatus),
+}
+
+// aggregateDeploymentStatus sums up the replicas of all sync targets. The observed generation is the
+// lowest one, and the conditions are merged.
+func aggregateDeploymentStatus(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	aggregated := firstStatus(statuses)
+	for _, field := range []string{"replicas", "updatedReplicas", "readyReplicas", "availableReplicas", "unavailableReplicas"} {
+		if err := sumField(aggregated, statuses, field); err != nil {
+			return nil, err
+		}
+	}
+	if err := minField(aggregated, statuses, "observedGeneration"); err != nil {
+		return nil, err
+	}
+	if err := mergeConditions(aggregated, statuses, "ReplicaFailure"); err != nil {
+		return nil, err
+	}
+	return aggregated, nil
+}
+
+// aggregateStatefulSetStatus sums up the replicas of all sync targets. The observed generation is the
+// lowest one, and the conditions are merged. The revisions are those of the first sync target.
+func aggregateStatefulSetStatus(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	aggregated := firstStatus(statuses)
+	for _, field := range []string{"replicas", "readyReplicas", "currentReplicas", "updatedReplicas", "availableReplicas"} {
+		if err := sumField(aggregated, statuses, field); err != nil {
+			return nil, err
+		}
+	}
+	if err := minField(aggregated, statuses, "observedGeneration"); err != nil {
+		return nil, err
+	}
+	if err := mergeConditions(aggregated, statuses); err != nil {
+		return nil, err
+	}
+	return aggregated, nil
+}
+
+// aggregateLoadBalancerStatus merges the load balancer ingress points of Services and Ingresses of all
+// sync targets, and the conditions.
+func aggregateLoadBalancerStatus(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	aggregated := firstStatus(statuses)
+	if err := mergeField(aggregated, statuses, "loadBalancer", "ingress"); err != nil {
+		return nil, err
+	}
+	if err := mergeConditions(aggregated, statuses); err != nil {
+		return nil, err
+	}
+	return aggregated, nil
+}
+
+// aggregateJobStatus sums up the pods of all sync targets. The job started with the earliest start
+// time, and it is complete when it is complete on all sync targets, but it failed when it failed on
+// any of them.
+func aggregateJobStatus(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	aggregated := firstStatus(statuses)
+	for _, field := range []string{"active", "succeeded", "failed", "ready"} {
+		if err := sumField(aggregated, statuses, field); err != nil {
+			return nil, err
+		}
+	}
+	if err := minField(aggregated, statuses, "startTime"); err != nil {
+		return nil, err
+	}
+
+	delete(aggregated, "completionTime")
+	var completionTime interface{}
+	for _, status := range statuses {
+		value, found := nestedValue(status, "completionTime")
+		if !found {
+			completionTime = nil
+			break
+		}
+		if completionTime == nil || less(completionTime, value) {
+			completionTime = value
+		}
+	}
+	if completionTime != nil {
+		aggregated["completionTime"] = completionTime
+	}
+
+	if err := mergeConditions(aggregated, statuses, "Failed", "Suspended"); err != nil {
+		return nil, err
+	}
+	return aggregated, nil
+}
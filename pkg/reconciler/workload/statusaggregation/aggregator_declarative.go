@@ -0,0 +1,176 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// AggregationOperation is the way a status field is aggregated over the sync targets.
+type AggregationOperation string
+
+const (
+	// AggregationOperationSum sums up the numeric field of all sync targets.
+	AggregationOperationSum AggregationOperation = "Sum"
+	// AggregationOperationMerge concatenates the list field of all sync targets without duplicates,
+	// or merges the object field with the values of the first sync targets winning.
+	AggregationOperationMerge AggregationOperation = "Merge"
+)
+
+// Config declares status aggregators for resources without a built-in one, e.g. for CRDs.
+type Config struct {
+	Resources []ResourceAggregation `json:"resources"`
+}
+
+// ResourceAggregation declares how the status of a resource is aggregated.
+type ResourceAggregation struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+
+	// fields lists the aggregated fields. The other fields are taken from the status
+	// of the first sync target in the order of their keys.
+	Fields []FieldAggregation `json:"fields"`
+}
+
+// FieldAggregation declares how a status field is aggregated.
+type FieldAggregation struct {
+	// path is the JSONPath of the field relative to the status, e.g. ".readyReplicas". Only
+	// field names are supported, no array indices, wildcards or filters.
+	Path string `json:"path"`
+
+	// operation is either Sum or Merge.
+	Operation AggregationOperation `json:"operation"`
+}
+
+// LoadConfig reads the declarative status aggregators from a YAML or JSON file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status aggregation config %q: %w", path, err)
+	}
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse status aggregation config %q: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid status aggregation config %q: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Validate checks the resources and fields of the config.
+func (c *Config) Validate() error {
+	seen := map[schema.GroupResource]bool{}
+	for i, r := range c.Resources {
+		gr := schema.GroupResource{Group: r.Group, Resource: r.Resource}
+		if r.Resource == "" {
+			return fmt.Errorf("resources[%d].resource must not be empty", i)
+		}
+		if seen[gr] {
+			return fmt.Errorf("resources[%d]: duplicate resource %q", i, gr)
+		}
+		seen[gr] = true
+		for j, f := range r.Fields {
+			if _, err := parseFieldPath(f.Path); err != nil {
+				return fmt.Errorf("resources[%d].fields[%d].path: %w", i, j, err)
+			}
+			switch f.Operation {
+			case AggregationOperationSum, AggregationOperationMerge:
+			default:
+				return fmt.Errorf("resources[%d].fields[%d].operation: unsupported operation %q, must be %q or %q", i, j, f.Operation, AggregationOperationSum, AggregationOperationMerge)
+			}
+		}
+	}
+	return nil
+}
+
+// Register registers the declared aggregators, replacing built-in ones for the same resources.
+func (c *Config) Register(registry *Registry) error {
+	for _, r := range c.Resources {
+		aggregator, err := NewDeclarativeAggregator(r.Fields)
+		if err != nil {
+			return err
+		}
+		registry.Register(schema.GroupResource{Group: r.Group, Resource: r.Resource}, aggregator)
+	}
+	return nil
+}
+
+type declarativeAggregator struct {
+	fields []declarativeField
+}
+
+type declarativeField struct {
+	path      []string
+	operation AggregationOperation
+}
+
+// NewDeclarativeAggregator returns an aggregator applying the given operations to the given fields,
+// and taking the other fields from the status of the first sync target.
+func NewDeclarativeAggregator(fields []FieldAggregation) (Aggregator, error) {
+	a := &declarativeAggregator{}
+	for _, f := range fields {
+		path, err := parseFieldPath(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		a.fields = append(a.fields, declarativeField{path: path, operation: f.Operation})
+	}
+	return a, nil
+}
+
+func (a *declarativeAggregator) Aggregate(statuses map[string]map[string]interface{}) (map[string]interface{}, error) {
+	aggregated := firstStatus(statuses)
+	for _, f := range a.fields {
+		var err error
+		switch f.operation {
+		case AggregationOperationSum:
+			err = sumField(aggregated, statuses, f.path...)
+		case AggregationOperationMerge:
+			err = mergeField(aggregated, statuses, f.path...)
+		default:
+			err = fmt.Errorf("unsupported operation %q", f.operation)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return aggregated, nil
+}
+
+// parseFieldPath parses a JSONPath of field names like ".loadBalancer.ingress" or "{.loadBalancer.ingress}".
+func parseFieldPath(path string) ([]string, error) {
+	p := strings.TrimSpace(path)
+	if strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}") {
+		p = p[1 : len(p)-1]
+	}
+	if !strings.HasPrefix(p, ".") {
+		return nil, fmt.Errorf("path %q must start with a dot", path)
+	}
+	fields := strings.Split(p[1:], ".")
+	for _, field := range fields {
+		if field == "" || strings.ContainsAny(field, "[]*?@$() ") {
+			return nil, fmt.Errorf("path %q must consist of field names only", path)
+		}
+	}
+	return fields, nil
+}
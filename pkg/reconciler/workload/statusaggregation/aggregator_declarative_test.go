@@ -0,0 +1,160 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+func TestDeclarativeAggregator(t *testing.T) {
+	aggregator, err := NewDeclarativeAggregator([]FieldAggregation{
+		{Path: ".readyWidgets", Operation: AggregationOperationSum},
+		{Path: "{.endpoints.urls}", Operation: AggregationOperationMerge},
+		{Path: ".labels", Operation: AggregationOperationMerge},
+		{Path: ".missing", Operation: AggregationOperationSum},
+	})
+	require.NoError(t, err)
+
+	got, err := aggregator.Aggregate(statusesFromJSON(t, map[string]string{
+		"cluster1": `{"phase":"Ready","readyWidgets":2,"endpoints":{"urls":["https://a"]},"labels":{"a":"1","b":"1"}}`,
+		"cluster2": `{"phase":"Pending","readyWidgets":1.5,"endpoints":{"urls":["https://b","https://a"]},"labels":{"b":"2","c":"2"}}`,
+	}))
+	require.NoError(t, err)
+
+	var want map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"phase":"Ready","readyWidgets":3.5,"endpoints":{"urls":["https://a","https://b"]},"labels":{"a":"1","b":"1","c":"2"}}`), &want))
+	require.Equal(t, want, got)
+}
+
+func TestParseFieldPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: ".readyReplicas", want: []string{"readyReplicas"}},
+		{path: "{.loadBalancer.ingress}", want: []string{"loadBalancer", "ingress"}},
+		{path: "readyReplicas", wantErr: true},
+		{path: ".", wantErr: true},
+		{path: ".a..b", wantErr: true},
+		{path: ".items[0]", wantErr: true},
+		{path: ".items[*].name", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseFieldPath(tt.path)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			config: `
+resources:
+- group: example.dev
+  resource: widgets
+  fields:
+  - path: .readyWidgets
+    operation: Sum
+  - path: .endpoints
+    operation: Merge
+`,
+		},
+		{
+			name: "unknown operation",
+			config: `
+resources:
+- group: example.dev
+  resource: widgets
+  fields:
+  - path: .readyWidgets
+    operation: Max
+`,
+			wantErr: `unsupported operation "Max"`,
+		},
+		{
+			name: "invalid path",
+			config: `
+resources:
+- group: example.dev
+  resource: widgets
+  fields:
+  - path: .items[*].ready
+    operation: Sum
+`,
+			wantErr: "must consist of field names only",
+		},
+		{
+			name: "duplicate resource",
+			config: `
+resources:
+- group: example.dev
+  resource: widgets
+- group: example.dev
+  resource: widgets
+`,
+			wantErr: "duplicate resource",
+		},
+		{
+			name: "unknown field",
+			config: `
+resources:
+- group: example.dev
+  resources: widgets
+`,
+			wantErr: "unknown field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.config), 0600))
+
+			config, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			registry := NewRegistry()
+			require.NoError(t, config.Register(registry))
+			_, found := registry.Aggregator(schema.GroupResource{Group: "example.dev", Resource: "widgets"})
+			require.True(t, found)
+		})
+	}
+}
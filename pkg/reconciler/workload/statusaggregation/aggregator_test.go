@@ -0,0 +1,157 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+)
+
+func statusesFromJSON(t *testing.T, statuses map[string]string) map[string]map[string]interface{} {
+	t.Helper()
+	ret := map[string]map[string]interface{}{}
+	for syncTarget, s := range statuses {
+		var status map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(s), &status))
+		ret[syncTarget] = status
+	}
+	return ret
+}
+
+func TestBuiltinAggregators(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource schema.GroupResource
+		statuses map[string]string
+		want     string
+	}{
+		{
+			name:     "deployment replicas are summed up",
+			resource: schema.GroupResource{Group: "apps", Resource: "deployments"},
+			statuses: map[string]string{
+				"cluster1": `{"observedGeneration":2,"replicas":2,"readyReplicas":2,"availableReplicas":2,"updatedReplicas":2,"conditions":[{"type":"Available","status":"True","reason":"MinimumReplicasAvailable"}]}`,
+				"cluster2": `{"observedGeneration":1,"replicas":3,"readyReplicas":1,"availableReplicas":1,"updatedReplicas":3,"unavailableReplicas":2,"conditions":[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable"}]}`,
+			},
+			want: `{"observedGeneration":1,"replicas":5,"readyReplicas":3,"availableReplicas":3,"updatedReplicas":5,"unavailableReplicas":2,"conditions":[{"type":"Available","status":"False","reason":"MinimumReplicasUnavailable"}]}`,
+		},
+		{
+			name:     "deployment on a single synctarget",
+			resource: schema.GroupResource{Group: "apps", Resource: "deployments"},
+			statuses: map[string]string{
+				"cluster1": `{"replicas":2,"readyReplicas":2,"conditions":[{"type":"Available","status":"True"}]}`,
+			},
+			want: `{"replicas":2,"readyReplicas":2,"conditions":[{"type":"Available","status":"True"}]}`,
+		},
+		{
+			name:     "statefulset replicas are summed up",
+			resource: schema.GroupResource{Group: "apps", Resource: "statefulsets"},
+			statuses: map[string]string{
+				"cluster1": `{"replicas":1,"readyReplicas":1,"currentReplicas":1,"updatedReplicas":1,"currentRevision":"a","updateRevision":"a"}`,
+				"cluster2": `{"replicas":1,"readyReplicas":0,"currentReplicas":1,"updatedReplicas":1,"currentRevision":"b","updateRevision":"b"}`,
+			},
+			want: `{"replicas":2,"readyReplicas":1,"currentReplicas":2,"updatedReplicas":2,"currentRevision":"a","updateRevision":"a"}`,
+		},
+		{
+			name:     "service load balancer ingress points are merged",
+			resource: schema.GroupResource{Resource: "services"},
+			statuses: map[string]string{
+				"cluster1": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]}}`,
+				"cluster2": `{"loadBalancer":{"ingress":[{"ip":"10.0.0.2"},{"ip":"10.0.0.1"}]}}`,
+				"cluster3": `{"loadBalancer":{}}`,
+			},
+			want: `{"loadBalancer":{"ingress":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}]}}`,
+		},
+		{
+			name:     "ingress load balancer ingress points are merged",
+			resource: schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"},
+			statuses: map[string]string{
+				"cluster1": `{"loadBalancer":{"ingress":[{"hostname":"a.example.com"}]}}`,
+				"cluster2": `{"loadBalancer":{"ingress":[{"hostname":"b.example.com"}]}}`,
+			},
+			want: `{"loadBalancer":{"ingress":[{"hostname":"a.example.com"},{"hostname":"b.example.com"}]}}`,
+		},
+		{
+			name:     "job is not complete until complete on all synctargets",
+			resource: schema.GroupResource{Group: "batch", Resource: "jobs"},
+			statuses: map[string]string{
+				"cluster1": `{"startTime":"2022-10-01T00:01:00Z","completionTime":"2022-10-01T00:02:00Z","succeeded":1,"conditions":[{"type":"Complete","status":"True"}]}`,
+				"cluster2": `{"startTime":"2022-10-01T00:00:00Z","active":1}`,
+			},
+			want: `{"startTime":"2022-10-01T00:00:00Z","succeeded":1,"active":1,"conditions":[]}`,
+		},
+		{
+			name:     "job is complete when complete on all synctargets",
+			resource: schema.GroupResource{Group: "batch", Resource: "jobs"},
+			statuses: map[string]string{
+				"cluster1": `{"startTime":"2022-10-01T00:01:00Z","completionTime":"2022-10-01T00:02:00Z","succeeded":1,"conditions":[{"type":"Complete","status":"True"}]}`,
+				"cluster2": `{"startTime":"2022-10-01T00:00:00Z","completionTime":"2022-10-01T00:03:00Z","succeeded":1,"conditions":[{"type":"Complete","status":"True"}]}`,
+			},
+			want: `{"startTime":"2022-10-01T00:00:00Z","completionTime":"2022-10-01T00:03:00Z","succeeded":2,"conditions":[{"type":"Complete","status":"True"}]}`,
+		},
+		{
+			name:     "job failed when failed on any synctarget",
+			resource: schema.GroupResource{Group: "batch", Resource: "jobs"},
+			statuses: map[string]string{
+				"cluster1": `{"succeeded":1,"conditions":[{"type":"Complete","status":"True"}]}`,
+				"cluster2": `{"failed":3,"conditions":[{"type":"Failed","status":"True","reason":"BackoffLimitExceeded"}]}`,
+			},
+			want: `{"succeeded":1,"failed":3,"conditions":[{"type":"Failed","status":"True","reason":"BackoffLimitExceeded"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aggregator, found := NewRegistry().Aggregator(tt.resource)
+			require.True(t, found)
+
+			got, err := aggregator.Aggregate(statusesFromJSON(t, tt.statuses))
+			require.NoError(t, err)
+
+			var want map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(tt.want), &want))
+			require.Equal(t, want, got)
+		})
+	}
+}
+
+func TestBuiltinAggregatorsDoNotMutateStatuses(t *testing.T) {
+	statuses := statusesFromJSON(t, map[string]string{
+		"cluster1": `{"replicas":1,"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]},"conditions":[{"type":"Available","status":"True"}]}`,
+		"cluster2": `{"replicas":2,"loadBalancer":{"ingress":[{"ip":"10.0.0.2"}]},"conditions":[{"type":"Available","status":"True"}]}`,
+	})
+	original := statusesFromJSON(t, map[string]string{
+		"cluster1": `{"replicas":1,"loadBalancer":{"ingress":[{"ip":"10.0.0.1"}]},"conditions":[{"type":"Available","status":"True"}]}`,
+		"cluster2": `{"replicas":2,"loadBalancer":{"ingress":[{"ip":"10.0.0.2"}]},"conditions":[{"type":"Available","status":"True"}]}`,
+	})
+
+	for gr, aggregator := range builtinAggregators {
+		_, err := aggregator.Aggregate(statuses)
+		require.NoError(t, err, "aggregator of %s", gr)
+		require.Equal(t, original, statuses, "aggregator of %s mutated the statuses", gr)
+	}
+}
+
+func TestSumFieldRejectsNonNumbers(t *testing.T) {
+	statuses := statusesFromJSON(t, map[string]string{
+		"cluster1": `{"replicas":"1"}`,
+	})
+	err := sumField(map[string]interface{}{}, statuses, "replicas")
+	require.Error(t, err)
+}
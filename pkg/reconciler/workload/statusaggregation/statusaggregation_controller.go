@@ -0,0 +1,261 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/informer"
+	"github.com/kcp-dev/kcp/pkg/logging"
+)
+
+const controllerName = "kcp-workload-status-aggregation"
+
+// NewController returns a new controller which sets the status of resources from the statuses
+// reported per sync target in experimental.status.workload.kcp.dev/<sync-target-key>, using the
+// aggregator registered for the resource.
+func NewController(
+	dynamicClusterClient dynamic.Interface,
+	ddsif *informer.DynamicDiscoverySharedInformerFactory,
+	registry *Registry,
+) (*controller, error) {
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+
+	c := &controller{
+		queue: queue,
+
+		dynClusterClient: dynamicClusterClient,
+		ddsif:            ddsif,
+		registry:         registry,
+	}
+
+	c.ddsif.AddEventHandler(informer.GVREventHandlerFuncs{
+		AddFunc:    func(gvr schema.GroupVersionResource, obj interface{}) { c.enqueueResource(gvr, obj) },
+		UpdateFunc: func(gvr schema.GroupVersionResource, _, obj interface{}) { c.enqueueResource(gvr, obj) },
+		DeleteFunc: nil, // Nothing to do.
+	})
+
+	return c, nil
+}
+
+type controller struct {
+	queue workqueue.RateLimitingInterface
+
+	dynClusterClient dynamic.Interface
+	ddsif            *informer.DynamicDiscoverySharedInformerFactory
+
+	registry *Registry
+}
+
+func hasStatusAnnotation(obj metav1.Object) bool {
+	for k := range obj.GetAnnotations() {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *controller) enqueueResource(gvr schema.GroupVersionResource, obj interface{}) {
+	if _, found := c.registry.Aggregator(gvr.GroupResource()); !found {
+		return
+	}
+	metaObj, ok := obj.(metav1.Object)
+	if !ok || !hasStatusAnnotation(metaObj) {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	queueKey := strings.Join([]string{gvr.Resource, gvr.Version, gvr.Group}, ".") + "::" + key
+	logger := logging.WithQueueKey(logging.WithReconciler(klog.Background(), controllerName), queueKey)
+	logger.V(2).Info("queueing resource")
+	c.queue.Add(queueKey)
+}
+
+// Start starts the controller, which stops when ctx.Done() is closed.
+func (c *controller) Start(ctx context.Context, numThreads int) {
+	defer runtime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	logger := logging.WithReconciler(klog.FromContext(ctx), controllerName)
+	ctx = klog.NewContext(ctx, logger)
+	logger.Info("Starting controller")
+	defer logger.Info("Shutting down controller")
+
+	for i := 0; i < numThreads; i++ {
+		go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+	}
+
+	<-ctx.Done()
+}
+
+func (c *controller) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *controller) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	logger := logging.WithQueueKey(klog.FromContext(ctx), key)
+	ctx = klog.NewContext(ctx, logger)
+	logger.V(1).Info("processing key")
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		runtime.HandleError(fmt.Errorf("%q controller failed to sync %q, err: %w", controllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// key is gvr::KEY
+func (c *controller) process(ctx context.Context, key string) error {
+	logger := klog.FromContext(ctx)
+	parts := strings.SplitN(key, "::", 2)
+	if len(parts) != 2 {
+		logger.Info("error parsing key; dropping")
+		return nil
+	}
+	gvr, _ := schema.ParseResourceArg(parts[0])
+	if gvr == nil {
+		logger.Info("error parsing GVR; dropping")
+		return nil
+	}
+	key = parts[1]
+
+	aggregator, found := c.registry.Aggregator(gvr.GroupResource())
+	if !found {
+		return nil
+	}
+
+	inf, err := c.ddsif.ForResource(*gvr)
+	if err != nil {
+		return err
+	}
+	obj, exists, err := inf.Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		logger.V(3).Info("object does not exist")
+		return nil
+	}
+	unstr, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		logger.WithValues("objectType", fmt.Sprintf("%T", obj)).Info("object was not Unstructured, dropping")
+		return nil
+	}
+
+	updated, err := aggregateStatus(unstr, aggregator)
+	if err != nil {
+		// retrying will not help until the syncers report another status.
+		logger.Error(err, "failed to aggregate status")
+		return nil
+	}
+	if updated == nil {
+		return nil
+	}
+
+	logger.V(2).Info("updating aggregated status")
+	_, err = c.dynClusterClient.Resource(*gvr).Namespace(updated.GetNamespace()).
+		UpdateStatus(logicalcluster.WithCluster(ctx, logicalcluster.From(updated)), updated, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		// a new event will come
+		return nil
+	}
+	return err
+}
+
+// aggregateStatus returns a copy of the object with the status aggregated from the statuses reported by
+// the sync targets the object is synced to, or nil if the status is up-to-date.
+func aggregateStatus(obj *unstructured.Unstructured, aggregator Aggregator) (*unstructured.Unstructured, error) {
+	statuses := map[string]map[string]interface{}{}
+	for k, v := range obj.GetAnnotations() {
+		if !strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+			continue
+		}
+		syncTarget := strings.TrimPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix)
+		if obj.GetLabels()[workloadv1alpha1.ClusterResourceStateLabelPrefix+syncTarget] != string(workloadv1alpha1.ResourceStateSync) {
+			// left-over of a sync target the object is not synced to anymore
+			continue
+		}
+		var status map[string]interface{}
+		if err := json.Unmarshal([]byte(v), &status); err != nil {
+			return nil, fmt.Errorf("invalid status reported by SyncTarget %q: %w", syncTarget, err)
+		}
+		if status == nil {
+			status = map[string]interface{}{}
+		}
+		statuses[syncTarget] = status
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	aggregated, err := aggregator.Aggregate(statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, _, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		existing = nil
+	}
+	if equality.Semantic.DeepEqual(existing, aggregated) {
+		return nil, nil
+	}
+
+	updated := obj.DeepCopy()
+	if err := unstructured.SetNestedMap(updated.Object, aggregated, "status"); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
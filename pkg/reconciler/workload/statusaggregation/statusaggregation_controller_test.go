@@ -0,0 +1,126 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+func TestAggregateStatus(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		status      map[string]interface{}
+
+		wantUpdate bool
+		wantStatus map[string]interface{}
+		wantErr    bool
+	}{
+		{
+			name: "no status reported",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+		},
+		{
+			name: "status of multiple synctargets",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster2": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: map[string]string{
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster1": `{"replicas":1,"readyReplicas":1}`,
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster2": `{"replicas":2,"readyReplicas":1}`,
+			},
+			wantUpdate: true,
+			wantStatus: map[string]interface{}{"replicas": int64(3), "readyReplicas": int64(2)},
+		},
+		{
+			name: "status up-to-date",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster2": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: map[string]string{
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster1": `{"replicas":1,"readyReplicas":1}`,
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster2": `{"replicas":2,"readyReplicas":1}`,
+			},
+			status: map[string]interface{}{"replicas": int64(3), "readyReplicas": int64(2)},
+		},
+		{
+			name: "status of a synctarget not synced to anymore is ignored",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: map[string]string{
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster1": `{"replicas":1,"readyReplicas":1}`,
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster2": `{"replicas":2,"readyReplicas":1}`,
+			},
+			status:     map[string]interface{}{"replicas": int64(3), "readyReplicas": int64(2)},
+			wantUpdate: true,
+			wantStatus: map[string]interface{}{"replicas": int64(1), "readyReplicas": int64(1)},
+		},
+		{
+			name: "invalid status",
+			labels: map[string]string{
+				workloadv1alpha1.ClusterResourceStateLabelPrefix + "cluster1": string(workloadv1alpha1.ResourceStateSync),
+			},
+			annotations: map[string]string{
+				workloadv1alpha1.InternalClusterStatusAnnotationPrefix + "cluster1": `{`,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("apps/v1")
+			obj.SetKind("Deployment")
+			obj.SetNamespace("default")
+			obj.SetName("test")
+			obj.SetLabels(tt.labels)
+			obj.SetAnnotations(tt.annotations)
+			if tt.status != nil {
+				require.NoError(t, unstructured.SetNestedMap(obj.Object, tt.status, "status"))
+			}
+
+			aggregator, _ := NewRegistry().Aggregator(schema.GroupResource{Group: "apps", Resource: "deployments"})
+			updated, err := aggregateStatus(obj, aggregator)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if !tt.wantUpdate {
+				require.Nil(t, updated)
+				return
+			}
+			require.NotNil(t, updated)
+			status, _, err := unstructured.NestedMap(updated.Object, "status")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
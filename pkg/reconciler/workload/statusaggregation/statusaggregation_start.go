@@ -0,0 +1,58 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statusaggregation
+
+import (
+	"github.com/spf13/pflag"
+)
+
+func DefaultOptions() *Options {
+	return &Options{}
+}
+
+func BindOptions(o *Options, fs *pflag.FlagSet) *Options {
+	fs.StringVar(&o.ConfigFile, "status-aggregation-config", o.ConfigFile, "Path to a YAML file declaring how the status of resources without built-in status aggregation, e.g. of CRDs, is aggregated from the statuses reported by multiple sync targets")
+	return o
+}
+
+type Options struct {
+	ConfigFile string
+}
+
+func (o *Options) Validate() error {
+	if o.ConfigFile == "" {
+		return nil
+	}
+	_, err := LoadConfig(o.ConfigFile)
+	return err
+}
+
+// Registry returns the registry with the built-in aggregators and the ones declared in the config file.
+func (o *Options) Registry() (*Registry, error) {
+	registry := NewRegistry()
+	if o.ConfigFile == "" {
+		return registry, nil
+	}
+	config, err := LoadConfig(o.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Register(registry); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
@@ -50,6 +50,8 @@ import (
 	kcpadmissioninitializers "github.com/kcp-dev/kcp/pkg/admission/initializers"
 	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/authorization"
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
 	"github.com/kcp-dev/kcp/pkg/embeddedetcd"
@@ -113,6 +115,12 @@ type ExtraConfig struct {
 	//
 	// TemporaryRootShardKcpSharedInformerFactory bring data from the root shard
 	TemporaryRootShardKcpSharedInformerFactory kcpinformers.SharedInformerFactory
+
+	// cacheServerConfig is the config of the cache server, or nil if no cache server is configured.
+	cacheServerConfig *rest.Config
+	// CacheKubeSharedInformerFactory brings the kube objects replicated into the cache server by all
+	// shards. It is nil if no cache server is configured.
+	CacheKubeSharedInformerFactory kubernetesinformers.SharedInformerFactory
 }
 
 type completedConfig struct {
@@ -232,6 +240,22 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		kcpinformers.WithExtraClusterScopedIndexers(indexers.ClusterScoped()),
 		kcpinformers.WithExtraNamespaceScopedIndexers(indexers.NamespaceScoped()),
 	)
+	if len(c.Options.Extra.CacheServerKubeconfigFile) > 0 {
+		c.cacheServerConfig, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: c.Options.Extra.CacheServerKubeconfigFile}, nil,
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for the cache server, err: %w", c.Options.Extra.CacheServerKubeconfigFile, err)
+		}
+		cacheKubeClusterClient, err := cacheclient.NewKubeClusterClientForConfig(c.cacheServerConfig, clientshard.Wildcard)
+		if err != nil {
+			return nil, err
+		}
+		c.CacheKubeSharedInformerFactory = kubernetesinformers.NewSharedInformerFactoryWithOptions(
+			cacheKubeClusterClient.Cluster(logicalcluster.Wildcard),
+			resyncPeriod,
+		)
+	}
 	c.DeepSARClient, err = kubernetesclient.NewClusterForConfig(authorization.WithDeepSARConfig(rest.CopyConfig(c.GenericConfig.LoopbackClientConfig)))
 	if err != nil {
 		return nil, err
@@ -255,7 +279,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		return nil, err
 	}
 
-	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory); err != nil {
+	if err := opts.Authorization.ApplyTo(c.GenericConfig, c.KubeSharedInformerFactory, c.KcpSharedInformerFactory, c.CacheKubeSharedInformerFactory); err != nil {
 		return nil, err
 	}
 	var userToken string
@@ -286,6 +310,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 	c.GenericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, genericConfig *genericapiserver.Config) (secure http.Handler) {
 		apiHandler = WithWildcardListWatchGuard(apiHandler)
 		apiHandler = WithWildcardIdentity(apiHandler)
+		apiHandler = authorization.WithAuthorizationExplanation(apiHandler, genericConfig.Authorization.Authorizer) // inside deep SAR, such that explained SARs can be deep
 		apiHandler = authorization.WithDeepSubjectAccessReview(apiHandler)
 
 		apiHandler = genericapiserver.DefaultBuildHandlerChainFromAuthz(apiHandler, genericConfig)
@@ -346,6 +371,7 @@ func NewConfig(opts *kcpserveroptions.CompletedOptions) (*Config, error) {
 		kcpadmissioninitializers.NewDeepSARClientInitializer(c.DeepSARClient),
 		kcpadmissioninitializers.NewShardBaseURLInitializer(opts.Extra.ShardBaseURL),
 		kcpadmissioninitializers.NewShardExternalURLInitializer(opts.Extra.ShardExternalURL),
+		kcpadmissioninitializers.NewShardNameInitializer(opts.Extra.ShardName),
 		// The external address is provided as a function, as its value may be updated
 		// with the default secure port, when the config is later completed.
 		kcpadmissioninitializers.NewExternalAddressInitializer(func() string { return c.GenericConfig.ExternalAddress }),
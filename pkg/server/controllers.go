@@ -31,6 +31,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	genericapiserver "k8s.io/apiserver/pkg/server"
@@ -39,6 +40,8 @@ import (
 	kubernetesclient "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/metadata"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
 	"k8s.io/klog/v2"
@@ -52,6 +55,8 @@ import (
 	confighomeroot "github.com/kcp-dev/kcp/config/homeroot"
 	configuniversal "github.com/kcp-dev/kcp/config/universal"
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	cacheclient "github.com/kcp-dev/kcp/pkg/cache/client"
+	clientshard "github.com/kcp-dev/kcp/pkg/cache/client/shard"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
 	"github.com/kcp-dev/kcp/pkg/informer"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apibinding"
@@ -59,22 +64,29 @@ import (
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiexport"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/apiresource"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/identitycache"
+	"github.com/kcp-dev/kcp/pkg/reconciler/apis/maximalpermissionpolicy"
 	"github.com/kcp-dev/kcp/pkg/reconciler/apis/permissionclaimlabel"
+	"github.com/kcp-dev/kcp/pkg/reconciler/cache/replication"
 	"github.com/kcp-dev/kcp/pkg/reconciler/kubequota"
 	schedulinglocationstatus "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/location"
 	schedulingplacement "github.com/kcp-dev/kcp/pkg/reconciler/scheduling/placement"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/bootstrap"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspace"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacedeletion"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemigration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacemigration/migration"
+	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspaceownerrole"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspaceshard"
 	"github.com/kcp-dev/kcp/pkg/reconciler/tenancy/clusterworkspacetype"
 	workloadsapiexport "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexport"
 	workloadsapiexportcreate "github.com/kcp-dev/kcp/pkg/reconciler/workload/apiexportcreate"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/defaultplacement"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/heartbeat"
+	workloadmigration "github.com/kcp-dev/kcp/pkg/reconciler/workload/migration"
 	workloadnamespace "github.com/kcp-dev/kcp/pkg/reconciler/workload/namespace"
 	workloadplacement "github.com/kcp-dev/kcp/pkg/reconciler/workload/placement"
 	workloadresource "github.com/kcp-dev/kcp/pkg/reconciler/workload/resource"
+	workloadstatusaggregation "github.com/kcp-dev/kcp/pkg/reconciler/workload/statusaggregation"
 	synctargetcontroller "github.com/kcp-dev/kcp/pkg/reconciler/workload/synctarget"
 	"github.com/kcp-dev/kcp/pkg/reconciler/workload/synctargetexports"
 	"github.com/kcp-dev/kcp/pkg/util"
@@ -301,6 +313,10 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 	if err != nil {
 		return err
 	}
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
 	metadataClusterClient, err := metadata.NewForConfig(config)
 	if err != nil {
 		return err
@@ -317,8 +333,11 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 
 	workspaceDeletionController := clusterworkspacedeletion.NewController(
 		kcpClusterClient,
+		kubeClusterClient,
 		metadataClusterClient,
 		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceTypes(),
+		s.KubeSharedInformerFactory.Core().V1().ConfigMaps(),
 		discoverResourcesFn,
 	)
 
@@ -335,6 +354,85 @@ func (s *Server) installWorkspaceDeletionController(ctx context.Context, config
 	})
 }
 
+func (s *Server) installWorkspaceMigrationController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-migration-controller"
+
+	// workspaces are migrated between shards with admin credentials to the peer shards.
+	loopbackConfig := rest.AddUserAgent(rest.CopyConfig(config), controllerName)
+	logicalClusterMigrator := migration.NewLogicalClusterMigrator(func(shard *tenancyv1alpha1.ClusterWorkspaceShard) (*rest.Config, error) {
+		if shard.Name == s.Options.Extra.ShardName {
+			return loopbackConfig, nil
+		}
+		if len(s.Options.Extra.ShardKubeconfigFile) == 0 {
+			return nil, fmt.Errorf("cannot connect to ClusterWorkspaceShard %q: --shard-kubeconfig-file is not set", shard.Name)
+		}
+		shardConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: s.Options.Extra.ShardKubeconfigFile},
+			&clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{Server: shard.Spec.BaseURL}},
+		).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the kubeconfig from: %s, for ClusterWorkspaceShard %q, err: %w", s.Options.Extra.ShardKubeconfigFile, shard.Name, err)
+		}
+		return rest.AddUserAgent(shardConfig, controllerName), nil
+	})
+
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kcpClusterClient, err := kcpclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	workspaceMigrationController, err := clusterworkspacemigration.NewController(
+		kcpClusterClient,
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaces(),
+		s.KcpSharedInformerFactory.Tenancy().V1alpha1().ClusterWorkspaceShards(),
+		logicalClusterMigrator,
+	)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceMigrationController.Start(ctx, 2)
+		return nil
+	})
+}
+
+func (s *Server) installWorkspaceOwnerRoleController(ctx context.Context, config *rest.Config) error {
+	controllerName := "kcp-workspace-owner-role-controller"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(config, controllerName)
+	kubeClusterClient, err := kubernetesclient.NewClusterForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	workspaceOwnerRoleController := clusterworkspaceownerrole.NewController(
+		kubeClusterClient,
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoles(),
+	)
+
+	return s.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go workspaceOwnerRoleController.Start(ctx, 2)
+		return nil
+	})
+}
+
 func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *rest.Config, ddsif *informer.DynamicDiscoverySharedInformerFactory) error {
 	controllerName := "kcp-workload-resource-scheduler"
 	config = rest.CopyConfig(config)
@@ -369,6 +467,7 @@ func (s *Server) installWorkloadResourceScheduler(ctx context.Context, config *r
 
 func (s *Server) installWorkspaceScheduler(ctx context.Context, config *rest.Config) error {
 	controllerName := "kcp-workspace-scheduler"
+
 	config = rest.CopyConfig(config)
 	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
 
@@ -843,6 +942,84 @@ func (s *Server) installWorkloadNamespaceScheduler(ctx context.Context, config *
 	return nil
 }
 
+func (s *Server) installWorkloadMigrationController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-workload-migration"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := workloadmigration.NewController(
+		kubeClusterClient,
+		s.DynamicDiscoverySharedInformerFactory,
+		s.KcpSharedInformerFactory.Workload().V1alpha1().SyncTargets(),
+		s.KubeSharedInformerFactory.Core().V1().Namespaces(),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) installWorkloadStatusAggregationController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	controllerName := "kcp-workload-status-aggregation"
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), controllerName)
+	dynamicClusterClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	registry, err := s.Options.Controllers.StatusAggregation.Registry()
+	if err != nil {
+		return err
+	}
+
+	c, err := workloadstatusaggregation.NewController(
+		dynamicClusterClient,
+		s.DynamicDiscoverySharedInformerFactory,
+		registry,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := server.AddPostStartHook(postStartHookName(controllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(controllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *Server) installWorkloadPlacementScheduler(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
 	controllerName := "kcp-workload-placement-scheduler"
 	config = rest.CopyConfig(config)
@@ -1129,6 +1306,68 @@ func (s *Server) installApiExportIdentityController(ctx context.Context, config
 	})
 }
 
+func (s *Server) installAPIExportMaximalPermissionPolicyController(ctx context.Context, config *rest.Config, server *genericapiserver.GenericAPIServer) error {
+	config = rest.CopyConfig(config)
+	config = rest.AddUserAgent(kcpclienthelper.SetMultiClusterRoundTripper(config), maximalpermissionpolicy.ControllerName)
+
+	kubeClusterClient, err := kubernetesclient.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c, err := maximalpermissionpolicy.NewController(
+		kubeClusterClient,
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoles(),
+		s.KubeSharedInformerFactory.Rbac().V1().ClusterRoleBindings(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return server.AddPostStartHook(postStartHookName(maximalpermissionpolicy.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(maximalpermissionpolicy.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+
+		return nil
+	})
+}
+
+func (s *Server) installCacheReplicationController(ctx context.Context) error {
+	cacheConfig := rest.AddUserAgent(rest.CopyConfig(s.cacheServerConfig), replication.ControllerName)
+	cacheClusterClient, err := cacheclient.NewDynamicClusterClientForConfig(cacheConfig, clientshard.New(s.Options.Extra.ShardName))
+	if err != nil {
+		return err
+	}
+
+	resources := make([]schema.GroupResource, 0, len(s.Options.Extra.CacheReplicatedResources))
+	for _, r := range s.Options.Extra.CacheReplicatedResources {
+		resources = append(resources, schema.ParseGroupResource(r))
+	}
+
+	c, err := replication.NewController(s.Options.Extra.ShardName, cacheClusterClient, s.DynamicDiscoverySharedInformerFactory, resources)
+	if err != nil {
+		return err
+	}
+
+	return s.AddPostStartHook(postStartHookName(replication.ControllerName), func(hookContext genericapiserver.PostStartHookContext) error {
+		logger := klog.FromContext(ctx).WithValues("postStartHook", postStartHookName(replication.ControllerName))
+		if err := s.waitForSync(hookContext.StopCh); err != nil {
+			logger.Error(err, "failed to finish post-start-hook")
+			// nolint:nilerr
+			return nil // don't klog.Fatal. This only happens when context is cancelled.
+		}
+
+		go c.Start(util.GoContext(hookContext), 2)
+		return nil
+	})
+}
+
 func (s *Server) waitForSync(stop <-chan struct{}) error {
 	// Wait for shared informer factories to by synced.
 	// factory. Otherwise, informer list calls may go into backoff (before the CRDs are ready) and
@@ -81,11 +81,11 @@ func (s *Authorization) AddFlags(fs *pflag.FlagSet) {
 			"contacting the 'core' kubernetes server.")
 }
 
-func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory) error {
+// ApplyTo sets up the authorizer chain of kcp. cacheKubeInformer brings the kube objects replicated
+// into the cache server, and is nil if there is no cache server.
+func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubernetesinformers.SharedInformerFactory, kcpinformer kcpinformers.SharedInformerFactory, cacheKubeInformer kubernetesinformers.SharedInformerFactory) error {
 	var authorizers []authorizer.Authorizer
 
-	workspaceLister := kcpinformer.Tenancy().V1alpha1().ClusterWorkspaces().Lister()
-
 	// group authorizer
 	if len(s.AlwaysAllowGroups) > 0 {
 		authorizers = append(authorizers, authorizerfactory.NewPrivilegedGroups(s.AlwaysAllowGroups...))
@@ -101,26 +101,13 @@ func (s *Authorization) ApplyTo(config *genericapiserver.Config, informer kubern
 	}
 
 	// kcp authorizers
-	bootstrapAuth, bootstrapRules := authorization.NewBootstrapPolicyAuthorizer(informer)
-	localAuth, localResolver := authorization.NewLocalAuthorizer(informer)
-	apiBindingAuth, err := authorization.NewAPIBindingAccessAuthorizer(informer, kcpinformer,
-		union.New(bootstrapAuth, localAuth),
-	)
+	kcpAuth, kcpRules, err := authorization.NewKcpAuthorizer(informer, kcpinformer, cacheKubeInformer)
 	if err != nil {
 		return err
 	}
+	authorizers = append(authorizers, kcpAuth)
 
-	authorizers = append(authorizers,
-		authorization.NewTopLevelOrganizationAccessAuthorizer(informer, workspaceLister,
-			authorization.NewWorkspaceContentAuthorizer(informer, workspaceLister,
-				authorization.NewSystemCRDAuthorizer(
-					apiBindingAuth,
-				),
-			),
-		),
-	)
-
-	config.RuleResolver = union.NewRuleResolvers(bootstrapRules, localResolver)
+	config.RuleResolver = kcpRules
 	config.Authorization.Authorizer = union.New(authorizers...)
 	return nil
 }
@@ -128,16 +128,18 @@ var (
 		"tracing-config-file", // File with apiserver tracing configuration.
 
 		// KCP flags
-		"profiler-address",            // [Address]:port to bind the profiler to
-		"root-directory",              // Root directory.
-		"shard-base-url",              // Base URL to this kcp shard. Defaults to external address.
-		"shard-external-url",          // URL used by outside clients to talk to this kcp shard. Defaults to external address.
-		"shard-virtual-workspace-url", // An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.
-		"shard-name",                  // A name of this kcp shard.
-		"shard-kubeconfig-file",       // Kubeconfig holding admin(!) credentials to peer kcp shards.
-		"root-shard-kubeconfig-file",  // Kubeconfig holding admin(!) credentials to the root kcp shard.
-		"experimental-bind-free-port", // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
-		"batteries-included",          // A list of batteries included (= default objects that might be unwanted in production, but very helpful in trying out kcp or development).
+		"profiler-address",             // [Address]:port to bind the profiler to
+		"root-directory",               // Root directory.
+		"shard-base-url",               // Base URL to this kcp shard. Defaults to external address.
+		"shard-external-url",           // URL used by outside clients to talk to this kcp shard. Defaults to external address.
+		"shard-virtual-workspace-url",  // An external URL address of a virtual workspace server associated with this shard. Defaults to shard's base address.
+		"shard-name",                   // A name of this kcp shard.
+		"shard-kubeconfig-file",        // Kubeconfig holding admin(!) credentials to peer kcp shards.
+		"root-shard-kubeconfig-file",   // Kubeconfig holding admin(!) credentials to the root kcp shard.
+		"cache-server-kubeconfig-file", // Kubeconfig for the cache server this shard replicates resources into.
+		"cache-replicated-resources",   // The resources in <resource>.<group> format which are replicated into the cache server.
+		"experimental-bind-free-port",  // Bind to a free port. --secure-bind-port must be 0. Use the admin.kubeconfig to extract the chosen port.
+		"batteries-included",           // A list of batteries included (= default objects that might be unwanted in production, but very helpful in trying out kcp or development).
 
 		// secure serving flags
 		"bind-address",                     // The IP address on which to listen for the --secure-port port. The associated interface(s) must be reachable by the rest of the cluster, and by CLI/web clients. If blank or an unspecified address (0.0.0.0 or ::), all interfaces will be used.
@@ -176,6 +178,7 @@ var (
 		"run-virtual-workspaces",                 // Run the virtual workspaces apiservers in-process
 		"unsupported-run-individual-controllers", // Run individual controllers in-process. The controller names can change at any time.
 		"sync-target-heartbeat-threshold",        // Amount of time to wait for a successful heartbeat before marking the cluster as not ready.
+		"status-aggregation-config",              // Path to a YAML file declaring how the status of resources without built-in status aggregation, e.g. of CRDs, is aggregated from the statuses reported by multiple sync targets
 
 		// generic flags
 		"cors-allowed-origins",                 // List of allowed origins for CORS, comma separated.  An allowed origin can be a regular expression to support subdomain matching. If this list is empty CORS will not be enabled.
@@ -27,6 +27,7 @@ import (
 
 	"github.com/spf13/pflag"
 
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	genericapiserveroptions "k8s.io/apiserver/pkg/server/options"
 	cliflag "k8s.io/component-base/cli/flag"
@@ -35,6 +36,7 @@ import (
 	kubeoptions "k8s.io/kubernetes/pkg/kubeapiserver/options"
 
 	kcpadmission "github.com/kcp-dev/kcp/pkg/admission"
+	cachebootstrap "github.com/kcp-dev/kcp/pkg/cache/server/bootstrap"
 	etcdoptions "github.com/kcp-dev/kcp/pkg/embeddedetcd/options"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/server/options/batteries"
@@ -53,16 +55,18 @@ type Options struct {
 }
 
 type ExtraOptions struct {
-	RootDirectory            string
-	ProfilerAddress          string
-	ShardKubeconfigFile      string
-	RootShardKubeconfigFile  string
-	ShardBaseURL             string
-	ShardExternalURL         string
-	ShardName                string
-	ShardVirtualWorkspaceURL string
-	DiscoveryPollInterval    time.Duration
-	ExperimentalBindFreePort bool
+	RootDirectory             string
+	ProfilerAddress           string
+	ShardKubeconfigFile       string
+	RootShardKubeconfigFile   string
+	CacheServerKubeconfigFile string
+	CacheReplicatedResources  []string
+	ShardBaseURL              string
+	ShardExternalURL          string
+	ShardName                 string
+	ShardVirtualWorkspaceURL  string
+	DiscoveryPollInterval     time.Duration
+	ExperimentalBindFreePort  bool
 
 	BatteriesIncluded []string
 }
@@ -108,6 +112,9 @@ func NewOptions(rootDir string) *Options {
 			BatteriesIncluded:        batteries.Defaults.List(),
 		},
 	}
+	for _, gr := range cachebootstrap.DefaultReplicatedResources {
+		o.Extra.CacheReplicatedResources = append(o.Extra.CacheReplicatedResources, gr.String())
+	}
 
 	// override all the stuff
 	o.GenericControlPlane.SecureServing.ServerCert.CertDirectory = rootDir
@@ -165,6 +172,8 @@ func (o *Options) rawFlags() cliflag.NamedFlagSets {
 	fs.StringVar(&o.Extra.ProfilerAddress, "profiler-address", o.Extra.ProfilerAddress, "[Address]:port to bind the profiler to")
 	fs.StringVar(&o.Extra.ShardKubeconfigFile, "shard-kubeconfig-file", o.Extra.ShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to peer kcp shards.")
 	fs.StringVar(&o.Extra.RootShardKubeconfigFile, "root-shard-kubeconfig-file", o.Extra.RootShardKubeconfigFile, "Kubeconfig holding admin(!) credentials to the root kcp shard.")
+	fs.StringVar(&o.Extra.CacheServerKubeconfigFile, "cache-server-kubeconfig-file", o.Extra.CacheServerKubeconfigFile, "Kubeconfig for the cache server this shard replicates resources into. Replication is disabled if not set.")
+	fs.StringSliceVar(&o.Extra.CacheReplicatedResources, "cache-replicated-resources", o.Extra.CacheReplicatedResources, "The resources in <resource>.<group> format which are replicated into the cache server. They must be served by the cache server, see its --replicated-resources flag.")
 	fs.StringVar(&o.Extra.ShardBaseURL, "shard-base-url", o.Extra.ShardBaseURL, "Base URL to this kcp shard. Defaults to external address.")
 	fs.StringVar(&o.Extra.ShardExternalURL, "shard-external-url", o.Extra.ShardExternalURL, "URL used by outside clients to talk to this kcp shard. Defaults to external address.")
 	fs.StringVar(&o.Extra.ShardName, "shard-name", o.Extra.ShardName, "A name of this kcp shard. Defaults to the \"root\" name.")
@@ -204,6 +213,12 @@ func (o *CompletedOptions) Validate() []error {
 	errs = append(errs, o.Virtual.Validate()...)
 	errs = append(errs, o.HomeWorkspaces.Validate()...)
 
+	for _, r := range o.Extra.CacheReplicatedResources {
+		if _, err := cachebootstrap.CRD(schema.ParseGroupResource(r)); err != nil {
+			errs = append(errs, fmt.Errorf("--cache-replicated-resources: %w", err))
+		}
+	}
+
 	differential := false
 	for i, b := range o.Extra.BatteriesIncluded {
 		if strings.HasPrefix(b, "+") || strings.HasPrefix(b, "-") {
@@ -115,6 +115,10 @@ func (s *Server) Run(ctx context.Context) error {
 		logger := logger.WithValues("postStartHook", hookName)
 		s.KubeSharedInformerFactory.Start(hookContext.StopCh)
 		s.ApiExtensionsSharedInformerFactory.Start(hookContext.StopCh)
+		if s.CacheKubeSharedInformerFactory != nil {
+			// not waited for, the shard must not depend on the availability of the cache server to start
+			s.CacheKubeSharedInformerFactory.Start(hookContext.StopCh)
+		}
 
 		s.KubeSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
 		s.ApiExtensionsSharedInformerFactory.WaitForCacheSync(hookContext.StopCh)
@@ -403,6 +407,12 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installWorkspaceDeletionController(ctx, controllerConfig); err != nil {
 			return err
 		}
+		if err := s.installWorkspaceMigrationController(ctx, controllerConfig); err != nil {
+			return err
+		}
+		if err := s.installWorkspaceOwnerRoleController(ctx, controllerConfig); err != nil {
+			return err
+		}
 	}
 
 	if s.Options.HomeWorkspaces.Enabled {
@@ -417,6 +427,12 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if s.Options.Controllers.EnableAll || enabled.Has("status-aggregation") {
+		if err := s.installWorkloadStatusAggregationController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
+	}
+
 	if s.Options.Controllers.EnableAll || enabled.Has("apibinding") {
 		if err := s.installAPIBindingController(ctx, controllerConfig, delegationChainHead, s.DynamicDiscoverySharedInformerFactory); err != nil {
 			return err
@@ -427,6 +443,9 @@ func (s *Server) Run(ctx context.Context) error {
 		if err := s.installAPIExportController(ctx, controllerConfig, delegationChainHead); err != nil {
 			return err
 		}
+		if err := s.installAPIExportMaximalPermissionPolicyController(ctx, controllerConfig, delegationChainHead); err != nil {
+			return err
+		}
 	}
 
 	if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.LocationAPI) {
@@ -434,6 +453,9 @@ func (s *Server) Run(ctx context.Context) error {
 			if err := s.installWorkloadNamespaceScheduler(ctx, controllerConfig, delegationChainHead); err != nil {
 				return err
 			}
+			if err := s.installWorkloadMigrationController(ctx, controllerConfig, delegationChainHead); err != nil {
+				return err
+			}
 			if err := s.installWorkloadPlacementScheduler(ctx, controllerConfig, delegationChainHead); err != nil {
 				return err
 			}
@@ -455,6 +477,12 @@ func (s *Server) Run(ctx context.Context) error {
 		}
 	}
 
+	if len(s.Options.Extra.CacheServerKubeconfigFile) > 0 && (s.Options.Controllers.EnableAll || enabled.Has("cache-replication")) {
+		if err := s.installCacheReplicationController(ctx); err != nil {
+			return err
+		}
+	}
+
 	if s.Options.Controllers.EnableAll || enabled.Has("quota") {
 		if err := s.installKubeQuotaController(ctx, controllerConfig, delegationChainHead); err != nil {
 			return err
@@ -20,18 +20,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/tools/cache"
@@ -44,34 +48,107 @@ import (
 )
 
 const (
-	byNamespaceLocatorIndexName = "syncer-namespace-ByNamespaceLocator"
-	controllerName              = "kcp-workload-syncer-namespace"
+	byNamespaceLocatorIndexName        = "syncer-namespace-ByNamespaceLocator"
+	byDownstreamNamespaceNameIndexName = "syncer-namespace-ByDownstreamNamespaceName"
+	controllerName                     = "kcp-workload-syncer-namespace"
+
+	// orphanedNamespaceRecheckInterval is the interval at which an orphaned downstream namespace
+	// is checked again, while it still contains objects.
+	orphanedNamespaceRecheckInterval = 5 * time.Minute
 )
 
+// defaultNamespaceObjects are the objects created in every namespace by the downstream cluster itself.
+// They do not prevent an orphaned downstream namespace from being considered empty.
+var defaultNamespaceObjects = map[schema.GroupResource]sets.String{
+	{Group: "", Resource: "configmaps"}:      sets.NewString("kube-root-ca.crt"),
+	{Group: "", Resource: "serviceaccounts"}: sets.NewString("default"),
+	// Events are left over by the objects of the namespace, and are deleted with it.
+	{Group: "", Resource: "events"}:              nil,
+	{Group: "events.k8s.io", Resource: "events"}: nil,
+}
+
+// UpstreamNamespaceExistsFunc checks whether the given upstream namespace exists.
+type UpstreamNamespaceExistsFunc func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error)
+
+// InformerUpstreamNamespaceExists returns an UpstreamNamespaceExistsFunc backed by the namespace
+// informer of the given upstream informer factory.
+func InformerUpstreamNamespaceExists(upstreamInformers dynamicinformer.DynamicSharedInformerFactory) UpstreamNamespaceExistsFunc {
+	namespaceGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	return func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
+		upstreamNamespaceKey := clusters.ToClusterAwareKey(clusterName, upstreamNamespaceName)
+		_, exists, err := upstreamInformers.ForResource(namespaceGVR).Informer().GetIndexer().GetByKey(upstreamNamespaceKey)
+		return exists, err
+	}
+}
+
+// DownstreamNamespaceHasUpstreamFunc checks whether an upstream namespace maps to the given
+// downstream namespace name.
+type DownstreamNamespaceHasUpstreamFunc func(downstreamNamespaceName string) (bool, error)
+
+// InformerDownstreamNamespaceHasUpstream returns a DownstreamNamespaceHasUpstreamFunc backed by the
+// namespace informer of the given upstream informer factory. It relies on the index added to the
+// informer by NewNamespaceController.
+func InformerDownstreamNamespaceHasUpstream(upstreamInformers dynamicinformer.DynamicSharedInformerFactory) DownstreamNamespaceHasUpstreamFunc {
+	namespaceGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	return func(downstreamNamespaceName string) (bool, error) {
+		namespaces, err := upstreamInformers.ForResource(namespaceGVR).Informer().GetIndexer().ByIndex(byDownstreamNamespaceNameIndexName, downstreamNamespaceName)
+		return len(namespaces) > 0, err
+	}
+}
+
 type Controller struct {
 	queue workqueue.RateLimitingInterface
 
 	deleteDownstreamNamespace                  func(ctx context.Context, namespace string) error
-	upstreamNamespaceExists                    func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error)
+	updateDownstreamNamespace                  func(ctx context.Context, namespace *unstructured.Unstructured) error
+	upstreamNamespaceExists                    UpstreamNamespaceExistsFunc
+	downstreamNamespaceHasUpstream             DownstreamNamespaceHasUpstreamFunc
+	getUpstreamNamespace                       func(clusterName logicalcluster.Name, name string) (*unstructured.Unstructured, error)
+	getUpstreamNamespaceFromDownstreamName     func(downstreamNamespaceName string) (*unstructured.Unstructured, error)
 	getDownstreamNamespace                     func(name string) (runtime.Object, error)
+	getDownstreamNamespaceFromServer           func(ctx context.Context, name string) (*unstructured.Unstructured, error)
 	getDownstreamNamespaceFromNamespaceLocator func(namespaceLocator shared.NamespaceLocator) (runtime.Object, error)
+	downstreamNamespaceIsEmpty                 func(ctx context.Context, name string) (bool, error)
 
 	syncTargetName      string
 	syncTargetWorkspace logicalcluster.Name
 	syncTargetUID       types.UID
 	syncTargetKey       string
+
+	// syncedNamespaceLabels are the keys of the upstream namespace labels propagated to the
+	// downstream namespaces. A key ending with "*" matches all keys with that prefix.
+	syncedNamespaceLabels []string
 }
 
+// NewNamespaceController returns a controller keeping the downstream namespaces converged with their
+// upstream namespaces: it restores the namespace locator annotation and the labels of the downstream
+// namespaces, propagates the syncedNamespaceLabels of the upstream namespaces, and deletes downstream
+// namespaces whose upstream namespace is gone. Downstream namespaces whose upstream namespace cannot be
+// determined anymore are deleted once the downstream cluster reports no objects in them anymore.
+//
+// If upstreamNamespaceExists and downstreamNamespaceHasUpstream are nil, the upstream namespace informer
+// is used to look up upstream namespaces. Custom functions are needed when several syncer virtual
+// workspaces share the same downstream namespaces, since a single upstream informer only knows part of them.
 func NewNamespaceController(
 	syncTargetWorkspace logicalcluster.Name,
 	syncTargetName, syncTargetKey string,
 	syncTargetUID types.UID,
 	upstreamClusterClient dynamic.ClusterInterface,
 	downstreamClient dynamic.Interface,
+	downstreamDiscoveryClient discovery.DiscoveryInterface,
 	upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory,
+	upstreamNamespaceExists UpstreamNamespaceExistsFunc,
+	downstreamNamespaceHasUpstream DownstreamNamespaceHasUpstreamFunc,
+	syncedNamespaceLabels []string,
 ) (*Controller, error) {
 	namespaceGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
 	logger := logging.WithReconciler(klog.Background(), controllerName)
+	if upstreamNamespaceExists == nil {
+		upstreamNamespaceExists = InformerUpstreamNamespaceExists(upstreamInformers)
+	}
+	if downstreamNamespaceHasUpstream == nil {
+		downstreamNamespaceHasUpstream = InformerDownstreamNamespaceHasUpstream(upstreamInformers)
+	}
 
 	c := Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
@@ -79,14 +156,36 @@ func NewNamespaceController(
 		deleteDownstreamNamespace: func(ctx context.Context, namespace string) error {
 			return downstreamClient.Resource(namespaceGVR).Delete(ctx, namespace, metav1.DeleteOptions{})
 		},
-		upstreamNamespaceExists: func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
-			upstreamNamespaceKey := clusters.ToClusterAwareKey(clusterName, upstreamNamespaceName)
-			_, exists, err := upstreamInformers.ForResource(namespaceGVR).Informer().GetIndexer().GetByKey(upstreamNamespaceKey)
-			return exists, err
+		updateDownstreamNamespace: func(ctx context.Context, namespace *unstructured.Unstructured) error {
+			_, err := downstreamClient.Resource(namespaceGVR).Update(ctx, namespace, metav1.UpdateOptions{})
+			return err
+		},
+		upstreamNamespaceExists:        upstreamNamespaceExists,
+		downstreamNamespaceHasUpstream: downstreamNamespaceHasUpstream,
+		getUpstreamNamespace: func(clusterName logicalcluster.Name, name string) (*unstructured.Unstructured, error) {
+			obj, exists, err := upstreamInformers.ForResource(namespaceGVR).Informer().GetIndexer().GetByKey(clusters.ToClusterAwareKey(clusterName, name))
+			if err != nil || !exists {
+				return nil, err
+			}
+			return obj.(*unstructured.Unstructured), nil
+		},
+		getUpstreamNamespaceFromDownstreamName: func(downstreamNamespaceName string) (*unstructured.Unstructured, error) {
+			namespaces, err := upstreamInformers.ForResource(namespaceGVR).Informer().GetIndexer().ByIndex(byDownstreamNamespaceNameIndexName, downstreamNamespaceName)
+			if err != nil {
+				return nil, err
+			}
+			if len(namespaces) == 0 {
+				return nil, nil
+			}
+			// The downstream namespace name is a hash of the namespace locator, return the first match.
+			return namespaces[0].(*unstructured.Unstructured), nil
 		},
 		getDownstreamNamespace: func(downstreamNamespaceName string) (runtime.Object, error) {
 			return downstreamInformers.ForResource(namespaceGVR).Lister().Get(downstreamNamespaceName)
 		},
+		getDownstreamNamespaceFromServer: func(ctx context.Context, downstreamNamespaceName string) (*unstructured.Unstructured, error) {
+			return downstreamClient.Resource(namespaceGVR).Get(ctx, downstreamNamespaceName, metav1.GetOptions{})
+		},
 		getDownstreamNamespaceFromNamespaceLocator: func(namespaceLocator shared.NamespaceLocator) (runtime.Object, error) {
 			namespaceLocatorJSONBytes, err := json.Marshal(namespaceLocator)
 			if err != nil {
@@ -102,24 +201,42 @@ func NewNamespaceController(
 			// There should be only one namespace with the same namespace locator, return it.
 			return namespaces[0].(*unstructured.Unstructured), nil
 		},
+		downstreamNamespaceIsEmpty: func(ctx context.Context, downstreamNamespaceName string) (bool, error) {
+			return downstreamNamespaceIsEmpty(ctx, downstreamClient, downstreamDiscoveryClient.ServerPreferredNamespacedResources, downstreamNamespaceName)
+		},
 
 		syncTargetName:      syncTargetName,
 		syncTargetWorkspace: syncTargetWorkspace,
 		syncTargetUID:       syncTargetUID,
 		syncTargetKey:       syncTargetKey,
+
+		syncedNamespaceLabels: syncedNamespaceLabels,
 	}
 
-	// React when there's a namespace deletion upstream.
+	// React when there's a namespace change or deletion upstream.
 	upstreamInformers.ForResource(namespaceGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.AddToQueue(obj, logger)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.AddToQueue(newObj, logger)
+		},
 		DeleteFunc: func(obj interface{}) {
 			c.AddToQueue(obj, logger)
 		},
 	})
 
+	err := upstreamInformers.ForResource(namespaceGVR).Informer().AddIndexers(cache.Indexers{byDownstreamNamespaceNameIndexName: c.indexByDownstreamNamespaceName})
+	if err != nil {
+		return nil, err
+	}
+
 	logger.V(2).Info("Set up upstream namespace informer", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "syncTargetKey", syncTargetKey)
 
 	// Those handlers are for start/resync cases, in case a namespace deletion event is missed, these handlers
-	// will make sure that we cleanup the namespace in downstream after restart/resync.
+	// will make sure that we cleanup the namespace in downstream after restart/resync. They also catch
+	// changes to the downstream namespaces, including the removal of the sync target label, which
+	// removes the namespace from the informer.
 	downstreamInformers.ForResource(namespaceGVR).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			c.AddToQueue(obj, logger)
@@ -127,9 +244,12 @@ func NewNamespaceController(
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			c.AddToQueue(newObj, logger)
 		},
+		DeleteFunc: func(obj interface{}) {
+			c.AddToQueue(obj, logger)
+		},
 	})
 
-	err := downstreamInformers.ForResource(namespaceGVR).Informer().AddIndexers(cache.Indexers{byNamespaceLocatorIndexName: indexByNamespaceLocator})
+	err = downstreamInformers.ForResource(namespaceGVR).Informer().AddIndexers(cache.Indexers{byNamespaceLocatorIndexName: indexByNamespaceLocator})
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +320,79 @@ func (c *Controller) processNextWorkItem(ctx context.Context) bool {
 	return true
 }
 
+// downstreamNamespaceIsEmpty checks against the downstream cluster whether the given namespace contains
+// objects of any namespaced resource type, not only of the synced ones. Objects created by the downstream
+// cluster in every namespace are ignored. Discovery errors are returned, and resource types the syncer
+// is not allowed to list make the namespace non-empty, so that a namespace is never considered empty
+// because a resource type could not be checked.
+func downstreamNamespaceIsEmpty(ctx context.Context, downstreamClient dynamic.Interface, discoverResources func() ([]*metav1.APIResourceList, error), namespace string) (bool, error) {
+	resourceLists, err := discoverResources()
+	if err != nil {
+		return false, err
+	}
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return false, err
+		}
+		for _, resource := range resourceList.APIResources {
+			if !sets.NewString(resource.Verbs...).Has("list") {
+				continue
+			}
+			ignored, isDefault := defaultNamespaceObjects[gv.WithResource(resource.Name).GroupResource()]
+			if isDefault && ignored == nil {
+				continue
+			}
+			// There is at most one default object per resource type, two objects are enough to find another one.
+			list, err := downstreamClient.Resource(gv.WithResource(resource.Name)).Namespace(namespace).List(ctx, metav1.ListOptions{Limit: 2})
+			if apierrors.IsForbidden(err) {
+				return false, nil
+			} else if err != nil {
+				return false, err
+			}
+			for _, item := range list.Items {
+				if !ignored.Has(item.GetName()) {
+					return false, nil
+				}
+			}
+		}
+	}
+	return true, nil
+}
+
+// isSyncedNamespaceLabel returns whether the upstream namespace label with the given key is
+// propagated to the downstream namespace. kcp labels are never propagated.
+func (c *Controller) isSyncedNamespaceLabel(key string) bool {
+	if prefix, _, found := strings.Cut(key, "/"); found && (prefix == "kcp.dev" || strings.HasSuffix(prefix, ".kcp.dev")) {
+		return false
+	}
+	for _, synced := range c.syncedNamespaceLabels {
+		if strings.HasSuffix(synced, "*") {
+			if strings.HasPrefix(key, strings.TrimSuffix(synced, "*")) {
+				return true
+			}
+		} else if key == synced {
+			return true
+		}
+	}
+	return false
+}
+
+// indexByDownstreamNamespaceName is a cache.IndexFunc that indexes upstream namespaces by the name
+// of their downstream namespace.
+func (c *Controller) indexByDownstreamNamespaceName(obj interface{}) ([]string, error) {
+	metaObj, ok := obj.(metav1.Object)
+	if !ok {
+		return []string{}, fmt.Errorf("obj is supposed to be a metav1.Object, but is %T", obj)
+	}
+	locator := shared.NewNamespaceLocator(logicalcluster.From(metaObj), c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, metaObj.GetName())
+	downstreamNamespaceName, err := shared.PhysicalClusterNamespaceName(locator)
+	if err != nil {
+		return []string{}, err
+	}
+	return []string{downstreamNamespaceName}, nil
+}
+
 // indexByNamespaceLocator is a cache.IndexFunc that indexes namespaces by the namespaceLocator annotation.
 func indexByNamespaceLocator(obj interface{}) ([]string, error) {
 	metaObj, ok := obj.(metav1.Object)
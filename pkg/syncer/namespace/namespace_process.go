@@ -19,6 +19,9 @@ package namespace
 import (
 	"context"
 	"encoding/json"
+	"reflect"
+
+	"github.com/kcp-dev/logicalcluster/v2"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -26,6 +29,7 @@ import (
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/logging"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
@@ -44,41 +48,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	//
 	// This happens on syncer start or during a resync.
 	if clusterName.Empty() {
-		downstreamNamespaceObj, err := c.getDownstreamNamespace(namespaceName)
-		if apierrors.IsNotFound(err) {
-			logger.V(4).Info("downstream namespace not found, ignoring key", "namespace", namespaceName)
-			return nil
-		} else if err != nil {
-			logger.Error(err, "failed to get downstream namespace", "namespace", namespaceName)
-			return nil
-		}
-
-		downstreamNamespace := downstreamNamespaceObj.(*unstructured.Unstructured)
-		logger := logging.WithObject(logger, downstreamNamespace)
-
-		namespaceLocatorJSON := downstreamNamespace.GetAnnotations()[shared.NamespaceLocatorAnnotation]
-		if namespaceLocatorJSON == "" {
-			logger.Error(nil, "downstream namespace has no namespaceLocator annotation")
-			return nil
-		}
-
-		nsLocator := shared.NamespaceLocator{}
-		if err := json.Unmarshal([]byte(namespaceLocatorJSON), &nsLocator); err != nil {
-			logger.Error(err, "failed to unmarshal namespace locator", "namespaceLocator", namespaceLocatorJSON)
-			return nil
-		}
-		logger = logger.WithValues("upstreamWorkspace", nsLocator.Workspace, "upstreamNamespace", nsLocator.Namespace)
-		exists, err := c.upstreamNamespaceExists(nsLocator.Workspace, nsLocator.Namespace)
-		if err != nil {
-			logger.Error(err, "failed to check if upstream namespace exists")
-			return nil
-		}
-		if !exists {
-			logger.Info("deleting downstream namespace because the upstream namespace doesn't exist")
-			return c.deleteDownstreamNamespace(ctx, namespaceName)
-		}
-		// The upstream namespace still exists, nothing to do.
-		return nil
+		return c.processDownstreamNamespace(ctx, namespaceName)
 	}
 
 	exists, err := c.upstreamNamespaceExists(clusterName, namespaceName)
@@ -88,8 +58,7 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	}
 
 	if exists {
-		logger.Info("upstream namespace exists, nothing to do")
-		return nil
+		return c.processUpstreamNamespace(ctx, clusterName, namespaceName)
 	}
 
 	namespaceLocator := shared.NamespaceLocator{
@@ -120,3 +89,211 @@ func (c *Controller) process(ctx context.Context, key string) error {
 	logger.V(2).Info("deleting downstream namespace because the upstream namespace doesn't exist", "downstreamNamespace", downstreamNamespaceName, "upstreamWorkspace", clusterName, "upstreamNamespace", namespaceName)
 	return c.deleteDownstreamNamespace(ctx, downstreamNamespaceName)
 }
+
+// processUpstreamNamespace converges the downstream namespace of an existing upstream namespace.
+func (c *Controller) processUpstreamNamespace(ctx context.Context, clusterName logicalcluster.Name, namespaceName string) error {
+	logger := klog.FromContext(ctx)
+
+	upstreamNamespace, err := c.getUpstreamNamespace(clusterName, namespaceName)
+	if err != nil {
+		logger.Error(err, "failed to get upstream namespace")
+		return nil
+	}
+	if upstreamNamespace == nil {
+		// The upstream namespace is known by the syncer of another virtual workspace.
+		logger.V(4).Info("upstream namespace not found in this virtual workspace, ignoring key")
+		return nil
+	}
+
+	locator := shared.NewNamespaceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, namespaceName)
+	downstreamNamespaceName, err := shared.PhysicalClusterNamespaceName(locator)
+	if err != nil {
+		logger.Error(err, "failed to compute the downstream namespace name")
+		return nil
+	}
+	logger = logger.WithValues("downstreamNamespace", downstreamNamespaceName)
+	ctx = klog.NewContext(ctx, logger)
+
+	downstreamNamespaceObj, err := c.getDownstreamNamespace(downstreamNamespaceName)
+	if apierrors.IsNotFound(err) {
+		// The downstream namespace is not created yet, or it has lost the sync target label and
+		// is not seen by the informer anymore.
+		downstreamNamespace, err := c.getDownstreamNamespaceFromServer(ctx, downstreamNamespaceName)
+		if apierrors.IsNotFound(err) {
+			logger.V(4).Info("downstream namespace not created yet, nothing to do")
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return c.ensureDownstreamNamespaceUpToDate(ctx, upstreamNamespace, downstreamNamespace)
+	} else if err != nil {
+		logger.Error(err, "failed to get downstream namespace")
+		return nil
+	}
+
+	return c.ensureDownstreamNamespaceUpToDate(ctx, upstreamNamespace, downstreamNamespaceObj.(*unstructured.Unstructured))
+}
+
+// processDownstreamNamespace deletes the downstream namespace if its upstream namespace is gone,
+// and converges it with its upstream namespace otherwise.
+func (c *Controller) processDownstreamNamespace(ctx context.Context, namespaceName string) error {
+	logger := klog.FromContext(ctx)
+
+	downstreamNamespaceObj, err := c.getDownstreamNamespace(namespaceName)
+	if apierrors.IsNotFound(err) {
+		// The namespace is deleted, or it has lost the sync target label. In the latter case it
+		// is fixed through its upstream namespace.
+		upstreamNamespace, err := c.getUpstreamNamespaceFromDownstreamName(namespaceName)
+		if err != nil {
+			logger.Error(err, "failed to get upstream namespace of downstream namespace", "namespace", namespaceName)
+			return nil
+		}
+		if upstreamNamespace != nil {
+			c.queue.Add(clusters.ToClusterAwareKey(logicalcluster.From(upstreamNamespace), upstreamNamespace.GetName()))
+			return nil
+		}
+		logger.V(4).Info("downstream namespace not found, ignoring key", "namespace", namespaceName)
+		return nil
+	} else if err != nil {
+		logger.Error(err, "failed to get downstream namespace", "namespace", namespaceName)
+		return nil
+	}
+
+	downstreamNamespace := downstreamNamespaceObj.(*unstructured.Unstructured)
+	logger = logging.WithObject(logger, downstreamNamespace)
+	ctx = klog.NewContext(ctx, logger)
+
+	namespaceLocatorJSON := downstreamNamespace.GetAnnotations()[shared.NamespaceLocatorAnnotation]
+	nsLocator := shared.NamespaceLocator{}
+	if namespaceLocatorJSON == "" {
+		logger.Info("downstream namespace has no namespaceLocator annotation")
+		return c.restoreOrDeleteOrphanedDownstreamNamespace(ctx, downstreamNamespace)
+	}
+	if err := json.Unmarshal([]byte(namespaceLocatorJSON), &nsLocator); err != nil {
+		logger.Error(err, "failed to unmarshal namespace locator", "namespaceLocator", namespaceLocatorJSON)
+		return c.restoreOrDeleteOrphanedDownstreamNamespace(ctx, downstreamNamespace)
+	}
+	logger = logger.WithValues("upstreamWorkspace", nsLocator.Workspace, "upstreamNamespace", nsLocator.Namespace)
+	ctx = klog.NewContext(ctx, logger)
+	exists, err := c.upstreamNamespaceExists(nsLocator.Workspace, nsLocator.Namespace)
+	if err != nil {
+		logger.Error(err, "failed to check if upstream namespace exists")
+		return nil
+	}
+	if !exists {
+		logger.Info("deleting downstream namespace because the upstream namespace doesn't exist")
+		return c.deleteDownstreamNamespace(ctx, namespaceName)
+	}
+
+	upstreamNamespace, err := c.getUpstreamNamespace(nsLocator.Workspace, nsLocator.Namespace)
+	if err != nil {
+		logger.Error(err, "failed to get upstream namespace")
+		return nil
+	}
+	if upstreamNamespace == nil {
+		// The upstream namespace is known by the syncer of another virtual workspace.
+		return nil
+	}
+	return c.ensureDownstreamNamespaceUpToDate(ctx, upstreamNamespace, downstreamNamespace)
+}
+
+// restoreOrDeleteOrphanedDownstreamNamespace restores the namespace locator of a downstream namespace
+// which lost it, from the upstream namespace with the matching downstream namespace name. If there is no
+// such upstream namespace behind any of the virtual workspaces, the downstream namespace is orphaned, and
+// deleted once the downstream cluster reports it as empty.
+func (c *Controller) restoreOrDeleteOrphanedDownstreamNamespace(ctx context.Context, downstreamNamespace *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+
+	upstreamNamespace, err := c.getUpstreamNamespaceFromDownstreamName(downstreamNamespace.GetName())
+	if err != nil {
+		logger.Error(err, "failed to get upstream namespace of downstream namespace")
+		return nil
+	}
+	if upstreamNamespace != nil {
+		logger.Info("restoring namespace locator of downstream namespace", "upstreamWorkspace", logicalcluster.From(upstreamNamespace), "upstreamNamespace", upstreamNamespace.GetName())
+		return c.ensureDownstreamNamespaceUpToDate(ctx, upstreamNamespace, downstreamNamespace)
+	}
+
+	// Downstream namespaces are shared by the syncers of all the virtual workspaces: the namespace is left to
+	// the syncer of the virtual workspace which knows its upstream namespace, if any.
+	hasUpstream, err := c.downstreamNamespaceHasUpstream(downstreamNamespace.GetName())
+	if err != nil {
+		logger.Error(err, "failed to check if the downstream namespace has an upstream namespace")
+		return nil
+	}
+	if hasUpstream {
+		logger.V(4).Info("upstream namespace of downstream namespace is known by another virtual workspace, ignoring key")
+		return nil
+	}
+
+	empty, err := c.downstreamNamespaceIsEmpty(ctx, downstreamNamespace.GetName())
+	if err != nil {
+		return err
+	}
+	if !empty {
+		logger.Info("orphaned downstream namespace still contains objects, not deleting it")
+		c.queue.AddAfter(downstreamNamespace.GetName(), orphanedNamespaceRecheckInterval)
+		return nil
+	}
+	logger.Info("deleting empty orphaned downstream namespace")
+	return c.deleteDownstreamNamespace(ctx, downstreamNamespace.GetName())
+}
+
+// ensureDownstreamNamespaceUpToDate restores the namespace locator annotation and the sync target label
+// of the downstream namespace, and propagates the synced labels of the upstream namespace.
+func (c *Controller) ensureDownstreamNamespaceUpToDate(ctx context.Context, upstreamNamespace, downstreamNamespace *unstructured.Unstructured) error {
+	logger := klog.FromContext(ctx)
+
+	desiredLocator := shared.NewNamespaceLocator(logicalcluster.From(upstreamNamespace), c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamNamespace.GetName())
+	locator, found, err := shared.LocatorFromAnnotations(downstreamNamespace.GetAnnotations())
+	if err == nil && found && !reflect.DeepEqual(desiredLocator, *locator) {
+		logger.Error(nil, "(namespace collision) downstream namespace has a different namespace locator, not updating it", "namespaceLocator", locator, "desiredNamespaceLocator", desiredLocator)
+		return nil
+	}
+
+	updated := downstreamNamespace.DeepCopy()
+	changed := false
+
+	if err != nil || !found {
+		locatorJSON, err := json.Marshal(desiredLocator)
+		if err != nil {
+			return err
+		}
+		annotations := updated.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[shared.NamespaceLocatorAnnotation] = string(locatorJSON)
+		updated.SetAnnotations(annotations)
+		changed = true
+	}
+
+	labels := updated.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	upstreamLabels := upstreamNamespace.GetLabels()
+	for key := range labels {
+		if _, exists := upstreamLabels[key]; !exists && c.isSyncedNamespaceLabel(key) {
+			delete(labels, key)
+			changed = true
+		}
+	}
+	for key, value := range upstreamLabels {
+		if c.isSyncedNamespaceLabel(key) && labels[key] != value {
+			labels[key] = value
+			changed = true
+		}
+	}
+	if labels[workloadv1alpha1.InternalDownstreamClusterLabel] != c.syncTargetKey {
+		labels[workloadv1alpha1.InternalDownstreamClusterLabel] = c.syncTargetKey
+		changed = true
+	}
+	updated.SetLabels(labels)
+
+	if !changed {
+		return nil
+	}
+	logger.V(2).Info("updating downstream namespace to match the upstream namespace")
+	return c.updateDownstreamNamespace(ctx, updated)
+}
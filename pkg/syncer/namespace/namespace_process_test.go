@@ -32,7 +32,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/util/workqueue"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
@@ -117,15 +120,29 @@ func TestSyncerNamespaceProcess(t *testing.T) {
 			syncTargetName := "us-west1"
 			syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)
 			deletedNamespace := ""
+			var updatedNamespace *unstructured.Unstructured
 
 			nsController := Controller{
 				deleteDownstreamNamespace: func(ctx context.Context, downstreamNamespaceName string) error {
 					deletedNamespace = downstreamNamespaceName
 					return nil
 				},
+				updateDownstreamNamespace: func(ctx context.Context, namespace *unstructured.Unstructured) error {
+					updatedNamespace = namespace
+					return nil
+				},
 				upstreamNamespaceExists: func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
 					return tc.upstreamNamespaceExists, tc.upstreamNamespaceExistsError
 				},
+				getUpstreamNamespace: func(clusterName logicalcluster.Name, name string) (*unstructured.Unstructured, error) {
+					return toUnstructured(t, namespace(clusterName, name, nil, nil)), nil
+				},
+				getUpstreamNamespaceFromDownstreamName: func(downstreamNamespaceName string) (*unstructured.Unstructured, error) {
+					return nil, nil
+				},
+				getDownstreamNamespaceFromServer: func(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+					return nil, apierrors.NewNotFound(corev1.Resource("namespaces"), name)
+				},
 				getDownstreamNamespace: func(name string) (runtime.Object, error) {
 					nsJSON, _ := json.Marshal(downstreamNamespace)
 					unstructured := &unstructured.Unstructured{}
@@ -156,6 +173,7 @@ func TestSyncerNamespaceProcess(t *testing.T) {
 			err := nsController.process(ctx, key)
 			require.NoError(t, err)
 			require.Equal(t, tc.deletedNamespace, deletedNamespace)
+			require.Nil(t, updatedNamespace, "the downstream namespace is up to date")
 		})
 	}
 }
@@ -180,3 +198,275 @@ func namespace(clusterName logicalcluster.Name, name string, labels, annotations
 		},
 	}
 }
+
+func TestSyncerNamespaceReconcile(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetName := "us-west1"
+	syncTargetUID := types.UID("syncTargetUID")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, syncTargetName)
+	upstreamWorkspace := logicalcluster.New("root:org:ws")
+
+	locator := shared.NewNamespaceLocator(upstreamWorkspace, syncTargetWorkspace, syncTargetUID, syncTargetName, "test")
+	locatorJSON, err := json.Marshal(locator)
+	require.NoError(t, err)
+	downstreamNamespaceName, err := shared.PhysicalClusterNamespaceName(locator)
+	require.NoError(t, err)
+	otherLocator := shared.NewNamespaceLocator(upstreamWorkspace, syncTargetWorkspace, syncTargetUID, syncTargetName, "other")
+	otherLocatorJSON, err := json.Marshal(otherLocator)
+	require.NoError(t, err)
+
+	locatorAnnotations := map[string]string{shared.NamespaceLocatorAnnotation: string(locatorJSON)}
+	syncTargetLabels := map[string]string{workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey}
+
+	tests := map[string]struct {
+		upstreamLabels        map[string]string
+		upstreamKnown         bool
+		syncedNamespaceLabels []string
+		downstreamInInformer  *corev1.Namespace
+		downstreamOnServer    *corev1.Namespace
+		downstreamEmpty       bool
+		ownedElsewhere        bool
+		eventOrigin           string // upstream or downstream
+
+		wantUpdated *corev1.Namespace
+		wantDeleted string
+		wantQueued  string
+	}{
+		"restores the namespace locator annotation": {
+			upstreamKnown:        true,
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, map[string]string{"other": "annotation"}),
+			eventOrigin:          "downstream",
+			wantUpdated: namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, map[string]string{
+				"other":                           "annotation",
+				shared.NamespaceLocatorAnnotation: string(locatorJSON),
+			}),
+		},
+		"restores the sync target label on an upstream event": {
+			upstreamKnown:      true,
+			downstreamOnServer: namespace(logicalcluster.New(""), downstreamNamespaceName, nil, locatorAnnotations),
+			eventOrigin:        "upstream",
+			wantUpdated:        namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, locatorAnnotations),
+		},
+		"downstream namespace leaving the informer requeues its upstream namespace": {
+			upstreamKnown: true,
+			eventOrigin:   "downstream",
+			wantQueued:    clusters.ToClusterAwareKey(upstreamWorkspace, "test"),
+		},
+		"propagates the synced labels and removes the stale ones": {
+			upstreamLabels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "restricted",
+				"team":                               "a",
+				"not-synced":                         "value",
+				"state.workload.kcp.dev/" + syncTargetKey:       "Sync",
+				workloadv1alpha1.InternalDownstreamClusterLabel: "foo",
+			},
+			upstreamKnown:         true,
+			syncedNamespaceLabels: []string{"pod-security.kubernetes.io/*", "team", "old", "*.kcp.dev/*"},
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, map[string]string{
+				workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey,
+				"team": "b",
+				"old":  "value",
+			}, locatorAnnotations),
+			eventOrigin: "upstream",
+			wantUpdated: namespace(logicalcluster.New(""), downstreamNamespaceName, map[string]string{
+				workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey,
+				"pod-security.kubernetes.io/enforce":            "restricted",
+				"team":                                          "a",
+			}, locatorAnnotations),
+		},
+		"up to date downstream namespace is not updated": {
+			upstreamLabels:        map[string]string{"team": "a"},
+			upstreamKnown:         true,
+			syncedNamespaceLabels: []string{"team"},
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, map[string]string{
+				workloadv1alpha1.InternalDownstreamClusterLabel: syncTargetKey,
+				"team": "a",
+			}, locatorAnnotations),
+			eventOrigin: "upstream",
+		},
+		"downstream namespace of another upstream namespace is not updated": {
+			upstreamKnown:        true,
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, nil, map[string]string{shared.NamespaceLocatorAnnotation: string(otherLocatorJSON)}),
+			eventOrigin:          "upstream",
+		},
+		"downstream namespace not created yet": {
+			upstreamKnown: true,
+			eventOrigin:   "upstream",
+		},
+		"empty orphaned downstream namespace is deleted": {
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, nil),
+			downstreamEmpty:      true,
+			eventOrigin:          "downstream",
+			wantDeleted:          downstreamNamespaceName,
+		},
+		"orphaned downstream namespace with objects is kept": {
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, nil),
+			eventOrigin:          "downstream",
+		},
+		"downstream namespace of an upstream namespace of another virtual workspace is kept": {
+			downstreamInInformer: namespace(logicalcluster.New(""), downstreamNamespaceName, syncTargetLabels, nil),
+			downstreamEmpty:      true,
+			ownedElsewhere:       true,
+			eventOrigin:          "downstream",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			upstreamNamespace := toUnstructured(t, namespace(upstreamWorkspace, "test", tc.upstreamLabels, nil))
+			notFound := apierrors.NewNotFound(corev1.Resource("namespaces"), downstreamNamespaceName)
+			deletedNamespace := ""
+			var updatedNamespace *unstructured.Unstructured
+
+			nsController := Controller{
+				queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test"),
+				deleteDownstreamNamespace: func(ctx context.Context, downstreamNamespaceName string) error {
+					deletedNamespace = downstreamNamespaceName
+					return nil
+				},
+				updateDownstreamNamespace: func(ctx context.Context, namespace *unstructured.Unstructured) error {
+					updatedNamespace = namespace
+					return nil
+				},
+				upstreamNamespaceExists: func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
+					return tc.upstreamKnown && clusterName == upstreamWorkspace && upstreamNamespaceName == "test", nil
+				},
+				getUpstreamNamespace: func(clusterName logicalcluster.Name, name string) (*unstructured.Unstructured, error) {
+					if !tc.upstreamKnown || clusterName != upstreamWorkspace || name != "test" {
+						return nil, nil
+					}
+					return upstreamNamespace, nil
+				},
+				getUpstreamNamespaceFromDownstreamName: func(name string) (*unstructured.Unstructured, error) {
+					if !tc.upstreamKnown || name != downstreamNamespaceName {
+						return nil, nil
+					}
+					return upstreamNamespace, nil
+				},
+				getDownstreamNamespace: func(name string) (runtime.Object, error) {
+					if tc.downstreamInInformer == nil || name != tc.downstreamInInformer.Name {
+						return nil, notFound
+					}
+					return toUnstructured(t, tc.downstreamInInformer), nil
+				},
+				getDownstreamNamespaceFromServer: func(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+					if tc.downstreamOnServer == nil || name != tc.downstreamOnServer.Name {
+						return nil, notFound
+					}
+					return toUnstructured(t, tc.downstreamOnServer), nil
+				},
+				getDownstreamNamespaceFromNamespaceLocator: func(namespaceLocator shared.NamespaceLocator) (runtime.Object, error) {
+					return nil, nil
+				},
+				downstreamNamespaceHasUpstream: func(name string) (bool, error) {
+					return (tc.upstreamKnown || tc.ownedElsewhere) && name == downstreamNamespaceName, nil
+				},
+				downstreamNamespaceIsEmpty: func(ctx context.Context, name string) (bool, error) {
+					return tc.downstreamEmpty, nil
+				},
+				syncTargetName:        syncTargetName,
+				syncTargetWorkspace:   syncTargetWorkspace,
+				syncTargetUID:         syncTargetUID,
+				syncTargetKey:         syncTargetKey,
+				syncedNamespaceLabels: tc.syncedNamespaceLabels,
+			}
+			defer nsController.queue.ShutDown()
+
+			key := downstreamNamespaceName
+			if tc.eventOrigin == "upstream" {
+				key = clusters.ToClusterAwareKey(upstreamWorkspace, "test")
+			}
+
+			err := nsController.process(ctx, key)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantDeleted, deletedNamespace)
+			if tc.wantUpdated == nil {
+				require.Nil(t, updatedNamespace)
+			} else {
+				require.NotNil(t, updatedNamespace)
+				require.Equal(t, tc.wantUpdated.Labels, updatedNamespace.GetLabels())
+				require.Equal(t, tc.wantUpdated.Annotations, updatedNamespace.GetAnnotations())
+			}
+			if tc.wantQueued != "" {
+				require.Equal(t, 1, nsController.queue.Len())
+				item, _ := nsController.queue.Get()
+				require.Equal(t, tc.wantQueued, item)
+			} else {
+				require.Zero(t, nsController.queue.Len())
+			}
+		})
+	}
+}
+
+func TestDownstreamNamespaceIsEmpty(t *testing.T) {
+	tests := map[string]struct {
+		objects   []runtime.Object
+		forbidden bool
+		wantEmpty bool
+	}{
+		"no objects": {
+			wantEmpty: true,
+		},
+		"only default objects and events": {
+			objects: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "kube-root-ca.crt"}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "default"}},
+				&corev1.Event{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "event"}},
+			},
+			wantEmpty: true,
+		},
+		"configmap not synced by the syncer": {
+			objects: []runtime.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "kube-root-ca.crt"}},
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "test", Name: "unlabeled"}},
+			},
+		},
+		"objects of another namespace": {
+			objects: []runtime.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "secret"}},
+			},
+			wantEmpty: true,
+		},
+		"resource type not allowed to be listed": {
+			forbidden: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			require.NoError(t, corev1.AddToScheme(scheme))
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.objects...)
+			if tc.forbidden {
+				dynamicClient.PrependReactor("list", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(corev1.Resource("secrets"), "", errors.New("forbidden"))
+				})
+			}
+			discoverResources := func() ([]*metav1.APIResourceList, error) {
+				return []*metav1.APIResourceList{{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{
+						{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"list"}},
+						{Name: "serviceaccounts", Namespaced: true, Kind: "ServiceAccount", Verbs: []string{"list"}},
+						{Name: "events", Namespaced: true, Kind: "Event", Verbs: []string{"list"}},
+						{Name: "secrets", Namespaced: true, Kind: "Secret", Verbs: []string{"list"}},
+						{Name: "pods/log", Namespaced: true, Kind: "Pod", Verbs: []string{"get"}},
+					},
+				}}, nil
+			}
+
+			empty, err := downstreamNamespaceIsEmpty(context.Background(), dynamicClient, discoverResources, "test")
+			require.NoError(t, err)
+			require.Equal(t, tc.wantEmpty, empty)
+		})
+	}
+}
+
+func toUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: raw}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcesync
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerFactory is a dynamicinformer.DynamicSharedInformerFactory whose informers
+// can be stopped and forgotten one by one, when the corresponding resource type should
+// not be watched anymore.
+type InformerFactory struct {
+	client           dynamic.Interface
+	namespace        string
+	tweakListOptions dynamicinformer.TweakListOptionsFunc
+	informerOptions  []cache.SharedInformerOption
+
+	lock      sync.Mutex
+	informers map[schema.GroupVersionResource]informers.GenericInformer
+	// startedInformers holds the stop channels of the started informers. This allows Start()
+	// to be called multiple times safely, and Forget() to stop a single informer.
+	startedInformers map[schema.GroupVersionResource]chan struct{}
+}
+
+var _ dynamicinformer.DynamicSharedInformerFactory = &InformerFactory{}
+
+// NewInformerFactory constructs a new InformerFactory. Listers obtained via this factory
+// will be subject to the same filters as specified here.
+func NewInformerFactory(client dynamic.Interface, namespace string, tweakListOptions dynamicinformer.TweakListOptionsFunc, opts ...cache.SharedInformerOption) *InformerFactory {
+	return &InformerFactory{
+		client:           client,
+		namespace:        namespace,
+		tweakListOptions: tweakListOptions,
+		informerOptions:  opts,
+
+		informers:        map[schema.GroupVersionResource]informers.GenericInformer{},
+		startedInformers: map[schema.GroupVersionResource]chan struct{}{},
+	}
+}
+
+// ForResource returns the informer for the given resource, creating it if needed.
+func (f *InformerFactory) ForResource(gvr schema.GroupVersionResource) informers.GenericInformer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	informer, exists := f.informers[gvr]
+	if exists {
+		return informer
+	}
+
+	informer = dynamicinformer.NewFilteredDynamicInformerWithOptions(f.client, gvr, f.namespace, f.tweakListOptions, f.informerOptions...)
+	f.informers[gvr] = informer
+
+	return informer
+}
+
+// Start initializes all requested informers. They run until stopCh is closed, or
+// until they are forgotten.
+func (f *InformerFactory) Start(stopCh <-chan struct{}) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for gvr, informer := range f.informers {
+		if _, started := f.startedInformers[gvr]; started {
+			continue
+		}
+		informerStopCh := make(chan struct{})
+		f.startedInformers[gvr] = informerStopCh
+		go informer.Informer().Run(mergeStopChannels(stopCh, informerStopCh))
+	}
+}
+
+// WaitForCacheSync waits for all started informers' cache were synced.
+func (f *InformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[schema.GroupVersionResource]bool {
+	informers := func() map[schema.GroupVersionResource]cache.SharedIndexInformer {
+		f.lock.Lock()
+		defer f.lock.Unlock()
+
+		informers := map[schema.GroupVersionResource]cache.SharedIndexInformer{}
+		for gvr, informer := range f.informers {
+			if _, started := f.startedInformers[gvr]; started {
+				informers[gvr] = informer.Informer()
+			}
+		}
+		return informers
+	}()
+
+	res := map[schema.GroupVersionResource]bool{}
+	for gvr, informer := range informers {
+		res[gvr] = cache.WaitForCacheSync(stopCh, informer.HasSynced)
+	}
+	return res
+}
+
+// Forget stops the informer of the given resource, if started, and removes it from the factory.
+// A subsequent call to ForResource creates a new informer.
+func (f *InformerFactory) Forget(gvr schema.GroupVersionResource) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if stopCh, started := f.startedInformers[gvr]; started {
+		close(stopCh)
+	}
+	delete(f.startedInformers, gvr)
+	delete(f.informers, gvr)
+}
+
+// Has returns whether an informer exists for the given resource.
+func (f *InformerFactory) Has(gvr schema.GroupVersionResource) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	_, exists := f.informers[gvr]
+	return exists
+}
+
+func mergeStopChannels(a, b <-chan struct{}) <-chan struct{} {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-a:
+		case <-b:
+		}
+	}()
+	return merged
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcesync
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	controllerName = "kcp-workload-syncer-resourcesync"
+
+	// informerSyncTimeout is how long the informers of a newly discovered resource type
+	// may take to sync before giving up until the next discovery.
+	informerSyncTimeout = 30 * time.Second
+)
+
+// GVRHandler is implemented by the syncer controllers that need to react to resource types
+// being added to or removed from the set of synced resource types.
+type GVRHandler interface {
+	// AddGVR starts watching the given resource type. It is called once the upstream and downstream
+	// informers for the resource type are synced, and must be idempotent.
+	AddGVR(gvr schema.GroupVersionResource)
+	// RemoveGVR stops watching the given resource type. It is called before the upstream and
+	// downstream informers for the resource type are stopped.
+	RemoveGVR(gvr schema.GroupVersionResource)
+}
+
+// GetGVRsFunc returns the resource types currently published by the upstream syncer virtual workspace.
+type GetGVRsFunc func() ([]schema.GroupVersionResource, error)
+
+// Controller periodically discovers the resource types published by the upstream syncer
+// virtual workspace. It starts the upstream and downstream informers of newly published
+// resource types and notifies the GVR handlers, and stops them when the resource types
+// are not published anymore.
+type Controller struct {
+	getGVRs                                GetGVRsFunc
+	upstreamInformers, downstreamInformers *InformerFactory
+	handlers                               []GVRHandler
+
+	gvrs map[schema.GroupVersionResource]bool
+}
+
+// NewController returns a resource sync controller. initialGVRs are the resource types already
+// watched by the handlers.
+func NewController(initialGVRs []schema.GroupVersionResource, getGVRs GetGVRsFunc, upstreamInformers, downstreamInformers *InformerFactory, handlers ...GVRHandler) *Controller {
+	c := &Controller{
+		getGVRs:             getGVRs,
+		upstreamInformers:   upstreamInformers,
+		downstreamInformers: downstreamInformers,
+		handlers:            handlers,
+
+		gvrs: map[schema.GroupVersionResource]bool{},
+	}
+	for _, gvr := range initialGVRs {
+		c.gvrs[gvr] = true
+	}
+	return c
+}
+
+// Start polls discovery every pollInterval until the context is cancelled.
+func (c *Controller) Start(ctx context.Context, pollInterval time.Duration) {
+	defer utilruntime.HandleCrash()
+
+	klog.InfoS("Starting controller", "controller", controllerName)
+	defer klog.InfoS("Stopping controller", "controller", controllerName)
+
+	wait.UntilWithContext(ctx, c.process, pollInterval)
+}
+
+func (c *Controller) process(ctx context.Context) {
+	gvrs, err := c.getGVRs()
+	if err != nil {
+		klog.Errorf("%s failed to retrieve GVRs from upstream: %v", controllerName, err)
+		return
+	}
+
+	desired := map[schema.GroupVersionResource]bool{}
+	for _, gvr := range gvrs {
+		desired[gvr] = true
+	}
+
+	for _, gvr := range sortedGVRs(c.gvrs) {
+		if desired[gvr] {
+			continue
+		}
+		klog.Infof("%s stopping to sync resource %q, not published upstream anymore", controllerName, gvr.String())
+		for _, handler := range c.handlers {
+			handler.RemoveGVR(gvr)
+		}
+		c.upstreamInformers.Forget(gvr)
+		c.downstreamInformers.Forget(gvr)
+		delete(c.gvrs, gvr)
+	}
+
+	for _, gvr := range sortedGVRs(desired) {
+		if c.gvrs[gvr] {
+			continue
+		}
+		if !c.startInformers(ctx, gvr) {
+			continue
+		}
+		klog.Infof("%s starting to sync resource %q, newly published upstream", controllerName, gvr.String())
+		for _, handler := range c.handlers {
+			handler.AddGVR(gvr)
+		}
+		c.gvrs[gvr] = true
+	}
+}
+
+// startInformers starts the upstream and downstream informers of the given resource type, and
+// waits for them to be synced. If they cannot be synced in time, they are stopped and false is
+// returned, so that a later discovery retries.
+func (c *Controller) startInformers(ctx context.Context, gvr schema.GroupVersionResource) bool {
+	upstreamInformer := c.upstreamInformers.ForResource(gvr).Informer()
+	downstreamInformer := c.downstreamInformers.ForResource(gvr).Informer()
+	c.upstreamInformers.Start(ctx.Done())
+	c.downstreamInformers.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, informerSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), upstreamInformer.HasSynced, downstreamInformer.HasSynced) {
+		klog.Errorf("%s failed to sync informers for resource %q, will retry", controllerName, gvr.String())
+		c.upstreamInformers.Forget(gvr)
+		c.downstreamInformers.Forget(gvr)
+		return false
+	}
+	return true
+}
+
+func sortedGVRs(gvrs map[schema.GroupVersionResource]bool) []schema.GroupVersionResource {
+	sorted := make([]schema.GroupVersionResource, 0, len(gvrs))
+	for gvr := range gvrs {
+		sorted = append(sorted, gvr)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+	return sorted
+}
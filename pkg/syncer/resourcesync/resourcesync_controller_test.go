@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resourcesync
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var (
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secretsGVR     = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+type fakeGVRHandler struct {
+	added, removed []schema.GroupVersionResource
+}
+
+func (h *fakeGVRHandler) AddGVR(gvr schema.GroupVersionResource) {
+	h.added = append(h.added, gvr)
+}
+
+func (h *fakeGVRHandler) RemoveGVR(gvr schema.GroupVersionResource) {
+	h.removed = append(h.removed, gvr)
+}
+
+func newFakeInformerFactory() *InformerFactory {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		configMapsGVR:  "ConfigMapList",
+		secretsGVR:     "SecretList",
+		deploymentsGVR: "DeploymentList",
+	})
+	return NewInformerFactory(client, metav1.NamespaceAll, nil)
+}
+
+func TestResourceSyncProcess(t *testing.T) {
+	tests := map[string]struct {
+		initialGVRs   []schema.GroupVersionResource
+		publishedGVRs []schema.GroupVersionResource
+		discoveryErr  error
+
+		wantAdded   []schema.GroupVersionResource
+		wantRemoved []schema.GroupVersionResource
+		wantGVRs    []schema.GroupVersionResource
+	}{
+		"nothing changed": {
+			initialGVRs:   []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+			publishedGVRs: []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+			wantGVRs:      []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+		},
+		"new resource published": {
+			initialGVRs:   []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+			publishedGVRs: []schema.GroupVersionResource{configMapsGVR, secretsGVR, deploymentsGVR},
+			wantAdded:     []schema.GroupVersionResource{deploymentsGVR},
+			wantGVRs:      []schema.GroupVersionResource{configMapsGVR, secretsGVR, deploymentsGVR},
+		},
+		"resource not published anymore": {
+			initialGVRs:   []schema.GroupVersionResource{configMapsGVR, secretsGVR, deploymentsGVR},
+			publishedGVRs: []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+			wantRemoved:   []schema.GroupVersionResource{deploymentsGVR},
+			wantGVRs:      []schema.GroupVersionResource{configMapsGVR, secretsGVR},
+		},
+		"discovery failure keeps current resources": {
+			initialGVRs:  []schema.GroupVersionResource{configMapsGVR, secretsGVR, deploymentsGVR},
+			discoveryErr: errors.New("discovery failed"),
+			wantGVRs:     []schema.GroupVersionResource{configMapsGVR, secretsGVR, deploymentsGVR},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			upstreamInformers := newFakeInformerFactory()
+			downstreamInformers := newFakeInformerFactory()
+			for _, gvr := range tc.initialGVRs {
+				upstreamInformers.ForResource(gvr)
+				downstreamInformers.ForResource(gvr)
+			}
+			upstreamInformers.Start(ctx.Done())
+			downstreamInformers.Start(ctx.Done())
+
+			handler := &fakeGVRHandler{}
+			c := NewController(tc.initialGVRs, func() ([]schema.GroupVersionResource, error) {
+				return tc.publishedGVRs, tc.discoveryErr
+			}, upstreamInformers, downstreamInformers, handler)
+
+			c.process(ctx)
+
+			require.Equal(t, tc.wantAdded, handler.added, "unexpected added GVRs")
+			require.Equal(t, tc.wantRemoved, handler.removed, "unexpected removed GVRs")
+			require.Equal(t, tc.wantGVRs, sortedGVRs(c.gvrs), "unexpected synced GVRs")
+			for _, gvr := range tc.wantRemoved {
+				require.False(t, upstreamInformers.Has(gvr), "expected upstream informer for %s to be forgotten", gvr)
+				require.False(t, downstreamInformers.Has(gvr), "expected downstream informer for %s to be forgotten", gvr)
+			}
+			for _, gvr := range tc.wantAdded {
+				require.True(t, upstreamInformers.ForResource(gvr).Informer().HasSynced(), "expected upstream informer for %s to be synced", gvr)
+				require.True(t, downstreamInformers.ForResource(gvr).Informer().HasSynced(), "expected downstream informer for %s to be synced", gvr)
+			}
+		})
+	}
+}
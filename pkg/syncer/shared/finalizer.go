@@ -24,9 +24,11 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
 
@@ -40,7 +42,7 @@ const (
 )
 
 func EnsureUpstreamFinalizerRemoved(ctx context.Context, gvr schema.GroupVersionResource, upstreamInformers dynamicinformer.DynamicSharedInformerFactory, upstreamClient dynamic.ClusterInterface, upstreamNamespace, syncTargetKey string, logicalClusterName logicalcluster.Name, resourceName string) error {
-	upstreamObjFromLister, err := upstreamInformers.ForResource(gvr).Lister().ByNamespace(upstreamNamespace).Get(clusters.ToClusterAwareKey(logicalClusterName, resourceName))
+	upstreamObjFromLister, err := GetFromLister(upstreamInformers.ForResource(gvr).Lister(), upstreamNamespace, clusters.ToClusterAwareKey(logicalClusterName, resourceName))
 	if err != nil && !apierrors.IsNotFound(err) {
 		return err
 	}
@@ -78,6 +80,7 @@ func EnsureUpstreamFinalizerRemoved(ctx context.Context, gvr schema.GroupVersion
 	// Clean up the status annotation and the locationDeletionAnnotation.
 	annotations := upstreamObj.GetAnnotations()
 	delete(annotations, workloadv1alpha1.InternalClusterStatusAnnotationPrefix+syncTargetKey)
+	delete(annotations, workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix+syncTargetKey)
 	delete(annotations, workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+syncTargetKey)
 	upstreamObj.SetAnnotations(annotations)
 
@@ -94,3 +97,12 @@ func EnsureUpstreamFinalizerRemoved(ctx context.Context, gvr schema.GroupVersion
 	klog.V(2).Infof("Updated resource %s|%s/%s after removing the finalizers", logicalClusterName, upstreamNamespace, upstreamObj.GetName())
 	return nil
 }
+
+// GetFromLister gets an object by namespace and cluster-aware name from a generic lister.
+// An empty namespace denotes a cluster-scoped object.
+func GetFromLister(lister cache.GenericLister, namespace, clusterAwareName string) (runtime.Object, error) {
+	if namespace == "" {
+		return lister.Get(clusterAwareName)
+	}
+	return lister.ByNamespace(namespace).Get(clusterAwareName)
+}
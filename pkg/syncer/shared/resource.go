@@ -0,0 +1,81 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/martinlindhe/base36"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	ResourceLocatorAnnotation = "kcp.dev/resource-locator"
+)
+
+// ResourceLocator stores a logical cluster and name of a cluster-scoped upstream resource,
+// and is used as the source for the mapped resource name in a physical cluster.
+type ResourceLocator struct {
+	SyncTarget SyncTargetLocator   `json:"syncTarget"`
+	Workspace  logicalcluster.Name `json:"workspace,omitempty"`
+	Name       string              `json:"name"`
+}
+
+func NewResourceLocator(workspace, syncTargetWorkspace logicalcluster.Name, syncTargetUID types.UID, syncTargetName, upstreamName string) ResourceLocator {
+	return ResourceLocator{
+		SyncTarget: SyncTargetLocator{
+			Workspace: syncTargetWorkspace.String(),
+			Name:      syncTargetName,
+			UID:       syncTargetUID,
+		},
+		Workspace: workspace,
+		Name:      upstreamName,
+	}
+}
+
+func ResourceLocatorFromAnnotations(annotations map[string]string) (*ResourceLocator, bool, error) {
+	annotation, ok := annotations[ResourceLocatorAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	var locator ResourceLocator
+	if err := json.Unmarshal([]byte(annotation), &locator); err != nil {
+		return nil, false, err
+	}
+	return &locator, true, nil
+}
+
+// PhysicalClusterResourceName encodes the ResourceLocator into a new name for a
+// cluster-scoped resource on a physical cluster. The encoding is repeatable, and
+// avoids collisions between resources with the same name in different workspaces.
+func PhysicalClusterResourceName(l ResourceLocator) (string, error) {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return "", err
+	}
+	// hash the marshalled locator
+	hash := sha256.Sum224(b[:])
+	// convert the hash to base36 (alphanumeric) to decrease collision probabilities
+	base36hash := strings.ToLower(base36.EncodeBytes(hash[:]))
+	// use 12 chars of the base36hash, same as for namespaces.
+	return fmt.Sprintf("kcp-%s", base36hash[:12]), nil
+}
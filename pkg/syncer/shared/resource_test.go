@@ -0,0 +1,112 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+)
+
+func TestResourceLocatorFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        *ResourceLocator
+		wantFound   bool
+		wantErrs    []string
+	}{
+		{
+			name:      "no annotation",
+			wantFound: false,
+		},
+		{
+			name: "garbage",
+			annotations: map[string]string{
+				ResourceLocatorAnnotation: "garbage",
+			},
+			wantErrs: []string{"invalid character"},
+		},
+		{
+			name: "happy case",
+			annotations: map[string]string{
+				ResourceLocatorAnnotation: `{"syncTarget":{"workspace":"test-workspace","name":"test-name","uid":"test-uid"},"workspace":"test-workspace","name":"test-resource"}`,
+			},
+			want: &ResourceLocator{
+				SyncTarget: SyncTargetLocator{
+					Workspace: "test-workspace",
+					Name:      "test-name",
+					UID:       "test-uid",
+				},
+				Workspace: logicalcluster.New("test-workspace"),
+				Name:      "test-resource",
+			},
+			wantFound: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotFound, err := ResourceLocatorFromAnnotations(tt.annotations)
+			if (err != nil) != (len(tt.wantErrs) > 0) {
+				t.Errorf("ResourceLocatorFromAnnotations() error = %v, wantErrs %v", err, tt.wantErrs)
+				return
+			} else if err != nil {
+				for _, wantErr := range tt.wantErrs {
+					if !strings.Contains(err.Error(), wantErr) {
+						t.Errorf("ResourceLocatorFromAnnotations() error = %q, wantErrs %q", err.Error(), wantErr)
+						return
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResourceLocatorFromAnnotations() got = %v, want %v", got, tt.want)
+			}
+			if gotFound != tt.wantFound {
+				t.Errorf("ResourceLocatorFromAnnotations() gotFound = %v, want %v", gotFound, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestPhysicalClusterResourceName(t *testing.T) {
+	locator := NewResourceLocator(logicalcluster.New("root:org:ws"), logicalcluster.New("root:org:ws"), "test-uid", "us-west1", "cluster-admin")
+	otherWorkspaceLocator := NewResourceLocator(logicalcluster.New("root:org:other"), logicalcluster.New("root:org:ws"), "test-uid", "us-west1", "cluster-admin")
+
+	name, err := PhysicalClusterResourceName(locator)
+	if err != nil {
+		t.Fatalf("PhysicalClusterResourceName() error = %v", err)
+	}
+	if !strings.HasPrefix(name, "kcp-") || len(name) != len("kcp-")+12 {
+		t.Errorf("PhysicalClusterResourceName() got = %q, want kcp- followed by 12 characters", name)
+	}
+	again, err := PhysicalClusterResourceName(locator)
+	if err != nil {
+		t.Fatalf("PhysicalClusterResourceName() error = %v", err)
+	}
+	if name != again {
+		t.Errorf("PhysicalClusterResourceName() is not repeatable: %q vs %q", name, again)
+	}
+	other, err := PhysicalClusterResourceName(otherWorkspaceLocator)
+	if err != nil {
+		t.Fatalf("PhysicalClusterResourceName() error = %v", err)
+	}
+	if name == other {
+		t.Errorf("PhysicalClusterResourceName() got the same name %q for resources in different workspaces", name)
+	}
+}
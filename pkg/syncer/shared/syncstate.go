@@ -0,0 +1,224 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// SyncStateReporter records the result of syncing upstream resources in their sync state annotation,
+// and keeps track of the failing resources to summarize them in the status of the SyncTarget. It is
+// shared by the spec and status syncers of all the virtual workspaces of a syncer.
+type SyncStateReporter struct {
+	syncTargetKey string
+	clock         clock.PassiveClock
+
+	lock   sync.Mutex
+	errors map[syncErrorKey]workloadv1alpha1.SyncError
+}
+
+type syncErrorKey struct {
+	direction workloadv1alpha1.SyncDirection
+	resource  string
+	workspace string
+	namespace string
+	name      string
+}
+
+// NewSyncStateReporter returns a SyncStateReporter for the SyncTarget with the given key. The clock
+// provides the time of the errors.
+func NewSyncStateReporter(syncTargetKey string, clock clock.PassiveClock) *SyncStateReporter {
+	return &SyncStateReporter{
+		syncTargetKey: syncTargetKey,
+		clock:         clock,
+		errors:        map[syncErrorKey]workloadv1alpha1.SyncError{},
+	}
+}
+
+// ReportSpecSync records the result of syncing the upstream resource to the SyncTarget. On success,
+// the generation of the resource is recorded as synced and a previous error is removed.
+func (r *SyncStateReporter) ReportSpecSync(ctx context.Context, upstreamClient dynamic.ClusterInterface, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) error {
+	r.recordSyncError(workloadv1alpha1.SyncDirectionSpec, gvr, upstreamObj, syncErr)
+	return r.updateSyncState(ctx, upstreamClient, gvr, upstreamObj, func(state *workloadv1alpha1.ResourceSyncState) {
+		if syncErr == nil {
+			state.SyncedGeneration = upstreamObj.GetGeneration()
+		}
+		state.SpecError = r.resourceSyncError(state.SpecError, syncErr)
+	})
+}
+
+// ReportStatusSync records the result of syncing the status of the upstream resource from the SyncTarget.
+func (r *SyncStateReporter) ReportStatusSync(ctx context.Context, upstreamClient dynamic.ClusterInterface, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) error {
+	r.recordSyncError(workloadv1alpha1.SyncDirectionStatus, gvr, upstreamObj, syncErr)
+	return r.updateSyncState(ctx, upstreamClient, gvr, upstreamObj, func(state *workloadv1alpha1.ResourceSyncState) {
+		state.StatusError = r.resourceSyncError(state.StatusError, syncErr)
+	})
+}
+
+// Forget drops the errors recorded for the upstream resource, e.g. because it was deleted.
+func (r *SyncStateReporter) Forget(gvr schema.GroupVersionResource, clusterName logicalcluster.Name, namespace, name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, direction := range []workloadv1alpha1.SyncDirection{workloadv1alpha1.SyncDirectionSpec, workloadv1alpha1.SyncDirectionStatus} {
+		delete(r.errors, syncErrorKey{direction: direction, resource: SyncErrorResource(gvr), workspace: clusterName.String(), namespace: namespace, name: name})
+	}
+}
+
+// SyncErrors returns the most recent sync errors, at most workloadv1alpha1.MaxSyncErrors, most recent first.
+func (r *SyncStateReporter) SyncErrors() []workloadv1alpha1.SyncError {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	syncErrors := make([]workloadv1alpha1.SyncError, 0, len(r.errors))
+	for _, e := range r.errors {
+		syncErrors = append(syncErrors, e)
+	}
+	sort.Slice(syncErrors, func(i, j int) bool {
+		a, b := syncErrors[i], syncErrors[j]
+		if !a.LastErrorTime.Equal(&b.LastErrorTime) {
+			return b.LastErrorTime.Before(&a.LastErrorTime)
+		}
+		return syncErrorKeyOf(a).String() < syncErrorKeyOf(b).String()
+	})
+	if len(syncErrors) > workloadv1alpha1.MaxSyncErrors {
+		syncErrors = syncErrors[:workloadv1alpha1.MaxSyncErrors]
+	}
+	return syncErrors
+}
+
+func (r *SyncStateReporter) recordSyncError(direction workloadv1alpha1.SyncDirection, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) {
+	key := syncErrorKey{
+		direction: direction,
+		resource:  SyncErrorResource(gvr),
+		workspace: logicalcluster.From(upstreamObj).String(),
+		namespace: upstreamObj.GetNamespace(),
+		name:      upstreamObj.GetName(),
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if syncErr == nil {
+		delete(r.errors, key)
+		return
+	}
+	if existing, found := r.errors[key]; found && existing.Message == syncErr.Error() {
+		return
+	}
+	r.errors[key] = workloadv1alpha1.SyncError{
+		Direction:     key.direction,
+		Resource:      key.resource,
+		Workspace:     key.workspace,
+		Namespace:     key.namespace,
+		Name:          key.name,
+		Message:       syncErr.Error(),
+		LastErrorTime: metav1.NewTime(r.clock.Now()),
+	}
+}
+
+// resourceSyncError returns the error to record for syncErr. The previous error is kept as long as
+// the message does not change, in order to not update the upstream resource on every retry.
+func (r *SyncStateReporter) resourceSyncError(previous *workloadv1alpha1.ResourceSyncError, syncErr error) *workloadv1alpha1.ResourceSyncError {
+	if syncErr == nil {
+		return nil
+	}
+	if previous != nil && previous.Message == syncErr.Error() {
+		return previous
+	}
+	return &workloadv1alpha1.ResourceSyncError{Message: syncErr.Error(), LastErrorTime: metav1.NewTime(r.clock.Now())}
+}
+
+// updateSyncState updates the sync state annotation of the upstream resource with the given function.
+// The resource is only patched if the state has changed.
+func (r *SyncStateReporter) updateSyncState(ctx context.Context, upstreamClient dynamic.ClusterInterface, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, update func(state *workloadv1alpha1.ResourceSyncState)) error {
+	logger := klog.FromContext(ctx)
+	annotation := workloadv1alpha1.SyncStateAnnotationPrefix + r.syncTargetKey
+
+	var state workloadv1alpha1.ResourceSyncState
+	value, found := upstreamObj.GetAnnotations()[annotation]
+	valid := true
+	if found {
+		if err := json.Unmarshal([]byte(value), &state); err != nil {
+			logger.Error(err, "invalid sync state annotation, overwriting it", "annotation", annotation)
+			state = workloadv1alpha1.ResourceSyncState{}
+			valid = false
+		}
+	}
+	existing := *state.DeepCopy()
+	update(&state)
+
+	if !found && reflect.DeepEqual(state, workloadv1alpha1.ResourceSyncState{}) {
+		return nil
+	}
+	if found && valid && reflect.DeepEqual(state, existing) {
+		return nil
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				annotation: string(stateJSON),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.V(4).Info("updating sync state of upstream resource", "syncState", string(stateJSON))
+	_, err = upstreamClient.Cluster(logicalcluster.From(upstreamObj)).Resource(gvr).Namespace(upstreamObj.GetNamespace()).Patch(ctx, upstreamObj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// SyncErrorResource formats the resource as <resource>.<version>.<group>.
+func SyncErrorResource(gvr schema.GroupVersionResource) string {
+	return strings.TrimSuffix(gvr.Resource+"."+gvr.Version+"."+gvr.Group, ".")
+}
+
+func syncErrorKeyOf(e workloadv1alpha1.SyncError) syncErrorKey {
+	return syncErrorKey{direction: e.Direction, resource: e.Resource, workspace: e.Workspace, namespace: e.Namespace, name: e.Name}
+}
+
+func (k syncErrorKey) String() string {
+	return strings.Join([]string{string(k.direction), k.resource, k.workspace, k.namespace, k.name}, "|")
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+type fakeDynamicCluster struct {
+	client *dynamicfake.FakeDynamicClient
+}
+
+func (c *fakeDynamicCluster) Cluster(logicalcluster.Name) dynamic.Interface {
+	return c.client
+}
+
+func newConfigMap(name string, generation int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetGeneration(generation)
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org:ws"})
+	return obj
+}
+
+func TestSyncStateReporter(t *testing.T) {
+	ctx := context.Background()
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	annotation := workloadv1alpha1.SyncStateAnnotationPrefix + "syncTargetKey"
+
+	obj := newConfigMap("cm", 2)
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+	upstreamClient := &fakeDynamicCluster{client: client}
+	// sync states are read back in local time
+	start := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC).Local()
+	clock := clocktesting.NewFakePassiveClock(start)
+	r := NewSyncStateReporter("syncTargetKey", clock)
+
+	syncState := func() workloadv1alpha1.ResourceSyncState {
+		got, err := client.Resource(gvr).Namespace("default").Get(ctx, "cm", metav1.GetOptions{})
+		require.NoError(t, err)
+		var state workloadv1alpha1.ResourceSyncState
+		require.NoError(t, json.Unmarshal([]byte(got.GetAnnotations()[annotation]), &state))
+		obj = got
+		return state
+	}
+
+	// a success without prior state does not write anything
+	require.NoError(t, r.ReportStatusSync(ctx, upstreamClient, gvr, obj, nil))
+	require.Empty(t, client.Actions())
+
+	// a failure is recorded on the object and in the summary
+	require.NoError(t, r.ReportSpecSync(ctx, upstreamClient, gvr, obj, errors.New("admission denied")))
+	require.Equal(t, workloadv1alpha1.ResourceSyncState{
+		SpecError: &workloadv1alpha1.ResourceSyncError{Message: "admission denied", LastErrorTime: metav1.NewTime(start)},
+	}, syncState())
+	require.Equal(t, []workloadv1alpha1.SyncError{{
+		Direction:     workloadv1alpha1.SyncDirectionSpec,
+		Resource:      "configmaps.v1",
+		Workspace:     "root:org:ws",
+		Namespace:     "default",
+		Name:          "cm",
+		Message:       "admission denied",
+		LastErrorTime: metav1.NewTime(start),
+	}}, r.SyncErrors())
+
+	// the same failure is not written again
+	client.ClearActions()
+	clock.SetTime(start.Add(time.Minute))
+	require.NoError(t, r.ReportSpecSync(ctx, upstreamClient, gvr, obj, errors.New("admission denied")))
+	require.Empty(t, client.Actions())
+	require.Equal(t, metav1.NewTime(start), r.SyncErrors()[0].LastErrorTime)
+
+	// a status failure is recorded next to the spec failure
+	require.NoError(t, r.ReportStatusSync(ctx, upstreamClient, gvr, obj, errors.New("conflict")))
+	require.Equal(t, workloadv1alpha1.ResourceSyncState{
+		SpecError:   &workloadv1alpha1.ResourceSyncError{Message: "admission denied", LastErrorTime: metav1.NewTime(start)},
+		StatusError: &workloadv1alpha1.ResourceSyncError{Message: "conflict", LastErrorTime: metav1.NewTime(start.Add(time.Minute))},
+	}, syncState())
+	require.Len(t, r.SyncErrors(), 2)
+	require.Equal(t, workloadv1alpha1.SyncDirectionStatus, r.SyncErrors()[0].Direction, "the most recent error should come first")
+
+	// a success records the synced generation and removes the failure
+	require.NoError(t, r.ReportSpecSync(ctx, upstreamClient, gvr, obj, nil))
+	require.Equal(t, workloadv1alpha1.ResourceSyncState{
+		SyncedGeneration: 2,
+		StatusError:      &workloadv1alpha1.ResourceSyncError{Message: "conflict", LastErrorTime: metav1.NewTime(start.Add(time.Minute))},
+	}, syncState())
+	require.Len(t, r.SyncErrors(), 1)
+
+	// a deleted object is forgotten
+	r.Forget(gvr, logicalcluster.New("root:org:ws"), "default", "cm")
+	require.Empty(t, r.SyncErrors())
+}
+
+func TestSyncStateReporterSyncErrorsAreBounded(t *testing.T) {
+	ctx := context.Background()
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+	var objs []runtime.Object
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	for _, name := range names {
+		objs = append(objs, newConfigMap(name, 1))
+	}
+	upstreamClient := &fakeDynamicCluster{client: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs...)}
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	clock := clocktesting.NewFakePassiveClock(now)
+	r := NewSyncStateReporter("syncTargetKey", clock)
+
+	for _, obj := range objs {
+		now = now.Add(time.Second)
+		clock.SetTime(now)
+		require.NoError(t, r.ReportSpecSync(ctx, upstreamClient, gvr, obj.(*unstructured.Unstructured), errors.New("boom")))
+	}
+
+	syncErrors := r.SyncErrors()
+	require.Len(t, syncErrors, workloadv1alpha1.MaxSyncErrors)
+	require.Equal(t, "l", syncErrors[0].Name, "the most recent error should come first")
+	require.Equal(t, "c", syncErrors[workloadv1alpha1.MaxSyncErrors-1].Name)
+}
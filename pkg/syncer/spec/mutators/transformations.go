@@ -0,0 +1,335 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+// ListTransformationsFunc returns the SyncTargetTransformations of the SyncTarget.
+type ListTransformationsFunc func() ([]*workloadv1alpha1.SyncTargetTransformation, error)
+
+// RuleError is returned by TransformationMutator.Mutate when a rule fails to apply.
+type RuleError struct {
+	// Transformation is the name of the SyncTargetTransformation.
+	Transformation string
+	// Rule is the name of the failing rule.
+	Rule string
+	// Err is the reason of the failure.
+	Err error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("rule %q of SyncTargetTransformation %q failed to apply: %v", e.Rule, e.Transformation, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+// TransformationMutator applies the rules of the SyncTargetTransformations of a SyncTarget.
+type TransformationMutator struct {
+	listTransformations ListTransformationsFunc
+}
+
+func NewTransformationMutator(listTransformations ListTransformationsFunc) *TransformationMutator {
+	return &TransformationMutator{
+		listTransformations: listTransformations,
+	}
+}
+
+// Mutate applies the rules selecting the object, in the order of the transformation names and of the
+// rules in a transformation. The labels of the object select the rules before any rule is applied.
+// A failing rule is returned as *RuleError.
+func (tm *TransformationMutator) Mutate(gvr schema.GroupVersionResource, obj *unstructured.Unstructured) error {
+	transformations, err := tm.listTransformations()
+	if err != nil {
+		return err
+	}
+	sort.Slice(transformations, func(i, j int) bool {
+		return transformations[i].Name < transformations[j].Name
+	})
+
+	objLabels := labels.Set(obj.GetLabels())
+	for _, transformation := range transformations {
+		for i := range transformation.Spec.Rules {
+			rule := &transformation.Spec.Rules[i]
+			if err := applyRule(rule, gvr, objLabels, obj); err != nil {
+				return &RuleError{Transformation: transformation.Name, Rule: rule.Name, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+func applyRule(rule *workloadv1alpha1.TransformationRule, gvr schema.GroupVersionResource, objLabels labels.Set, obj *unstructured.Unstructured) error {
+	if selected, err := RuleSelects(rule, gvr, objLabels); err != nil || !selected {
+		return err
+	}
+
+	if len(rule.JSONPatch) > 0 {
+		if err := applyJSONPatch(rule.JSONPatch, obj); err != nil {
+			return err
+		}
+	}
+	for i := range rule.Fields {
+		if err := applyFieldTransformation(&rule.Fields[i], obj.Object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RuleSelects returns whether the rule applies to an object of the given resource type with the given labels.
+func RuleSelects(rule *workloadv1alpha1.TransformationRule, gvr schema.GroupVersionResource, objLabels labels.Set) (bool, error) {
+	if !RuleSelectsResource(rule, gvr) {
+		return false, nil
+	}
+	if rule.LabelSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(rule.LabelSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid label selector: %w", err)
+	}
+	return selector.Matches(objLabels), nil
+}
+
+// RuleSelectsResource returns whether the rule applies to objects of the given resource type.
+func RuleSelectsResource(rule *workloadv1alpha1.TransformationRule, gvr schema.GroupVersionResource) bool {
+	if rule.Resource.Group != gvr.Group || rule.Resource.Resource != gvr.Resource {
+		return false
+	}
+	return rule.Resource.Version == "" || rule.Resource.Version == gvr.Version
+}
+
+func applyJSONPatch(operations []workloadv1alpha1.JSONPatchOperation, obj *unstructured.Unstructured) error {
+	type operation struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		From  string          `json:"from,omitempty"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+	ops := make([]operation, 0, len(operations))
+	for _, o := range operations {
+		op := operation{Op: o.Op, Path: o.Path, From: o.From}
+		if o.Value != nil {
+			op.Value = o.Value.Raw
+		}
+		ops = append(ops, op)
+	}
+	patchBytes, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.DecodePatch(patchBytes)
+	if err != nil {
+		return fmt.Errorf("invalid JSON patch: %w", err)
+	}
+
+	objBytes, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	patchedBytes, err := patch.Apply(objBytes)
+	if err != nil {
+		return fmt.Errorf("failed to apply JSON patch: %w", err)
+	}
+	patched := map[string]interface{}{}
+	if err := utiljson.Unmarshal(patchedBytes, &patched); err != nil {
+		return err
+	}
+	obj.Object = patched
+	return nil
+}
+
+func applyFieldTransformation(ft *workloadv1alpha1.FieldTransformation, obj map[string]interface{}) error {
+	segments, err := parsePath(ft.Path)
+	if err != nil {
+		return err
+	}
+
+	if ft.Value != nil {
+		value, err := rawValue(ft.Value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %q: %w", ft.Path, err)
+		}
+		create := true
+		for _, s := range segments {
+			if s == "*" {
+				create = false
+			}
+		}
+		if err := visitPath(obj, segments, ft.Path, create, func(_ interface{}, _ bool) (interface{}, bool, error) {
+			return runtime.DeepCopyJSONValue(value), true, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(ft.Replacements) > 0 {
+		if err := visitPath(obj, segments, ft.Path, false, func(old interface{}, found bool) (interface{}, bool, error) {
+			if !found || old == nil {
+				return nil, false, nil
+			}
+			s, ok := old.(string)
+			if !ok {
+				return nil, false, fmt.Errorf("field %q is a %T, not a string", ft.Path, old)
+			}
+			replaced, ok := replaceString(ft.Replacements, s)
+			return replaced, ok, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func replaceString(replacements []workloadv1alpha1.StringReplacement, s string) (string, bool) {
+	for _, r := range replacements {
+		switch r.Match {
+		case workloadv1alpha1.StringMatchPrefix:
+			if strings.HasPrefix(s, r.From) {
+				return r.To + strings.TrimPrefix(s, r.From), true
+			}
+		default:
+			if s == r.From {
+				return r.To, true
+			}
+		}
+	}
+	return s, false
+}
+
+func rawValue(raw *runtime.RawExtension) (interface{}, error) {
+	var value interface{}
+	if err := utiljson.Unmarshal(raw.Raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// parsePath splits a JSON pointer into its unescaped segments.
+func parsePath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %q must start with /", path)
+	}
+	segments := strings.Split(path[1:], "/")
+	for i, s := range segments {
+		segments[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(s)
+	}
+	return segments, nil
+}
+
+// visitValueFunc returns the new value of a field, and whether to set it.
+type visitValueFunc func(old interface{}, found bool) (interface{}, bool, error)
+
+// visitPath calls fn for every field at the path below node. Missing maps on the path are created
+// if create is true, otherwise paths that do not exist are skipped.
+func visitPath(node interface{}, segments []string, path string, create bool, fn visitValueFunc) error {
+	segment, last := segments[0], len(segments) == 1
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := []string{segment}
+		if segment == "*" {
+			keys = make([]string, 0, len(n))
+			for k := range n {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+		}
+		for _, k := range keys {
+			child, found := n[k]
+			if last {
+				value, set, err := fn(child, found)
+				if err != nil {
+					return err
+				}
+				if set {
+					n[k] = value
+				}
+				continue
+			}
+			if !found || child == nil {
+				if !create {
+					continue
+				}
+				child = map[string]interface{}{}
+				n[k] = child
+			}
+			if err := visitPath(child, segments[1:], path, create, fn); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		indices := []int{}
+		if segment == "*" {
+			for i := range n {
+				indices = append(indices, i)
+			}
+		} else {
+			i, err := strconv.Atoi(segment)
+			if err != nil {
+				return fmt.Errorf("invalid list index %q in path %q", segment, path)
+			}
+			if i < 0 || i >= len(n) {
+				if create {
+					return fmt.Errorf("list index %d out of range in path %q", i, path)
+				}
+				return nil
+			}
+			indices = append(indices, i)
+		}
+		for _, i := range indices {
+			if last {
+				value, set, err := fn(n[i], true)
+				if err != nil {
+					return err
+				}
+				if set {
+					n[i] = value
+				}
+				continue
+			}
+			if err := visitPath(n[i], segments[1:], path, create, fn); err != nil {
+				return err
+			}
+		}
+	default:
+		if create {
+			return fmt.Errorf("cannot set path %q: found %T instead of a map or list", path, node)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutators
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	pvcsGVR        = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	deployments    = workloadv1alpha1.TransformationResource{Group: "apps", Resource: "deployments"}
+)
+
+func unstructuredFromJSON(t *testing.T, s string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	require.NoError(t, obj.UnmarshalJSON([]byte(s)))
+	return obj
+}
+
+func raw(s string) *runtime.RawExtension {
+	return &runtime.RawExtension{Raw: []byte(s)}
+}
+
+const deploymentJSON = `{
+	"apiVersion": "apps/v1",
+	"kind": "Deployment",
+	"metadata": {"name": "web", "labels": {"app": "web"}},
+	"spec": {"replicas": 1, "template": {"spec": {"containers": [
+		{"name": "web", "image": "docker.io/library/nginx:1.23"},
+		{"name": "sidecar", "image": "quay.io/sidecar:1"}
+	]}}}
+}`
+
+func TestTransformationMutator(t *testing.T) {
+	tests := map[string]struct {
+		gvr             schema.GroupVersionResource
+		obj             string
+		transformations []*workloadv1alpha1.SyncTargetTransformation
+		want            string
+		wantRuleErr     *RuleError
+	}{
+		"image registry mirror": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "mirror"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:     "images",
+					Resource: deployments,
+					Fields: []workloadv1alpha1.FieldTransformation{{
+						Path: "/spec/template/spec/containers/*/image",
+						Replacements: []workloadv1alpha1.StringReplacement{
+							{From: "docker.io/", To: "mirror.example.com/docker/", Match: workloadv1alpha1.StringMatchPrefix},
+						},
+					}},
+				}}},
+			}},
+			want: `{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web", "labels": {"app": "web"}},
+				"spec": {"replicas": 1, "template": {"spec": {"containers": [
+					{"name": "web", "image": "mirror.example.com/docker/library/nginx:1.23"},
+					{"name": "sidecar", "image": "quay.io/sidecar:1"}
+				]}}}
+			}`,
+		},
+		"node selector, tolerations and labels, in order of the transformation names": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "b-labels"},
+					Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+						Name:     "team",
+						Resource: workloadv1alpha1.TransformationResource{Group: "apps", Version: "v1", Resource: "deployments"},
+						Fields: []workloadv1alpha1.FieldTransformation{
+							{Path: "/metadata/labels/example.com~1team", Value: raw(`"platform"`)},
+						},
+					}}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "a-scheduling"},
+					Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+						Name:     "gpu-nodes",
+						Resource: deployments,
+						JSONPatch: []workloadv1alpha1.JSONPatchOperation{
+							{Op: "add", Path: "/spec/template/spec/tolerations", Value: raw(`[{"key":"gpu","operator":"Exists"}]`)},
+						},
+						Fields: []workloadv1alpha1.FieldTransformation{
+							{Path: "/spec/template/spec/nodeSelector", Value: raw(`{"gpu":"true"}`)},
+						},
+					}}},
+				},
+			},
+			want: `{
+				"apiVersion": "apps/v1",
+				"kind": "Deployment",
+				"metadata": {"name": "web", "labels": {"app": "web", "example.com/team": "platform"}},
+				"spec": {"replicas": 1, "template": {"spec": {
+					"containers": [
+						{"name": "web", "image": "docker.io/library/nginx:1.23"},
+						{"name": "sidecar", "image": "quay.io/sidecar:1"}
+					],
+					"nodeSelector": {"gpu": "true"},
+					"tolerations": [{"key": "gpu", "operator": "Exists"}]
+				}}}
+			}`,
+		},
+		"label selector does not match": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "scheduling"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:          "gpu-nodes",
+					Resource:      deployments,
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"gpu": "true"}},
+					Fields: []workloadv1alpha1.FieldTransformation{
+						{Path: "/spec/template/spec/nodeSelector", Value: raw(`{"gpu":"true"}`)},
+					},
+				}}},
+			}},
+			want: deploymentJSON,
+		},
+		"other version is not selected": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "scheduling"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:     "gpu-nodes",
+					Resource: workloadv1alpha1.TransformationResource{Group: "apps", Version: "v2", Resource: "deployments"},
+					Fields: []workloadv1alpha1.FieldTransformation{
+						{Path: "/spec/template/spec/nodeSelector", Value: raw(`{"gpu":"true"}`)},
+					},
+				}}},
+			}},
+			want: deploymentJSON,
+		},
+		"storage class rename": {
+			gvr: pvcsGVR,
+			obj: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data"}, "spec": {"storageClassName": "standard"}}`,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "storage"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:     "storage-classes",
+					Resource: workloadv1alpha1.TransformationResource{Resource: "persistentvolumeclaims"},
+					Fields: []workloadv1alpha1.FieldTransformation{{
+						Path: "/spec/storageClassName",
+						Replacements: []workloadv1alpha1.StringReplacement{
+							{From: "standard-rwo", To: "gp3"},
+							{From: "standard", To: "gp2"},
+						},
+					}},
+				}}},
+			}},
+			want: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data"}, "spec": {"storageClassName": "gp2"}}`,
+		},
+		"failing JSON patch": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:     "replace-missing",
+					Resource: deployments,
+					JSONPatch: []workloadv1alpha1.JSONPatchOperation{
+						{Op: "replace", Path: "/spec/strategy/type", Value: raw(`"Recreate"`)},
+					},
+				}}},
+			}},
+			wantRuleErr: &RuleError{Transformation: "broken", Rule: "replace-missing"},
+		},
+		"replacing a field that is not a string": {
+			gvr: deploymentsGVR,
+			obj: deploymentJSON,
+			transformations: []*workloadv1alpha1.SyncTargetTransformation{{
+				ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+				Spec: workloadv1alpha1.SyncTargetTransformationSpec{Rules: []workloadv1alpha1.TransformationRule{{
+					Name:     "replicas",
+					Resource: deployments,
+					Fields: []workloadv1alpha1.FieldTransformation{{
+						Path:         "/spec/replicas",
+						Replacements: []workloadv1alpha1.StringReplacement{{From: "1", To: "2"}},
+					}},
+				}}},
+			}},
+			wantRuleErr: &RuleError{Transformation: "broken", Rule: "replicas"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tm := NewTransformationMutator(func() ([]*workloadv1alpha1.SyncTargetTransformation, error) {
+				return tt.transformations, nil
+			})
+			obj := unstructuredFromJSON(t, tt.obj)
+			err := tm.Mutate(tt.gvr, obj)
+			if tt.wantRuleErr != nil {
+				var ruleErr *RuleError
+				require.True(t, errors.As(err, &ruleErr), "expected a RuleError, got %v", err)
+				require.Equal(t, tt.wantRuleErr.Transformation, ruleErr.Transformation)
+				require.Equal(t, tt.wantRuleErr.Rule, ruleErr.Rule)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, unstructuredFromJSON(t, tt.want).Object, obj.Object)
+		})
+	}
+}
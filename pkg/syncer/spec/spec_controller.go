@@ -21,12 +21,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -37,6 +41,9 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
@@ -53,9 +60,17 @@ type Controller struct {
 
 	mutators mutatorGvrMap
 
+	transformationMutator *specmutators.TransformationMutator
+	transformationStatus  *transformationStatusReporter
+	syncStateReporter     *shared.SyncStateReporter
+
 	upstreamClient                         dynamic.ClusterInterface
 	downstreamClient                       dynamic.Interface
 	upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory
+	downstreamNamespaceLister              cache.GenericLister
+
+	gvrsLock sync.RWMutex
+	gvrs     map[schema.GroupVersionResource]bool
 
 	syncTargetName            string
 	syncTargetWorkspace       logicalcluster.Name
@@ -65,16 +80,21 @@ type Controller struct {
 }
 
 func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetKey string, upstreamURL *url.URL, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID,
+	transformationInformer workloadinformers.SyncTargetTransformationInformer, kcpClient kcpclient.Interface, syncStateReporter *shared.SyncStateReporter) (*Controller, error) {
 
 	c := Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
 
+		syncStateReporter: syncStateReporter,
+
 		upstreamClient:      upstreamClient,
 		downstreamClient:    downstreamClient,
 		upstreamInformers:   upstreamInformers,
 		downstreamInformers: downstreamInformers,
 
+		gvrs: map[schema.GroupVersionResource]bool{},
+
 		syncTargetName:            syncTargetName,
 		syncTargetWorkspace:       syncTargetWorkspace,
 		syncTargetUID:             syncTargetUID,
@@ -87,7 +107,7 @@ func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace logic
 		Version:  "v1",
 		Resource: "namespaces",
 	}
-	namespaceLister := downstreamInformers.ForResource(namespaceGVR).Lister()
+	c.downstreamNamespaceLister = downstreamInformers.ForResource(namespaceGVR).Lister()
 
 	err := downstreamInformers.ForResource(namespaceGVR).Informer().AddIndexers(cache.Indexers{byNamespaceLocatorIndexName: indexByNamespaceLocator})
 	if err != nil {
@@ -95,74 +115,7 @@ func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace logic
 	}
 
 	for _, gvr := range gvrs {
-		gvr := gvr // because used in closure
-
-		upstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				oldUnstrob := oldObj.(*unstructured.Unstructured)
-				newUnstrob := newObj.(*unstructured.Unstructured)
-
-				if !deepEqualApartFromStatus(oldUnstrob, newUnstrob) {
-					c.AddToQueue(gvr, newUnstrob)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-		})
-
-		klog.V(2).InfoS("Set up upstream informer", "syncTargetWorkspace", syncTargetWorkspace, "syncTargetName", syncTargetName, "syncTargetKey", syncTargetKey, "gvr", gvr.String())
-
-		downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			DeleteFunc: func(obj interface{}) {
-				key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
-				if err != nil {
-					utilruntime.HandleError(fmt.Errorf("error getting key for type %T: %w", obj, err))
-					return
-				}
-				namespace, name, err := cache.SplitMetaNamespaceKey(key)
-				if err != nil {
-					utilruntime.HandleError(fmt.Errorf("error splitting key %q: %w", key, err))
-				}
-				klog.V(3).InfoS("processing delete event", "key", key, "gvr", gvr, "namespace", namespace, "name", name)
-
-				// Use namespace lister
-				nsObj, err := namespaceLister.Get(namespace)
-				if err != nil {
-					utilruntime.HandleError(err)
-					return
-				}
-				ns, ok := nsObj.(*unstructured.Unstructured)
-				if !ok {
-					utilruntime.HandleError(fmt.Errorf("unexpected object type: %T", nsObj))
-					return
-				}
-				locator, ok := ns.GetAnnotations()[shared.NamespaceLocatorAnnotation]
-				if !ok {
-					utilruntime.HandleError(fmt.Errorf("unable to find the locator annotation in namespace %s", namespace))
-					return
-				}
-				nsLocator := &shared.NamespaceLocator{}
-				err = json.Unmarshal([]byte(locator), nsLocator)
-				if err != nil {
-					utilruntime.HandleError(err)
-					return
-				}
-				klog.V(4).InfoS("found", "NamespaceLocator", nsLocator)
-				m := &metav1.ObjectMeta{
-					Annotations: map[string]string{
-						logicalcluster.AnnotationKey: nsLocator.Workspace.String(),
-					},
-					Namespace: nsLocator.Namespace,
-					Name:      name,
-				}
-				c.AddToQueue(gvr, m)
-			},
-		})
-		klog.V(2).InfoS("Set up downstream informer", "SyncTarget Workspace", syncTargetWorkspace, "SyncTarget Name", syncTargetName, "gvr", gvr.String())
+		c.AddGVR(gvr)
 	}
 
 	secretMutator := specmutators.NewSecretMutator()
@@ -180,9 +133,222 @@ func NewSpecSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace logic
 		secretMutator.GVR():     secretMutator.Mutate,
 	}
 
+	// SyncTargetTransformations are applied after the built-in mutators.
+	c.transformationStatus = &transformationStatusReporter{
+		syncTargetName: syncTargetName,
+		lister:         transformationInformer.Lister(),
+		kcpClient:      kcpClient,
+		now:            metav1.Now,
+	}
+	c.transformationMutator = specmutators.NewTransformationMutator(c.transformationStatus.listTransformations)
+
+	// Objects are synced again when the rules selecting them change.
+	transformationInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueTransformed(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldTransformation, ok := oldObj.(*workloadv1alpha1.SyncTargetTransformation)
+			if !ok {
+				return
+			}
+			newTransformation, ok := newObj.(*workloadv1alpha1.SyncTargetTransformation)
+			if !ok {
+				return
+			}
+			if equality.Semantic.DeepEqual(oldTransformation.Spec, newTransformation.Spec) {
+				// status updates by the syncers themselves
+				return
+			}
+			// objects selected by the old rules only have to be synced without them now.
+			c.enqueueTransformed(oldObj)
+			c.enqueueTransformed(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.enqueueTransformed(obj)
+		},
+	})
+
 	return &c, nil
 }
 
+// AddGVR starts syncing the given resource type. It is a no-op if the resource type is already synced.
+func (c *Controller) AddGVR(gvr schema.GroupVersionResource) {
+	c.gvrsLock.Lock()
+	defer c.gvrsLock.Unlock()
+
+	if c.gvrs[gvr] {
+		return
+	}
+	c.gvrs[gvr] = true
+
+	c.upstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldUnstrob := oldObj.(*unstructured.Unstructured)
+			newUnstrob := newObj.(*unstructured.Unstructured)
+
+			if !deepEqualApartFromStatus(oldUnstrob, newUnstrob) {
+				c.AddToQueue(gvr, newUnstrob)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+	})
+
+	klog.V(2).InfoS("Set up upstream informer", "syncTargetWorkspace", c.syncTargetWorkspace, "syncTargetName", c.syncTargetName, "syncTargetKey", c.syncTargetKey, "gvr", gvr.String())
+
+	c.downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			key, err := keyfunctions.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("error getting key for type %T: %w", obj, err))
+				return
+			}
+			namespace, name, err := cache.SplitMetaNamespaceKey(key)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("error splitting key %q: %w", key, err))
+			}
+			klog.V(3).InfoS("processing delete event", "key", key, "gvr", gvr, "namespace", namespace, "name", name)
+
+			if namespace == "" {
+				// cluster-scoped resources carry their upstream location themselves
+				c.enqueueClusterScopedDownstreamDeletion(gvr, obj)
+				return
+			}
+
+			// Use namespace lister
+			nsObj, err := c.downstreamNamespaceLister.Get(namespace)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+			ns, ok := nsObj.(*unstructured.Unstructured)
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("unexpected object type: %T", nsObj))
+				return
+			}
+			locator, ok := ns.GetAnnotations()[shared.NamespaceLocatorAnnotation]
+			if !ok {
+				utilruntime.HandleError(fmt.Errorf("unable to find the locator annotation in namespace %s", namespace))
+				return
+			}
+			nsLocator := &shared.NamespaceLocator{}
+			err = json.Unmarshal([]byte(locator), nsLocator)
+			if err != nil {
+				utilruntime.HandleError(err)
+				return
+			}
+			klog.V(4).InfoS("found", "NamespaceLocator", nsLocator)
+			m := &metav1.ObjectMeta{
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey: nsLocator.Workspace.String(),
+				},
+				Namespace: nsLocator.Namespace,
+				Name:      name,
+			}
+			c.AddToQueue(gvr, m)
+		},
+	})
+	klog.V(2).InfoS("Set up downstream informer", "SyncTarget Workspace", c.syncTargetWorkspace, "SyncTarget Name", c.syncTargetName, "gvr", gvr.String())
+}
+
+func (c *Controller) enqueueClusterScopedDownstreamDeletion(gvr schema.GroupVersionResource, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	downstreamObj, ok := obj.(metav1.Object)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+		return
+	}
+	resourceLocator, exists, err := shared.ResourceLocatorFromAnnotations(downstreamObj.GetAnnotations())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	if !exists {
+		utilruntime.HandleError(fmt.Errorf("unable to find the locator annotation in resource %s", downstreamObj.GetName()))
+		return
+	}
+	klog.V(4).InfoS("found", "ResourceLocator", resourceLocator)
+	m := &metav1.ObjectMeta{
+		Annotations: map[string]string{
+			logicalcluster.AnnotationKey: resourceLocator.Workspace.String(),
+		},
+		Name: resourceLocator.Name,
+	}
+	c.AddToQueue(gvr, m)
+}
+
+// enqueueTransformed enqueues the synced objects selected by the rules of the given SyncTargetTransformation.
+func (c *Controller) enqueueTransformed(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	transformation, ok := obj.(*workloadv1alpha1.SyncTargetTransformation)
+	if !ok {
+		utilruntime.HandleError(fmt.Errorf("unexpected object type: %T", obj))
+		return
+	}
+	if transformation.Spec.SyncTargetName != c.syncTargetName {
+		return
+	}
+
+	c.gvrsLock.RLock()
+	gvrs := make([]schema.GroupVersionResource, 0, len(c.gvrs))
+	for gvr := range c.gvrs {
+		gvrs = append(gvrs, gvr)
+	}
+	c.gvrsLock.RUnlock()
+
+	for _, gvr := range gvrs {
+		var objs []runtime.Object
+		for i := range transformation.Spec.Rules {
+			rule := &transformation.Spec.Rules[i]
+			if !specmutators.RuleSelectsResource(rule, gvr) {
+				continue
+			}
+			if objs == nil {
+				var err error
+				if objs, err = c.upstreamInformers.ForResource(gvr).Lister().List(labels.Everything()); err != nil {
+					utilruntime.HandleError(err)
+					break
+				}
+			}
+			for _, o := range objs {
+				metaObj, ok := o.(metav1.Object)
+				if !ok {
+					continue
+				}
+				if selected, err := specmutators.RuleSelects(rule, gvr, metaObj.GetLabels()); err == nil && selected {
+					c.AddToQueue(gvr, o)
+				}
+			}
+		}
+	}
+	klog.V(4).InfoS("Enqueued objects selected by SyncTargetTransformation", "syncTargetTransformation", transformation.Name)
+}
+
+// RemoveGVR stops syncing the given resource type. Queued keys of this resource type are dropped.
+func (c *Controller) RemoveGVR(gvr schema.GroupVersionResource) {
+	c.gvrsLock.Lock()
+	defer c.gvrsLock.Unlock()
+
+	delete(c.gvrs, gvr)
+	klog.V(2).InfoS("Stopped syncing resource", "syncTargetWorkspace", c.syncTargetWorkspace, "syncTargetName", c.syncTargetName, "gvr", gvr.String())
+}
+
+func (c *Controller) hasGVR(gvr schema.GroupVersionResource) bool {
+	c.gvrsLock.RLock()
+	defer c.gvrsLock.RUnlock()
+
+	return c.gvrs[gvr]
+}
+
 type queueKey struct {
 	gvr schema.GroupVersionResource
 	key string // meta namespace key
@@ -19,6 +19,7 @@ package spec
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -41,6 +42,7 @@ import (
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	"github.com/kcp-dev/kcp/pkg/syncer/shared"
+	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
 )
 
 const (
@@ -51,14 +53,14 @@ type mutatorGvrMap map[schema.GroupVersionResource]func(obj *unstructured.Unstru
 
 func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured) bool {
 	// TODO(jmprusi): Remove this after switching to virtual workspaces.
-	// remove status annotation from oldObj and newObj before comparing
+	// remove status and sync state annotations from oldObj and newObj before comparing
 	oldAnnotations, _, err := unstructured.NestedStringMap(oldUnstrob.Object, "metadata", "annotations")
 	if err != nil {
 		klog.Errorf("failed to get annotations from object: %v", err)
 		return false
 	}
 	for k := range oldAnnotations {
-		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) || strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix) || strings.HasPrefix(k, workloadv1alpha1.SyncStateAnnotationPrefix) {
 			delete(oldAnnotations, k)
 		}
 	}
@@ -69,7 +71,7 @@ func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured)
 		return false
 	}
 	for k := range newAnnotations {
-		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) {
+		if strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusAnnotationPrefix) || strings.HasPrefix(k, workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix) || strings.HasPrefix(k, workloadv1alpha1.SyncStateAnnotationPrefix) {
 			delete(newAnnotations, k)
 		}
 	}
@@ -106,6 +108,11 @@ func deepEqualApartFromStatus(oldUnstrob, newUnstrob *unstructured.Unstructured)
 func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResource, key string) error {
 	klog.V(3).InfoS("Processing", "gvr", gvr, "key", key)
 
+	if !c.hasGVR(gvr) {
+		klog.V(3).InfoS("Resource is not synced anymore, ignoring key", "gvr", gvr, "key", key)
+		return nil
+	}
+
 	// from upstream
 	upstreamNamespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -114,6 +121,10 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	}
 	clusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
 
+	if upstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, key, clusterName, name)
+	}
+
 	namespaceGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
 	desiredNSLocator := shared.NewNamespaceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamNamespace)
 	jsonNSLocator, err := json.Marshal(desiredNSLocator)
@@ -157,6 +168,7 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		if err := c.downstreamClient.Resource(gvr).Namespace(downstreamNamespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
 			return err
 		}
+		c.syncStateReporter.Forget(gvr, clusterName, upstreamNamespace, name)
 		return nil
 	}
 
@@ -167,7 +179,7 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	}
 
 	if err := c.ensureDownstreamNamespaceExists(ctx, downstreamNamespace, upstreamObj); err != nil {
-		return err
+		return c.reportSpecSync(ctx, gvr, upstreamObj, err)
 	}
 
 	if added, err := c.ensureSyncerFinalizer(ctx, gvr, upstreamObj); added {
@@ -180,6 +192,47 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	return c.applyToDownstream(ctx, gvr, downstreamNamespace, upstreamObj)
 }
 
+// processClusterScoped syncs a cluster-scoped upstream resource. The downstream resource name is derived
+// from a ResourceLocator, so that resources with the same name in different workspaces don't collide.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, key string, clusterName logicalcluster.Name, name string) error {
+	resourceLocator := shared.NewResourceLocator(clusterName, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, name)
+	downstreamName, err := shared.PhysicalClusterResourceName(resourceLocator)
+	if err != nil {
+		klog.Errorf("Error hashing resource %s|%s: %v", clusterName, name, err)
+		return nil
+	}
+
+	// get the upstream object
+	obj, exists, err := c.upstreamInformers.ForResource(gvr).Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// deleted upstream => delete downstream
+		klog.Infof("Deleting downstream GVR %q object %s for upstream cluster %q object %s", gvr.String(), downstreamName, clusterName, name)
+		if err := c.downstreamClient.Resource(gvr).Delete(ctx, downstreamName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		c.syncStateReporter.Forget(gvr, clusterName, "", name)
+		return nil
+	}
+
+	// upsert downstream
+	upstreamObj, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+
+	if added, err := c.ensureSyncerFinalizer(ctx, gvr, upstreamObj); added {
+		// The successful update of the upstream resource finalizer will trigger a new reconcile
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.applyToDownstream(ctx, gvr, "", upstreamObj)
+}
+
 // TODO: This function is there as a quick and dirty implementation of namespace creation.
 //
 //	In fact We should also be getting notifications about namespaces created upstream and be creating downstream equivalents.
@@ -195,8 +248,8 @@ func (c *Controller) ensureDownstreamNamespaceExists(ctx context.Context, downst
 	newNamespace.SetKind("Namespace")
 	newNamespace.SetName(downstreamNamespace)
 
-	// TODO: if the downstream namespace loses these annotations/labels after creation,
-	// we don't have anything in place currently that will put them back.
+	// If the downstream namespace loses these annotations/labels after creation, the namespace
+	// controller puts them back.
 	upstreamLogicalCluster := logicalcluster.From(upstreamObj)
 	desiredNSLocator := shared.NewNamespaceLocator(upstreamLogicalCluster, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamObj.GetNamespace())
 	b, err := json.Marshal(desiredNSLocator)
@@ -284,6 +337,22 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	// Run name transformations on the downstreamObj.
 	transformedName := getTransformedName(downstreamObj)
 
+	// Cluster-scoped resources get a name unique to their upstream location, which is recorded in an annotation.
+	var resourceLocatorAnnotation string
+	if upstreamObj.GetNamespace() == "" {
+		resourceLocator := shared.NewResourceLocator(upstreamObjLogicalCluster, c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamObj.GetName())
+		name, err := shared.PhysicalClusterResourceName(resourceLocator)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(resourceLocator)
+		if err != nil {
+			return err
+		}
+		transformedName = name
+		resourceLocatorAnnotation = string(b)
+	}
+
 	// TODO(jmprusi): When using syncer virtual workspace we would check the DeletionTimestamp on the upstream object, instead of the DeletionTimestamp annotation,
 	//                as the virtual workspace will set the the deletionTimestamp() on the location view by a transformation.
 	intendedToBeRemovedFromLocation := upstreamObj.GetAnnotations()[workloadv1alpha1.InternalClusterDeletionTimestampAnnotationPrefix+c.syncTargetKey] != ""
@@ -309,12 +378,21 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 		return nil
 	}
 
+	err := c.upsertDownstream(ctx, gvr, downstreamNamespace, upstreamObj, downstreamObj, transformedName, resourceLocatorAnnotation)
+	return c.reportSpecSync(ctx, gvr, upstreamObj, err)
+}
+
+// upsertDownstream transforms the copy of the upstream object and applies it to the downstream cluster.
+func (c *Controller) upsertDownstream(ctx context.Context, gvr schema.GroupVersionResource, downstreamNamespace string, upstreamObj, downstreamObj *unstructured.Unstructured, transformedName, resourceLocatorAnnotation string) error {
 	// Run any transformations on the object before we apply it to the downstream cluster.
 	if mutator, ok := c.mutators[gvr]; ok {
 		if err := mutator(downstreamObj); err != nil {
 			return err
 		}
 	}
+	if err := c.applyTransformations(ctx, gvr, upstreamObj, downstreamObj); err != nil {
+		return err
+	}
 
 	downstreamObj.SetName(transformedName)
 	downstreamObj.SetUID("")
@@ -322,9 +400,20 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	downstreamObj.SetNamespace(downstreamNamespace)
 	downstreamObj.SetManagedFields(nil)
 
-	// Strip cluster name annotation
+	// Strip cluster name and sync state annotations
 	downstreamAnnotations := downstreamObj.GetAnnotations()
 	delete(downstreamAnnotations, logicalcluster.AnnotationKey)
+	for k := range downstreamAnnotations {
+		if strings.HasPrefix(k, workloadv1alpha1.SyncStateAnnotationPrefix) {
+			delete(downstreamAnnotations, k)
+		}
+	}
+	if resourceLocatorAnnotation != "" {
+		if downstreamAnnotations == nil {
+			downstreamAnnotations = map[string]string{}
+		}
+		downstreamAnnotations[shared.ResourceLocatorAnnotation] = resourceLocatorAnnotation
+	}
 	// If we're left with 0 annotations, nil out the map so it's not included in the patch
 	if len(downstreamAnnotations) == 0 {
 		downstreamAnnotations = nil
@@ -395,6 +484,17 @@ func (c *Controller) applyToDownstream(ctx context.Context, gvr schema.GroupVers
 	return nil
 }
 
+// reportSpecSync records the result of the sync in the sync state of the upstream object, and returns the sync error.
+func (c *Controller) reportSpecSync(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) error {
+	if err := c.syncStateReporter.ReportSpecSync(ctx, c.upstreamClient, gvr, upstreamObj, syncErr); err != nil {
+		klog.Errorf("Failed to report sync state of %s %s|%s/%s: %v", gvr.Resource, logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		if syncErr == nil {
+			return err
+		}
+	}
+	return syncErr
+}
+
 // getTransformedName returns the desired object name.
 func getTransformedName(syncedObject *unstructured.Unstructured) string {
 	configMapGVK := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "ConfigMap"}
@@ -411,3 +511,23 @@ func getTransformedName(syncedObject *unstructured.Unstructured) string {
 	}
 	return syncedObject.GetName()
 }
+
+// applyTransformations applies the rules of the SyncTargetTransformations to the downstream object, and
+// reports failing rules in the status of the SyncTargetTransformation. The object is not synced while a
+// rule fails.
+func (c *Controller) applyTransformations(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj, downstreamObj *unstructured.Unstructured) error {
+	err := c.transformationMutator.Mutate(gvr, downstreamObj)
+
+	var ruleErr *specmutators.RuleError
+	switch {
+	case errors.As(err, &ruleErr):
+		if reportErr := c.transformationStatus.reportFailure(ctx, ruleErr, gvr, upstreamObj); reportErr != nil {
+			klog.Errorf("Failed to report failure of SyncTargetTransformation %s rule %s: %v", ruleErr.Transformation, ruleErr.Rule, reportErr)
+		}
+		return err
+	case err != nil:
+		return err
+	}
+
+	return c.transformationStatus.reportSuccess(ctx, gvr, upstreamObj)
+}
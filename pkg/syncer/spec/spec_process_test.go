@@ -35,14 +35,22 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
 var scheme *runtime.Scheme
@@ -871,8 +879,15 @@ func TestSyncerProcess(t *testing.T) {
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
 			expectError:                         true,
-			expectActionsOnFrom:                 []clienttesting.Action{},
-			expectActionsOnTo:                   []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				patchDeploymentAction(
+					"theDeployment",
+					"test",
+					types.MergePatchType,
+					[]byte(`{"metadata":{"annotations":{"sync-state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5":"{\"specError\":{\"message\":\"(namespace collision) namespace kcp-hcbsa8z6c2er already exists, but has a different namespace locator annotation: \\u0026{SyncTarget:{Workspace:root:org:ws DeprecatedPath: Name:us-west1 UID:syncTargetUID} Workspace:root:org:ws Namespace:ANOTHERNAMESPACE} vs {SyncTarget:{Workspace:root:org:ws DeprecatedPath: Name:us-west1 UID:syncTargetUID} Workspace:root:org:ws Namespace:test}\",\"lastErrorTime\":\"2022-10-01T00:00:00Z\"}}"}}}`),
+				),
+			},
+			expectActionsOnTo: []clienttesting.Action{},
 		},
 		"SpecSyncer namespace conflict: try to sync to an already existing namespace without a namespace-locator, expect error": {
 			upstreamLogicalCluster: "root:org:ws",
@@ -903,8 +918,15 @@ func TestSyncerProcess(t *testing.T) {
 			resourceToProcessName:               "theDeployment",
 			syncTargetName:                      "us-west1",
 			expectError:                         true,
-			expectActionsOnFrom:                 []clienttesting.Action{},
-			expectActionsOnTo:                   []clienttesting.Action{},
+			expectActionsOnFrom: []clienttesting.Action{
+				patchDeploymentAction(
+					"theDeployment",
+					"test",
+					types.MergePatchType,
+					[]byte(`{"metadata":{"annotations":{"sync-state.workload.kcp.dev/2gzO8uuQmIoZ2FE95zoOPKtrtGGXzzjAvtl6q5":"{\"specError\":{\"message\":\"(namespace collision) namespace kcp-hcbsa8z6c2er has no namespace locator\",\"lastErrorTime\":\"2022-10-01T00:00:00Z\"}}"}}}`),
+				),
+			},
+			expectActionsOnTo: []clienttesting.Action{},
 		},
 		"old v0.6.0 namespace locator exists downstream": {
 			upstreamLogicalCluster: "root:org:ws",
@@ -1000,7 +1022,8 @@ func TestSyncerProcess(t *testing.T) {
 			}
 			upstreamURL, err := url.Parse("https://kcp.dev:6443")
 			require.NoError(t, err)
-			controller, err := NewSpecSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, syncTargetKey, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID)
+			controller, err := NewSpecSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, syncTargetKey, upstreamURL, tc.advancedSchedulingEnabled, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID,
+				newTransformationInformer(), kcpfake.NewSimpleClientset(), shared.NewSyncStateReporter(syncTargetKey, clocktesting.NewFakePassiveClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))))
 			require.NoError(t, err)
 
 			fromInformers.Start(ctx.Done())
@@ -1031,6 +1054,232 @@ func TestSyncerProcess(t *testing.T) {
 	}
 }
 
+func TestSyncerProcessClusterScoped(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetUID := types.UID("syncTargetUID")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+	resourceLocator := shared.NewResourceLocator(logicalcluster.New("root:org:ws"), syncTargetWorkspace, syncTargetUID, "us-west1", "thePV")
+	downstreamName, err := shared.PhysicalClusterResourceName(resourceLocator)
+	require.NoError(t, err)
+	resourceLocatorJSON, err := json.Marshal(resourceLocator)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		fromResources []runtime.Object
+		toResources   []runtime.Object
+
+		expectActionsOnFrom []clienttesting.Action
+		expectActionsOnTo   []clienttesting.Action
+	}{
+		"SpecSyncer sync cluster-scoped resource to downstream with a collision-safe name": {
+			fromResources: []runtime.Object{
+				persistentVolume("thePV", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+				}, nil, []string{"workload.kcp.dev/syncer-" + syncTargetKey}),
+			},
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				patchPersistentVolumeAction(
+					downstreamName,
+					types.ApplyPatchType,
+					toJson(t, toUnstructured(t, persistentVolume(downstreamName, "", map[string]string{
+						"internal.workload.kcp.dev/cluster": syncTargetKey,
+					}, map[string]string{
+						"kcp.dev/resource-locator": string(resourceLocatorJSON),
+					}, nil))),
+				),
+			},
+		},
+		"SpecSyncer add upstream syncer finalizer to cluster-scoped resource": {
+			fromResources: []runtime.Object{
+				persistentVolume("thePV", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+				}, nil, nil),
+			},
+
+			expectActionsOnFrom: []clienttesting.Action{
+				updatePersistentVolumeAction(
+					toUnstructured(t, persistentVolume("thePV", "root:org:ws", map[string]string{
+						"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+					}, nil, []string{"workload.kcp.dev/syncer-" + syncTargetKey})),
+				),
+			},
+			expectActionsOnTo: []clienttesting.Action{},
+		},
+		"SpecSyncer deletion: cluster-scoped resource deleted upstream": {
+			toResources: []runtime.Object{
+				persistentVolume(downstreamName, "", map[string]string{
+					"internal.workload.kcp.dev/cluster": syncTargetKey,
+				}, map[string]string{
+					"kcp.dev/resource-locator": string(resourceLocatorJSON),
+				}, nil),
+			},
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				deletePersistentVolumeAction(downstreamName),
+			},
+		},
+	}
+
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fromClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.fromResources...)
+			fromClusterClient := &mockedDynamicCluster{
+				client: fromClient,
+			}
+			toClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.toResources...)
+
+			fromInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClusterClient.Cluster(logicalcluster.Wildcard), time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+				o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
+			})
+			toInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+				o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
+			})
+
+			setupServersideApplyPatchReactor(toClient)
+			resourceWatcherStarted := setupWatchReactor(gvr.Resource, fromClient)
+
+			gvrs := []schema.GroupVersionResource{
+				{Group: "", Version: "v1", Resource: "namespaces"},
+				{Group: "", Version: "v1", Resource: "secrets"},
+				gvr,
+			}
+			upstreamURL, err := url.Parse("https://kcp.dev:6443")
+			require.NoError(t, err)
+			controller, err := NewSpecSyncer(gvrs, syncTargetWorkspace, "us-west1", syncTargetKey, upstreamURL, false, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID,
+				newTransformationInformer(), kcpfake.NewSimpleClientset(), shared.NewSyncStateReporter(syncTargetKey, clocktesting.NewFakePassiveClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))))
+			require.NoError(t, err)
+
+			fromInformers.Start(ctx.Done())
+			toInformers.Start(ctx.Done())
+
+			fromInformers.WaitForCacheSync(ctx.Done())
+			toInformers.WaitForCacheSync(ctx.Done())
+
+			<-resourceWatcherStarted
+
+			fromClient.ClearActions()
+			toClient.ClearActions()
+
+			err = controller.process(context.Background(), gvr, clusters.ToClusterAwareKey(logicalcluster.New("root:org:ws"), "thePV"))
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.expectActionsOnFrom, fromClient.Actions())
+			assert.EqualValues(t, tc.expectActionsOnTo, toClient.Actions())
+		})
+	}
+}
+
+func TestSyncerProcessTransformationChanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetUID := types.UID("syncTargetUID")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}
+
+	selected := persistentVolume("thePV", "root:org:ws", map[string]string{
+		"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+		"app": "transformed",
+	}, nil, []string{"workload.kcp.dev/syncer-" + syncTargetKey})
+	other := persistentVolume("otherPV", "root:org:ws", map[string]string{
+		"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+	}, nil, []string{"workload.kcp.dev/syncer-" + syncTargetKey})
+
+	fromClient := dynamicfake.NewSimpleDynamicClient(scheme, selected, other)
+	fromClusterClient := &mockedDynamicCluster{
+		client: fromClient,
+	}
+	toClient := dynamicfake.NewSimpleDynamicClient(scheme)
+	fromInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClusterClient.Cluster(logicalcluster.Wildcard), time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
+	})
+	toInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
+	})
+	kcpClient := kcpfake.NewSimpleClientset()
+	kcpInformers := kcpinformers.NewSharedInformerFactory(kcpClient, time.Hour)
+
+	setupServersideApplyPatchReactor(toClient)
+	resourceWatcherStarted := setupWatchReactor(gvr.Resource, fromClient)
+
+	gvrs := []schema.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "namespaces"},
+		{Group: "", Version: "v1", Resource: "secrets"},
+		gvr,
+	}
+	upstreamURL, err := url.Parse("https://kcp.dev:6443")
+	require.NoError(t, err)
+	controller, err := NewSpecSyncer(gvrs, syncTargetWorkspace, "us-west1", syncTargetKey, upstreamURL, false, fromClusterClient, toClient, fromInformers, toInformers, syncTargetUID,
+		kcpInformers.Workload().V1alpha1().SyncTargetTransformations(), kcpClient, shared.NewSyncStateReporter(syncTargetKey, clocktesting.NewFakePassiveClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))))
+	require.NoError(t, err)
+
+	fromInformers.Start(ctx.Done())
+	toInformers.Start(ctx.Done())
+	kcpInformers.Start(ctx.Done())
+	fromInformers.WaitForCacheSync(ctx.Done())
+	toInformers.WaitForCacheSync(ctx.Done())
+	kcpInformers.WaitForCacheSync(ctx.Done())
+	<-resourceWatcherStarted
+
+	// the first sync, without transformation
+	require.Eventually(t, func() bool { return controller.queue.Len() == 2 }, wait.ForeverTestTimeout, 10*time.Millisecond)
+	drainQueue(controller)
+	key := clusters.ToClusterAwareKey(logicalcluster.New("root:org:ws"), "thePV")
+	require.NoError(t, controller.process(ctx, gvr, key))
+	toClient.ClearActions()
+
+	transformation := &workloadv1alpha1.SyncTargetTransformation{
+		ObjectMeta: metav1.ObjectMeta{Name: "annotate"},
+		Spec: workloadv1alpha1.SyncTargetTransformationSpec{
+			SyncTargetName: "us-west1",
+			Rules: []workloadv1alpha1.TransformationRule{{
+				Name:          "annotate",
+				Resource:      workloadv1alpha1.TransformationResource{Resource: "persistentvolumes"},
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "transformed"}},
+				JSONPatch: []workloadv1alpha1.JSONPatchOperation{{
+					Op:    "add",
+					Path:  "/metadata/annotations/transformed",
+					Value: &runtime.RawExtension{Raw: []byte(`"true"`)},
+				}},
+			}},
+		},
+	}
+	_, err = kcpClient.WorkloadV1alpha1().SyncTargetTransformations().Create(ctx, transformation, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// only the selected object is synced again, now with the transformation
+	require.Eventually(t, func() bool { return controller.queue.Len() == 1 }, wait.ForeverTestTimeout, 10*time.Millisecond)
+	item, _ := controller.queue.Get()
+	controller.queue.Done(item)
+	require.Equal(t, queueKey{gvr: gvr, key: key}, item)
+	require.NoError(t, controller.process(ctx, gvr, key))
+
+	var patched bool
+	for _, action := range toClient.Actions() {
+		if patch, ok := action.(clienttesting.PatchAction); ok && patch.GetPatchType() == types.ApplyPatchType {
+			require.Contains(t, string(patch.GetPatch()), `"transformed":"true"`)
+			patched = true
+		}
+	}
+	require.True(t, patched, "the downstream object is not applied again")
+}
+
+// drainQueue removes all keys from the queue of the controller without processing them.
+func drainQueue(controller *Controller) {
+	for controller.queue.Len() > 0 {
+		item, _ := controller.queue.Get()
+		controller.queue.Done(item)
+		controller.queue.Forget(item)
+	}
+}
+
 func setupServersideApplyPatchReactor(toClient *dynamicfake.FakeDynamicClient) {
 	toClient.PrependReactor("patch", "*", func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
 		patchAction := action.(clienttesting.PatchAction)
@@ -1254,3 +1503,65 @@ func patchSecretAction(name, namespace string, patchType types.PatchType, patch
 		Patch:      patch,
 	}
 }
+
+func persistentVolume(name, clusterName string, labels, annotations map[string]string, finalizers []string) *corev1.PersistentVolume {
+	if clusterName != "" {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[logicalcluster.AnnotationKey] = clusterName
+	}
+
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+			Finalizers:  finalizers,
+		},
+	}
+}
+
+func persistentVolumeAction(verb string, subresources ...string) clienttesting.ActionImpl {
+	return clienttesting.ActionImpl{
+		Verb:        verb,
+		Resource:    schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"},
+		Subresource: strings.Join(subresources, "/"),
+	}
+}
+
+func updatePersistentVolumeAction(object runtime.Object, subresources ...string) clienttesting.UpdateActionImpl {
+	return clienttesting.UpdateActionImpl{
+		ActionImpl: persistentVolumeAction("update", subresources...),
+		Object:     object,
+	}
+}
+
+func patchPersistentVolumeAction(name string, patchType types.PatchType, patch []byte, subresources ...string) clienttesting.PatchActionImpl {
+	return clienttesting.PatchActionImpl{
+		ActionImpl: persistentVolumeAction("patch", subresources...),
+		Name:       name,
+		PatchType:  patchType,
+		Patch:      patch,
+	}
+}
+
+func deletePersistentVolumeAction(name string, subresources ...string) clienttesting.DeleteActionImpl {
+	return clienttesting.DeleteActionImpl{
+		ActionImpl:    persistentVolumeAction("delete", subresources...),
+		Name:          name,
+		DeleteOptions: metav1.DeleteOptions{},
+	}
+}
+
+func newTransformationInformer(transformations ...runtime.Object) workloadinformers.SyncTargetTransformationInformer {
+	return kcpinformers.NewSharedInformerFactory(kcpfake.NewSimpleClientset(transformations...), time.Hour).Workload().V1alpha1().SyncTargetTransformations()
+}
+
+func newTransformationLister(transformations ...*workloadv1alpha1.SyncTargetTransformation) workloadlisters.SyncTargetTransformationLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, t := range transformations {
+		_ = indexer.Add(t)
+	}
+	return workloadlisters.NewSyncTargetTransformationLister(indexer)
+}
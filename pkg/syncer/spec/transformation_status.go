@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	conditionsv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/apis/conditions/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
+)
+
+// transformationStatusReporter records the objects the rules of SyncTargetTransformations fail to apply
+// to in the status of the SyncTargetTransformations.
+type transformationStatusReporter struct {
+	syncTargetName string
+	lister         workloadlisters.SyncTargetTransformationLister
+	kcpClient      kcpclient.Interface
+
+	now func() metav1.Time
+}
+
+// listTransformations returns the SyncTargetTransformations of the SyncTarget.
+func (r *transformationStatusReporter) listTransformations() ([]*workloadv1alpha1.SyncTargetTransformation, error) {
+	all, err := r.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	var transformations []*workloadv1alpha1.SyncTargetTransformation
+	for _, t := range all {
+		if t.Spec.SyncTargetName == r.syncTargetName {
+			transformations = append(transformations, t)
+		}
+	}
+	return transformations, nil
+}
+
+// reportFailure records that the rule failed to apply to the upstream object. It only writes the status
+// if the failure is new or has changed, to not update the status on every retry.
+func (r *transformationStatusReporter) reportFailure(ctx context.Context, ruleErr *specmutators.RuleError, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) error {
+	failure := workloadv1alpha1.TransformationFailure{
+		Rule:            ruleErr.Rule,
+		Resource:        failureResource(gvr),
+		Workspace:       logicalcluster.From(upstreamObj).String(),
+		Namespace:       upstreamObj.GetNamespace(),
+		Name:            upstreamObj.GetName(),
+		Message:         ruleErr.Err.Error(),
+		LastFailureTime: r.now(),
+	}
+
+	return r.updateStatus(ctx, ruleErr.Transformation, func(status *workloadv1alpha1.SyncTargetTransformationStatus) bool {
+		failures := make([]workloadv1alpha1.TransformationFailure, 0, len(status.Failures)+1)
+		for _, f := range status.Failures {
+			if sameObject(f, failure) {
+				if f.Rule == failure.Rule && f.Message == failure.Message {
+					return false
+				}
+				continue
+			}
+			failures = append(failures, f)
+		}
+		failures = append(failures, failure)
+
+		// keep the most recent failures
+		sort.SliceStable(failures, func(i, j int) bool {
+			return failures[j].LastFailureTime.Before(&failures[i].LastFailureTime)
+		})
+		if len(failures) > workloadv1alpha1.MaxTransformationFailures {
+			failures = failures[:workloadv1alpha1.MaxTransformationFailures]
+		}
+		status.Failures = failures
+		return true
+	})
+}
+
+// reportSuccess removes recorded failures of the upstream object, after all rules applied to it.
+func (r *transformationStatusReporter) reportSuccess(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured) error {
+	object := workloadv1alpha1.TransformationFailure{
+		Resource:  failureResource(gvr),
+		Workspace: logicalcluster.From(upstreamObj).String(),
+		Namespace: upstreamObj.GetNamespace(),
+		Name:      upstreamObj.GetName(),
+	}
+
+	transformations, err := r.listTransformations()
+	if err != nil {
+		return err
+	}
+	for _, t := range transformations {
+		if !hasFailure(t.Status.Failures, object) {
+			continue
+		}
+		if err := r.updateStatus(ctx, t.Name, func(status *workloadv1alpha1.SyncTargetTransformationStatus) bool {
+			failures := make([]workloadv1alpha1.TransformationFailure, 0, len(status.Failures))
+			for _, f := range status.Failures {
+				if !sameObject(f, object) {
+					failures = append(failures, f)
+				}
+			}
+			if len(failures) == len(status.Failures) {
+				return false
+			}
+			status.Failures = failures
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateStatus updates the failures with the given function, and the RulesApplied condition accordingly.
+func (r *transformationStatusReporter) updateStatus(ctx context.Context, name string, update func(status *workloadv1alpha1.SyncTargetTransformationStatus) bool) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		transformation, err := r.kcpClient.WorkloadV1alpha1().SyncTargetTransformations().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if !update(&transformation.Status) {
+			return nil
+		}
+
+		if len(transformation.Status.Failures) == 0 {
+			conditions.MarkTrue(transformation, workloadv1alpha1.RulesApplied)
+		} else {
+			last := transformation.Status.Failures[0]
+			conditions.MarkFalse(transformation, workloadv1alpha1.RulesApplied, workloadv1alpha1.RuleFailedReason, conditionsv1alpha1.ConditionSeverityError,
+				"%d object(s) not synced, latest: rule %q failed for %s %s: %s", len(transformation.Status.Failures), last.Rule, last.Resource, failureObjectName(last), last.Message)
+		}
+
+		if _, err := r.kcpClient.WorkloadV1alpha1().SyncTargetTransformations().UpdateStatus(ctx, transformation, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.V(2).InfoS("Updated SyncTargetTransformation status", "syncTargetName", r.syncTargetName, "name", name, "failures", len(transformation.Status.Failures))
+		return nil
+	})
+}
+
+func hasFailure(failures []workloadv1alpha1.TransformationFailure, object workloadv1alpha1.TransformationFailure) bool {
+	for _, f := range failures {
+		if sameObject(f, object) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameObject(a, b workloadv1alpha1.TransformationFailure) bool {
+	return a.Resource == b.Resource && a.Workspace == b.Workspace && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// failureResource formats the resource as <resource>.<version>.<group>.
+func failureResource(gvr schema.GroupVersionResource) string {
+	return strings.TrimSuffix(gvr.Resource+"."+gvr.Version+"."+gvr.Group, ".")
+}
+
+func failureObjectName(f workloadv1alpha1.TransformationFailure) string {
+	if f.Namespace == "" {
+		return fmt.Sprintf("%s|%s", f.Workspace, f.Name)
+	}
+	return fmt.Sprintf("%s|%s/%s", f.Workspace, f.Namespace, f.Name)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kcp-dev/kcp/pkg/apis/third_party/conditions/util/conditions"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	kcpfake "github.com/kcp-dev/kcp/pkg/client/clientset/versioned/fake"
+	specmutators "github.com/kcp-dev/kcp/pkg/syncer/spec/mutators"
+)
+
+func TestTransformationStatusReporter(t *testing.T) {
+	ctx := context.Background()
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	transformation := &workloadv1alpha1.SyncTargetTransformation{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror"},
+		Spec:       workloadv1alpha1.SyncTargetTransformationSpec{SyncTargetName: "us-west1"},
+	}
+	other := &workloadv1alpha1.SyncTargetTransformation{
+		ObjectMeta: metav1.ObjectMeta{Name: "other"},
+		Spec:       workloadv1alpha1.SyncTargetTransformationSpec{SyncTargetName: "us-east1"},
+	}
+	kcpClient := kcpfake.NewSimpleClientset(transformation, other)
+
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	r := &transformationStatusReporter{
+		syncTargetName: "us-west1",
+		lister:         newTransformationLister(transformation, other),
+		kcpClient:      kcpClient,
+		now:            func() metav1.Time { return metav1.NewTime(now) },
+	}
+
+	transformations, err := r.listTransformations()
+	require.NoError(t, err)
+	require.Equal(t, []*workloadv1alpha1.SyncTargetTransformation{transformation}, transformations)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetName("web")
+	obj.SetNamespace("default")
+	obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org:ws"})
+	ruleErr := &specmutators.RuleError{Transformation: "mirror", Rule: "images", Err: errors.New("boom")}
+
+	require.NoError(t, r.reportFailure(ctx, ruleErr, gvr, obj))
+	got, err := kcpClient.WorkloadV1alpha1().SyncTargetTransformations().Get(ctx, "mirror", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []workloadv1alpha1.TransformationFailure{{
+		Rule:            "images",
+		Resource:        "deployments.v1.apps",
+		Workspace:       "root:org:ws",
+		Namespace:       "default",
+		Name:            "web",
+		Message:         "boom",
+		LastFailureTime: metav1.NewTime(now),
+	}}, got.Status.Failures)
+	require.True(t, conditions.IsFalse(got, workloadv1alpha1.RulesApplied))
+	require.Equal(t, workloadv1alpha1.RuleFailedReason, conditions.GetReason(got, workloadv1alpha1.RulesApplied))
+
+	// the same failure is not written again
+	kcpClient.ClearActions()
+	now = now.Add(time.Minute)
+	require.NoError(t, r.reportFailure(ctx, ruleErr, gvr, obj))
+	for _, action := range kcpClient.Actions() {
+		require.NotEqual(t, "update", action.GetVerb(), "unexpected status update")
+	}
+
+	// a success removes the failure
+	r.lister = newTransformationLister(got, other)
+	require.NoError(t, r.reportSuccess(ctx, gvr, obj))
+	got, err = kcpClient.WorkloadV1alpha1().SyncTargetTransformations().Get(ctx, "mirror", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Empty(t, got.Status.Failures)
+	require.True(t, conditions.IsTrue(got, workloadv1alpha1.RulesApplied))
+}
+
+func TestTransformationFailuresAreBounded(t *testing.T) {
+	ctx := context.Background()
+	gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+
+	transformation := &workloadv1alpha1.SyncTargetTransformation{
+		ObjectMeta: metav1.ObjectMeta{Name: "labels"},
+		Spec:       workloadv1alpha1.SyncTargetTransformationSpec{SyncTargetName: "us-west1"},
+	}
+	kcpClient := kcpfake.NewSimpleClientset(transformation)
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	r := &transformationStatusReporter{
+		syncTargetName: "us-west1",
+		lister:         newTransformationLister(transformation),
+		kcpClient:      kcpClient,
+		now: func() metav1.Time {
+			now = now.Add(time.Second)
+			return metav1.NewTime(now)
+		},
+	}
+
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}
+	for _, name := range names {
+		obj := &unstructured.Unstructured{}
+		obj.SetName(name)
+		obj.SetAnnotations(map[string]string{logicalcluster.AnnotationKey: "root:org:ws"})
+		require.NoError(t, r.reportFailure(ctx, &specmutators.RuleError{Transformation: "labels", Rule: "team", Err: errors.New("boom")}, gvr, obj))
+	}
+
+	got, err := kcpClient.WorkloadV1alpha1().SyncTargetTransformations().Get(ctx, "labels", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, got.Status.Failures, workloadv1alpha1.MaxTransformationFailures)
+	require.Equal(t, "l", got.Status.Failures[0].Name, "the most recent failure should come first")
+	require.Equal(t, "c", got.Status.Failures[workloadv1alpha1.MaxTransformationFailures-1].Name)
+}
@@ -19,6 +19,7 @@ package status
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
 )
 
@@ -49,15 +51,21 @@ type Controller struct {
 	upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory
 	downstreamNamespaceLister              cache.GenericLister
 
+	gvrsLock sync.RWMutex
+	gvrs     map[schema.GroupVersionResource]bool
+
 	syncTargetName            string
 	syncTargetWorkspace       logicalcluster.Name
 	syncTargetUID             types.UID
 	syncTargetKey             string
 	advancedSchedulingEnabled bool
+
+	syncStateReporter *shared.SyncStateReporter
 }
 
 func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace logicalcluster.Name, syncTargetName, syncTargetKey string, advancedSchedulingEnabled bool,
-	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID) (*Controller, error) {
+	upstreamClient dynamic.ClusterInterface, downstreamClient dynamic.Interface, upstreamInformers, downstreamInformers dynamicinformer.DynamicSharedInformerFactory, syncTargetUID types.UID,
+	syncStateReporter *shared.SyncStateReporter) (*Controller, error) {
 
 	c := &Controller{
 		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName),
@@ -68,38 +76,69 @@ func NewStatusSyncer(gvrs []schema.GroupVersionResource, syncTargetWorkspace log
 		downstreamInformers:       downstreamInformers,
 		downstreamNamespaceLister: downstreamInformers.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Lister(),
 
+		gvrs: map[schema.GroupVersionResource]bool{},
+
 		syncTargetName:            syncTargetName,
 		syncTargetWorkspace:       syncTargetWorkspace,
 		syncTargetUID:             syncTargetUID,
 		syncTargetKey:             syncTargetKey,
 		advancedSchedulingEnabled: advancedSchedulingEnabled,
+
+		syncStateReporter: syncStateReporter,
 	}
 
 	for _, gvr := range gvrs {
-		gvr := gvr // because used in closure
-
-		downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-			AddFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-			UpdateFunc: func(oldObj, newObj interface{}) {
-				oldUnstrob := oldObj.(*unstructured.Unstructured)
-				newUnstrob := newObj.(*unstructured.Unstructured)
-
-				if !deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob) {
-					c.AddToQueue(gvr, newUnstrob)
-				}
-			},
-			DeleteFunc: func(obj interface{}) {
-				c.AddToQueue(gvr, obj)
-			},
-		})
-		klog.InfoS("Set up informer", "SyncTarget Workspace", syncTargetWorkspace, "SyncTarget Name", syncTargetName, "gvr", gvr.String())
+		c.AddGVR(gvr)
 	}
 
 	return c, nil
 }
 
+// AddGVR starts syncing the status of the given resource type. It is a no-op if the resource type is already synced.
+func (c *Controller) AddGVR(gvr schema.GroupVersionResource) {
+	c.gvrsLock.Lock()
+	defer c.gvrsLock.Unlock()
+
+	if c.gvrs[gvr] {
+		return
+	}
+	c.gvrs[gvr] = true
+
+	c.downstreamInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldUnstrob := oldObj.(*unstructured.Unstructured)
+			newUnstrob := newObj.(*unstructured.Unstructured)
+
+			if !deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob) {
+				c.AddToQueue(gvr, newUnstrob)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.AddToQueue(gvr, obj)
+		},
+	})
+	klog.InfoS("Set up informer", "SyncTarget Workspace", c.syncTargetWorkspace, "SyncTarget Name", c.syncTargetName, "gvr", gvr.String())
+}
+
+// RemoveGVR stops syncing the status of the given resource type. Queued keys of this resource type are dropped.
+func (c *Controller) RemoveGVR(gvr schema.GroupVersionResource) {
+	c.gvrsLock.Lock()
+	defer c.gvrsLock.Unlock()
+
+	delete(c.gvrs, gvr)
+	klog.InfoS("Stopped syncing resource status", "SyncTarget Workspace", c.syncTargetWorkspace, "SyncTarget Name", c.syncTargetName, "gvr", gvr.String())
+}
+
+func (c *Controller) hasGVR(gvr schema.GroupVersionResource) bool {
+	c.gvrsLock.RLock()
+	defer c.gvrsLock.RUnlock()
+
+	return c.gvrs[gvr]
+}
+
 type queueKey struct {
 	gvr schema.GroupVersionResource
 	key string // meta namespace key
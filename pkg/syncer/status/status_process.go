@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/kcp-dev/logicalcluster/v2"
@@ -28,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
@@ -51,6 +53,11 @@ func deepEqualFinalizersAndStatus(oldUnstrob, newUnstrob *unstructured.Unstructu
 func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResource, key string) error {
 	klog.V(3).InfoS("Processing", "gvr", gvr, "key", key)
 
+	if !c.hasGVR(gvr) {
+		klog.V(3).InfoS("Resource is not synced anymore, ignoring key", "gvr", gvr, "key", key)
+		return nil
+	}
+
 	// from downstream
 	downstreamNamespace, clusterAwareName, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
@@ -58,6 +65,11 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 		return nil
 	}
 	downstreamClusterName, name := clusters.SplitClusterAwareKey(clusterAwareName)
+
+	if downstreamNamespace == "" {
+		return c.processClusterScoped(ctx, gvr, key, name)
+	}
+
 	// TODO(sttts): do not reference the cli plugin here
 	if strings.HasPrefix(workloadcliplugin.SyncerIDPrefix, downstreamNamespace) {
 		// skip syncer namespace
@@ -109,11 +121,73 @@ func (c *Controller) process(ctx context.Context, gvr schema.GroupVersionResourc
 	if !ok {
 		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
 	}
-	return c.updateStatusInUpstream(ctx, gvr, upstreamNamespace, upstreamWorkspace, u)
+	return c.updateStatusInUpstream(ctx, gvr, upstreamNamespace, upstreamWorkspace, getUpstreamResourceName(gvr, u.GetName()), u)
 }
 
-func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, downstreamObj *unstructured.Unstructured) error {
-	upstreamName := getUpstreamResourceName(gvr, downstreamObj.GetName())
+// processClusterScoped syncs the status of a cluster-scoped downstream resource. The upstream
+// resource is found through the ResourceLocator annotation set by the spec syncer.
+func (c *Controller) processClusterScoped(ctx context.Context, gvr schema.GroupVersionResource, key, name string) error {
+	// get the downstream object
+	obj, exists, err := c.downstreamInformers.ForResource(gvr).Informer().GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		upstreamObj, err := c.findUpstreamClusterScopedResource(gvr, name)
+		if err != nil {
+			return err
+		}
+		if upstreamObj == nil {
+			return nil
+		}
+		klog.Infof("Downstream GVR %q object %s does not exist. Removing finalizer upstream", gvr.String(), name)
+		return shared.EnsureUpstreamFinalizerRemoved(ctx, gvr, c.upstreamInformers, c.upstreamClient, "", c.syncTargetKey, logicalcluster.From(upstreamObj), upstreamObj.GetName())
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("object to synchronize is expected to be Unstructured, but is %T", obj)
+	}
+	resourceLocator, exists, err := shared.ResourceLocatorFromAnnotations(u.GetAnnotations())
+	if err != nil {
+		klog.Errorf("Resource %q: error decoding annotation: %v", key, err)
+		return nil
+	}
+	if !exists || resourceLocator == nil {
+		// Only sync resources for the configured logical cluster to ensure
+		// that syncers for multiple logical clusters can coexist.
+		return nil
+	}
+
+	// update upstream status
+	return c.updateStatusInUpstream(ctx, gvr, "", resourceLocator.Workspace, resourceLocator.Name, u)
+}
+
+// findUpstreamClusterScopedResource returns the upstream cluster-scoped resource that is synced
+// to the downstream resource with the given name, or nil if there is none.
+func (c *Controller) findUpstreamClusterScopedResource(gvr schema.GroupVersionResource, downstreamName string) (*unstructured.Unstructured, error) {
+	upstreamObjs, err := c.upstreamInformers.ForResource(gvr).Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range upstreamObjs {
+		upstreamObj, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		resourceLocator := shared.NewResourceLocator(logicalcluster.From(upstreamObj), c.syncTargetWorkspace, c.syncTargetUID, c.syncTargetName, upstreamObj.GetName())
+		name, err := shared.PhysicalClusterResourceName(resourceLocator)
+		if err != nil {
+			return nil, err
+		}
+		if name == downstreamName {
+			return upstreamObj, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.GroupVersionResource, upstreamNamespace string, upstreamLogicalCluster logicalcluster.Name, upstreamName string, downstreamObj *unstructured.Unstructured) error {
 
 	downstreamStatus, statusExists, err := unstructured.NestedFieldCopy(downstreamObj.UnstructuredContent(), "status")
 	if err != nil {
@@ -123,7 +197,7 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 		return nil
 	}
 
-	existingObj, err := c.upstreamInformers.ForResource(gvr).Lister().ByNamespace(upstreamNamespace).Get(clusters.ToClusterAwareKey(upstreamLogicalCluster, upstreamName))
+	existingObj, err := shared.GetFromLister(c.upstreamInformers.ForResource(gvr).Lister(), upstreamNamespace, clusters.ToClusterAwareKey(upstreamLogicalCluster, upstreamName))
 	if err != nil {
 		klog.Errorf("Getting resource %s/%s: %v", upstreamNamespace, upstreamName, err)
 		return err
@@ -147,19 +221,22 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 			newUpstreamAnnotations = make(map[string]string)
 		}
 		newUpstreamAnnotations[workloadv1alpha1.InternalClusterStatusAnnotationPrefix+c.syncTargetKey] = string(statusAnnotationValue)
+		if generation := downstreamObj.GetGeneration(); generation > 0 {
+			newUpstreamAnnotations[workloadv1alpha1.InternalClusterStatusGenerationAnnotationPrefix+c.syncTargetKey] = strconv.FormatInt(generation, 10)
+		}
 		newUpstream.SetAnnotations(newUpstreamAnnotations)
 
 		if reflect.DeepEqual(existing, newUpstream) {
 			klog.V(2).Infof("No need to update the status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
-			return nil
+			return c.reportStatusSync(ctx, gvr, existing, nil)
 		}
 
 		if _, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).Update(ctx, newUpstream, metav1.UpdateOptions{}); err != nil {
 			klog.Errorf("Failed updating location status annotation of resource %s|%s/%s from syncTargetName namespace %s: %v", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace(), err)
-			return err
+			return c.reportStatusSync(ctx, gvr, existing, err)
 		}
 		klog.Infof("Updated status of resource %s|%s/%s from syncTargetName namespace %s", upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
-		return nil
+		return c.reportStatusSync(ctx, gvr, existing, nil)
 	}
 
 	if err := unstructured.SetNestedField(newUpstream.UnstructuredContent(), downstreamStatus, "status"); err != nil {
@@ -172,10 +249,21 @@ func (c *Controller) updateStatusInUpstream(ctx context.Context, gvr schema.Grou
 
 	if _, err := c.upstreamClient.Cluster(upstreamLogicalCluster).Resource(gvr).Namespace(upstreamNamespace).UpdateStatus(ctx, newUpstream, metav1.UpdateOptions{}); err != nil {
 		klog.Errorf("Failed updating status of resource %q %s|%s/%s from pcluster namespace %s: %v", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace(), err)
-		return err
+		return c.reportStatusSync(ctx, gvr, existing, err)
 	}
 	klog.Infof("Updated status of resource %q %s|%s/%s from pcluster namespace %s", gvr.String(), upstreamLogicalCluster, upstreamNamespace, upstreamName, downstreamObj.GetNamespace())
-	return nil
+	return c.reportStatusSync(ctx, gvr, existing, nil)
+}
+
+// reportStatusSync records the result of the status sync in the sync state of the upstream object, and returns the sync error.
+func (c *Controller) reportStatusSync(ctx context.Context, gvr schema.GroupVersionResource, upstreamObj *unstructured.Unstructured, syncErr error) error {
+	if err := c.syncStateReporter.ReportStatusSync(ctx, c.upstreamClient, gvr, upstreamObj, syncErr); err != nil {
+		klog.Errorf("Failed to report status sync state of %s %s|%s/%s: %v", gvr.Resource, logicalcluster.From(upstreamObj), upstreamObj.GetNamespace(), upstreamObj.GetName(), err)
+		if syncErr == nil {
+			return err
+		}
+	}
+	return syncErr
 }
 
 // getUpstreamResourceName returns the name with which the resource is known upstream.
@@ -18,6 +18,7 @@ package status
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
@@ -40,8 +41,10 @@ import (
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clusters"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 )
 
 var scheme *runtime.Scheme
@@ -539,7 +542,7 @@ func TestSyncerProcess(t *testing.T) {
 				{Group: "", Version: "v1", Resource: "namespaces"},
 				tc.gvr,
 			}
-			controller, err := NewStatusSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, syncTargetKey, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, tc.syncTargetUID)
+			controller, err := NewStatusSyncer(gvrs, kcpLogicalCluster, tc.syncTargetName, syncTargetKey, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, tc.syncTargetUID, shared.NewSyncStateReporter(syncTargetKey, clocktesting.NewFakePassiveClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))))
 			require.NoError(t, err)
 
 			toInformers.ForResource(tc.gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
@@ -577,6 +580,140 @@ func TestSyncerProcess(t *testing.T) {
 	}
 }
 
+func TestSyncerProcessClusterScoped(t *testing.T) {
+	syncTargetWorkspace := logicalcluster.New("root:org:ws")
+	syncTargetUID := types.UID("syncTargetUID")
+	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(syncTargetWorkspace, "us-west1")
+	resourceLocator := shared.NewResourceLocator(logicalcluster.New("root:org:ws"), syncTargetWorkspace, syncTargetUID, "us-west1", "thePV")
+	downstreamName, err := shared.PhysicalClusterResourceName(resourceLocator)
+	require.NoError(t, err)
+	resourceLocatorJSON, err := json.Marshal(resourceLocator)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		fromResource runtime.Object
+		toResources  []runtime.Object
+
+		advancedSchedulingEnabled bool
+
+		expectActionsOnFrom []clienttesting.Action
+		expectActionsOnTo   []clienttesting.Action
+	}{
+		"StatusSyncer upsert to existing cluster-scoped resource": {
+			fromResource: changePersistentVolume(
+				persistentVolume(downstreamName, "", map[string]string{
+					"internal.workload.kcp.dev/cluster": syncTargetKey,
+				}, map[string]string{
+					"kcp.dev/resource-locator": string(resourceLocatorJSON),
+				}, nil),
+				addPersistentVolumeStatus(corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound})),
+			toResources: []runtime.Object{
+				persistentVolume("thePV", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+				}, nil, nil),
+			},
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updatePersistentVolumeAction(
+					toUnstructured(t, changePersistentVolume(
+						persistentVolume("thePV", "root:org:ws", map[string]string{
+							"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+						}, nil, nil),
+						addPersistentVolumeStatus(corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound}))),
+					"status"),
+			},
+		},
+		"StatusSyncer ignores cluster-scoped resource without resource locator": {
+			fromResource: changePersistentVolume(
+				persistentVolume(downstreamName, "", map[string]string{
+					"internal.workload.kcp.dev/cluster": syncTargetKey,
+				}, nil, nil),
+				addPersistentVolumeStatus(corev1.PersistentVolumeStatus{Phase: corev1.VolumeBound})),
+			toResources: []runtime.Object{
+				persistentVolume("thePV", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+				}, nil, nil),
+			},
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo:   []clienttesting.Action{},
+		},
+		"StatusSyncer with AdvancedScheduling, cluster-scoped resource deleted downstream": {
+			fromResource: nil,
+			toResources: []runtime.Object{
+				persistentVolume("thePV", "root:org:ws", map[string]string{
+					"state.workload.kcp.dev/" + syncTargetKey: "Sync",
+				}, map[string]string{
+					"deletion.internal.workload.kcp.dev/" + syncTargetKey: time.Now().Format(time.RFC3339),
+				}, []string{"workload.kcp.dev/syncer-" + syncTargetKey}),
+			},
+			advancedSchedulingEnabled: true,
+
+			expectActionsOnFrom: []clienttesting.Action{},
+			expectActionsOnTo: []clienttesting.Action{
+				updatePersistentVolumeAction(
+					changeUnstructured(
+						toUnstructured(t, persistentVolume("thePV", "root:org:ws", map[string]string{}, map[string]string{}, nil)),
+						setNestedField(map[string]interface{}{}, "metadata", "labels"),
+						setNestedField([]interface{}{}, "metadata", "finalizers"),
+					),
+				),
+			},
+		},
+	}
+
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var allFromResources []runtime.Object
+			if tc.fromResource != nil {
+				allFromResources = append(allFromResources, tc.fromResource)
+			}
+			fromClient := dynamicfake.NewSimpleDynamicClient(scheme, allFromResources...)
+			toClient := dynamicfake.NewSimpleDynamicClient(scheme, tc.toResources...)
+			toClusterClient := &mockedDynamicCluster{
+				client: toClient,
+			}
+
+			fromInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(fromClient, time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+				o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
+			})
+			toInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(toClusterClient.Cluster(logicalcluster.Wildcard), time.Hour, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+				o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
+			})
+
+			fromClientResourceWatcherStarted := setupWatchReactor(gvr.Resource, fromClient)
+			toClientResourceWatcherStarted := setupWatchReactor(gvr.Resource, toClient)
+
+			controller, err := NewStatusSyncer([]schema.GroupVersionResource{gvr}, syncTargetWorkspace, "us-west1", syncTargetKey, tc.advancedSchedulingEnabled, toClusterClient, fromClient, toInformers, fromInformers, syncTargetUID, shared.NewSyncStateReporter(syncTargetKey, clocktesting.NewFakePassiveClock(time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC))))
+			require.NoError(t, err)
+
+			toInformers.ForResource(gvr).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{})
+
+			fromInformers.Start(ctx.Done())
+			toInformers.Start(ctx.Done())
+
+			fromInformers.WaitForCacheSync(ctx.Done())
+			toInformers.WaitForCacheSync(ctx.Done())
+
+			<-fromClientResourceWatcherStarted
+			<-toClientResourceWatcherStarted
+
+			fromClient.ClearActions()
+			toClient.ClearActions()
+
+			err = controller.process(context.Background(), gvr, downstreamName)
+			require.NoError(t, err)
+			assert.EqualValues(t, tc.expectActionsOnFrom, fromClient.Actions())
+			assert.EqualValues(t, tc.expectActionsOnTo, toClient.Actions())
+		})
+	}
+}
+
 func setupServersideApplyPatchReactor(toClient *dynamicfake.FakeDynamicClient) {
 	toClient.PrependReactor("patch", "*", func(action clienttesting.Action) (handled bool, ret runtime.Object, err error) {
 		patchAction := action.(clienttesting.PatchAction)
@@ -691,3 +828,47 @@ func updateDeploymentAction(namespace string, object runtime.Object, subresource
 		Object:     object,
 	}
 }
+
+func persistentVolume(name, clusterName string, labels, annotations map[string]string, finalizers []string) *corev1.PersistentVolume {
+	if clusterName != "" {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[logicalcluster.AnnotationKey] = clusterName
+	}
+
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      labels,
+			Annotations: annotations,
+			Finalizers:  finalizers,
+		},
+	}
+}
+
+type persistentVolumeChange func(*corev1.PersistentVolume)
+
+func changePersistentVolume(in *corev1.PersistentVolume, changes ...persistentVolumeChange) *corev1.PersistentVolume {
+	for _, change := range changes {
+		change(in)
+	}
+	return in
+}
+
+func addPersistentVolumeStatus(status corev1.PersistentVolumeStatus) persistentVolumeChange {
+	return func(pv *corev1.PersistentVolume) {
+		pv.Status = status
+	}
+}
+
+func updatePersistentVolumeAction(object runtime.Object, subresources ...string) clienttesting.UpdateActionImpl {
+	return clienttesting.UpdateActionImpl{
+		ActionImpl: clienttesting.ActionImpl{
+			Verb:        "update",
+			Resource:    schema.GroupVersionResource{Group: "", Version: "v1", Resource: "persistentvolumes"},
+			Subresource: strings.Join(subresources, "/"),
+		},
+		Object: object,
+	}
+}
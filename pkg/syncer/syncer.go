@@ -18,6 +18,7 @@ package syncer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -25,23 +26,30 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/pkg/version"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
 	kcpclient "github.com/kcp-dev/kcp/pkg/client/clientset/versioned"
+	kcpinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
 	kcpfeatures "github.com/kcp-dev/kcp/pkg/features"
 	"github.com/kcp-dev/kcp/pkg/syncer/namespace"
+	"github.com/kcp-dev/kcp/pkg/syncer/resourcesync"
+	"github.com/kcp-dev/kcp/pkg/syncer/shared"
 	"github.com/kcp-dev/kcp/pkg/syncer/spec"
 	"github.com/kcp-dev/kcp/pkg/syncer/status"
 	"github.com/kcp-dev/kcp/third_party/keyfunctions"
@@ -57,6 +65,15 @@ const (
 
 	// TODO(marun) Ensure backoff rather than using a constant to avoid thundering herds
 	gvrQueryInterval = 1 * time.Second
+
+	// gvrDiscoveryInterval is the interval at which the upstream syncer virtual workspace
+	// discovery is checked for newly published or removed resource types, once the syncer
+	// has started.
+	gvrDiscoveryInterval = 10 * time.Second
+
+	// syncErrorsInterval is the interval at which the resources failing to sync are summarized
+	// in the SyncTarget status.
+	syncErrorsInterval = 10 * time.Second
 )
 
 // SyncerConfig defines the syncer configuration that is guaranteed to
@@ -69,6 +86,9 @@ type SyncerConfig struct {
 	SyncTargetWorkspace logicalcluster.Name
 	SyncTargetName      string
 	SyncTargetUID       string
+	// SyncedNamespaceLabels are the keys of the upstream namespace labels propagated to the
+	// downstream namespaces. A key ending with "*" matches all keys with that prefix.
+	SyncedNamespaceLabels []string
 }
 
 func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, importPollInterval time.Duration) error {
@@ -81,15 +101,10 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		return err
 	}
 
-	// TODO(david): Implement real support for several virtual workspace URLs that can change over time.
-	// TODO(david): For now we retrieve the syncerVirtualWorkpaceURL at start, since we temporarily stick to a single URL (sharding not supported).
-	// TODO(david): But the complete implementation should setup a SyncTarget informer, create spec and status syncer for every URLs found in the
-	// TODO(david): Status.SyncerVirtualWorkspaceURLs slice, and update them each time this list changes.
-	var syncerVirtualWorkspaceURL string
 	// TODO(david): we need to provide user-facing details if this polling goes on forever. Blocking here is a bad UX.
 	// TODO(david): Also, any regressions in our code will make any e2e test that starts a syncer (at least in-process)
 	// TODO(david): block until it hits the 10 minute overall test timeout.
-	klog.Infof("Attempting to retrieve the Syncer virtual workspace URL from SyncTarget %s|%s", cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+	klog.Infof("Attempting to retrieve SyncTarget %s|%s", cfg.SyncTargetWorkspace, cfg.SyncTargetName)
 	var syncTarget *workloadv1alpha1.SyncTarget
 	err = wait.PollImmediateInfinite(5*time.Second, func() (bool, error) {
 		var err error
@@ -103,15 +118,6 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		if cfg.SyncTargetUID != "" && cfg.SyncTargetUID != string(syncTarget.UID) {
 			return false, fmt.Errorf("unexpected SyncTarget UID %s, expected %s, refusing to sync", syncTarget.UID, cfg.SyncTargetUID)
 		}
-
-		if len(syncTarget.Status.VirtualWorkspaces) == 0 {
-			return false, nil
-		}
-
-		if len(syncTarget.Status.VirtualWorkspaces) > 1 {
-			klog.Errorf("SyncTarget %s|%s should not have several Syncer virtual workspace URLs: not supported for now, ignoring additional URLs", cfg.SyncTargetWorkspace, cfg.SyncTargetName)
-		}
-		syncerVirtualWorkspaceURL = syncTarget.Status.VirtualWorkspaces[0].URL
 		return true, nil
 	})
 	if err != nil {
@@ -132,6 +138,102 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	}
 	go apiImporter.Start(ctx, importPollInterval)
 
+	// The SyncTarget is watched so that a spec/status syncer pair is started for every
+	// syncer virtual workspace URL found in its status, and stopped when the URL goes away.
+	syncTargetInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(cfg.SyncTargetWorkspace), resyncPeriod,
+		kcpinformers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", cfg.SyncTargetName).String()
+		}),
+	)
+	// SyncTargetTransformations live next to the SyncTarget, and are shared by all spec syncers.
+	transformationInformerFactory := kcpinformers.NewSharedInformerFactoryWithOptions(kcpClusterClient.Cluster(cfg.SyncTargetWorkspace), resyncPeriod)
+	transformationInformer := transformationInformerFactory.Workload().V1alpha1().SyncTargetTransformations()
+	transformationInformer.Informer() // register the informer before starting the factory
+	// The sync state of the resources is shared by the syncers of all virtual workspaces.
+	syncStateReporter := shared.NewSyncStateReporter(workloadv1alpha1.ToSyncTargetKey(cfg.SyncTargetWorkspace, cfg.SyncTargetName), clock.RealClock{})
+	// Resources synced to the SyncTarget after startup are picked up from its status.
+	syncTargetLister := syncTargetInformerFactory.Workload().V1alpha1().SyncTargets().Lister()
+	getSyncTarget := func() (*workloadv1alpha1.SyncTarget, error) {
+		return syncTargetLister.Get(clusters.ToClusterAwareKey(cfg.SyncTargetWorkspace, cfg.SyncTargetName))
+	}
+	var syncers *virtualWorkspaceSyncers
+	syncers = newVirtualWorkspaceSyncers(syncTargetInformerFactory.Workload().V1alpha1().SyncTargets(), cfg.SyncTargetUID,
+		func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, virtualWorkspaceURL string) error {
+			return startVirtualWorkspaceSyncers(ctx, cfg, syncTarget, virtualWorkspaceURL, resources, getSyncTarget, numSyncerThreads, syncers.upstreamNamespaceExists, syncers.downstreamNamespaceHasUpstream, syncers.setUpstreamNamespaceChecks,
+				transformationInformer, kcpClusterClient.Cluster(cfg.SyncTargetWorkspace), syncStateReporter)
+		},
+	)
+	syncTargetInformerFactory.Start(ctx.Done())
+	transformationInformerFactory.Start(ctx.Done())
+	go syncers.Start(ctx)
+
+	// Attempt to heartbeat every interval
+	go wait.UntilWithContext(ctx, func(ctx context.Context) {
+		var heartbeatTime time.Time
+
+		// TODO(marun) Figure out a strategy for backoff to avoid a thundering herd problem with lots of syncers
+		// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
+		// poll error can be safely ignored.
+		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
+			patchBytes := []byte(fmt.Sprintf(`[{"op":"test","path":"/metadata/uid","value":%q},{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, cfg.SyncTargetUID, time.Now().Format(time.RFC3339)))
+			syncTarget, err = kcpClusterClient.Cluster(cfg.SyncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+			if err != nil {
+				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for SyncTarget %s|%s: %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, err)
+				return false, nil
+			}
+
+			heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
+			return true, nil
+		})
+		klog.V(5).Infof("Heartbeat set for SyncTarget %s|%s: %s", cfg.SyncTargetWorkspace, cfg.SyncTargetName, heartbeatTime)
+	}, heartbeatInterval)
+
+	go reportSyncErrors(ctx, cfg, kcpClusterClient.Cluster(cfg.SyncTargetWorkspace), syncStateReporter)
+
+	return nil
+}
+
+// reportSyncErrors periodically summarizes the resources failing to sync in the SyncTarget status,
+// until the context is cancelled. The status is only patched when the summary changes.
+func reportSyncErrors(ctx context.Context, cfg *SyncerConfig, kcpClient kcpclient.Interface, syncStateReporter *shared.SyncStateReporter) {
+	var lastSyncErrors []workloadv1alpha1.SyncError
+	written := false
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		syncErrors := syncStateReporter.SyncErrors()
+		// The errors of a previous syncer run are cleared on the first patch.
+		if written && equality.Semantic.DeepEqual(syncErrors, lastSyncErrors) {
+			return
+		}
+
+		patchBytes, err := json.Marshal([]map[string]interface{}{
+			{"op": "test", "path": "/metadata/uid", "value": cfg.SyncTargetUID},
+			{"op": "add", "path": "/status/syncErrors", "value": syncErrors},
+		})
+		if err != nil {
+			klog.Errorf("failed to marshal status.syncErrors patch for SyncTarget %s|%s: %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, err)
+			return
+		}
+		if _, err := kcpClient.WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status"); err != nil {
+			klog.Errorf("failed to set status.syncErrors for SyncTarget %s|%s: %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, err)
+			return
+		}
+		klog.V(4).Infof("Set %d sync errors for SyncTarget %s|%s", len(syncErrors), cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+		lastSyncErrors = syncErrors
+		written = true
+	}, syncErrorsInterval)
+}
+
+// startVirtualWorkspaceSyncers starts the spec, status and namespace syncers against the given
+// syncer virtual workspace URL. It returns once the clients are set up, the syncers themselves
+// are started in the background as soon as GVR discovery succeeds, and run until the context
+// is cancelled.
+func startVirtualWorkspaceSyncers(ctx context.Context, cfg *SyncerConfig, syncTarget *workloadv1alpha1.SyncTarget, syncerVirtualWorkspaceURL string, resources []string,
+	getSyncTarget func() (*workloadv1alpha1.SyncTarget, error), numSyncerThreads int,
+	upstreamNamespaceExists namespace.UpstreamNamespaceExistsFunc, downstreamNamespaceHasUpstream namespace.DownstreamNamespaceHasUpstreamFunc,
+	setUpstreamNamespaceChecks func(syncerVirtualWorkspaceURL string, upstreamNamespaceExists namespace.UpstreamNamespaceExistsFunc, downstreamNamespaceHasUpstream namespace.DownstreamNamespaceHasUpstreamFunc),
+	transformationInformer workloadinformers.SyncTargetTransformationInformer, kcpClient kcpclient.Interface, syncStateReporter *shared.SyncStateReporter) error {
+	kcpVersion := version.Get().GitVersion
+
 	upstreamConfig := rest.CopyConfig(cfg.UpstreamConfig)
 	upstreamConfig.Host = syncerVirtualWorkspaceURL
 	upstreamConfig.UserAgent = "kcp#spec-syncer/" + kcpVersion
@@ -146,47 +248,33 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 	if err != nil {
 		return err
 	}
+	downstreamDiscoveryClient, err := discovery.NewDiscoveryClientForConfig(downstreamConfig)
+	if err != nil {
+		return err
+	}
 	upstreamDiscoveryClusterClient, err := discovery.NewDiscoveryClientForConfig(upstreamConfig)
 	if err != nil {
 		return err
 	}
 	upstreamDiscoveryClient := upstreamDiscoveryClusterClient.WithCluster(logicalcluster.Wildcard)
 
+	upstreamURL, err := url.Parse(cfg.UpstreamConfig.Host)
+	if err != nil {
+		return err
+	}
+
 	syncTargetKey := workloadv1alpha1.ToSyncTargetKey(cfg.SyncTargetWorkspace, cfg.SyncTargetName)
-	upstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactory(upstreamDynamicClusterClient.Cluster(logicalcluster.Wildcard), resyncPeriod, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+	// Informers are created through a resourcesync.InformerFactory, so that they can be stopped
+	// when a resource type is not published by the virtual workspace anymore.
+	upstreamInformers := resourcesync.NewInformerFactory(upstreamDynamicClusterClient.Cluster(logicalcluster.Wildcard), metav1.NamespaceAll, func(o *metav1.ListOptions) {
 		o.LabelSelector = workloadv1alpha1.ClusterResourceStateLabelPrefix + syncTargetKey + "=" + string(workloadv1alpha1.ResourceStateSync)
-	})
-	downstreamInformers := dynamicinformer.NewFilteredDynamicSharedInformerFactoryWithOptions(downstreamDynamicClient, metav1.NamespaceAll, func(o *metav1.ListOptions) {
+	}, cache.WithResyncPeriod(resyncPeriod), cache.WithIndexers(cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}))
+	// Every virtual workspace URL gets its own downstream informers, since event handlers
+	// cannot be removed from a shared informer when the URL goes away.
+	downstreamInformers := resourcesync.NewInformerFactory(downstreamDynamicClient, metav1.NamespaceAll, func(o *metav1.ListOptions) {
 		o.LabelSelector = workloadv1alpha1.InternalDownstreamClusterLabel + "=" + syncTargetKey
 	}, cache.WithResyncPeriod(resyncPeriod), cache.WithKeyFunction(keyfunctions.DeletionHandlingMetaNamespaceKeyFunc))
 
-	// TODO(ncdc): we need to provide user-facing details if this polling goes on forever. Blocking here is a bad UX.
-	// TODO(ncdc): Also, any regressions in our code will make any e2e test that starts a syncer (at least in-process)
-	// TODO(ncdc): block until it hits the 10 minute overall test timeout.
-	//
-	// Block syncer start on gvr discovery completing successfully and
-	// including the resources configured for syncing. The spec and status
-	// syncers depend on the types being present to start their informers.
-	var gvrs []schema.GroupVersionResource
-	err = wait.PollImmediateInfinite(gvrQueryInterval, func() (bool, error) {
-		klog.Infof("Attempting to retrieve GVRs from upstream...")
-
-		var err error
-		// Get all types the upstream API server knows about.
-		// TODO: watch this and learn about new types, or forget about old ones.
-		gvrs, err = getAllGVRs(upstreamDiscoveryClient, resources...)
-		// TODO(marun) Should some of these errors be fatal?
-		if err != nil {
-			klog.Errorf("Failed to retrieve GVRs from kcp: %v", err)
-			return false, nil
-		}
-		return true, nil
-	})
-	if err != nil {
-		// Should never happen
-		return err
-	}
-
 	// Check whether we're in the Advanced Scheduling feature-gated mode.
 	advancedSchedulingEnabled := false
 	if syncTarget.GetAnnotations()[AdvancedSchedulingFeatureAnnotation] == "true" {
@@ -194,63 +282,88 @@ func StartSyncer(ctx context.Context, cfg *SyncerConfig, numSyncerThreads int, i
 		advancedSchedulingEnabled = true
 	}
 
-	klog.Infof("Creating spec syncer for SyncTarget %s|%s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, resources)
-	upstreamURL, err := url.Parse(cfg.UpstreamConfig.Host)
-	if err != nil {
-		return err
-	}
-	specSyncer, err := spec.NewSpecSyncer(gvrs, cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, upstreamURL, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
-	if err != nil {
-		return err
-	}
+	go func() {
+		// TODO(ncdc): we need to provide user-facing details if this polling goes on forever. Blocking here is a bad UX.
+		// TODO(ncdc): Also, any regressions in our code will make any e2e test that starts a syncer (at least in-process)
+		// TODO(ncdc): block until it hits the 10 minute overall test timeout.
+		//
+		// Block syncer start on gvr discovery completing successfully and
+		// including the resources configured for syncing. The spec and status
+		// syncers depend on the types being present to start their informers.
+		var gvrs []schema.GroupVersionResource
+		err := wait.PollImmediateInfiniteWithContext(ctx, gvrQueryInterval, func(ctx context.Context) (bool, error) {
+			klog.Infof("Attempting to retrieve GVRs from upstream %s...", syncerVirtualWorkspaceURL)
+
+			var err error
+			// Get all types the upstream API server knows about. Types published or removed
+			// later on are picked up by the resource sync controller.
+			gvrs, err = getAllGVRs(upstreamDiscoveryClient, resources...)
+			// TODO(marun) Should some of these errors be fatal?
+			if err != nil {
+				klog.Errorf("Failed to retrieve GVRs from kcp: %v", err)
+				return false, nil
+			}
+			return true, nil
+		})
+		if err != nil {
+			// The context was cancelled: the virtual workspace URL is not used anymore.
+			return
+		}
 
-	klog.Infof("Creating status syncer for SyncTarget %s|%s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, resources)
-	statusSyncer, err := status.NewStatusSyncer(gvrs, cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, advancedSchedulingEnabled,
-		upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID())
-	if err != nil {
-		return err
-	}
+		klog.Infof("Creating spec syncer for SyncTarget %s|%s, virtual workspace %s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncerVirtualWorkspaceURL, resources)
+		specSyncer, err := spec.NewSpecSyncer(gvrs, cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, upstreamURL, advancedSchedulingEnabled,
+			upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID(),
+			transformationInformer, kcpClient, syncStateReporter)
+		if err != nil {
+			klog.Errorf("Failed to create spec syncer for virtual workspace %s: %v", syncerVirtualWorkspaceURL, err)
+			return
+		}
 
-	namespaceSyncer, err := namespace.NewNamespaceController(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, syncTarget.GetUID(), upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers)
-	if err != nil {
-		return err
-	}
+		klog.Infof("Creating status syncer for SyncTarget %s|%s, virtual workspace %s, resources %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncerVirtualWorkspaceURL, resources)
+		statusSyncer, err := status.NewStatusSyncer(gvrs, cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, advancedSchedulingEnabled,
+			upstreamDynamicClusterClient, downstreamDynamicClient, upstreamInformers, downstreamInformers, syncTarget.GetUID(), syncStateReporter)
+		if err != nil {
+			klog.Errorf("Failed to create status syncer for virtual workspace %s: %v", syncerVirtualWorkspaceURL, err)
+			return
+		}
 
-	upstreamInformers.Start(ctx.Done())
-	downstreamInformers.Start(ctx.Done())
+		namespaceSyncer, err := namespace.NewNamespaceController(cfg.SyncTargetWorkspace, cfg.SyncTargetName, syncTargetKey, syncTarget.GetUID(), upstreamDynamicClusterClient, downstreamDynamicClient,
+			downstreamDiscoveryClient, upstreamInformers, downstreamInformers, upstreamNamespaceExists, downstreamNamespaceHasUpstream, cfg.SyncedNamespaceLabels)
+		if err != nil {
+			klog.Errorf("Failed to create namespace syncer for virtual workspace %s: %v", syncerVirtualWorkspaceURL, err)
+			return
+		}
 
-	upstreamInformers.WaitForCacheSync(ctx.Done())
-	downstreamInformers.WaitForCacheSync(ctx.Done())
+		upstreamInformers.Start(ctx.Done())
+		downstreamInformers.Start(ctx.Done())
 
-	go specSyncer.Start(ctx, numSyncerThreads)
-	go statusSyncer.Start(ctx, numSyncerThreads)
-	go namespaceSyncer.Start(ctx, numSyncerThreads)
+		upstreamInformers.WaitForCacheSync(ctx.Done())
+		downstreamInformers.WaitForCacheSync(ctx.Done())
+		// Objects must not be synced before their transformations are known.
+		cache.WaitForCacheSync(ctx.Done(), transformationInformer.Informer().HasSynced)
+		if ctx.Err() != nil {
+			return
+		}
 
-	if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
-		go startSyncerTunnel(ctx, upstreamConfig, downstreamConfig, cfg.SyncTargetWorkspace, cfg.SyncTargetName)
-	}
+		setUpstreamNamespaceChecks(syncerVirtualWorkspaceURL, namespace.InformerUpstreamNamespaceExists(upstreamInformers), namespace.InformerDownstreamNamespaceHasUpstream(upstreamInformers))
 
-	// Attempt to heartbeat every interval
-	go wait.UntilWithContext(ctx, func(ctx context.Context) {
-		var heartbeatTime time.Time
+		go specSyncer.Start(ctx, numSyncerThreads)
+		go statusSyncer.Start(ctx, numSyncerThreads)
+		go namespaceSyncer.Start(ctx, numSyncerThreads)
 
-		// TODO(marun) Figure out a strategy for backoff to avoid a thundering herd problem with lots of syncers
-		// Attempt to heartbeat every second until successful. Errors are logged instead of being returned so the
-		// poll error can be safely ignored.
-		_ = wait.PollImmediateInfiniteWithContext(ctx, 1*time.Second, func(ctx context.Context) (bool, error) {
-			patchBytes := []byte(fmt.Sprintf(`[{"op":"test","path":"/metadata/uid","value":%q},{"op":"replace","path":"/status/lastSyncerHeartbeatTime","value":%q}]`, cfg.SyncTargetUID, time.Now().Format(time.RFC3339)))
-			syncTarget, err = kcpClusterClient.Cluster(cfg.SyncTargetWorkspace).WorkloadV1alpha1().SyncTargets().Patch(ctx, cfg.SyncTargetName, types.JSONPatchType, patchBytes, metav1.PatchOptions{}, "status")
+		resourceSyncer := resourcesync.NewController(gvrs, func() ([]schema.GroupVersionResource, error) {
+			syncTarget, err := getSyncTarget()
 			if err != nil {
-				klog.Errorf("failed to set status.lastSyncerHeartbeatTime for SyncTarget %s|%s: %v", cfg.SyncTargetWorkspace, cfg.SyncTargetName, err)
-				return false, nil
+				return nil, err
 			}
+			return getPublishedGVRs(upstreamDiscoveryClient, resourcesToSync(resources, syncTarget)...)
+		}, upstreamInformers, downstreamInformers, specSyncer, statusSyncer)
+		go resourceSyncer.Start(ctx, gvrDiscoveryInterval)
 
-			heartbeatTime = syncTarget.Status.LastSyncerHeartbeatTime.Time
-			return true, nil
-		})
-		klog.V(5).Infof("Heartbeat set for SyncTarget %s|%s: %s", cfg.SyncTargetWorkspace, cfg.SyncTargetName, heartbeatTime)
-	}, heartbeatInterval)
+		if kcpfeatures.DefaultFeatureGate.Enabled(kcpfeatures.SyncerTunnel) {
+			go startSyncerTunnel(ctx, upstreamConfig, downstreamConfig, cfg.SyncTargetWorkspace, cfg.SyncTargetName)
+		}
+	}()
 
 	return nil
 }
@@ -264,9 +377,68 @@ func contains(ss []string, s string) bool {
 	return false
 }
 
+// getAllGVRs returns the GVRs of the given resources published by the upstream syncer virtual workspace,
+// along with configmaps and secrets. It fails if some of the requested resources are not published yet.
 func getAllGVRs(discoveryClient discovery.DiscoveryInterface, resourcesToSync ...string) ([]schema.GroupVersionResource, error) {
 	toSyncSet := sets.NewString(resourcesToSync...)
 	willBeSyncedSet := sets.NewString()
+
+	accept := acceptResourcesToSync(toSyncSet)
+	gvrs, err := discoverGVRs(discoveryClient, func(groupResource schema.GroupResource) bool {
+		if !accept(groupResource) {
+			return false
+		}
+		if toSyncSet.Has(groupResource.String()) {
+			willBeSyncedSet.Insert(groupResource.String())
+		} else {
+			willBeSyncedSet.Insert(groupResource.Resource)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notFoundResourceTypes := toSyncSet.Difference(willBeSyncedSet)
+	if notFoundResourceTypes.Len() != 0 {
+		// Some of the API resources expected to be there are still not published by KCP.
+		// We should just retry without a limit on the number of retries in such a case,
+		// until the corresponding resources are added inside KCP as CRDs and published as API resources.
+		return nil, fmt.Errorf("the following resource types were requested to be synced, but were not found in the KCP logical cluster: %v", notFoundResourceTypes.List())
+	}
+	return gvrs, nil
+}
+
+// getPublishedGVRs returns the GVRs of the given resources currently published by the upstream syncer
+// virtual workspace. Contrary to getAllGVRs, it does not fail if some of them are not published. At
+// runtime, the resources include those synced to the SyncTarget after startup, see resourcesToSync.
+func getPublishedGVRs(discoveryClient discovery.DiscoveryInterface, resourcesToSync ...string) ([]schema.GroupVersionResource, error) {
+	return discoverGVRs(discoveryClient, acceptResourcesToSync(sets.NewString(resourcesToSync...)))
+}
+
+// resourcesToSync returns the given resources along with the resources synced to the SyncTarget according
+// to its status, i.e. those accepted as compatible and hence published by the syncer virtual workspace.
+func resourcesToSync(resources []string, syncTarget *workloadv1alpha1.SyncTarget) []string {
+	toSync := sets.NewString(resources...)
+	for _, syncedResource := range syncTarget.Status.SyncedResources {
+		if syncedResource.State != workloadv1alpha1.ResourceSchemaAcceptedState {
+			continue
+		}
+		toSync.Insert(schema.GroupResource{Group: syncedResource.Group, Resource: syncedResource.Resource}.String())
+	}
+	return toSync.List()
+}
+
+// acceptResourcesToSync returns the filter shared by getAllGVRs and getPublishedGVRs, so that the set of
+// synced resources does not change between startup and runtime discovery. A resource is accepted if either
+// its qualified or its plain resource name is in the given set.
+func acceptResourcesToSync(toSyncSet sets.String) func(groupResource schema.GroupResource) bool {
+	return func(groupResource schema.GroupResource) bool {
+		return toSyncSet.Has(groupResource.String()) || toSyncSet.Has(groupResource.Resource)
+	}
+}
+
+func discoverGVRs(discoveryClient discovery.DiscoveryInterface, accept func(groupResource schema.GroupResource) bool) ([]schema.GroupVersionResource, error) {
 	rs, err := discoveryClient.ServerPreferredResources()
 	if err != nil {
 		if strings.Contains(err.Error(), "unable to retrieve the complete list of server APIs") {
@@ -295,45 +467,25 @@ func getAllGVRs(discoveryClient discovery.DiscoveryInterface, resourcesToSync ..
 		}
 		vr := groupVersion.Version + "." + groupVersion.Group
 		for _, ai := range r.APIResources {
-			var willBeSynced string
-			groupResource := schema.GroupResource{
-				Group:    groupVersion.Group,
-				Resource: ai.Name,
-			}
-
-			if toSyncSet.Has(groupResource.String()) {
-				willBeSynced = groupResource.String()
-			} else if toSyncSet.Has(ai.Name) {
-				willBeSynced = ai.Name
-			} else {
-				// We're not interested in this resource type
-				continue
-			}
 			if strings.Contains(ai.Name, "/") {
 				// foo/status, pods/exec, namespace/finalize, etc.
 				continue
 			}
-			if !ai.Namespaced {
-				// Ignore cluster-scoped things.
+			if groupVersion.Group == "" && ai.Name == "namespaces" {
+				// Namespaces are synced by the namespace controller.
 				continue
 			}
 			if !contains(ai.Verbs, "watch") {
 				klog.Infof("resource %s %s is not watchable: %v", vr, ai.Name, ai.Verbs)
 				continue
 			}
+			if !accept(schema.GroupResource{Group: groupVersion.Group, Resource: ai.Name}) {
+				continue
+			}
 			gvrstrs.Insert(fmt.Sprintf("%s.%s", ai.Name, vr))
-			willBeSyncedSet.Insert(willBeSynced)
 		}
 	}
 
-	notFoundResourceTypes := toSyncSet.Difference(willBeSyncedSet)
-	if notFoundResourceTypes.Len() != 0 {
-		// Some of the API resources expected to be there are still not published by KCP.
-		// We should just retry without a limit on the number of retries in such a case,
-		// until the corresponding resources are added inside KCP as CRDs and published as API resources.
-		return nil, fmt.Errorf("the following resource types were requested to be synced, but were not found in the KCP logical cluster: %v", notFoundResourceTypes.List())
-	}
-
 	gvrs := make([]schema.GroupVersionResource, 0, gvrstrs.Len())
 	for _, gvrstr := range gvrstrs.List() {
 		gvr, _ := schema.ParseResourceArg(gvrstr)
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clientgotesting "k8s.io/client-go/testing"
+
+	apisv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/apis/v1alpha1"
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+)
+
+type fakePreferredDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d fakePreferredDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.Resources, nil
+}
+
+func TestDiscoveredGVRs(t *testing.T) {
+	watchable := []string{"get", "list", "watch"}
+	discoveryClient := fakePreferredDiscovery{&fakediscovery.FakeDiscovery{Fake: &clientgotesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "namespaces", Verbs: watchable},
+					{Name: "services", Namespaced: true, Verbs: watchable},
+					{Name: "services/status", Namespaced: true, Verbs: watchable},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Namespaced: true, Verbs: watchable},
+					{Name: "statefulsets", Namespaced: true, Verbs: watchable},
+				},
+			},
+		},
+	}}}
+
+	expected := []schema.GroupVersionResource{
+		{Version: "v1", Resource: "configmaps"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "secrets"},
+		{Version: "v1", Resource: "services"},
+	}
+
+	// Namespaces are synced by the namespace controller, and subresources are not synced.
+	gvrs, err := getAllGVRs(discoveryClient, "deployments.apps", "services")
+	require.NoError(t, err)
+	require.Equal(t, expected, gvrs)
+
+	gvrs, err = getPublishedGVRs(discoveryClient, "deployments.apps", "services")
+	require.NoError(t, err)
+	require.Equal(t, expected, gvrs, "runtime discovery should filter resources like startup discovery")
+
+	_, err = getAllGVRs(discoveryClient, "deployments.apps", "ingresses.networking.k8s.io")
+	require.Error(t, err)
+
+	gvrs, err = getPublishedGVRs(discoveryClient, "deployments.apps", "ingresses.networking.k8s.io")
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{
+		{Version: "v1", Resource: "configmaps"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "secrets"},
+	}, gvrs)
+}
+
+func TestDiscoveredGVRsOfResourcesSyncedAfterStartup(t *testing.T) {
+	watchable := []string{"get", "list", "watch"}
+	discoveryClient := fakePreferredDiscovery{&fakediscovery.FakeDiscovery{Fake: &clientgotesting.Fake{
+		Resources: []*metav1.APIResourceList{
+			{
+				GroupVersion: "v1",
+				APIResources: []metav1.APIResource{
+					{Name: "services", Namespaced: true, Verbs: watchable},
+				},
+			},
+			{
+				GroupVersion: "apps/v1",
+				APIResources: []metav1.APIResource{
+					{Name: "deployments", Namespaced: true, Verbs: watchable},
+				},
+			},
+		},
+	}}}
+
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		Status: workloadv1alpha1.SyncTargetStatus{
+			SyncedResources: []workloadv1alpha1.ResourceToSync{
+				{GroupResource: apisv1alpha1.GroupResource{Group: "apps", Resource: "deployments"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaAcceptedState},
+			},
+		},
+	}
+	gvrs, err := getPublishedGVRs(discoveryClient, resourcesToSync([]string{"deployments.apps"}, syncTarget)...)
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{
+		{Version: "v1", Resource: "configmaps"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "secrets"},
+	}, gvrs)
+
+	// services are synced to the SyncTarget after startup, but only discovered once accepted
+	syncTarget.Status.SyncedResources = append(syncTarget.Status.SyncedResources, workloadv1alpha1.ResourceToSync{
+		GroupResource: apisv1alpha1.GroupResource{Resource: "services"}, Versions: []string{"v1"}, State: workloadv1alpha1.ResourceSchemaPendingState,
+	})
+	gvrs, err = getPublishedGVRs(discoveryClient, resourcesToSync([]string{"deployments.apps"}, syncTarget)...)
+	require.NoError(t, err)
+	require.NotContains(t, gvrs, schema.GroupVersionResource{Version: "v1", Resource: "services"})
+
+	syncTarget.Status.SyncedResources[1].State = workloadv1alpha1.ResourceSchemaAcceptedState
+	gvrs, err = getPublishedGVRs(discoveryClient, resourcesToSync([]string{"deployments.apps"}, syncTarget)...)
+	require.NoError(t, err)
+	require.Equal(t, []schema.GroupVersionResource{
+		{Version: "v1", Resource: "configmaps"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Version: "v1", Resource: "secrets"},
+		{Version: "v1", Resource: "services"},
+	}, gvrs)
+}
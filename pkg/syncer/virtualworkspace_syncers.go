@@ -0,0 +1,280 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	workloadinformers "github.com/kcp-dev/kcp/pkg/client/informers/externalversions/workload/v1alpha1"
+	workloadlisters "github.com/kcp-dev/kcp/pkg/client/listers/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/namespace"
+)
+
+const virtualWorkspaceSyncersControllerName = "kcp-workload-syncer-virtualworkspaces"
+
+// startSyncersFunc starts the spec, status and namespace syncers against a single
+// syncer virtual workspace URL. It must not block: the syncers are expected to run
+// until the given context is cancelled.
+type startSyncersFunc func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, virtualWorkspaceURL string) error
+
+// virtualWorkspaceSyncers watches the SyncTarget of the syncer and runs a spec/status syncer
+// pair for every URL found in Status.VirtualWorkspaces. Syncers are started when a URL is
+// added to the SyncTarget status and stopped when the URL is removed.
+type virtualWorkspaceSyncers struct {
+	queue workqueue.RateLimitingInterface
+
+	syncTargetLister workloadlisters.SyncTargetLister
+	syncTargetUID    string
+	startSyncers     startSyncersFunc
+
+	lock                    sync.Mutex
+	cancelFuncs             map[string]context.CancelFunc
+	upstreamNamespaceChecks map[string]upstreamNamespaceChecks
+}
+
+// upstreamNamespaceChecks look up the upstream namespaces behind a single syncer virtual workspace.
+type upstreamNamespaceChecks struct {
+	upstreamNamespaceExists        namespace.UpstreamNamespaceExistsFunc
+	downstreamNamespaceHasUpstream namespace.DownstreamNamespaceHasUpstreamFunc
+}
+
+func newVirtualWorkspaceSyncers(syncTargetInformer workloadinformers.SyncTargetInformer, syncTargetUID string, startSyncers startSyncersFunc) *virtualWorkspaceSyncers {
+	c := &virtualWorkspaceSyncers{
+		queue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), virtualWorkspaceSyncersControllerName),
+
+		syncTargetLister: syncTargetInformer.Lister(),
+		syncTargetUID:    syncTargetUID,
+		startSyncers:     startSyncers,
+
+		cancelFuncs:             map[string]context.CancelFunc{},
+		upstreamNamespaceChecks: map[string]upstreamNamespaceChecks{},
+	}
+
+	syncTargetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+	})
+
+	return c
+}
+
+func (c *virtualWorkspaceSyncers) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+
+	klog.V(2).Infof("%s queueing SyncTarget %s", virtualWorkspaceSyncersControllerName, key)
+	c.queue.Add(key)
+}
+
+// Start runs the controller until the context is cancelled. All running syncers
+// are stopped when it returns.
+func (c *virtualWorkspaceSyncers) Start(ctx context.Context) {
+	defer utilruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	klog.InfoS("Starting syncer workers", "controller", virtualWorkspaceSyncersControllerName)
+	defer klog.InfoS("Stopping syncer workers", "controller", virtualWorkspaceSyncersControllerName)
+
+	// A single worker is enough since there is only one SyncTarget to watch, and it avoids
+	// starting concurrent syncers for the same URL.
+	go wait.UntilWithContext(ctx, c.startWorker, time.Second)
+
+	<-ctx.Done()
+
+	c.stopAll()
+}
+
+func (c *virtualWorkspaceSyncers) startWorker(ctx context.Context) {
+	for c.processNextWorkItem(ctx) {
+	}
+}
+
+func (c *virtualWorkspaceSyncers) processNextWorkItem(ctx context.Context) bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	if err := c.process(ctx, key); err != nil {
+		utilruntime.HandleError(fmt.Errorf("%s failed to sync %q, err: %w", virtualWorkspaceSyncersControllerName, key, err))
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+func (c *virtualWorkspaceSyncers) process(ctx context.Context, key string) error {
+	syncTarget, err := c.syncTargetLister.Get(key)
+	if apierrors.IsNotFound(err) {
+		klog.Infof("SyncTarget %s was deleted, stopping all syncers", key)
+		c.stopAll()
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return c.reconcile(ctx, syncTarget)
+}
+
+// reconcile starts syncers for the virtual workspace URLs that appeared in the SyncTarget status,
+// and stops the ones whose URL has disappeared.
+func (c *virtualWorkspaceSyncers) reconcile(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget) error {
+	if c.syncTargetUID != "" && c.syncTargetUID != string(syncTarget.UID) {
+		klog.Errorf("unexpected SyncTarget UID %s, expected %s, refusing to sync", syncTarget.UID, c.syncTargetUID)
+		c.stopAll()
+		return nil
+	}
+
+	desiredURLs := sets.NewString()
+	for _, virtualWorkspace := range syncTarget.Status.VirtualWorkspaces {
+		desiredURLs.Insert(virtualWorkspace.URL)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url, cancel := range c.cancelFuncs {
+		if desiredURLs.Has(url) {
+			continue
+		}
+		klog.Infof("Stopping syncers for virtual workspace URL %s of SyncTarget %s", url, syncTarget.Name)
+		cancel()
+		delete(c.cancelFuncs, url)
+		delete(c.upstreamNamespaceChecks, url)
+	}
+
+	var errs []error
+	for _, url := range desiredURLs.List() {
+		if _, running := c.cancelFuncs[url]; running {
+			continue
+		}
+		klog.Infof("Starting syncers for virtual workspace URL %s of SyncTarget %s", url, syncTarget.Name)
+		syncersCtx, cancel := context.WithCancel(ctx)
+		if err := c.startSyncers(syncersCtx, syncTarget, url); err != nil {
+			cancel()
+			errs = append(errs, fmt.Errorf("failed to start syncers for virtual workspace URL %s: %w", url, err))
+			continue
+		}
+		c.cancelFuncs[url] = cancel
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *virtualWorkspaceSyncers) stopAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url, cancel := range c.cancelFuncs {
+		cancel()
+		delete(c.cancelFuncs, url)
+		delete(c.upstreamNamespaceChecks, url)
+	}
+}
+
+// runningURLs returns the virtual workspace URLs for which syncers are currently running.
+func (c *virtualWorkspaceSyncers) runningURLs() sets.String {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return sets.StringKeySet(c.cancelFuncs)
+}
+
+// setUpstreamNamespaceChecks registers the functions looking up upstream namespaces for the given
+// virtual workspace URL, once its upstream informers are synced. They are unregistered when the
+// syncers of the URL are stopped.
+func (c *virtualWorkspaceSyncers) setUpstreamNamespaceChecks(virtualWorkspaceURL string, upstreamNamespaceExists namespace.UpstreamNamespaceExistsFunc, downstreamNamespaceHasUpstream namespace.DownstreamNamespaceHasUpstreamFunc) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, running := c.cancelFuncs[virtualWorkspaceURL]; !running {
+		return
+	}
+	c.upstreamNamespaceChecks[virtualWorkspaceURL] = upstreamNamespaceChecks{
+		upstreamNamespaceExists:        upstreamNamespaceExists,
+		downstreamNamespaceHasUpstream: downstreamNamespaceHasUpstream,
+	}
+}
+
+// upstreamNamespaceExists checks whether an upstream namespace exists behind any of the
+// syncer virtual workspaces. Downstream namespaces are shared by all the virtual workspaces,
+// so a namespace must not be considered as gone before every virtual workspace has been
+// checked.
+func (c *virtualWorkspaceSyncers) upstreamNamespaceExists(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
+	return c.anyUpstreamNamespaceCheck(func(checks upstreamNamespaceChecks) (bool, error) {
+		return checks.upstreamNamespaceExists(clusterName, upstreamNamespaceName)
+	})
+}
+
+// downstreamNamespaceHasUpstream checks whether an upstream namespace behind any of the syncer
+// virtual workspaces maps to the given downstream namespace name.
+func (c *virtualWorkspaceSyncers) downstreamNamespaceHasUpstream(downstreamNamespaceName string) (bool, error) {
+	return c.anyUpstreamNamespaceCheck(func(checks upstreamNamespaceChecks) (bool, error) {
+		return checks.downstreamNamespaceHasUpstream(downstreamNamespaceName)
+	})
+}
+
+// anyUpstreamNamespaceCheck returns whether the check succeeds for any of the virtual workspaces. It
+// fails as long as the upstream informers of a running virtual workspace are not synced.
+func (c *virtualWorkspaceSyncers) anyUpstreamNamespaceCheck(check func(checks upstreamNamespaceChecks) (bool, error)) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for url := range c.cancelFuncs {
+		if _, synced := c.upstreamNamespaceChecks[url]; !synced {
+			return false, fmt.Errorf("upstream informers for virtual workspace URL %s are not synced yet", url)
+		}
+	}
+
+	for _, checks := range c.upstreamNamespaceChecks {
+		found, err := check(checks)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2022 The KCP Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kcp-dev/logicalcluster/v2"
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	workloadv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/workload/v1alpha1"
+	"github.com/kcp-dev/kcp/pkg/syncer/namespace"
+)
+
+func syncTargetWithURLs(uid string, urls ...string) *workloadv1alpha1.SyncTarget {
+	syncTarget := &workloadv1alpha1.SyncTarget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "us-west1",
+			UID:  "syncTargetUID",
+		},
+	}
+	if uid != "" {
+		syncTarget.UID = types.UID(uid)
+	}
+	for _, url := range urls {
+		syncTarget.Status.VirtualWorkspaces = append(syncTarget.Status.VirtualWorkspaces, workloadv1alpha1.VirtualWorkspace{URL: url})
+	}
+	return syncTarget
+}
+
+func TestVirtualWorkspaceSyncersReconcile(t *testing.T) {
+	tests := map[string]struct {
+		runningURLs []string
+		syncTarget  *workloadv1alpha1.SyncTarget
+		startErrors map[string]error
+
+		wantStarted []string
+		wantStopped []string
+		wantRunning []string
+		wantError   bool
+	}{
+		"no virtual workspace URL yet": {
+			syncTarget: syncTargetWithURLs(""),
+		},
+		"start syncers for every URL": {
+			syncTarget:  syncTargetWithURLs("", "https://shard1/services/syncer", "https://shard2/services/syncer"),
+			wantStarted: []string{"https://shard1/services/syncer", "https://shard2/services/syncer"},
+			wantRunning: []string{"https://shard1/services/syncer", "https://shard2/services/syncer"},
+		},
+		"add a URL": {
+			runningURLs: []string{"https://shard1/services/syncer"},
+			syncTarget:  syncTargetWithURLs("", "https://shard1/services/syncer", "https://shard2/services/syncer"),
+			wantStarted: []string{"https://shard2/services/syncer"},
+			wantRunning: []string{"https://shard1/services/syncer", "https://shard2/services/syncer"},
+		},
+		"remove a URL": {
+			runningURLs: []string{"https://shard1/services/syncer", "https://shard2/services/syncer"},
+			syncTarget:  syncTargetWithURLs("", "https://shard2/services/syncer"),
+			wantStopped: []string{"https://shard1/services/syncer"},
+			wantRunning: []string{"https://shard2/services/syncer"},
+		},
+		"failure to start syncers is retried": {
+			syncTarget:  syncTargetWithURLs("", "https://shard1/services/syncer", "https://shard2/services/syncer"),
+			startErrors: map[string]error{"https://shard1/services/syncer": errors.New("boom")},
+			wantStarted: []string{"https://shard2/services/syncer"},
+			wantRunning: []string{"https://shard2/services/syncer"},
+			wantError:   true,
+		},
+		"unexpected SyncTarget UID stops all syncers": {
+			runningURLs: []string{"https://shard1/services/syncer"},
+			syncTarget:  syncTargetWithURLs("otherUID", "https://shard1/services/syncer"),
+			wantStopped: []string{"https://shard1/services/syncer"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			started := sets.NewString()
+			stopped := sets.NewString()
+			c := &virtualWorkspaceSyncers{
+				syncTargetUID:           "syncTargetUID",
+				cancelFuncs:             map[string]context.CancelFunc{},
+				upstreamNamespaceChecks: map[string]upstreamNamespaceChecks{},
+				startSyncers: func(ctx context.Context, syncTarget *workloadv1alpha1.SyncTarget, virtualWorkspaceURL string) error {
+					if err := tc.startErrors[virtualWorkspaceURL]; err != nil {
+						return err
+					}
+					started.Insert(virtualWorkspaceURL)
+					return nil
+				},
+			}
+			for _, url := range tc.runningURLs {
+				url := url
+				c.cancelFuncs[url] = func() { stopped.Insert(url) }
+			}
+
+			err := c.reconcile(ctx, tc.syncTarget)
+			if tc.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, sets.NewString(tc.wantStarted...), started, "unexpected started syncers")
+			require.Equal(t, sets.NewString(tc.wantStopped...), stopped, "unexpected stopped syncers")
+			require.Equal(t, sets.NewString(tc.wantRunning...), c.runningURLs(), "unexpected running syncers")
+		})
+	}
+}
+
+func TestVirtualWorkspaceSyncersUpstreamNamespaceChecks(t *testing.T) {
+	exists := func(found bool) namespace.UpstreamNamespaceExistsFunc {
+		return func(clusterName logicalcluster.Name, upstreamNamespaceName string) (bool, error) {
+			return found, nil
+		}
+	}
+	hasUpstream := func(found bool) namespace.DownstreamNamespaceHasUpstreamFunc {
+		return func(downstreamNamespaceName string) (bool, error) {
+			return found, nil
+		}
+	}
+
+	c := &virtualWorkspaceSyncers{
+		cancelFuncs: map[string]context.CancelFunc{
+			"https://shard1/services/syncer": func() {},
+			"https://shard2/services/syncer": func() {},
+		},
+		upstreamNamespaceChecks: map[string]upstreamNamespaceChecks{},
+	}
+
+	c.setUpstreamNamespaceChecks("https://shard1/services/syncer", exists(false), hasUpstream(false))
+	_, err := c.upstreamNamespaceExists(logicalcluster.New("root:org:ws"), "test")
+	require.Error(t, err, "expected an error as long as all virtual workspaces are not synced")
+	_, err = c.downstreamNamespaceHasUpstream("kcp-01c0zzvlqsi7n")
+	require.Error(t, err, "expected an error as long as all virtual workspaces are not synced")
+
+	c.setUpstreamNamespaceChecks("https://shard2/services/syncer", exists(true), hasUpstream(true))
+	found, err := c.upstreamNamespaceExists(logicalcluster.New("root:org:ws"), "test")
+	require.NoError(t, err)
+	require.True(t, found, "expected namespace to be found behind the second virtual workspace")
+	found, err = c.downstreamNamespaceHasUpstream("kcp-01c0zzvlqsi7n")
+	require.NoError(t, err)
+	require.True(t, found, "expected downstream namespace owner to be found behind the second virtual workspace")
+
+	c.setUpstreamNamespaceChecks("https://shard3/services/syncer", exists(true), hasUpstream(true))
+	require.NotContains(t, c.upstreamNamespaceChecks, "https://shard3/services/syncer", "expected URL without running syncers to be ignored")
+}
@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -164,6 +165,10 @@ type AuthorizationCache struct {
 	allKnownWorkspaces        sets.String
 	workspaceLister           tenancylisters.ClusterWorkspaceLister
 	lastSyncResourceVersioner LastSyncResourceVersioner
+	// synchronizedResourceVersions are the last synced resource versions of the workspace and RBAC
+	// informers observed at the beginning of the last synchronization. They are opaque, i.e. only
+	// compared for equality with the resource versions of lists of the same resource.
+	synchronizedResourceVersions informerResourceVersions
 
 	clusterRoleLister             SyncedClusterRoleLister
 	clusterRoleBindingLister      SyncedClusterRoleBindingLister
@@ -335,7 +340,7 @@ func (ac *AuthorizationCache) invalidateCache() bool {
 		return invalidateCache
 	}
 
-	temporaryVersions.Delete(temporaryVersions.List()...)
+	temporaryVersions = sets.NewString()
 	for _, clusterRoleBinding := range clusterRoleBindingList {
 		temporaryVersions.Insert(clusterRoleBinding.ResourceVersion)
 	}
@@ -351,6 +356,14 @@ func (ac *AuthorizationCache) synchronize() {
 	ac.rwMutex.Lock()
 	defer ac.rwMutex.Unlock()
 
+	// the informers update their store before their resource version, hence the listers
+	// have seen at least everything up to these resource versions.
+	resourceVersions := informerResourceVersions{
+		workspaces:          ac.lastSyncResourceVersioner.LastSyncResourceVersion(),
+		clusterRoles:        ac.clusterRoleLister.LastSyncResourceVersion(),
+		clusterRoleBindings: ac.clusterRoleBindingLister.LastSyncResourceVersion(),
+	}
+
 	// if none of our internal reflectors changed, then we can skip reviewing the cache
 	skip, currentState := ac.skip.SkipSynchronize(ac.lastState, ac.lastSyncResourceVersioner, ac.roleLastSyncResourceVersioner)
 	if skip {
@@ -384,6 +397,14 @@ func (ac *AuthorizationCache) synchronize() {
 
 	// we were able to update our cache since this last observation period
 	ac.lastState = currentState
+	ac.synchronizedResourceVersions = resourceVersions
+}
+
+// informerResourceVersions are the last synced resource versions of the informers the cache is built from.
+type informerResourceVersions struct {
+	workspaces          string
+	clusterRoles        string
+	clusterRoleBindings string
 }
 
 // syncRequest takes a reviewRequest and determines if it should update the caches supplied, it is not thread-safe
@@ -503,9 +524,83 @@ func (ac *AuthorizationCache) List(userInfo user.Info, labelSelector labels.Sele
 
 		workspaceList.Items = append(workspaceList.Items, *workspace)
 	}
+	workspaceList.ResourceVersion = ac.synchronizedResourceVersions.workspaces
 	return workspaceList, nil
 }
 
+// Allows returns true if the user has access to the given workspace, as far as known to the cache.
+func (ac *AuthorizationCache) Allows(userInfo user.Info, workspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	workspaceKey, err := cache.MetaNamespaceKeyFunc(workspace)
+	if err != nil {
+		return false
+	}
+
+	ac.rwMutex.RLock()
+	defer ac.rwMutex.RUnlock()
+
+	if obj, exists, _ := ac.userSubjectRecordStore.GetByKey(userInfo.GetName()); exists && obj.(*subjectRecord).workspaces.Has(workspaceKey) {
+		return true
+	}
+	for _, group := range userInfo.GetGroups() {
+		if obj, exists, _ := ac.groupSubjectRecordStore.GetByKey(group); exists && obj.(*subjectRecord).workspaces.Has(workspaceKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSynchronized returns whether the last synchronization of the cache reflects the given ClusterWorkspaces,
+// ClusterRoles and ClusterRoleBindings listed from the server. Resource versions are not ordered: an
+// informer is known to be up to date with a list if its last synced resource version is the one of the
+// list. Otherwise, e.g. because the informer did not receive any event since, it is up to date if it knows
+// exactly the listed objects at their resource versions. A cache which is ahead of a list because objects
+// changed since is not reported as synchronized.
+func (ac *AuthorizationCache) IsSynchronized(workspaces *tenancyv1alpha1.ClusterWorkspaceList, clusterRoles *rbacv1.ClusterRoleList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) bool {
+	ac.rwMutex.RLock()
+	defer ac.rwMutex.RUnlock()
+
+	if !sameResourceVersion(ac.synchronizedResourceVersions.workspaces, workspaces.ResourceVersion) {
+		// workspace lists can be filtered or paginated, hence only the listed workspaces are checked.
+		for i := range workspaces.Items {
+			workspaceKey, err := cache.MetaNamespaceKeyFunc(&workspaces.Items[i])
+			if err != nil {
+				return false
+			}
+			obj, exists, _ := ac.reviewRecordStore.GetByKey(workspaceKey)
+			if !exists || obj.(*reviewRecord).workspaceResourceVersion != workspaces.Items[i].ResourceVersion {
+				return false
+			}
+		}
+	}
+
+	if !sameResourceVersion(ac.synchronizedResourceVersions.clusterRoles, clusterRoles.ResourceVersion) {
+		resourceVersions := sets.NewString()
+		for i := range clusterRoles.Items {
+			resourceVersions.Insert(clusterRoles.Items[i].ResourceVersion)
+		}
+		if !resourceVersions.Equal(ac.clusterRoleResourceVersions) {
+			return false
+		}
+	}
+
+	if !sameResourceVersion(ac.synchronizedResourceVersions.clusterRoleBindings, clusterRoleBindings.ResourceVersion) {
+		resourceVersions := sets.NewString()
+		for i := range clusterRoleBindings.Items {
+			resourceVersions.Insert(clusterRoleBindings.Items[i].ResourceVersion)
+		}
+		if !resourceVersions.Equal(ac.clusterBindingResourceVersions) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sameResourceVersion returns whether the given resource versions are known and equal.
+func sameResourceVersion(informerResourceVersion, listResourceVersion string) bool {
+	return informerResourceVersion != "" && informerResourceVersion == listResourceVersion
+}
+
 func (ac *AuthorizationCache) ReadyForAccess() bool {
 	ac.rwMutex.RLock()
 	defer ac.rwMutex.RUnlock()
@@ -20,6 +20,8 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -211,3 +213,55 @@ func TestSyncWorkspace(t *testing.T) {
 		fields.SelectorFromSet(fields.Set{"metadata.name": "foo"}),
 		sets.NewString("foo"))
 }
+
+type fakeResourceVersioner string
+
+func (v fakeResourceVersioner) LastSyncResourceVersion() string {
+	return string(v)
+}
+
+func TestAuthorizationCacheResourceVersion(t *testing.T) {
+	workspace := &workspaceapi.ClusterWorkspace{ObjectMeta: metav1.ObjectMeta{Name: "foo", ResourceVersion: "42"}}
+	wsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	require.NoError(t, wsIndexer.Add(workspace))
+
+	kubeInformers := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), controller.NoResyncPeriodFunc())
+	authorizationCache := NewAuthorizationCache(
+		workspacelisters.NewClusterWorkspaceLister(wsIndexer),
+		fakeResourceVersioner("42"),
+		NewReviewer(&mockSubjectLocator{subjects: map[string][]rbacv1.Subject{"foo": rbacUsers(alice.GetName())}}),
+		authorizer.AttributesRecord{},
+		kubeInformers.Rbac().V1(),
+	)
+
+	// the workspace informer has not seen any event after the workspace, but the list is more recent
+	workspaces := &workspaceapi.ClusterWorkspaceList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}, Items: []workspaceapi.ClusterWorkspace{*workspace}}
+	clusterRoles := &rbacv1.ClusterRoleList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}}
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}}
+
+	require.False(t, authorizationCache.IsSynchronized(workspaces, clusterRoles, clusterRoleBindings), "cache has not been synchronized yet")
+	require.False(t, authorizationCache.Allows(alice, workspace))
+
+	authorizationCache.synchronize()
+
+	// the RBAC informers have not seen anything, but know the listed objects
+	require.True(t, authorizationCache.IsSynchronized(workspaces, clusterRoles, clusterRoleBindings))
+	// the workspace informer has synced the list
+	require.True(t, authorizationCache.IsSynchronized(&workspaceapi.ClusterWorkspaceList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}}, clusterRoles, clusterRoleBindings))
+	require.True(t, authorizationCache.Allows(alice, workspace))
+	require.False(t, authorizationCache.Allows(bob, workspace))
+
+	newerWorkspace := workspace.DeepCopy()
+	newerWorkspace.ResourceVersion = "45"
+	require.False(t, authorizationCache.IsSynchronized(&workspaceapi.ClusterWorkspaceList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}, Items: []workspaceapi.ClusterWorkspace{*newerWorkspace}}, clusterRoles, clusterRoleBindings), "workspace is not known at its resource version")
+	olderWorkspace := workspace.DeepCopy()
+	olderWorkspace.ResourceVersion = "41"
+	require.False(t, authorizationCache.IsSynchronized(&workspaceapi.ClusterWorkspaceList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}, Items: []workspaceapi.ClusterWorkspace{*olderWorkspace}}, clusterRoles, clusterRoleBindings), "resource versions are not ordered")
+
+	unknownClusterRoles := &rbacv1.ClusterRoleList{ListMeta: metav1.ListMeta{ResourceVersion: "50"}, Items: []rbacv1.ClusterRole{{ObjectMeta: metav1.ObjectMeta{Name: "admin", ResourceVersion: "43"}}}}
+	require.False(t, authorizationCache.IsSynchronized(workspaces, unknownClusterRoles, clusterRoleBindings), "ClusterRole is not known to the cache")
+
+	list, err := authorizationCache.List(alice, labels.Everything(), fields.Everything())
+	require.NoError(t, err)
+	require.Equal(t, "42", list.ResourceVersion)
+}
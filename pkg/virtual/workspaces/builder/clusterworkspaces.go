@@ -19,6 +19,7 @@ package builder
 import (
 	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -81,6 +82,14 @@ func (o *authCacheClusterWorkspaces) List(user user.Info, labelSelector labels.S
 	return o.clusterWorkspaceLister.List(user, labelSelector, fieldSelector)
 }
 
+func (o *authCacheClusterWorkspaces) Allows(user user.Info, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	return o.authCache.Allows(user, clusterWorkspace)
+}
+
+func (o *authCacheClusterWorkspaces) IsSynchronized(clusterWorkspaces *tenancyv1alpha1.ClusterWorkspaceList, clusterRoles *rbacv1.ClusterRoleList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) bool {
+	return o.authCache.IsSynchronized(clusterWorkspaces, clusterRoles, clusterRoleBindings)
+}
+
 func (o *authCacheClusterWorkspaces) RemoveWatcher(watcher workspaceauth.CacheWatcher) {
 	o.authCache.RemoveWatcher(watcher)
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -214,6 +215,25 @@ func (cws *preCreationClusterWorkspaces) List(user user.Info, labelSelector labe
 	return &tenancyv1alpha1.ClusterWorkspaceList{}, nil
 }
 
+func (cws *preCreationClusterWorkspaces) Allows(user user.Info, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	cws.lock.RLock()
+	defer cws.lock.RUnlock()
+	if cws.delegate != nil {
+		return cws.delegate.Allows(user, clusterWorkspace)
+	}
+	return false
+}
+
+func (cws *preCreationClusterWorkspaces) IsSynchronized(clusterWorkspaces *tenancyv1alpha1.ClusterWorkspaceList, clusterRoles *rbacv1.ClusterRoleList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) bool {
+	cws.lock.RLock()
+	defer cws.lock.RUnlock()
+	if cws.delegate != nil {
+		return cws.delegate.IsSynchronized(clusterWorkspaces, clusterRoles, clusterRoleBindings)
+	}
+	// the auth cache is started when the first ClusterWorkspace is seen
+	return len(clusterWorkspaces.Items) == 0
+}
+
 func (cws *preCreationClusterWorkspaces) RemoveWatcher(watcher authorization.CacheWatcher) {
 	// fast path
 	cws.lock.RLock()
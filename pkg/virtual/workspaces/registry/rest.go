@@ -18,7 +18,9 @@ package registry
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 
@@ -30,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	kuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
@@ -53,7 +56,7 @@ import (
 )
 
 const (
-	WorkspaceNameLabel string = "workspaces.kcp.dev/name"
+	WorkspaceNameLabel string = tenancyv1alpha1.ClusterWorkspaceOwnerNameLabel
 )
 
 // FilteredClusterWorkspaces allows to list and watch ClusterWorkspaces
@@ -63,8 +66,18 @@ type FilteredClusterWorkspaces interface {
 	workspaceauth.WatchableCache
 	AddWatcher(watcher workspaceauth.CacheWatcher)
 	Stop()
+
+	// Allows returns true if the user has access to the ClusterWorkspace.
+	Allows(user kuser.Info, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace) bool
+	// IsSynchronized returns true if the access to ClusterWorkspaces is known for the given ClusterWorkspaces,
+	// ClusterRoles and ClusterRoleBindings listed from the server, or a later state of them.
+	IsSynchronized(clusterWorkspaces *tenancyv1alpha1.ClusterWorkspaceList, clusterRoles *rbacv1.ClusterRoleList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) bool
 }
 
+// authorizationCacheWaitTimeout is how long a list waits for the authorization cache to catch up
+// with the listed ClusterWorkspaces and RBAC objects.
+const authorizationCacheWaitTimeout = 3 * time.Second
+
 type WorkspacesScopeKeyType string
 
 const (
@@ -96,6 +109,7 @@ var _ rest.Lister = &REST{}
 var _ rest.Watcher = &REST{}
 var _ rest.Scoper = &REST{}
 var _ rest.Creater = &REST{}
+var _ rest.Updater = &REST{}
 var _ rest.GracefulDeleter = &REST{}
 
 // NewREST returns a RESTStorage object that will work against ClusterWorkspace resources in
@@ -189,14 +203,14 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 			return nil, err
 		}
 	} else if clusterWorkspaces := s.getFilteredClusterWorkspaces(orgClusterName); clusterWorkspaces != nil {
-		// TODO:
-		// The workspaceLister is informer driven, so it's important to note that the lister can be stale.
-		// It breaks the API guarantees of lists.
-		// To make it correct we have to know the latest RV of the org workspace shard,
-		// and then wait for freshness relative to that RV of the lister.
-		labelSelector, fieldSelector := InternalListOptionsToSelectors(options)
 		var err error
-		clusterWorkspaceList, err = clusterWorkspaces.List(userInfo, labelSelector, fieldSelector)
+		if requiresConsistentList(options) {
+			clusterWorkspaceList, err = s.listAuthorized(ctx, orgClusterName, clusterWorkspaces, userInfo, options)
+		} else {
+			// serve from the informer driven cache which can be stale.
+			labelSelector, fieldSelector := InternalListOptionsToSelectors(options)
+			clusterWorkspaceList, err = clusterWorkspaces.List(userInfo, labelSelector, fieldSelector)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -214,6 +228,77 @@ func (s *REST) List(ctx context.Context, options *metainternal.ListOptions) (run
 	return workspaceList, nil
 }
 
+// requiresConsistentList returns whether the list options explicitly ask for a list which is consistent
+// with the server, i.e. for a resourceVersion other than 0, or for a page of a paginated list. Other lists
+// are served from the authorization cache.
+func requiresConsistentList(options *metainternal.ListOptions) bool {
+	if options == nil {
+		return false
+	}
+	return (options.ResourceVersion != "" && options.ResourceVersion != "0") ||
+		options.ResourceVersionMatch == metav1.ResourceVersionMatchExact ||
+		options.Limit > 0 || options.Continue != ""
+}
+
+// listAuthorized lists the ClusterWorkspaces of the org shard as requested by the options, i.e. honoring
+// resourceVersion, resourceVersionMatch and pagination, and returns those the user has access to.
+// As the access is looked up in the informer driven authorization cache, it lists the ClusterRoles and
+// ClusterRoleBindings of the org too and waits for the cache to reflect all three lists. If the cache
+// does not catch up within authorizationCacheWaitTimeout, e.g. because it lags behind or because
+// the listed objects changed in the meantime, the list fails with a timeout (504) and can be retried.
+//
+// Pages can contain fewer items than the limit, or none at all, because items are filtered after
+// listing. Clients have to rely on the continue token only.
+func (s *REST) listAuthorized(ctx context.Context, orgClusterName logicalcluster.Name, clusterWorkspaces FilteredClusterWorkspaces, userInfo kuser.Info, options *metainternal.ListOptions) (*tenancyv1alpha1.ClusterWorkspaceList, error) {
+	labelSelector, fieldSelector := InternalListOptionsToSelectors(options)
+	v1Opts := metav1.ListOptions{}
+	if options != nil {
+		if err := metainternal.Convert_internalversion_ListOptions_To_v1_ListOptions(options, &v1Opts, nil); err != nil {
+			return nil, err
+		}
+	}
+	// ClusterWorkspaces only support metadata.name field selectors. We match all fields below.
+	v1Opts.FieldSelector = ""
+
+	list, err := s.kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().List(ctx, v1Opts)
+	if err != nil {
+		return nil, err
+	}
+	// access to the ClusterWorkspaces is granted through the RBAC objects of the org, which are listed after
+	// the ClusterWorkspaces so that they are at least as recent.
+	clusterRoles, err := s.kubeClusterClient.Cluster(orgClusterName).RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings, err := s.kubeClusterClient.Cluster(orgClusterName).RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, authorizationCacheWaitTimeout)
+	defer cancel()
+	if err := wait.PollImmediateUntilWithContext(waitCtx, 50*time.Millisecond, func(ctx context.Context) (bool, error) {
+		return clusterWorkspaces.IsSynchronized(list, clusterRoles, clusterRoleBindings), nil
+	}); err != nil {
+		return nil, kerrors.NewTimeoutError(fmt.Sprintf("the workspace authorization cache has not caught up with resourceVersion %s yet", list.ResourceVersion), 1)
+	}
+
+	predicate := workspaceutil.MatchWorkspace(labelSelector, fieldSelector)
+	authorized := &tenancyv1alpha1.ClusterWorkspaceList{ListMeta: list.ListMeta}
+	// unknown after filtering
+	authorized.RemainingItemCount = nil
+	for i := range list.Items {
+		if matches, err := predicate.Matches(&list.Items[i]); err != nil || !matches {
+			continue
+		}
+		if !clusterWorkspaces.Allows(userInfo, &list.Items[i]) {
+			continue
+		}
+		authorized.Items = append(authorized.Items, list.Items[i])
+	}
+	return authorized, nil
+}
+
 func (s *REST) Watch(ctx context.Context, options *metainternal.ListOptions) (watch.Interface, error) {
 	userInfo, exists := apirequest.UserFrom(ctx)
 	if !exists {
@@ -269,7 +354,7 @@ func (s *REST) Get(ctx context.Context, name string, options *metav1.GetOptions)
 
 var ownerRoleRules = []rbacv1.PolicyRule{
 	{
-		Verbs:     []string{"get", "delete"},
+		Verbs:     []string{"get", "update", "patch", "delete"},
 		Resources: []string{"workspaces"},
 	},
 	{
@@ -305,6 +390,7 @@ var _ = rest.Creater(&REST{})
 // This will give the workspace creator the following permissions on the newly-created workspace:
 // - 'cluster-admin' inside the newly created workspace,
 // - 'get' permission to the workspace resource itself, so that it would appear when listing workspaces in the parent
+// - 'update' and 'patch' permissions so that the user can change labels, annotations and spec.readOnly of the workspace,
 // - 'delete' permission so that the user can delete a workspace it has created.
 func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
 	workspace, isWorkspace := obj.(*tenancyv1beta1.Workspace)
@@ -323,9 +409,14 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	clusterWorkspace := &tenancyv1alpha1.ClusterWorkspace{
 		ObjectMeta: workspace.ObjectMeta,
 		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
-			Type: workspace.Spec.Type,
+			Type:     workspace.Spec.Type,
+			ReadOnly: workspace.Spec.ReadOnly,
 		},
 	}
+	clusterWorkspace.ManagedFields = nil
+	if err := setWorkspaceManagedFields(clusterWorkspace, workspace.ManagedFields); err != nil {
+		return nil, kerrors.NewInternalError(err)
+	}
 	createdClusterWorkspace, err := s.kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Create(ctx, clusterWorkspace, metav1.CreateOptions{})
 	if kerrors.IsAlreadyExists(err) {
 		return nil, kerrors.NewAlreadyExists(tenancyv1beta1.Resource("workspaces"), workspace.Name)
@@ -393,11 +484,128 @@ func (s *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	return &createdWorkspace, nil
 }
 
+var _ = rest.Updater(&REST{})
+
+// Update updates a workspace, and creates it if forceAllowCreate is set, e.g. for a
+// server-side apply request of a workspace that does not exist yet.
+//
+// Only labels, annotations and spec.readOnly are mapped to the ClusterWorkspace. The
+// managed fields of the workspace are kept in an annotation of the ClusterWorkspace
+// for server-side apply.
+func (s *REST) Update(ctx context.Context, name string, objInfo rest.UpdatedObjectInfo, createValidation rest.ValidateObjectFunc, updateValidation rest.ValidateObjectUpdateFunc, forceAllowCreate bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	orgClusterName := ctx.Value(WorkspacesOrgKey).(logicalcluster.Name)
+	clusterWorkspaces := s.kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces()
+
+	existing, err := clusterWorkspaces.Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		if !forceAllowCreate {
+			return nil, false, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+		}
+		obj, err := objInfo.UpdatedObject(ctx, nil)
+		if err != nil {
+			return nil, false, err
+		}
+		created, err := s.Create(ctx, obj, createValidation, &metav1.CreateOptions{DryRun: options.DryRun, FieldManager: options.FieldManager})
+		if err != nil {
+			return nil, false, err
+		}
+		return created, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var oldWorkspace tenancyv1beta1.Workspace
+	projection.ProjectClusterWorkspaceToWorkspace(existing, &oldWorkspace)
+
+	obj, err := objInfo.UpdatedObject(ctx, &oldWorkspace)
+	if err != nil {
+		return nil, false, err
+	}
+	workspace, isWorkspace := obj.(*tenancyv1beta1.Workspace)
+	if !isWorkspace {
+		return nil, false, kerrors.NewBadRequest(fmt.Sprintf("not a Workspace: %T", obj))
+	}
+	if workspace.ResourceVersion == "" {
+		return nil, false, kerrors.NewInvalid(tenancyv1beta1.Kind("Workspace"), name, field.ErrorList{
+			field.Invalid(field.NewPath("metadata", "resourceVersion"), workspace.ResourceVersion, "must be specified for an update"),
+		})
+	}
+
+	s.updateStrategy.PrepareForUpdate(ctx, workspace, &oldWorkspace)
+	if errs := s.updateStrategy.ValidateUpdate(ctx, workspace, &oldWorkspace); len(errs) > 0 {
+		return nil, false, kerrors.NewInvalid(tenancyv1beta1.Kind("Workspace"), name, errs)
+	}
+	if updateValidation != nil {
+		if err := updateValidation(ctx, workspace, &oldWorkspace); err != nil {
+			return nil, false, err
+		}
+	}
+
+	clusterWorkspace := existing.DeepCopy()
+	clusterWorkspace.ResourceVersion = workspace.ResourceVersion
+	clusterWorkspace.Labels = workspace.Labels
+	clusterWorkspace.Annotations = workspace.Annotations
+	if owner, found := existing.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey]; found {
+		// hidden by the projection
+		if clusterWorkspace.Annotations == nil {
+			clusterWorkspace.Annotations = map[string]string{}
+		}
+		clusterWorkspace.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey] = owner
+	}
+	if err := setWorkspaceManagedFields(clusterWorkspace, workspace.ManagedFields); err != nil {
+		return nil, false, kerrors.NewInternalError(err)
+	}
+	clusterWorkspace.Spec.ReadOnly = workspace.Spec.ReadOnly
+
+	updated, err := clusterWorkspaces.Update(ctx, clusterWorkspace, metav1.UpdateOptions{DryRun: options.DryRun, FieldManager: options.FieldManager})
+	if kerrors.IsNotFound(err) {
+		return nil, false, kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
+	}
+	if kerrors.IsConflict(err) {
+		return nil, false, kerrors.NewConflict(tenancyv1beta1.Resource("workspaces"), name, fmt.Errorf("the object has been modified; please apply your changes to the latest version and try again"))
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var updatedWorkspace tenancyv1beta1.Workspace
+	projection.ProjectClusterWorkspaceToWorkspace(updated, &updatedWorkspace)
+	return &updatedWorkspace, false, nil
+}
+
+// setWorkspaceManagedFields stores the managed fields of the Workspace projection in an annotation,
+// such that they survive the round-trip through the ClusterWorkspace, which keeps its own managed
+// fields about the v1alpha1 schema.
+func setWorkspaceManagedFields(clusterWorkspace *tenancyv1alpha1.ClusterWorkspace, managedFields []metav1.ManagedFieldsEntry) error {
+	annotations := make(map[string]string, len(clusterWorkspace.Annotations)+1)
+	for k, v := range clusterWorkspace.Annotations {
+		annotations[k] = v
+	}
+	delete(annotations, tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey)
+	if len(managedFields) > 0 {
+		bs, err := json.Marshal(managedFields)
+		if err != nil {
+			return err
+		}
+		annotations[tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey] = string(bs)
+	}
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	clusterWorkspace.Annotations = annotations
+	return nil
+}
+
 var _ = rest.GracefulDeleter(&REST{})
 
 func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
 	orgClusterName := ctx.Value(WorkspacesOrgKey).(logicalcluster.Name)
 
+	retained, err := s.retainedOnDeletion(ctx, orgClusterName, name)
+	if err != nil {
+		return nil, false, err
+	}
 	errorToReturn := s.kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Delete(ctx, name, *options)
 	if errorToReturn != nil && !kerrors.IsNotFound(errorToReturn) {
 		return nil, false, errorToReturn
@@ -405,6 +613,11 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 	if kerrors.IsNotFound(errorToReturn) {
 		errorToReturn = kerrors.NewNotFound(tenancyv1beta1.Resource("workspaces"), name)
 	}
+	if retained && errorToReturn == nil {
+		// the owner keeps access in order to restore the workspace. The workspace deletion
+		// controller deletes the RBAC objects when the contents are purged.
+		return nil, false, nil
+	}
 	workspaceNameLabelSelector := fmt.Sprintf("%s=%s", WorkspaceNameLabel, name)
 	if err := s.kubeClusterClient.Cluster(orgClusterName).RbacV1().ClusterRoles().DeleteCollection(ctx, *options, metav1.ListOptions{
 		LabelSelector: workspaceNameLabelSelector,
@@ -420,6 +633,29 @@ func (s *REST) Delete(ctx context.Context, name string, deleteValidation rest.Va
 	return nil, false, errorToReturn
 }
 
+// retainedOnDeletion returns whether the contents of the given workspace are retained for the
+// deletion retention period of its type when it is deleted. A workspace or type that does not
+// exist retains nothing.
+func (s *REST) retainedOnDeletion(ctx context.Context, orgClusterName logicalcluster.Name, name string) (bool, error) {
+	clusterWorkspace, err := s.kcpClusterClient.Cluster(orgClusterName).TenancyV1alpha1().ClusterWorkspaces().Get(ctx, name, metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if clusterWorkspace.Spec.Type.Path == "" || clusterWorkspace.Status.Location.Current == "" {
+		return false, nil
+	}
+	typeRef := clusterWorkspace.Spec.Type
+	cwt, err := s.kcpClusterClient.Cluster(logicalcluster.New(typeRef.Path)).TenancyV1alpha1().ClusterWorkspaceTypes().Get(ctx, tenancyv1alpha1.ObjectName(typeRef.Name), metav1.GetOptions{})
+	if kerrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return cwt.Spec.DeletionRetentionPeriod != nil && cwt.Spec.DeletionRetentionPeriod.Duration > 0, nil
+}
+
 type withProjection struct {
 	delegate watch.Interface
 	ch       chan watch.Event
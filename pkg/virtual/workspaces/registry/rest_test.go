@@ -22,6 +22,7 @@ import (
 	"math/rand"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/kcp-dev/logicalcluster/v2"
 	"github.com/stretchr/testify/assert"
@@ -29,6 +30,7 @@ import (
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metainternal "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/labels"
@@ -38,12 +40,14 @@ import (
 	kuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/registry/rest"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/kubernetes/pkg/controller"
+	"k8s.io/utils/pointer"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
@@ -56,6 +60,8 @@ import (
 type mockLister struct {
 	checkedUsers []kuser.Info
 	workspaces   []tenancyv1alpha1.ClusterWorkspace
+	// synchronized are the ClusterWorkspaces known to the cache, with their resource versions.
+	synchronized []tenancyv1alpha1.ClusterWorkspace
 }
 
 func (m *mockLister) CheckedUsers() []kuser.Info {
@@ -69,6 +75,29 @@ func (m *mockLister) List(user kuser.Info, _ labels.Selector, _ fields.Selector)
 	}, nil
 }
 
+func (m *mockLister) Allows(user kuser.Info, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	m.checkedUsers = append(m.checkedUsers, user)
+	for _, ws := range m.workspaces {
+		if ws.Name == clusterWorkspace.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *mockLister) IsSynchronized(clusterWorkspaces *tenancyv1alpha1.ClusterWorkspaceList, _ *rbacv1.ClusterRoleList, _ *rbacv1.ClusterRoleBindingList) bool {
+	known := map[string]string{}
+	for _, ws := range m.synchronized {
+		known[ws.Name] = ws.ResourceVersion
+	}
+	for _, ws := range clusterWorkspaces.Items {
+		if rv, found := known[ws.Name]; !found || rv != ws.ResourceVersion {
+			return false
+		}
+	}
+	return true
+}
+
 type TestData struct {
 	clusterRoles           []rbacv1.ClusterRole
 	clusterRoleBindings    []rbacv1.ClusterRoleBinding
@@ -192,8 +221,10 @@ func applyTest(t *testing.T, test TestDescription) {
 
 	clusterWorkspaceLister := test.workspaceLister
 	if clusterWorkspaceLister == nil {
+		// the cache is in sync with the ClusterWorkspaces
 		clusterWorkspaceLister = &mockLister{
-			workspaces: test.clusterWorkspaces,
+			workspaces:   test.clusterWorkspaces,
+			synchronized: test.clusterWorkspaces,
 		}
 	}
 
@@ -211,6 +242,8 @@ func applyTest(t *testing.T, test TestDescription) {
 			}
 			return test.reviewer, nil
 		},
+		createStrategy: Strategy,
+		updateStrategy: Strategy,
 	}
 	ctx = apirequest.WithUser(ctx, test.user)
 	ctx = apirequest.WithValue(ctx, WorkspacesOrgKey, test.orgName)
@@ -242,7 +275,8 @@ func TestListWorkspacesWithGroupPermission(t *testing.T) {
 						Annotations: map[string]string{
 							logicalcluster.AnnotationKey: "root:orgName",
 						},
-						Name: "foo",
+						Name:            "foo",
+						ResourceVersion: "1",
 					},
 				},
 			},
@@ -312,7 +346,8 @@ func TestListWorkspacesWithUserPermission(t *testing.T) {
 						Annotations: map[string]string{
 							logicalcluster.AnnotationKey: "root:orgName",
 						},
-						Name: "foo",
+						Name:            "foo",
+						ResourceVersion: "1",
 					},
 				},
 			},
@@ -376,7 +411,7 @@ func TestListWorkspacesOnRootOrgWithPermission(t *testing.T) {
 					},
 				},
 			}),
-			clusterWorkspaces:   []tenancyv1alpha1.ClusterWorkspace{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{logicalcluster.AnnotationKey: "root"}, Name: "orgName"}}},
+			clusterWorkspaces:   []tenancyv1alpha1.ClusterWorkspace{{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{logicalcluster.AnnotationKey: "root"}, Name: "orgName", ResourceVersion: "1"}}},
 			clusterRoleBindings: []rbacv1.ClusterRoleBinding{},
 		},
 		apply: func(t *testing.T, storage *REST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
@@ -397,6 +432,150 @@ func TestListWorkspacesOnRootOrgWithPermission(t *testing.T) {
 	applyTest(t, test)
 }
 
+func TestListWorkspacesConsistently(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	workspace := func(name, resourceVersion string, phase tenancyv1alpha1.ClusterWorkspacePhaseType) tenancyv1alpha1.ClusterWorkspace {
+		return tenancyv1alpha1.ClusterWorkspace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            name,
+				ResourceVersion: resourceVersion,
+				Annotations: map[string]string{
+					logicalcluster.AnnotationKey: "root:orgName",
+				},
+			},
+			Status: tenancyv1alpha1.ClusterWorkspaceStatus{Phase: phase},
+		}
+	}
+
+	tests := []struct {
+		name              string
+		clusterWorkspaces []tenancyv1alpha1.ClusterWorkspace
+		workspaceLister   *mockLister
+		options           *metainternal.ListOptions
+		listResponse      *tenancyv1alpha1.ClusterWorkspaceList
+
+		wantNames    []string
+		wantContinue string
+		wantErr      func(err error) bool
+	}{
+		{
+			name:              "only accessible workspaces are listed",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			},
+			options:   &metainternal.ListOptions{ResourceVersion: "2"},
+			wantNames: []string{"foo"},
+		},
+		{
+			name:              "waits for the cache to know the listed workspaces",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+			},
+			options: &metainternal.ListOptions{ResourceVersion: "2"},
+			wantErr: errors.IsTimeout,
+		},
+		{
+			name:              "waits for the cache to know the listed workspaces at their resource versions",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "3", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+			},
+			options: &metainternal.ListOptions{Limit: 10},
+			wantErr: errors.IsTimeout,
+		},
+		{
+			name:              "lists without resourceVersion are served from the cache",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+			},
+			wantNames: []string{"foo"},
+		},
+		{
+			name:              "resourceVersion 0 is served from the cache",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+			},
+			options:   &metainternal.ListOptions{ResourceVersion: "0"},
+			wantNames: []string{"foo"},
+		},
+		{
+			name:              "field selectors are matched",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", tenancyv1alpha1.ClusterWorkspacePhaseReady), workspace("bar", "2", tenancyv1alpha1.ClusterWorkspacePhaseInitializing)},
+			options:           &metainternal.ListOptions{ResourceVersion: "2", FieldSelector: fields.OneTermEqualSelector("status.phase", string(tenancyv1alpha1.ClusterWorkspacePhaseReady))},
+			wantNames:         []string{"foo"},
+		},
+		{
+			name:              "pages are filtered",
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			workspaceLister: &mockLister{
+				workspaces:   []tenancyv1alpha1.ClusterWorkspace{workspace("bar", "2", "")},
+				synchronized: []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", ""), workspace("bar", "2", "")},
+			},
+			options: &metainternal.ListOptions{Limit: 1},
+			listResponse: &tenancyv1alpha1.ClusterWorkspaceList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "3", Continue: "next-page", RemainingItemCount: pointer.Int64(1)},
+				Items:    []tenancyv1alpha1.ClusterWorkspace{workspace("foo", "1", "")},
+			},
+			wantContinue: "next-page",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyTest(t, TestDescription{
+				TestData: TestData{
+					user:              user,
+					orgName:           logicalcluster.New("root:orgName"),
+					reviewer:          workspaceauth.NewReviewer(nil),
+					rootReviewer:      workspaceauth.NewReviewer(nil),
+					clusterWorkspaces: tt.clusterWorkspaces,
+					workspaceLister:   tt.workspaceLister,
+				},
+				apply: func(t *testing.T, storage *REST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					if tt.listResponse != nil {
+						kcpClient.PrependReactor("list", "clusterworkspaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+							return true, tt.listResponse, nil
+						})
+					}
+					ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+					defer cancel()
+
+					response, err := storage.List(ctx, tt.options)
+					if tt.wantErr != nil {
+						require.Error(t, err)
+						require.True(t, tt.wantErr(err), "unexpected error: %v", err)
+						return
+					}
+					require.NoError(t, err)
+					workspaces := response.(*tenancyv1beta1.WorkspaceList)
+					names := []string{}
+					for _, ws := range workspaces.Items {
+						names = append(names, ws.Name)
+					}
+					if tt.wantNames == nil {
+						tt.wantNames = []string{}
+					}
+					require.Equal(t, tt.wantNames, names)
+					require.Equal(t, tt.wantContinue, workspaces.Continue)
+					require.Nil(t, workspaces.RemainingItemCount)
+				},
+			})
+		})
+	}
+}
+
 func TestGetWorkspace(t *testing.T) {
 	user := &kuser.DefaultInfo{
 		Name:   "test-user",
@@ -605,7 +784,7 @@ func TestCreateWorkspace(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -692,7 +871,7 @@ func TestCreateWorkspaceWithCreateAnyPermission(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -788,7 +967,7 @@ func TestCreateWorkspaceCustomLocalType(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -873,7 +1052,7 @@ func TestCreateWorkspaceNameAlreadyExists(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -1028,7 +1207,7 @@ func TestDeleteWorkspaceNotFound(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -1125,7 +1304,7 @@ func TestDeleteWorkspace(t *testing.T) {
 					},
 					Rules: []rbacv1.PolicyRule{
 						{
-							Verbs:         []string{"get", "delete"},
+							Verbs:         []string{"get", "update", "patch", "delete"},
 							ResourceNames: []string{"foo"},
 							Resources:     []string{"workspaces"},
 							APIGroups:     []string{"tenancy.kcp.dev"},
@@ -1156,6 +1335,212 @@ func TestDeleteWorkspace(t *testing.T) {
 	applyTest(t, test)
 }
 
+func TestDeleteWorkspaceGetError(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	test := TestDescription{
+		TestData: TestData{
+			user:    user,
+			orgName: logicalcluster.New("root:orgName"),
+			reviewer: workspaceauth.NewReviewer(&mockSubjectLocator{
+				subjects: map[string]map[string][]rbacv1.Subject{
+					"delete/tenancy.kcp.dev/v1alpha1/workspaces": {
+						"foo": rbacUsers("test-user"),
+					},
+				},
+			}),
+			rootReviewer: workspaceauth.NewReviewer(nil),
+			clusterWorkspaces: []tenancyv1alpha1.ClusterWorkspace{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							logicalcluster.AnnotationKey: "root:orgName",
+						},
+						Name: "foo",
+					},
+				},
+			},
+		},
+		apply: func(t *testing.T, storage *REST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+			kcpClient.PrependReactor("get", "clusterworkspaces", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewServiceUnavailable("unavailable")
+			})
+			response, deletedNow, err := storage.Delete(ctx, "foo", nil, &metav1.DeleteOptions{})
+			assert.True(t, errors.IsServiceUnavailable(err), "unexpected error: %v", err)
+			assert.Nil(t, response)
+			assert.False(t, deletedNow)
+			workspaceList, err := kcpClient.Tracker().List(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), tenancyv1alpha1.SchemeGroupVersion.WithKind("ClusterWorkspace"), "")
+			require.NoError(t, err)
+			wsList := workspaceList.(*tenancyv1alpha1.ClusterWorkspaceList)
+			assert.ElementsMatch(t, wsList.Items, testData.clusterWorkspaces)
+		},
+	}
+	applyTest(t, test)
+}
+
+func TestUpdateWorkspace(t *testing.T) {
+	user := &kuser.DefaultInfo{
+		Name:   "test-user",
+		UID:    "test-uid",
+		Groups: []string{"test-group"},
+	}
+	existing := tenancyv1alpha1.ClusterWorkspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo",
+			ResourceVersion: "1",
+			Labels: map[string]string{
+				"team": "a",
+				tenancyv1alpha1.ClusterWorkspacePhaseLabel: "Ready",
+			},
+			Annotations: map[string]string{
+				logicalcluster.AnnotationKey:                                   "root:orgName",
+				tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey: `{"username":"test-user"}`,
+			},
+		},
+		Spec: tenancyv1alpha1.ClusterWorkspaceSpec{
+			Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"},
+		},
+		Status: tenancyv1alpha1.ClusterWorkspaceStatus{
+			Phase:   tenancyv1alpha1.ClusterWorkspacePhaseReady,
+			BaseURL: "https://example.com/clusters/root:orgName:foo",
+		},
+	}
+
+	tests := []struct {
+		name             string
+		existing         []tenancyv1alpha1.ClusterWorkspace
+		update           func(ws *tenancyv1beta1.Workspace)
+		forceAllowCreate bool
+
+		wantErr     func(err error) bool
+		wantCreated bool
+		wantUpdated func(t *testing.T, cws *tenancyv1alpha1.ClusterWorkspace)
+	}{
+		{
+			name:     "labels, annotations and readOnly are updated",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Labels["team"] = "b"
+				ws.Annotations["description"] = "the foo workspace"
+				ws.Spec.ReadOnly = true
+				ws.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "tenancy.kcp.dev/v1beta1"}}
+			},
+			wantUpdated: func(t *testing.T, cws *tenancyv1alpha1.ClusterWorkspace) {
+				require.Equal(t, "b", cws.Labels["team"])
+				require.Equal(t, "the foo workspace", cws.Annotations["description"])
+				require.True(t, cws.Spec.ReadOnly)
+				require.Equal(t, `{"username":"test-user"}`, cws.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey], "owner annotation must be preserved")
+				require.Contains(t, cws.Annotations[tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey], `"manager":"kubectl"`)
+				require.Empty(t, cws.ManagedFields)
+			},
+		},
+		{
+			name:     "status is not updated",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Status.URL = "https://evil.com"
+			},
+			wantUpdated: func(t *testing.T, cws *tenancyv1alpha1.ClusterWorkspace) {
+				require.Equal(t, "https://example.com/clusters/root:orgName:foo", cws.Status.BaseURL)
+			},
+		},
+		{
+			name:     "type is immutable",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Spec.Type.Name = "organization"
+			},
+			wantErr: errors.IsInvalid,
+		},
+		{
+			name:     "system labels cannot be changed",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				delete(ws.Labels, tenancyv1alpha1.ClusterWorkspacePhaseLabel)
+			},
+			wantErr: errors.IsInvalid,
+		},
+		{
+			name:     "owner annotation cannot be set",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Annotations[tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey] = `{"username":"someone-else"}`
+			},
+			wantErr: errors.IsInvalid,
+		},
+		{
+			name:     "resourceVersion is required",
+			existing: []tenancyv1alpha1.ClusterWorkspace{existing},
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.ResourceVersion = ""
+			},
+			wantErr: errors.IsInvalid,
+		},
+		{
+			name: "not found",
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Labels = map[string]string{"team": "b"}
+			},
+			wantErr: errors.IsNotFound,
+		},
+		{
+			name: "created on apply",
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Labels = map[string]string{"team": "b"}
+				ws.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "tenancy.kcp.dev/v1beta1"}}
+			},
+			forceAllowCreate: true,
+			wantCreated:      true,
+			wantUpdated: func(t *testing.T, cws *tenancyv1alpha1.ClusterWorkspace) {
+				require.Equal(t, "b", cws.Labels["team"])
+				require.Contains(t, cws.Annotations[tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey], `"manager":"kubectl"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applyTest(t, TestDescription{
+				TestData: TestData{
+					user:              user,
+					orgName:           logicalcluster.New("root:orgName"),
+					reviewer:          workspaceauth.NewReviewer(nil),
+					rootReviewer:      workspaceauth.NewReviewer(nil),
+					clusterWorkspaces: tt.existing,
+				},
+				apply: func(t *testing.T, storage *REST, ctx context.Context, kubeClient *fake.Clientset, kcpClient *tenancyv1fake.Clientset, listerCheckedUsers func() []kuser.Info, testData TestData) {
+					objInfo := rest.DefaultUpdatedObjectInfo(nil, func(ctx context.Context, newObj, oldObj runtime.Object) (runtime.Object, error) {
+						ws := &tenancyv1beta1.Workspace{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+						if oldObj != nil {
+							ws = oldObj.DeepCopyObject().(*tenancyv1beta1.Workspace)
+						}
+						tt.update(ws)
+						return ws, nil
+					})
+					response, created, err := storage.Update(ctx, "foo", objInfo, nil, nil, tt.forceAllowCreate, &metav1.UpdateOptions{})
+					if tt.wantErr != nil {
+						require.Error(t, err)
+						require.True(t, tt.wantErr(err), "unexpected error: %v", err)
+						return
+					}
+					require.NoError(t, err)
+					require.Equal(t, tt.wantCreated, created)
+					require.IsType(t, &tenancyv1beta1.Workspace{}, response)
+					workspace := response.(*tenancyv1beta1.Workspace)
+					require.NotContains(t, workspace.Annotations, tenancyv1alpha1.ExperimentalClusterWorkspaceOwnerAnnotationKey)
+					require.NotContains(t, workspace.Annotations, tenancyv1alpha1.InternalWorkspaceManagedFieldsAnnotationKey)
+
+					cws, err := kcpClient.Tracker().Get(tenancyv1alpha1.SchemeGroupVersion.WithResource("clusterworkspaces"), "", "foo")
+					require.NoError(t, err)
+					tt.wantUpdated(t, cws.(*tenancyv1alpha1.ClusterWorkspace))
+				},
+			})
+		})
+	}
+}
+
 type clusterWorkspaces struct {
 	clusterWorkspaceLister *mockLister
 }
@@ -1167,6 +1552,14 @@ func (c clusterWorkspaces) List(user kuser.Info, labelSelector labels.Selector,
 	return c.clusterWorkspaceLister.List(user, labelSelector, fieldSelector)
 }
 
+func (c clusterWorkspaces) Allows(user kuser.Info, clusterWorkspace *tenancyv1alpha1.ClusterWorkspace) bool {
+	return c.clusterWorkspaceLister.Allows(user, clusterWorkspace)
+}
+
+func (c clusterWorkspaces) IsSynchronized(clusterWorkspaces *tenancyv1alpha1.ClusterWorkspaceList, clusterRoles *rbacv1.ClusterRoleList, clusterRoleBindings *rbacv1.ClusterRoleBindingList) bool {
+	return c.clusterWorkspaceLister.IsSynchronized(clusterWorkspaces, clusterRoles, clusterRoleBindings)
+}
+
 func (c clusterWorkspaces) RemoveWatcher(watcher workspaceauth.CacheWatcher) {
 }
 
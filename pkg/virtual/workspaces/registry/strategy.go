@@ -18,11 +18,16 @@ package registry
 
 import (
 	"context"
+	"strings"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apiserver/pkg/storage/names"
 
+	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
 	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 )
 
@@ -53,6 +58,9 @@ func (workspaceStrategy) PrepareForCreate(ctx context.Context, obj runtime.Objec
 
 // PrepareForUpdate clears fields that are not allowed to be set by end users on update.
 func (workspaceStrategy) PrepareForUpdate(ctx context.Context, obj, old runtime.Object) {
+	newWorkspace := obj.(*tenancyv1beta1.Workspace)
+	oldWorkspace := old.(*tenancyv1beta1.Workspace)
+	newWorkspace.Status = oldWorkspace.Status
 }
 
 // Validate validates a new workspace.
@@ -78,9 +86,85 @@ func (workspaceStrategy) AllowUnconditionalUpdate() bool {
 func (workspaceStrategy) Canonicalize(obj runtime.Object) {
 }
 
-// ValidateUpdate is the default update validation for an end user.
+// ValidateUpdate is the default update validation for an end user. A workspace owner may
+// change labels, annotations and spec.readOnly, and may request the restoration of a deleted
+// workspace through the tenancy.kcp.dev/restore annotation. Everything else, and labels and
+// annotations in the kcp.dev domain, are owned by the system.
 func (workspaceStrategy) ValidateUpdate(ctx context.Context, obj, old runtime.Object) field.ErrorList {
-	return nil
+	newWorkspace := obj.(*tenancyv1beta1.Workspace)
+	oldWorkspace := old.(*tenancyv1beta1.Workspace)
+	deleted := oldWorkspace.DeletionTimestamp != nil
+
+	newAnnotations, oldAnnotations := newWorkspace.Annotations, oldWorkspace.Annotations
+	if deleted {
+		newAnnotations = withoutKey(newAnnotations, tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey)
+		oldAnnotations = withoutKey(oldAnnotations, tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey)
+	}
+
+	metaPath := field.NewPath("metadata")
+	allErrs := apivalidation.ValidateObjectMetaUpdate(&newWorkspace.ObjectMeta, &oldWorkspace.ObjectMeta, metaPath)
+	allErrs = append(allErrs, metav1validation.ValidateLabels(newWorkspace.Labels, metaPath.Child("labels"))...)
+	allErrs = append(allErrs, apivalidation.ValidateAnnotations(newWorkspace.Annotations, metaPath.Child("annotations"))...)
+	allErrs = append(allErrs, validateReservedKeys(newWorkspace.Labels, oldWorkspace.Labels, metaPath.Child("labels"))...)
+	allErrs = append(allErrs, validateReservedKeys(newAnnotations, oldAnnotations, metaPath.Child("annotations"))...)
+	if !apiequality.Semantic.DeepEqual(newWorkspace.Finalizers, oldWorkspace.Finalizers) {
+		allErrs = append(allErrs, field.Forbidden(metaPath.Child("finalizers"), "finalizers cannot be changed"))
+	}
+	if !apiequality.Semantic.DeepEqual(newWorkspace.OwnerReferences, oldWorkspace.OwnerReferences) {
+		allErrs = append(allErrs, field.Forbidden(metaPath.Child("ownerReferences"), "ownerReferences cannot be changed"))
+	}
+
+	specPath := field.NewPath("spec")
+	allErrs = append(allErrs, apivalidation.ValidateImmutableField(newWorkspace.Spec.Type, oldWorkspace.Spec.Type, specPath.Child("type"))...)
+	if newWorkspace.Spec.ReadOnly != oldWorkspace.Spec.ReadOnly && oldWorkspace.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey] != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("readOnly"), "cannot be changed while the workspace is migrated to another shard"))
+	}
+	if newWorkspace.Spec.ReadOnly != oldWorkspace.Spec.ReadOnly && deleted {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("readOnly"), "cannot be changed for a deleted workspace"))
+	}
+
+	return allErrs
+}
+
+// isReservedKey returns true for label and annotation keys in the kcp.dev domain or any of its
+// sub-domains.
+func isReservedKey(key string) bool {
+	i := strings.Index(key, "/")
+	if i < 0 {
+		return false
+	}
+	prefix := key[:i]
+	return prefix == "kcp.dev" || strings.HasSuffix(prefix, ".kcp.dev")
+}
+
+// validateReservedKeys forbids adding, changing or removing reserved keys.
+func validateReservedKeys(newMap, oldMap map[string]string, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for k, v := range newMap {
+		if old, found := oldMap[k]; isReservedKey(k) && (!found || old != v) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Key(k), "reserved for the system"))
+		}
+	}
+	for k := range oldMap {
+		if _, found := newMap[k]; isReservedKey(k) && !found {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Key(k), "reserved for the system"))
+		}
+	}
+	return allErrs
+}
+
+// withoutKey returns a copy of the given map without key.
+func withoutKey(m map[string]string, key string) map[string]string {
+	if _, found := m[key]; !found {
+		return m
+	}
+	ret := make(map[string]string, len(m))
+	for k, v := range m {
+		if k != key {
+			ret[k] = v
+		}
+	}
+	return ret
 }
 
 // WarningsOnUpdate returns warnings for the given update.
@@ -19,10 +19,13 @@ package registry
 import (
 	"testing"
 
+	"github.com/stretchr/testify/require"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 
 	tenancyv1alpha1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1alpha1"
+	tenancyv1beta1 "github.com/kcp-dev/kcp/pkg/apis/tenancy/v1beta1"
 )
 
 func TestWorkspaceStrategy(t *testing.T) {
@@ -45,3 +48,85 @@ func TestWorkspaceStrategy(t *testing.T) {
 		t.Errorf("Unexpected error validating %v", errs)
 	}
 }
+
+func TestWorkspaceStrategyValidateUpdate(t *testing.T) {
+	old := &tenancyv1beta1.Workspace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "foo",
+			ResourceVersion: "10",
+			Labels:          map[string]string{"team": "a", tenancyv1alpha1.ClusterWorkspacePhaseLabel: "Ready"},
+			Annotations:     map[string]string{"kcp.dev/cluster": "root:org"},
+			Finalizers:      []string{"tenancy.kcp.dev/finalizer"},
+		},
+		Spec: tenancyv1beta1.WorkspaceSpec{
+			Type: tenancyv1alpha1.ClusterWorkspaceTypeReference{Name: "universal", Path: "root"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		old     func(ws *tenancyv1beta1.Workspace)
+		update  func(ws *tenancyv1beta1.Workspace)
+		wantErr bool
+	}{
+		{name: "labels can be changed", update: func(ws *tenancyv1beta1.Workspace) { ws.Labels["team"] = "b" }},
+		{name: "annotations can be added", update: func(ws *tenancyv1beta1.Workspace) { ws.Annotations["example.com/description"] = "foo" }},
+		{name: "readOnly can be toggled", update: func(ws *tenancyv1beta1.Workspace) { ws.Spec.ReadOnly = true }},
+		{name: "invalid label", update: func(ws *tenancyv1beta1.Workspace) { ws.Labels["team"] = "not valid" }, wantErr: true},
+		{name: "reserved label cannot be removed", update: func(ws *tenancyv1beta1.Workspace) { delete(ws.Labels, tenancyv1alpha1.ClusterWorkspacePhaseLabel) }, wantErr: true},
+		{name: "reserved annotation cannot be changed", update: func(ws *tenancyv1beta1.Workspace) { ws.Annotations["kcp.dev/cluster"] = "root:other" }, wantErr: true},
+		{name: "reserved annotation cannot be added", update: func(ws *tenancyv1beta1.Workspace) { ws.Annotations["internal.tenancy.kcp.dev/foo"] = "bar" }, wantErr: true},
+		{name: "finalizers cannot be changed", update: func(ws *tenancyv1beta1.Workspace) { ws.Finalizers = nil }, wantErr: true},
+		{name: "type is immutable", update: func(ws *tenancyv1beta1.Workspace) { ws.Spec.Type.Name = "organization" }, wantErr: true},
+		{
+			name: "readOnly cannot be toggled during a migration",
+			old: func(ws *tenancyv1beta1.Workspace) {
+				ws.Annotations[tenancyv1alpha1.ClusterWorkspaceMigrationReadOnlyAnnotationKey] = "true"
+				ws.Spec.ReadOnly = true
+			},
+			update:  func(ws *tenancyv1beta1.Workspace) { ws.Spec.ReadOnly = false },
+			wantErr: true,
+		},
+		{
+			name: "restore can be requested for a deleted workspace",
+			old:  func(ws *tenancyv1beta1.Workspace) { ws.DeletionTimestamp = &metav1.Time{} },
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey] = "true"
+			},
+		},
+		{
+			name: "restore cannot be requested for a workspace that is not deleted",
+			update: func(ws *tenancyv1beta1.Workspace) {
+				ws.Annotations[tenancyv1alpha1.ClusterWorkspaceRestoreAnnotationKey] = "true"
+			},
+			wantErr: true,
+		},
+		{
+			name: "readOnly cannot be toggled for a deleted workspace",
+			old: func(ws *tenancyv1beta1.Workspace) {
+				ws.DeletionTimestamp = &metav1.Time{}
+				ws.Spec.ReadOnly = true
+			},
+			update:  func(ws *tenancyv1beta1.Workspace) { ws.Spec.ReadOnly = false },
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldWorkspace := old.DeepCopy()
+			if tt.old != nil {
+				tt.old(oldWorkspace)
+			}
+			newWorkspace := oldWorkspace.DeepCopy()
+			tt.update(newWorkspace)
+
+			Strategy.PrepareForUpdate(apirequest.NewDefaultContext(), newWorkspace, oldWorkspace)
+			errs := Strategy.ValidateUpdate(apirequest.NewDefaultContext(), newWorkspace, oldWorkspace)
+			if tt.wantErr {
+				require.NotEmpty(t, errs)
+			} else {
+				require.Empty(t, errs)
+			}
+		})
+	}
+}
@@ -419,6 +419,8 @@ func syncerConfigFromCluster(t *testing.T, downstreamConfig *rest.Config, namesp
 		SyncTargetWorkspace: kcpClusterName,
 		SyncTargetName:      syncTargetName,
 		SyncTargetUID:       syncTargetUID,
+
+		SyncedNamespaceLabels: argMap["--synced-namespace-labels"],
 	}
 }
 